@@ -1,60 +1,75 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
-	"github.com/lllllan02/chitchat/internal/api/router"
+	"github.com/lllllan02/chitchat/internal/app"
 	"github.com/lllllan02/chitchat/internal/model"
 	"github.com/lllllan02/chitchat/internal/utils"
 	"github.com/lllllan02/chitchat/pkg/logger"
 )
 
-func main() {
-	// 初始化日志
-	if err := logger.Init("INFO", ""); err != nil {
-		fmt.Printf("初始化日志失败: %v\n", err)
-		return
-	}
-	defer logger.Close()
+// 关闭服务器时等待处理中请求的最长时间
+const shutdownTimeout = 10 * time.Second
 
+func main() {
 	// 加载配置
 	if err := utils.LoadConfig(""); err != nil {
-		logger.Fatal("加载配置失败: %v", err)
+		logger.Fatal("加载配置失败", logger.Err(err))
+	}
+
+	// 初始化日志
+	if err := logger.Init(utils.AppConfig.Log); err != nil {
+		logger.Fatal("初始化日志失败", logger.Err(err))
 	}
+	defer logger.Close()
 
 	// 初始化数据库
-	if err := utils.InitDB(); err != nil {
-		logger.Fatal("初始化数据库失败: %v", err)
+	db, err := utils.InitDB(utils.AppConfig.Database)
+	if err != nil {
+		logger.Fatal("初始化数据库失败", logger.Err(err))
 	}
 
 	// 自动迁移表
-	if err := model.AutoMigrate(); err != nil {
-		logger.Fatal("数据库迁移失败: %v", err)
+	if err := model.AutoMigrate(db); err != nil {
+		logger.Fatal("数据库迁移失败", logger.Err(err))
 	}
 
 	// 加载初始数据
-	if err := model.SeedData(); err != nil {
-		logger.Fatal("填充初始数据失败: %v", err)
+	if err := model.SeedData(db); err != nil {
+		logger.Fatal("填充初始数据失败", logger.Err(err))
 	}
 
-	// 初始化路由
-	r := router.InitRouter()
+	// 组装应用
+	application, err := app.New(&utils.AppConfig, db)
+	if err != nil {
+		logger.Fatal("初始化应用失败", logger.Err(err))
+	}
 
 	// 启动服务器
 	port := utils.AppConfig.Server.Port
 	if port == 0 {
 		port = 8080
 	}
+	srv := &http.Server{
+		Addr:    fmt.Sprintf(":%d", port),
+		Handler: application.Router,
+	}
+	// 关闭时断开推送连接，否则 Shutdown 会一直等到超时
+	srv.RegisterOnShutdown(application.Hub.Close)
 
 	// 非阻塞方式启动
 	go func() {
-		addr := fmt.Sprintf(":%d", port)
-		logger.Info("服务器启动成功，监听端口: %d", port)
-		if err := r.Run(addr); err != nil {
-			logger.Fatal("服务器启动失败: %v", err)
+		logger.Info("服务器启动成功", logger.Int("port", port))
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatal("服务器启动失败", logger.Err(err))
 		}
 	}()
 
@@ -62,7 +77,17 @@ func main() {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	logger.Info("正在关闭服务器...")
+	logger.Info("正在关闭服务器")
+
+	// 等待处理中的请求完成，再写入剩余的浏览次数
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Error("关闭服务器失败", logger.Err(err))
+	}
+	if err := application.Close(); err != nil {
+		logger.Error("释放应用资源失败", logger.Err(err))
+	}
 
 	logger.Info("服务器已关闭")
 }
@@ -1,52 +1,83 @@
 package handler
 
 import (
+	"crypto/subtle"
 	"errors"
+	"math"
+	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/lllllan02/chitchat/internal/model"
 	"github.com/lllllan02/chitchat/internal/service"
 	"github.com/lllllan02/chitchat/internal/utils"
+	"github.com/lllllan02/chitchat/pkg/logger"
 	"github.com/lllllan02/chitchat/pkg/response"
 	"gorm.io/gorm"
 )
 
-// 初始化服务
-var userService = service.NewUserService()
+const (
+	// 第三方登录 state 的 Cookie，只在授权和回调接口之间传递
+	oauthStateCookie     = "oauth_state"
+	oauthStateCookiePath = "/api/v1/auth/oauth"
+	// 与服务端保存的登录请求有效期一致
+	oauthStateMaxAge = 10 * 60
+)
+
+// AuthHandler 认证处理器
+type AuthHandler struct {
+	userService      service.UserService
+	tokenService     service.TokenService
+	accountService   service.AccountService
+	twoFactorService service.TwoFactorService
+	oauthService     service.OAuthService
+	loginGuard       service.LoginGuardService
+}
+
+// NewAuthHandler 创建认证处理器
+func NewAuthHandler(userService service.UserService, tokenService service.TokenService, accountService service.AccountService, twoFactorService service.TwoFactorService, oauthService service.OAuthService, loginGuard service.LoginGuardService) *AuthHandler {
+	return &AuthHandler{
+		userService:      userService,
+		tokenService:     tokenService,
+		accountService:   accountService,
+		twoFactorService: twoFactorService,
+		oauthService:     oauthService,
+		loginGuard:       loginGuard,
+	}
+}
 
 // Register 用户注册
-func Register(c *gin.Context) {
+func (h *AuthHandler) Register(c *gin.Context) {
 	// 绑定请求参数
 	var req model.UserRegisterRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "参数错误: "+err.Error())
+	if !response.BindJSON(c, &req) {
 		return
 	}
 
 	// 检查用户名是否已存在
-	_, err := userService.GetUserByUsername(req.Username)
+	_, err := h.userService.GetUserByUsername(c.Request.Context(), req.Username)
 	if err == nil {
-		response.BadRequest(c, "用户名已存在")
+		response.Error(c, http.StatusBadRequest, response.CodeUsernameTaken, "用户名已存在")
 		return
 	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
-		response.ServerError(c, "服务器错误")
+		respondError(c, err, "服务器错误")
 		return
 	}
 
 	// 检查邮箱是否已存在
-	_, err = userService.GetUserByEmail(req.Email)
+	_, err = h.userService.GetUserByEmail(c.Request.Context(), req.Email)
 	if err == nil {
-		response.BadRequest(c, "邮箱已存在")
+		response.Error(c, http.StatusBadRequest, response.CodeEmailInUse, "邮箱已存在")
 		return
 	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
-		response.ServerError(c, "服务器错误")
+		respondError(c, err, "服务器错误")
 		return
 	}
 
 	// 密码加密
 	passwordHash, err := utils.HashPassword(req.Password)
 	if err != nil {
-		response.ServerError(c, "密码加密失败")
+		respondError(c, err, "密码加密失败")
 		return
 	}
 
@@ -58,60 +89,351 @@ func Register(c *gin.Context) {
 		Role:         "user", // 默认为普通用户
 	}
 
-	if err := userService.CreateUser(user); err != nil {
-		response.ServerError(c, "创建用户失败: "+err.Error())
+	if err := h.userService.CreateUser(c.Request.Context(), user); err != nil {
+		respondError(c, err, "创建用户失败")
 		return
 	}
 
-	// 生成token
-	token, err := utils.GenerateToken(user.ID, user.Role)
+	// 发送验证邮件，发送失败不影响注册，用户可以稍后重新发送
+	if err := h.accountService.SendVerificationEmail(c.Request.Context(), user); err != nil {
+		logger.FromContext(c.Request.Context()).Error("发送验证邮件失败", logger.Uint("user_id", user.ID), logger.Err(err))
+	}
+
+	// 签发令牌
+	tokens, err := h.tokenService.IssueTokens(c.Request.Context(), user, clientInfo(c), false)
 	if err != nil {
-		response.ServerError(c, "生成令牌失败")
+		respondError(c, err, "生成令牌失败")
 		return
 	}
 
 	// 返回用户信息和token
 	response.Success(c, model.UserWithToken{
-		User:  user,
-		Token: token,
+		User:      user,
+		TokenPair: *tokens,
 	})
 }
 
 // Login 用户登录
-func Login(c *gin.Context) {
+func (h *AuthHandler) Login(c *gin.Context) {
 	// 绑定请求参数
 	var req model.UserLoginRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "参数错误: "+err.Error())
+	if !response.BindJSON(c, &req) {
+		return
+	}
+
+	// 失败次数过多时不再校验密码
+	if err := h.loginGuard.Check(c.Request.Context(), req.Username, c.ClientIP()); err != nil {
+		loginBlocked(c, err)
 		return
 	}
 
 	// 根据用户名获取用户
-	user, err := userService.GetUserByUsername(req.Username)
+	user, err := h.userService.GetUserByUsername(c.Request.Context(), req.Username)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		response.BadRequest(c, "用户名或密码错误")
+		h.loginFailed(c, req.Username)
 		return
 	} else if err != nil {
-		response.ServerError(c, "服务器错误")
+		respondError(c, err, "服务器错误")
 		return
 	}
 
 	// 验证密码
 	if !utils.CheckPasswordHash(req.Password, user.PasswordHash) {
-		response.BadRequest(c, "用户名或密码错误")
+		h.loginFailed(c, req.Username)
+		return
+	}
+
+	// 清除失败记录
+	if err := h.loginGuard.RecordSuccess(c.Request.Context(), req.Username); err != nil {
+		logger.FromContext(c.Request.Context()).Error("清除登录失败记录失败", logger.Err(err))
+	}
+
+	h.completeLogin(c, user)
+}
+
+// loginFailed 记录失败的登录，用户不存在和密码错误返回相同的结果
+func (h *AuthHandler) loginFailed(c *gin.Context, username string) {
+	err := h.loginGuard.RecordFailure(c.Request.Context(), username, c.ClientIP())
+	if errors.Is(err, utils.ErrAccountLocked) {
+		loginBlocked(c, err)
+		return
+	} else if err != nil {
+		logger.FromContext(c.Request.Context()).Error("记录登录失败次数失败", logger.Err(err))
+	}
+
+	response.Error(c, http.StatusBadRequest, response.CodeInvalidCredentials, "用户名或密码错误")
+}
+
+// loginBlocked 拒绝登录尝试，并通过 Retry-After 告知需要等待的时间
+func loginBlocked(c *gin.Context, err error) {
+	var retry *utils.RetryAfterError
+	if !errors.As(err, &retry) {
+		respondError(c, err, "服务器错误")
+		return
+	}
+
+	seconds := int(math.Ceil(retry.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+
+	if errors.Is(err, utils.ErrAccountLocked) {
+		response.Errorf(c, http.StatusForbidden, response.CodeAccountLocked, "登录失败次数过多，账号已被临时锁定，请 %d 分钟后再试", (seconds+59)/60)
+		return
+	}
+	response.Errorf(c, http.StatusTooManyRequests, response.CodeTooManyAttempts, "登录失败次数过多，请 %d 秒后再试", seconds)
+}
+
+// LoginTwoFactor 两步验证登录，提交登录挑战和验证码或备用码
+func (h *AuthHandler) LoginTwoFactor(c *gin.Context) {
+	// 绑定请求参数
+	var req model.TwoFactorLoginRequest
+	if !response.BindJSON(c, &req) {
+		return
+	}
+
+	user, err := h.twoFactorService.VerifyChallenge(c.Request.Context(), req.ChallengeToken, req.Code)
+	if err != nil {
+		if errors.Is(err, utils.ErrAccountLocked) {
+			loginBlocked(c, err)
+			return
+		}
+		if errors.Is(err, utils.ErrInvalidToken) {
+			response.Error(c, http.StatusUnauthorized, response.CodeInvalidToken, "登录已过期，请重新登录")
+			return
+		}
+		respondError(c, err, "两步验证失败")
+		return
+	}
+
+	// 签发令牌
+	tokens, err := h.tokenService.IssueTokens(c.Request.Context(), user, clientInfo(c), true)
+	if err != nil {
+		respondError(c, err, "生成令牌失败")
+		return
+	}
+
+	// 返回用户信息和token
+	response.Success(c, model.UserWithToken{
+		User:      user,
+		TokenPair: *tokens,
+	})
+}
+
+// ListOAuthProviders 获取可用的第三方登录方式
+func (h *AuthHandler) ListOAuthProviders(c *gin.Context) {
+	response.Success(c, h.oauthService.Providers())
+}
+
+// OAuthAuthorize 生成第三方登录的授权地址
+func (h *AuthHandler) OAuthAuthorize(c *gin.Context) {
+	authorization, err := h.oauthService.Authorize(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		respondError(c, err, "生成授权地址失败")
+		return
+	}
+
+	// state 同时写入浏览器 Cookie，回调时比对，防止攻击者诱导用户登录到攻击者的账号
+	setOAuthStateCookie(c, authorization.State, oauthStateMaxAge)
+	response.Success(c, authorization)
+}
+
+// OAuthCallback 第三方登录回调，提交身份提供方返回的授权码和 state
+func (h *AuthHandler) OAuthCallback(c *gin.Context) {
+	// 绑定请求参数
+	var req model.OAuthCallbackRequest
+	if !response.BindJSON(c, &req) {
+		return
+	}
+
+	// 回调必须来自发起授权的同一浏览器
+	cookie, err := c.Cookie(oauthStateCookie)
+	setOAuthStateCookie(c, "", -1)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie), []byte(req.State)) != 1 {
+		respondError(c, utils.ErrInvalidOAuthState, "第三方登录失败")
+		return
+	}
+
+	user, err := h.oauthService.Login(c.Request.Context(), c.Param("provider"), req.Code, req.State)
+	if err != nil {
+		switch {
+		case errors.Is(err, utils.ErrEmailInUse):
+			response.Error(c, http.StatusBadRequest, response.CodeEmailInUse, "该邮箱已注册，请使用密码登录")
+		case errors.Is(err, utils.ErrUserNotFound):
+			response.Error(c, http.StatusForbidden, response.CodeUserNotFound, "账号不存在或已被删除")
+		case errors.Is(err, utils.ErrOAuthFailed):
+			logger.FromContext(c.Request.Context()).Error("第三方登录失败", logger.Err(err))
+			response.Error(c, http.StatusUnauthorized, response.CodeOAuthFailed, "第三方登录失败")
+		default:
+			respondError(c, err, "第三方登录失败")
+		}
+		return
+	}
+
+	h.completeLogin(c, user)
+}
+
+// setOAuthStateCookie 设置或清除（maxAge 为负数时）保存 state 的 Cookie
+func setOAuthStateCookie(c *gin.Context, state string, maxAge int) {
+	secure := c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthStateCookie, state, maxAge, oauthStateCookiePath, "", secure, true)
+}
+
+// completeLogin 身份校验通过后签发令牌，启用两步验证的用户返回登录挑战
+func (h *AuthHandler) completeLogin(c *gin.Context, user *model.User) {
+	// 启用两步验证的用户需要再提交验证码
+	if user.TwoFactor {
+		challenge, err := h.twoFactorService.CreateChallenge(c.Request.Context(), user)
+		if err != nil {
+			respondError(c, err, "生成登录挑战失败")
+			return
+		}
+		response.Success(c, challenge)
 		return
 	}
 
-	// 生成token
-	token, err := utils.GenerateToken(user.ID, user.Role)
+	// 签发令牌
+	tokens, err := h.tokenService.IssueTokens(c.Request.Context(), user, clientInfo(c), false)
 	if err != nil {
-		response.ServerError(c, "生成令牌失败")
+		respondError(c, err, "生成令牌失败")
 		return
 	}
 
 	// 返回用户信息和token
 	response.Success(c, model.UserWithToken{
-		User:  user,
-		Token: token,
+		User:      user,
+		TokenPair: *tokens,
 	})
 }
+
+// RefreshToken 使用刷新令牌换取新的令牌
+func (h *AuthHandler) RefreshToken(c *gin.Context) {
+	// 绑定请求参数
+	var req struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if !response.BindJSON(c, &req) {
+		return
+	}
+
+	// 轮换刷新令牌
+	tokens, err := h.tokenService.Refresh(c.Request.Context(), req.RefreshToken, clientInfo(c))
+	if err != nil {
+		if errors.Is(err, utils.ErrInvalidToken) || errors.Is(err, utils.ErrTokenRevoked) {
+			response.Error(c, http.StatusUnauthorized, response.CodeInvalidToken, "刷新令牌无效或已失效")
+			return
+		}
+		respondError(c, err, "刷新令牌失败")
+		return
+	}
+
+	response.Success(c, tokens)
+}
+
+// Logout 退出登录，吊销当前访问令牌和刷新令牌
+func (h *AuthHandler) Logout(c *gin.Context) {
+	// 从上下文中获取令牌声明
+	claims, exists := c.Get("claims")
+	if !exists {
+		response.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	// 刷新令牌可选
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if c.Request.ContentLength > 0 {
+		if !response.BindJSON(c, &req) {
+			return
+		}
+	}
+
+	if err := h.tokenService.Logout(c.Request.Context(), claims.(*utils.CustomClaims), req.RefreshToken); err != nil {
+		respondError(c, err, "退出登录失败")
+		return
+	}
+
+	response.Success(c, "退出登录成功")
+}
+
+// VerifyEmail 验证邮箱
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	// 绑定请求参数
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if !response.BindJSON(c, &req) {
+		return
+	}
+
+	if err := h.accountService.VerifyEmail(c.Request.Context(), req.Token); err != nil {
+		if errors.Is(err, utils.ErrInvalidToken) {
+			response.Error(c, http.StatusBadRequest, response.CodeInvalidToken, "验证链接无效或已过期")
+			return
+		}
+		respondError(c, err, "验证邮箱失败")
+		return
+	}
+
+	response.Success(c, "邮箱验证成功")
+}
+
+// ResendVerificationEmail 重新发送验证邮件
+func (h *AuthHandler) ResendVerificationEmail(c *gin.Context) {
+	// 从上下文中获取用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	user, err := h.userService.GetUserByID(c.Request.Context(), userID.(uint))
+	if err != nil {
+		respondError(c, err, "获取用户信息失败")
+		return
+	}
+
+	if err := h.accountService.SendVerificationEmail(c.Request.Context(), user); err != nil {
+		respondError(c, err, "发送验证邮件失败")
+		return
+	}
+
+	response.Success(c, "验证邮件已发送")
+}
+
+// ForgotPassword 发送密码重置邮件
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	// 绑定请求参数
+	var req model.ForgotPasswordRequest
+	if !response.BindJSON(c, &req) {
+		return
+	}
+
+	if err := h.accountService.SendPasswordReset(c.Request.Context(), req.Email); err != nil {
+		respondError(c, err, "发送重置邮件失败")
+		return
+	}
+
+	// 无论邮箱是否注册都返回相同结果
+	response.Success(c, "如果该邮箱已注册，重置邮件已发送")
+}
+
+// ResetPassword 使用邮件中的令牌重置密码
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	// 绑定请求参数
+	var req model.ResetPasswordRequest
+	if !response.BindJSON(c, &req) {
+		return
+	}
+
+	if err := h.accountService.ResetPassword(c.Request.Context(), req.Token, req.NewPassword); err != nil {
+		if errors.Is(err, utils.ErrInvalidToken) {
+			response.Error(c, http.StatusBadRequest, response.CodeInvalidToken, "重置链接无效或已过期")
+			return
+		}
+		respondError(c, err, "重置密码失败")
+		return
+	}
+
+	response.Success(c, "密码重置成功，请重新登录")
+}
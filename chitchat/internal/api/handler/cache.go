@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/lllllan02/chitchat/internal/cache"
+	"github.com/lllllan02/chitchat/pkg/response"
+)
+
+// CacheHandler 缓存处理器
+type CacheHandler struct {
+	cache *cache.Cache
+}
+
+// NewCacheHandler 创建缓存处理器，未启用缓存时 c 为 nil
+func NewCacheHandler(c *cache.Cache) *CacheHandler {
+	return &CacheHandler{cache: c}
+}
+
+// Stats 获取缓存命中统计
+func (h *CacheHandler) Stats(c *gin.Context) {
+	if h.cache == nil {
+		response.Success(c, gin.H{"enabled": false, "groups": []cache.Stat{}})
+		return
+	}
+
+	response.Success(c, gin.H{"enabled": true, "groups": h.cache.Stats()})
+}
@@ -8,14 +8,21 @@ import (
 	"github.com/lllllan02/chitchat/pkg/response"
 )
 
-// 初始化分类服务
-var categoryService = service.NewCategoryService()
+// CategoryHandler 分类处理器
+type CategoryHandler struct {
+	categoryService service.CategoryService
+}
+
+// NewCategoryHandler 创建分类处理器
+func NewCategoryHandler(categoryService service.CategoryService) *CategoryHandler {
+	return &CategoryHandler{categoryService: categoryService}
+}
 
 // ListCategories 获取分类列表
-func ListCategories(c *gin.Context) {
-	categories, err := categoryService.ListCategories()
+func (h *CategoryHandler) ListCategories(c *gin.Context) {
+	categories, err := h.categoryService.ListCategories(c.Request.Context())
 	if err != nil {
-		response.ServerError(c, "获取分类列表失败")
+		respondError(c, err, "获取分类列表失败")
 		return
 	}
 
@@ -23,7 +30,7 @@ func ListCategories(c *gin.Context) {
 }
 
 // GetCategory 获取分类详情
-func GetCategory(c *gin.Context) {
+func (h *CategoryHandler) GetCategory(c *gin.Context) {
 	// 获取分类ID
 	categoryIDStr := c.Param("id")
 	categoryID, err := strconv.ParseUint(categoryIDStr, 10, 32)
@@ -33,9 +40,9 @@ func GetCategory(c *gin.Context) {
 	}
 
 	// 获取分类
-	category, err := categoryService.GetCategoryByID(uint(categoryID))
+	category, err := h.categoryService.GetCategoryByID(c.Request.Context(), uint(categoryID))
 	if err != nil {
-		response.NotFound(c, "分类不存在")
+		respondError(c, err, "获取分类失败")
 		return
 	}
 
@@ -43,21 +50,20 @@ func GetCategory(c *gin.Context) {
 }
 
 // CreateCategory 创建分类
-func CreateCategory(c *gin.Context) {
+func (h *CategoryHandler) CreateCategory(c *gin.Context) {
 	// 绑定请求参数
 	var req struct {
 		Name        string `json:"name" binding:"required"`
 		Description string `json:"description"`
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "参数错误: "+err.Error())
+	if !response.BindJSON(c, &req) {
 		return
 	}
 
 	// 创建分类
-	category, err := categoryService.CreateCategory(req.Name, req.Description)
+	category, err := h.categoryService.CreateCategory(c.Request.Context(), req.Name, req.Description)
 	if err != nil {
-		response.ServerError(c, "创建分类失败")
+		respondError(c, err, "创建分类失败")
 		return
 	}
 
@@ -65,7 +71,7 @@ func CreateCategory(c *gin.Context) {
 }
 
 // UpdateCategory 更新分类
-func UpdateCategory(c *gin.Context) {
+func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
 	// 获取分类ID
 	categoryIDStr := c.Param("id")
 	categoryID, err := strconv.ParseUint(categoryIDStr, 10, 32)
@@ -79,15 +85,14 @@ func UpdateCategory(c *gin.Context) {
 		Name        string `json:"name"`
 		Description string `json:"description"`
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "参数错误: "+err.Error())
+	if !response.BindJSON(c, &req) {
 		return
 	}
 
 	// 获取原始分类
-	category, err := categoryService.GetCategoryByID(uint(categoryID))
+	category, err := h.categoryService.GetCategoryByID(c.Request.Context(), uint(categoryID))
 	if err != nil {
-		response.NotFound(c, "分类不存在")
+		respondError(c, err, "获取分类失败")
 		return
 	}
 
@@ -99,8 +104,8 @@ func UpdateCategory(c *gin.Context) {
 		category.Description = req.Description
 	}
 
-	if err := categoryService.UpdateCategory(category); err != nil {
-		response.ServerError(c, "更新分类失败")
+	if err := h.categoryService.UpdateCategory(c.Request.Context(), category); err != nil {
+		respondError(c, err, "更新分类失败")
 		return
 	}
 
@@ -108,7 +113,7 @@ func UpdateCategory(c *gin.Context) {
 }
 
 // DeleteCategory 删除分类
-func DeleteCategory(c *gin.Context) {
+func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
 	// 获取分类ID
 	categoryIDStr := c.Param("id")
 	categoryID, err := strconv.ParseUint(categoryIDStr, 10, 32)
@@ -118,8 +123,8 @@ func DeleteCategory(c *gin.Context) {
 	}
 
 	// 删除分类
-	if err := categoryService.DeleteCategory(uint(categoryID)); err != nil {
-		response.ServerError(c, "删除分类失败")
+	if err := h.categoryService.DeleteCategory(c.Request.Context(), uint(categoryID)); err != nil {
+		respondError(c, err, "删除分类失败")
 		return
 	}
 
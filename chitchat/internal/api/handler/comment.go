@@ -1,66 +1,118 @@
 package handler
 
 import (
+	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lllllan02/chitchat/internal/api/middleware"
+	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/pagination"
+	"github.com/lllllan02/chitchat/internal/service"
 	"github.com/lllllan02/chitchat/pkg/response"
 )
 
+// CommentHandler 评论处理器
+type CommentHandler struct {
+	commentService service.CommentService
+	likeService    service.LikeService
+	mentionService service.MentionService
+	staff          middleware.StaffPolicy
+	cursors        *pagination.Codec
+}
+
+// NewCommentHandler 创建评论处理器
+func NewCommentHandler(commentService service.CommentService, likeService service.LikeService, mentionService service.MentionService, staff middleware.StaffPolicy, cursors *pagination.Codec) *CommentHandler {
+	return &CommentHandler{
+		commentService: commentService,
+		likeService:    likeService,
+		mentionService: mentionService,
+		staff:          staff,
+		cursors:        cursors,
+	}
+}
+
 // ListPostComments 获取帖子评论
-func ListPostComments(c *gin.Context) {
+func (h *CommentHandler) ListPostComments(c *gin.Context) {
 	// 获取帖子ID
 	postIDStr := c.Param("id")
-	_, err := strconv.ParseUint(postIDStr, 10, 32)
+	postID, err := strconv.ParseUint(postIDStr, 10, 32)
 	if err != nil {
 		response.BadRequest(c, "无效的帖子ID")
 		return
 	}
 
 	// 获取分页参数
-	_, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
-	_, _ = strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	query := model.CursorQuery{PageSize: 20}
+	if !response.BindQuery(c, &query) {
+		return
+	}
+	req, err := pageRequest(h.cursors, cursorScopeComments, &query)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidCursor, "无效的游标")
+		return
+	}
 
-	// TODO: 实现获取帖子评论功能
-	response.NotImplemented(c, "功能未实现")
+	// 查询评论列表
+	comments, page, err := h.commentService.ListPostComments(c.Request.Context(), uint(postID), req)
+	if err != nil {
+		respondError(c, err, "获取评论列表失败")
+		return
+	}
+
+	// 填充当前用户的点赞状态
+	if err := h.likeService.MarkLikedComments(c.Request.Context(), currentUserID(c), comments); err != nil {
+		respondError(c, err, "获取点赞状态失败")
+		return
+	}
+
+	// 填充评论中提及的用户
+	if err := h.mentionService.FillCommentMentions(c.Request.Context(), comments); err != nil {
+		respondError(c, err, "获取提及用户失败")
+		return
+	}
+
+	response.List(c, comments, cursorMeta(h.cursors, cursorScopeComments, req, page))
 }
 
 // CreateComment 创建评论
-func CreateComment(c *gin.Context) {
+func (h *CommentHandler) CreateComment(c *gin.Context) {
 	// 从上下文中获取用户ID
-	_, exists := c.Get("userID")
+	userID, exists := c.Get("userID")
 	if !exists {
 		response.Unauthorized(c, "用户未认证")
 		return
 	}
 
 	// 绑定请求参数
-	var req struct {
-		PostID   uint   `json:"post_id" binding:"required"`
-		Content  string `json:"content" binding:"required"`
-		ParentID uint   `json:"parent_id"`
+	var req model.CommentRequest
+	if !response.BindJSON(c, &req) {
+		return
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "参数错误: "+err.Error())
+
+	// 创建评论
+	comment, err := h.commentService.CreateComment(c.Request.Context(), userID.(uint), req.PostID, req.ParentID, req.Content)
+	if err != nil {
+		respondError(c, err, "创建评论失败")
 		return
 	}
 
-	// TODO: 实现创建评论功能
-	response.NotImplemented(c, "功能未实现")
+	response.Success(c, comment)
 }
 
 // UpdateComment 更新评论
-func UpdateComment(c *gin.Context) {
-	// 从上下文中获取用户ID
-	_, exists := c.Get("userID")
+func (h *CommentHandler) UpdateComment(c *gin.Context) {
+	// 从上下文中获取用户ID和角色
+	userID, exists := c.Get("userID")
 	if !exists {
 		response.Unauthorized(c, "用户未认证")
 		return
 	}
+	canModerate := h.staff.Allows(c, middleware.RoleAdmin, middleware.RoleModerator)
 
 	// 获取评论ID
 	commentIDStr := c.Param("id")
-	_, err := strconv.ParseUint(commentIDStr, 10, 32)
+	commentID, err := strconv.ParseUint(commentIDStr, 10, 32)
 	if err != nil {
 		response.BadRequest(c, "无效的评论ID")
 		return
@@ -70,42 +122,52 @@ func UpdateComment(c *gin.Context) {
 	var req struct {
 		Content string `json:"content" binding:"required"`
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "参数错误: "+err.Error())
+	if !response.BindJSON(c, &req) {
 		return
 	}
 
-	// TODO: 实现更新评论功能
-	response.NotImplemented(c, "功能未实现")
+	// 更新评论
+	comment, err := h.commentService.UpdateComment(c.Request.Context(), uint(commentID), userID.(uint), req.Content, canModerate)
+	if err != nil {
+		respondError(c, err, "更新评论失败")
+		return
+	}
+
+	response.Success(c, comment)
 }
 
 // DeleteComment 删除评论
-func DeleteComment(c *gin.Context) {
+func (h *CommentHandler) DeleteComment(c *gin.Context) {
 	// 从上下文中获取用户ID和角色
-	_, exists := c.Get("userID")
+	userID, exists := c.Get("userID")
 	if !exists {
 		response.Unauthorized(c, "用户未认证")
 		return
 	}
-	userRole, _ := c.Get("userRole")
-	_ = userRole.(string) == "admin"
+	canModerate := h.staff.Allows(c, middleware.RoleAdmin, middleware.RoleModerator)
 
 	// 获取评论ID
 	commentIDStr := c.Param("id")
-	_, err := strconv.ParseUint(commentIDStr, 10, 32)
+	commentID, err := strconv.ParseUint(commentIDStr, 10, 32)
 	if err != nil {
 		response.BadRequest(c, "无效的评论ID")
 		return
 	}
 
-	// TODO: 实现删除评论功能
-	response.NotImplemented(c, "功能未实现")
+	// 删除评论
+	err = h.commentService.DeleteComment(c.Request.Context(), uint(commentID), userID.(uint), canModerate)
+	if err != nil {
+		respondError(c, err, "删除评论失败")
+		return
+	}
+
+	response.Success(c, "删除评论成功")
 }
 
 // LikeComment 点赞评论
-func LikeComment(c *gin.Context) {
+func (h *CommentHandler) LikeComment(c *gin.Context) {
 	// 从上下文中获取用户ID
-	_, exists := c.Get("userID")
+	userID, exists := c.Get("userID")
 	if !exists {
 		response.Unauthorized(c, "用户未认证")
 		return
@@ -113,20 +175,29 @@ func LikeComment(c *gin.Context) {
 
 	// 获取评论ID
 	commentIDStr := c.Param("id")
-	_, err := strconv.ParseUint(commentIDStr, 10, 32)
+	commentID, err := strconv.ParseUint(commentIDStr, 10, 32)
 	if err != nil {
 		response.BadRequest(c, "无效的评论ID")
 		return
 	}
 
-	// TODO: 实现点赞评论功能
-	response.NotImplemented(c, "功能未实现")
+	// 点赞评论
+	comment, err := h.likeService.LikeComment(c.Request.Context(), userID.(uint), uint(commentID))
+	if err != nil {
+		respondError(c, err, "点赞评论失败")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"like_count":  comment.LikeCount,
+		"liked_by_me": comment.LikedByMe,
+	})
 }
 
 // UnlikeComment 取消点赞评论
-func UnlikeComment(c *gin.Context) {
+func (h *CommentHandler) UnlikeComment(c *gin.Context) {
 	// 从上下文中获取用户ID
-	_, exists := c.Get("userID")
+	userID, exists := c.Get("userID")
 	if !exists {
 		response.Unauthorized(c, "用户未认证")
 		return
@@ -134,12 +205,21 @@ func UnlikeComment(c *gin.Context) {
 
 	// 获取评论ID
 	commentIDStr := c.Param("id")
-	_, err := strconv.ParseUint(commentIDStr, 10, 32)
+	commentID, err := strconv.ParseUint(commentIDStr, 10, 32)
 	if err != nil {
 		response.BadRequest(c, "无效的评论ID")
 		return
 	}
 
-	// TODO: 实现取消点赞评论功能
-	response.NotImplemented(c, "功能未实现")
+	// 取消点赞评论
+	comment, err := h.likeService.UnlikeComment(c.Request.Context(), userID.(uint), uint(commentID))
+	if err != nil {
+		respondError(c, err, "取消点赞失败")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"like_count":  comment.LikeCount,
+		"liked_by_me": comment.LikedByMe,
+	})
 }
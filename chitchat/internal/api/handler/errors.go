@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lllllan02/chitchat/internal/utils"
+	"github.com/lllllan02/chitchat/pkg/response"
+	"gorm.io/gorm"
+)
+
+// errorResponse 业务错误对应的状态码、错误码和提示
+type errorResponse struct {
+	err     error
+	status  int
+	code    response.ErrorCode
+	message string
+}
+
+// errorResponses 服务层返回的业务错误与响应的对应关系，按顺序匹配
+var errorResponses = []errorResponse{
+	{utils.ErrPermissionDenied, http.StatusForbidden, response.CodePermissionDenied, "没有权限执行该操作"},
+	{utils.ErrCategoryNotFound, http.StatusNotFound, response.CodeCategoryNotFound, "分类不存在"},
+	{utils.ErrUserNotFound, http.StatusNotFound, response.CodeUserNotFound, "用户不存在"},
+	{utils.ErrPostNotFound, http.StatusNotFound, response.CodePostNotFound, "帖子不存在"},
+	{utils.ErrCommentNotFound, http.StatusNotFound, response.CodeCommentNotFound, "评论不存在"},
+	{utils.ErrInvalidParentComment, http.StatusBadRequest, response.CodeInvalidParent, "父评论不属于该帖子"},
+	{utils.ErrCannotFollowSelf, http.StatusBadRequest, response.CodeCannotFollowSelf, "不能关注自己"},
+	{utils.ErrNotificationNotFound, http.StatusNotFound, response.CodeNotificationNotFound, "通知不存在"},
+	{utils.ErrInvalidToken, http.StatusUnauthorized, response.CodeInvalidToken, "无效的令牌"},
+	{utils.ErrTokenRevoked, http.StatusUnauthorized, response.CodeTokenRevoked, "令牌已失效，请重新登录"},
+	{utils.ErrSessionNotFound, http.StatusNotFound, response.CodeSessionNotFound, "会话不存在"},
+	{utils.ErrEmailAlreadyVerified, http.StatusBadRequest, response.CodeEmailAlreadyVerified, "邮箱已验证"},
+	{utils.ErrEmailNotVerified, http.StatusForbidden, response.CodeEmailNotVerified, "请先验证邮箱"},
+	{utils.ErrTwoFactorEnabled, http.StatusBadRequest, response.CodeTwoFactorEnabled, "已启用两步验证"},
+	{utils.ErrTwoFactorNotEnabled, http.StatusBadRequest, response.CodeTwoFactorNotEnabled, "未启用两步验证"},
+	{utils.ErrInvalidTwoFactorCode, http.StatusBadRequest, response.CodeInvalidTwoFactorCode, "验证码错误"},
+	{utils.ErrInvalidPassword, http.StatusBadRequest, response.CodeInvalidPassword, "密码错误"},
+	{utils.ErrProviderNotFound, http.StatusNotFound, response.CodeProviderNotFound, "不支持的登录方式"},
+	{utils.ErrInvalidOAuthState, http.StatusBadRequest, response.CodeInvalidOAuthState, "登录请求无效或已过期，请重新登录"},
+	{utils.ErrOAuthFailed, http.StatusUnauthorized, response.CodeOAuthFailed, "第三方登录失败"},
+	{utils.ErrEmailInUse, http.StatusBadRequest, response.CodeEmailInUse, "该邮箱已注册"},
+	{utils.ErrAccountLocked, http.StatusForbidden, response.CodeAccountLocked, "账号已被临时锁定，请稍后再试"},
+	{utils.ErrTooManyAttempts, http.StatusTooManyRequests, response.CodeTooManyAttempts, "失败次数过多，请稍后再试"},
+	{utils.ErrInvalidCursor, http.StatusBadRequest, response.CodeInvalidCursor, "无效的游标"},
+	{utils.ErrSearchNotReady, http.StatusServiceUnavailable, response.CodeSearchNotReady, "搜索索引正在重建，请稍后再试"},
+	{gorm.ErrRecordNotFound, http.StatusNotFound, response.CodeNotFound, "资源不存在"},
+}
+
+// respondError 按业务错误返回对应的响应，未知错误记录日志后返回 500，提示为 fallback
+func respondError(c *gin.Context, err error, fallback string) {
+	for _, r := range errorResponses {
+		if errors.Is(err, r.err) {
+			response.Error(c, r.status, r.code, r.message)
+			return
+		}
+	}
+	response.InternalError(c, fallback, err)
+}
@@ -1,16 +1,35 @@
 package handler
 
 import (
+	"context"
+	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/pagination"
+	"github.com/lllllan02/chitchat/internal/service"
 	"github.com/lllllan02/chitchat/pkg/response"
 )
 
+// FollowHandler 关注处理器
+type FollowHandler struct {
+	followService service.FollowService
+	cursors       *pagination.Codec
+}
+
+// NewFollowHandler 创建关注处理器
+func NewFollowHandler(followService service.FollowService, cursors *pagination.Codec) *FollowHandler {
+	return &FollowHandler{
+		followService: followService,
+		cursors:       cursors,
+	}
+}
+
 // FollowUser 关注用户
-func FollowUser(c *gin.Context) {
+func (h *FollowHandler) FollowUser(c *gin.Context) {
 	// 从上下文中获取用户ID
-	_, exists := c.Get("userID")
+	userID, exists := c.Get("userID")
 	if !exists {
 		response.Unauthorized(c, "用户未认证")
 		return
@@ -18,20 +37,25 @@ func FollowUser(c *gin.Context) {
 
 	// 获取目标用户ID
 	targetIDStr := c.Param("id")
-	_, err := strconv.ParseUint(targetIDStr, 10, 32)
+	targetID, err := strconv.ParseUint(targetIDStr, 10, 32)
 	if err != nil {
 		response.BadRequest(c, "无效的用户ID")
 		return
 	}
 
-	// TODO: 实现关注用户功能
-	response.NotImplemented(c, "功能未实现")
+	// 关注用户
+	if err := h.followService.Follow(c.Request.Context(), userID.(uint), uint(targetID)); err != nil {
+		respondError(c, err, "关注用户失败")
+		return
+	}
+
+	response.Success(c, "关注成功")
 }
 
 // UnfollowUser 取消关注用户
-func UnfollowUser(c *gin.Context) {
+func (h *FollowHandler) UnfollowUser(c *gin.Context) {
 	// 从上下文中获取用户ID
-	_, exists := c.Get("userID")
+	userID, exists := c.Get("userID")
 	if !exists {
 		response.Unauthorized(c, "用户未认证")
 		return
@@ -39,46 +63,112 @@ func UnfollowUser(c *gin.Context) {
 
 	// 获取目标用户ID
 	targetIDStr := c.Param("id")
-	_, err := strconv.ParseUint(targetIDStr, 10, 32)
+	targetID, err := strconv.ParseUint(targetIDStr, 10, 32)
 	if err != nil {
 		response.BadRequest(c, "无效的用户ID")
 		return
 	}
 
-	// TODO: 实现取消关注用户功能
-	response.NotImplemented(c, "功能未实现")
+	// 取消关注用户
+	if err := h.followService.Unfollow(c.Request.Context(), userID.(uint), uint(targetID)); err != nil {
+		respondError(c, err, "取消关注失败")
+		return
+	}
+
+	response.Success(c, "取消关注成功")
 }
 
-// ListFollowers 获取粉丝列表
-func ListFollowers(c *gin.Context) {
+// ListFollowers 获取当前用户的粉丝列表
+func (h *FollowHandler) ListFollowers(c *gin.Context) {
 	// 从上下文中获取用户ID
-	_, exists := c.Get("userID")
+	userID, exists := c.Get("userID")
 	if !exists {
 		response.Unauthorized(c, "用户未认证")
 		return
 	}
 
-	// 获取分页参数
-	_, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
-	_, _ = strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	h.listFollowUsers(c, userID.(uint), h.followService.ListFollowers)
+}
+
+// ListFollowing 获取当前用户的关注列表
+func (h *FollowHandler) ListFollowing(c *gin.Context) {
+	// 从上下文中获取用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	h.listFollowUsers(c, userID.(uint), h.followService.ListFollowing)
+}
+
+// ListUserFollowers 获取指定用户的粉丝列表
+func (h *FollowHandler) ListUserFollowers(c *gin.Context) {
+	// 获取用户ID
+	userIDStr := c.Param("id")
+	userID, err := strconv.ParseUint(userIDStr, 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的用户ID")
+		return
+	}
 
-	// TODO: 实现获取粉丝列表功能
-	response.NotImplemented(c, "功能未实现")
+	h.listFollowUsers(c, uint(userID), h.followService.ListFollowers)
 }
 
-// ListFollowing 获取关注列表
-func ListFollowing(c *gin.Context) {
+// ListUserFollowing 获取指定用户的关注列表
+func (h *FollowHandler) ListUserFollowing(c *gin.Context) {
+	// 获取用户ID
+	userIDStr := c.Param("id")
+	userID, err := strconv.ParseUint(userIDStr, 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的用户ID")
+		return
+	}
+
+	h.listFollowUsers(c, uint(userID), h.followService.ListFollowing)
+}
+
+// ListMutualFollowers 获取当前用户关注的人中也关注了指定用户的人
+func (h *FollowHandler) ListMutualFollowers(c *gin.Context) {
 	// 从上下文中获取用户ID
-	_, exists := c.Get("userID")
+	viewerID, exists := c.Get("userID")
 	if !exists {
 		response.Unauthorized(c, "用户未认证")
 		return
 	}
 
+	// 获取目标用户ID
+	userIDStr := c.Param("id")
+	userID, err := strconv.ParseUint(userIDStr, 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的用户ID")
+		return
+	}
+
+	h.listFollowUsers(c, uint(userID), func(ctx context.Context, userID uint, req *pagination.Request) ([]*model.User, *pagination.Page, error) {
+		return h.followService.ListMutualFollowers(ctx, viewerID.(uint), userID, req)
+	})
+}
+
+// listFollowUsers 分页返回关注关系中的用户列表
+func (h *FollowHandler) listFollowUsers(c *gin.Context, userID uint, list func(ctx context.Context, userID uint, req *pagination.Request) ([]*model.User, *pagination.Page, error)) {
 	// 获取分页参数
-	_, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
-	_, _ = strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	query := model.CursorQuery{PageSize: 10}
+	if !response.BindQuery(c, &query) {
+		return
+	}
+	req, err := pageRequest(h.cursors, cursorScopeFollows, &query)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidCursor, "无效的游标")
+		return
+	}
+
+	// 查询用户列表
+	users, page, err := list(c.Request.Context(), userID, req)
+	if err != nil {
+		respondError(c, err, "获取用户列表失败")
+		return
+	}
 
-	// TODO: 实现获取关注列表功能
-	response.NotImplemented(c, "功能未实现")
+	response.List(c, users, cursorMeta(h.cursors, cursorScopeFollows, req, page))
 }
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/utils"
+)
+
+// Handlers 路由使用的全部处理器
+type Handlers struct {
+	Auth         *AuthHandler
+	User         *UserHandler
+	Category     *CategoryHandler
+	Post         *PostHandler
+	Comment      *CommentHandler
+	Follow       *FollowHandler
+	Session      *SessionHandler
+	TwoFactor    *TwoFactorHandler
+	Notification *NotificationHandler
+	Stream       *StreamHandler
+	Cache        *CacheHandler
+	Search       *SearchHandler
+}
+
+// currentUserID 获取当前登录用户ID，游客返回0
+func currentUserID(c *gin.Context) uint {
+	userID, exists := c.Get("userID")
+	if !exists {
+		return 0
+	}
+	return userID.(uint)
+}
+
+// currentTwoFactor 当前会话登录时是否通过了两步验证
+func currentTwoFactor(c *gin.Context) bool {
+	claims, exists := c.Get("claims")
+	if !exists {
+		return false
+	}
+	return claims.(*utils.CustomClaims).TwoFactor
+}
+
+// clientInfo 获取发起请求的客户端信息
+func clientInfo(c *gin.Context) model.ClientInfo {
+	return model.ClientInfo{
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+	}
+}
+
+// 爬虫和自动化工具 User-Agent 中常见的关键字
+var botKeywords = []string{"bot", "crawl", "spider", "slurp", "headless", "preview"}
+
+// viewerKey 浏览者标识，登录用户按用户ID，游客按 IP
+// 没有 User-Agent 或来自爬虫的请求返回空字符串
+func viewerKey(c *gin.Context) string {
+	ua := strings.ToLower(c.Request.UserAgent())
+	if ua == "" {
+		return ""
+	}
+	for _, keyword := range botKeywords {
+		if strings.Contains(ua, keyword) {
+			return ""
+		}
+	}
+
+	if userID := currentUserID(c); userID != 0 {
+		return fmt.Sprintf("user:%d", userID)
+	}
+	return "ip:" + c.ClientIP()
+}
@@ -1,29 +1,84 @@
 package handler
 
 import (
+	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/pagination"
+	"github.com/lllllan02/chitchat/internal/service"
 	"github.com/lllllan02/chitchat/pkg/response"
 )
 
+// NotificationHandler 通知处理器
+type NotificationHandler struct {
+	notificationService service.NotificationService
+	cursors             *pagination.Codec
+}
+
+// NewNotificationHandler 创建通知处理器
+func NewNotificationHandler(notificationService service.NotificationService, cursors *pagination.Codec) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+		cursors:             cursors,
+	}
+}
+
 // ListNotifications 获取通知列表
-func ListNotifications(c *gin.Context) {
+func (h *NotificationHandler) ListNotifications(c *gin.Context) {
 	// 从上下文中获取用户ID
-	_, exists := c.Get("userID")
+	userID, exists := c.Get("userID")
 	if !exists {
 		response.Unauthorized(c, "用户未认证")
 		return
 	}
 
-	// TODO: 实现获取通知列表功能
-	response.NotImplemented(c, "功能未实现")
+	// 绑定查询参数
+	query := model.NotificationListQuery{CursorQuery: model.CursorQuery{PageSize: 20}}
+	if !response.BindQuery(c, &query) {
+		return
+	}
+	req, err := pageRequest(h.cursors, cursorScopeNotifications, &query.CursorQuery)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidCursor, "无效的游标")
+		return
+	}
+
+	// 查询通知列表
+	notifications, page, err := h.notificationService.ListNotifications(c.Request.Context(), userID.(uint), &query, req)
+	if err != nil {
+		respondError(c, err, "获取通知列表失败")
+		return
+	}
+
+	response.List(c, notifications, cursorMeta(h.cursors, cursorScopeNotifications, req, page))
+}
+
+// GetUnreadNotificationCount 获取未读通知数
+func (h *NotificationHandler) GetUnreadNotificationCount(c *gin.Context) {
+	// 从上下文中获取用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	count, err := h.notificationService.CountUnread(c.Request.Context(), userID.(uint))
+	if err != nil {
+		respondError(c, err, "获取未读通知数失败")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"unread_count": count,
+	})
 }
 
 // MarkNotificationAsRead 标记通知为已读
-func MarkNotificationAsRead(c *gin.Context) {
+func (h *NotificationHandler) MarkNotificationAsRead(c *gin.Context) {
 	// 从上下文中获取用户ID
-	_, exists := c.Get("userID")
+	userID, exists := c.Get("userID")
 	if !exists {
 		response.Unauthorized(c, "用户未认证")
 		return
@@ -31,25 +86,35 @@ func MarkNotificationAsRead(c *gin.Context) {
 
 	// 获取通知ID
 	notificationIDStr := c.Param("id")
-	_, err := strconv.ParseUint(notificationIDStr, 10, 32)
+	notificationID, err := strconv.ParseUint(notificationIDStr, 10, 32)
 	if err != nil {
 		response.BadRequest(c, "无效的通知ID")
 		return
 	}
 
-	// TODO: 实现标记通知为已读功能
-	response.NotImplemented(c, "功能未实现")
+	// 标记为已读
+	if err := h.notificationService.MarkAsRead(c.Request.Context(), uint(notificationID), userID.(uint)); err != nil {
+		respondError(c, err, "标记通知失败")
+		return
+	}
+
+	response.Success(c, "标记通知成功")
 }
 
 // MarkAllNotificationsAsRead 标记所有通知为已读
-func MarkAllNotificationsAsRead(c *gin.Context) {
+func (h *NotificationHandler) MarkAllNotificationsAsRead(c *gin.Context) {
 	// 从上下文中获取用户ID
-	_, exists := c.Get("userID")
+	userID, exists := c.Get("userID")
 	if !exists {
 		response.Unauthorized(c, "用户未认证")
 		return
 	}
 
-	// TODO: 实现标记所有通知为已读功能
-	response.NotImplemented(c, "功能未实现")
+	// 标记所有通知为已读
+	if err := h.notificationService.MarkAllAsRead(c.Request.Context(), userID.(uint)); err != nil {
+		respondError(c, err, "标记通知失败")
+		return
+	}
+
+	response.Success(c, "标记所有通知成功")
 }
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/pagination"
+	"github.com/lllllan02/chitchat/pkg/response"
+)
+
+// 游标所属的列表，不同列表的排序键不同，游标不能混用
+const (
+	cursorScopePosts         = "posts"
+	cursorScopeUserPosts     = "user_posts"
+	cursorScopeComments      = "comments"
+	cursorScopeNotifications = "notifications"
+	cursorScopeFollows       = "follows"
+)
+
+// pageRequest 解析游标分页参数，游标无效时返回 utils.ErrInvalidCursor
+func pageRequest(cursors *pagination.Codec, scope string, query *model.CursorQuery) (*pagination.Request, error) {
+	cursor, err := cursors.Decode(scope, query.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	return &pagination.Request{
+		Cursor:    cursor,
+		Limit:     query.PageSize,
+		WithTotal: query.WithTotal,
+	}, nil
+}
+
+// cursorMeta 游标分页响应的分页信息
+func cursorMeta(cursors *pagination.Codec, scope string, req *pagination.Request, page *pagination.Page) response.Meta {
+	return response.Meta{
+		PageSize:   req.Limit,
+		Total:      page.Total,
+		NextCursor: encodeCursor(cursors, scope, page.Next),
+		PrevCursor: encodeCursor(cursors, scope, page.Prev),
+		Truncated:  page.Truncated,
+	}
+}
+
+// encodeCursor 编码游标，游标为空时返回 nil
+func encodeCursor(cursors *pagination.Codec, scope string, cursor *pagination.Cursor) *string {
+	if cursor == nil {
+		return nil
+	}
+	token := cursors.Encode(scope, cursor)
+	return &token
+}
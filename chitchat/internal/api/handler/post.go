@@ -1,15 +1,42 @@
 package handler
 
 import (
+	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"github.com/lllllan02/chitchat/internal/utils"
+	"github.com/lllllan02/chitchat/internal/api/middleware"
+	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/pagination"
+	"github.com/lllllan02/chitchat/internal/service"
+	"github.com/lllllan02/chitchat/pkg/logger"
 	"github.com/lllllan02/chitchat/pkg/response"
 )
 
+// PostHandler 帖子处理器
+type PostHandler struct {
+	postService    service.PostService
+	likeService    service.LikeService
+	mentionService service.MentionService
+	viewService    service.ViewService
+	staff          middleware.StaffPolicy
+	cursors        *pagination.Codec
+}
+
+// NewPostHandler 创建帖子处理器
+func NewPostHandler(postService service.PostService, likeService service.LikeService, mentionService service.MentionService, viewService service.ViewService, staff middleware.StaffPolicy, cursors *pagination.Codec) *PostHandler {
+	return &PostHandler{
+		postService:    postService,
+		likeService:    likeService,
+		mentionService: mentionService,
+		viewService:    viewService,
+		staff:          staff,
+		cursors:        cursors,
+	}
+}
+
 // CreatePost 创建帖子
-func CreatePost(c *gin.Context) {
+func (h *PostHandler) CreatePost(c *gin.Context) {
 	// 从上下文中获取用户ID
 	userID, exists := c.Get("userID")
 	if !exists {
@@ -18,20 +45,15 @@ func CreatePost(c *gin.Context) {
 	}
 
 	// 绑定请求参数
-	var req struct {
-		Title      string `json:"title" binding:"required"`
-		Content    string `json:"content" binding:"required"`
-		CategoryID uint   `json:"category_id"`
-	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "参数错误: "+err.Error())
+	var req model.PostRequest
+	if !response.BindJSON(c, &req) {
 		return
 	}
 
 	// 创建帖子
-	post, err := postService.CreatePost(userID.(uint), req.CategoryID, req.Title, req.Content)
+	post, err := h.postService.CreatePost(c.Request.Context(), userID.(uint), req.CategoryID, req.Title, req.Content)
 	if err != nil {
-		response.ServerError(c, "创建帖子失败: "+err.Error())
+		respondError(c, err, "创建帖子失败")
 		return
 	}
 
@@ -39,7 +61,7 @@ func CreatePost(c *gin.Context) {
 }
 
 // GetPost 获取帖子详情
-func GetPost(c *gin.Context) {
+func (h *PostHandler) GetPost(c *gin.Context) {
 	// 获取帖子ID
 	postIDStr := c.Param("id")
 	postID, err := strconv.ParseUint(postIDStr, 10, 32)
@@ -49,22 +71,36 @@ func GetPost(c *gin.Context) {
 	}
 
 	// 获取帖子
-	post, err := postService.GetPostByID(uint(postID), true)
+	post, err := h.postService.GetPostByID(c.Request.Context(), uint(postID), true)
 	if err != nil {
-		response.NotFound(c, "帖子不存在")
+		respondError(c, err, "获取帖子失败")
 		return
 	}
 
-	// 增加浏览次数
-	go func() {
-		_ = postService.ViewPost(uint(postID))
-	}()
+	// 记录浏览次数，爬虫不计数
+	if viewer := viewerKey(c); viewer != "" {
+		if err := h.viewService.RecordView(c.Request.Context(), uint(postID), viewer); err != nil {
+			logger.FromContext(c.Request.Context()).Error("记录浏览次数失败", logger.Uint("post_id", uint(postID)), logger.Err(err))
+		}
+	}
+
+	// 填充当前用户的点赞状态
+	if err := h.likeService.MarkLikedPosts(c.Request.Context(), currentUserID(c), []*model.Post{post}); err != nil {
+		respondError(c, err, "获取点赞状态失败")
+		return
+	}
+
+	// 填充正文中提及的用户
+	if err := h.mentionService.FillPostMentions(c.Request.Context(), []*model.Post{post}); err != nil {
+		respondError(c, err, "获取提及用户失败")
+		return
+	}
 
 	response.Success(c, post)
 }
 
 // UpdatePost 更新帖子
-func UpdatePost(c *gin.Context) {
+func (h *PostHandler) UpdatePost(c *gin.Context) {
 	// 从上下文中获取用户ID
 	userID, exists := c.Get("userID")
 	if !exists {
@@ -81,24 +117,15 @@ func UpdatePost(c *gin.Context) {
 	}
 
 	// 绑定请求参数
-	var req struct {
-		Title      string `json:"title"`
-		Content    string `json:"content"`
-		CategoryID uint   `json:"category_id"`
-	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "参数错误: "+err.Error())
+	var req model.PostUpdateRequest
+	if !response.BindJSON(c, &req) {
 		return
 	}
 
 	// 更新帖子
-	post, err := postService.UpdatePost(uint(postID), userID.(uint), req.Title, req.Content, req.CategoryID)
+	post, err := h.postService.UpdatePost(c.Request.Context(), uint(postID), userID.(uint), req.Title, req.Content, req.CategoryID)
 	if err != nil {
-		if err == utils.ErrPermissionDenied {
-			response.Forbidden(c, "没有权限更新该帖子")
-			return
-		}
-		response.ServerError(c, "更新帖子失败: "+err.Error())
+		respondError(c, err, "更新帖子失败")
 		return
 	}
 
@@ -106,15 +133,14 @@ func UpdatePost(c *gin.Context) {
 }
 
 // DeletePost 删除帖子
-func DeletePost(c *gin.Context) {
+func (h *PostHandler) DeletePost(c *gin.Context) {
 	// 从上下文中获取用户ID和角色
 	userID, exists := c.Get("userID")
 	if !exists {
 		response.Unauthorized(c, "用户未认证")
 		return
 	}
-	userRole, _ := c.Get("userRole")
-	isAdmin := userRole.(string) == "admin"
+	isAdmin := h.staff.Allows(c, middleware.RoleAdmin)
 
 	// 获取帖子ID
 	postIDStr := c.Param("id")
@@ -125,13 +151,9 @@ func DeletePost(c *gin.Context) {
 	}
 
 	// 删除帖子
-	err = postService.DeletePost(uint(postID), userID.(uint), isAdmin)
+	err = h.postService.DeletePost(c.Request.Context(), uint(postID), userID.(uint), isAdmin)
 	if err != nil {
-		if err == utils.ErrPermissionDenied {
-			response.Forbidden(c, "没有权限删除该帖子")
-			return
-		}
-		response.ServerError(c, "删除帖子失败: "+err.Error())
+		respondError(c, err, "删除帖子失败")
 		return
 	}
 
@@ -139,48 +161,104 @@ func DeletePost(c *gin.Context) {
 }
 
 // ListPosts 获取帖子列表
-func ListPosts(c *gin.Context) {
-	// 获取分页参数
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
-
-	// 获取筛选参数
-	categoryIDStr := c.DefaultQuery("category_id", "0")
-	categoryID, _ := strconv.ParseUint(categoryIDStr, 10, 32)
-	keyword := c.DefaultQuery("keyword", "")
-	orderBy := c.DefaultQuery("order_by", "recent")
+func (h *PostHandler) ListPosts(c *gin.Context) {
+	// 绑定查询参数
+	query := model.PostListQuery{CursorQuery: model.CursorQuery{PageSize: 10}}
+	if !response.BindQuery(c, &query) {
+		return
+	}
+	// 不同排序的游标不能混用
+	scope := cursorScopePosts + ":" + query.OrderBy
+	req, err := pageRequest(h.cursors, scope, &query.CursorQuery)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidCursor, "无效的游标")
+		return
+	}
 
 	// 查询帖子列表
-	posts, total, err := postService.ListPosts(page, pageSize, uint(categoryID), 0, keyword, orderBy)
+	posts, page, err := h.postService.ListPosts(c.Request.Context(), query.CategoryID, query.UserID, query.Keyword, query.OrderBy, req)
 	if err != nil {
-		response.ServerError(c, "获取帖子列表失败")
+		respondError(c, err, "获取帖子列表失败")
 		return
 	}
 
-	response.Success(c, gin.H{
-		"posts": posts,
-		"meta": gin.H{
-			"total":     total,
-			"page":      page,
-			"page_size": pageSize,
-		},
-	})
+	// 填充当前用户的点赞状态
+	if err := h.likeService.MarkLikedPosts(c.Request.Context(), currentUserID(c), posts); err != nil {
+		respondError(c, err, "获取点赞状态失败")
+		return
+	}
+
+	// 填充正文中提及的用户
+	if err := h.mentionService.FillPostMentions(c.Request.Context(), posts); err != nil {
+		respondError(c, err, "获取提及用户失败")
+		return
+	}
+
+	response.List(c, posts, cursorMeta(h.cursors, scope, req, page))
 }
 
 // LikePost 点赞帖子
-func LikePost(c *gin.Context) {
-	// TODO: 实现点赞帖子功能
-	response.NotImplemented(c, "功能未实现")
+func (h *PostHandler) LikePost(c *gin.Context) {
+	// 从上下文中获取用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	// 获取帖子ID
+	postIDStr := c.Param("id")
+	postID, err := strconv.ParseUint(postIDStr, 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的帖子ID")
+		return
+	}
+
+	// 点赞帖子
+	post, err := h.likeService.LikePost(c.Request.Context(), userID.(uint), uint(postID))
+	if err != nil {
+		respondError(c, err, "点赞帖子失败")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"like_count":  post.LikeCount,
+		"liked_by_me": post.LikedByMe,
+	})
 }
 
 // UnlikePost 取消点赞帖子
-func UnlikePost(c *gin.Context) {
-	// TODO: 实现取消点赞帖子功能
-	response.NotImplemented(c, "功能未实现")
+func (h *PostHandler) UnlikePost(c *gin.Context) {
+	// 从上下文中获取用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	// 获取帖子ID
+	postIDStr := c.Param("id")
+	postID, err := strconv.ParseUint(postIDStr, 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的帖子ID")
+		return
+	}
+
+	// 取消点赞帖子
+	post, err := h.likeService.UnlikePost(c.Request.Context(), userID.(uint), uint(postID))
+	if err != nil {
+		respondError(c, err, "取消点赞失败")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"like_count":  post.LikeCount,
+		"liked_by_me": post.LikedByMe,
+	})
 }
 
 // PinPost 置顶帖子
-func PinPost(c *gin.Context) {
+func (h *PostHandler) PinPost(c *gin.Context) {
 	// 获取帖子ID
 	postIDStr := c.Param("id")
 	postID, err := strconv.ParseUint(postIDStr, 10, 32)
@@ -190,8 +268,8 @@ func PinPost(c *gin.Context) {
 	}
 
 	// 设置置顶
-	if err := postService.SetPostPinned(uint(postID), true); err != nil {
-		response.ServerError(c, "置顶帖子失败")
+	if err := h.postService.SetPostPinned(c.Request.Context(), uint(postID), true); err != nil {
+		respondError(c, err, "置顶帖子失败")
 		return
 	}
 
@@ -199,7 +277,7 @@ func PinPost(c *gin.Context) {
 }
 
 // UnpinPost 取消置顶帖子
-func UnpinPost(c *gin.Context) {
+func (h *PostHandler) UnpinPost(c *gin.Context) {
 	// 获取帖子ID
 	postIDStr := c.Param("id")
 	postID, err := strconv.ParseUint(postIDStr, 10, 32)
@@ -209,8 +287,8 @@ func UnpinPost(c *gin.Context) {
 	}
 
 	// 取消置顶
-	if err := postService.SetPostPinned(uint(postID), false); err != nil {
-		response.ServerError(c, "取消置顶失败")
+	if err := h.postService.SetPostPinned(c.Request.Context(), uint(postID), false); err != nil {
+		respondError(c, err, "取消置顶失败")
 		return
 	}
 
@@ -218,7 +296,7 @@ func UnpinPost(c *gin.Context) {
 }
 
 // FeaturePost 设置精华帖子
-func FeaturePost(c *gin.Context) {
+func (h *PostHandler) FeaturePost(c *gin.Context) {
 	// 获取帖子ID
 	postIDStr := c.Param("id")
 	postID, err := strconv.ParseUint(postIDStr, 10, 32)
@@ -228,8 +306,8 @@ func FeaturePost(c *gin.Context) {
 	}
 
 	// 设置精华
-	if err := postService.SetPostFeatured(uint(postID), true); err != nil {
-		response.ServerError(c, "设置精华失败")
+	if err := h.postService.SetPostFeatured(c.Request.Context(), uint(postID), true); err != nil {
+		respondError(c, err, "设置精华失败")
 		return
 	}
 
@@ -237,7 +315,7 @@ func FeaturePost(c *gin.Context) {
 }
 
 // UnfeaturePost 取消精华帖子
-func UnfeaturePost(c *gin.Context) {
+func (h *PostHandler) UnfeaturePost(c *gin.Context) {
 	// 获取帖子ID
 	postIDStr := c.Param("id")
 	postID, err := strconv.ParseUint(postIDStr, 10, 32)
@@ -247,10 +325,56 @@ func UnfeaturePost(c *gin.Context) {
 	}
 
 	// 取消精华
-	if err := postService.SetPostFeatured(uint(postID), false); err != nil {
-		response.ServerError(c, "取消精华失败")
+	if err := h.postService.SetPostFeatured(c.Request.Context(), uint(postID), false); err != nil {
+		respondError(c, err, "取消精华失败")
 		return
 	}
 
 	response.Success(c, "取消精华成功")
 }
+
+// ListPinnedPosts 获取置顶帖子，可按分类筛选
+func (h *PostHandler) ListPinnedPosts(c *gin.Context) {
+	query := model.HighlightQuery{Limit: 10}
+	if !response.BindQuery(c, &query) {
+		return
+	}
+
+	posts, err := h.postService.GetPinnedPosts(c.Request.Context(), query.CategoryID, query.Limit)
+	if err != nil {
+		respondError(c, err, "获取置顶帖子失败")
+		return
+	}
+
+	h.respondHighlights(c, posts, query.Limit)
+}
+
+// ListFeaturedPosts 获取精华帖子
+func (h *PostHandler) ListFeaturedPosts(c *gin.Context) {
+	query := model.HighlightQuery{Limit: 10}
+	if !response.BindQuery(c, &query) {
+		return
+	}
+
+	posts, err := h.postService.GetFeaturedPosts(c.Request.Context(), query.Limit)
+	if err != nil {
+		respondError(c, err, "获取精华帖子失败")
+		return
+	}
+
+	h.respondHighlights(c, posts, query.Limit)
+}
+
+// respondHighlights 填充点赞状态和提及的用户后返回帖子列表
+func (h *PostHandler) respondHighlights(c *gin.Context, posts []*model.Post, limit int) {
+	if err := h.likeService.MarkLikedPosts(c.Request.Context(), currentUserID(c), posts); err != nil {
+		respondError(c, err, "获取点赞状态失败")
+		return
+	}
+	if err := h.mentionService.FillPostMentions(c.Request.Context(), posts); err != nil {
+		respondError(c, err, "获取提及用户失败")
+		return
+	}
+
+	response.List(c, posts, response.Meta{PageSize: limit})
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/service"
+	"github.com/lllllan02/chitchat/pkg/response"
+	"net/http"
+)
+
+// SearchHandler 全文搜索处理器
+type SearchHandler struct {
+	searchService  service.SearchService
+	likeService    service.LikeService
+	mentionService service.MentionService
+}
+
+// NewSearchHandler 创建全文搜索处理器
+func NewSearchHandler(searchService service.SearchService, likeService service.LikeService, mentionService service.MentionService) *SearchHandler {
+	return &SearchHandler{
+		searchService:  searchService,
+		likeService:    likeService,
+		mentionService: mentionService,
+	}
+}
+
+// Search 搜索帖子、评论和用户
+func (h *SearchHandler) Search(c *gin.Context) {
+	// 绑定查询参数
+	query := model.SearchQuery{Page: 1, PageSize: 10}
+	if !response.BindQuery(c, &query) {
+		return
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && query.To.Before(query.From) {
+		response.Error(c, http.StatusBadRequest, response.CodeValidation, "结束日期不能早于开始日期")
+		return
+	}
+
+	hits, total, err := h.searchService.Search(c.Request.Context(), &query)
+	if err != nil {
+		respondError(c, err, "搜索失败")
+		return
+	}
+
+	// 填充当前用户对帖子和评论的点赞状态，以及其中提及的用户
+	var posts []*model.Post
+	var comments []*model.Comment
+	for _, hit := range hits {
+		if hit.Post != nil {
+			posts = append(posts, hit.Post)
+		}
+		if hit.Comment != nil {
+			comments = append(comments, hit.Comment)
+		}
+	}
+	if err := h.likeService.MarkLikedPosts(c.Request.Context(), currentUserID(c), posts); err != nil {
+		respondError(c, err, "获取点赞状态失败")
+		return
+	}
+	if err := h.likeService.MarkLikedComments(c.Request.Context(), currentUserID(c), comments); err != nil {
+		respondError(c, err, "获取点赞状态失败")
+		return
+	}
+	if err := h.mentionService.FillPostMentions(c.Request.Context(), posts); err != nil {
+		respondError(c, err, "获取提及用户失败")
+		return
+	}
+	if err := h.mentionService.FillCommentMentions(c.Request.Context(), comments); err != nil {
+		respondError(c, err, "获取提及用户失败")
+		return
+	}
+
+	response.List(c, hits, response.PageMeta(query.Page, query.PageSize, total))
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lllllan02/chitchat/internal/service"
+	"github.com/lllllan02/chitchat/internal/utils"
+	"github.com/lllllan02/chitchat/pkg/response"
+)
+
+// SessionHandler 登录会话处理器
+type SessionHandler struct {
+	tokenService service.TokenService
+}
+
+// NewSessionHandler 创建登录会话处理器
+func NewSessionHandler(tokenService service.TokenService) *SessionHandler {
+	return &SessionHandler{tokenService: tokenService}
+}
+
+// ListSessions 获取当前用户登录的设备列表
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	// 从上下文中获取令牌声明
+	claims, exists := c.Get("claims")
+	if !exists {
+		response.Unauthorized(c, "用户未认证")
+		return
+	}
+	current := claims.(*utils.CustomClaims)
+
+	// 查询会话列表
+	sessions, err := h.tokenService.ListSessions(c.Request.Context(), current.UserID)
+	if err != nil {
+		respondError(c, err, "获取会话列表失败")
+		return
+	}
+
+	// 标记发起请求的会话
+	for _, session := range sessions {
+		session.Current = session.ID == current.SessionID
+	}
+
+	response.Success(c, sessions)
+}
+
+// RevokeSession 注销指定会话，使该设备下线
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	// 从上下文中获取用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	// 获取会话ID
+	sessionIDStr := c.Param("id")
+	sessionID, err := strconv.ParseUint(sessionIDStr, 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的会话ID")
+		return
+	}
+
+	// 注销会话
+	if err := h.tokenService.RevokeSession(c.Request.Context(), userID.(uint), uint(sessionID)); err != nil {
+		respondError(c, err, "注销会话失败")
+		return
+	}
+
+	response.Success(c, "注销会话成功")
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/lllllan02/chitchat/internal/api/middleware"
+	"github.com/lllllan02/chitchat/internal/stream"
+	"github.com/lllllan02/chitchat/pkg/response"
+)
+
+const (
+	// 心跳间隔
+	streamPingInterval = 30 * time.Second
+	// WebSocket 写超时
+	streamWriteTimeout = 10 * time.Second
+)
+
+// StreamHandler 实时推送处理器
+type StreamHandler struct {
+	hub      *stream.Hub
+	upgrader websocket.Upgrader
+}
+
+// NewStreamHandler 创建实时推送处理器，WebSocket 握手的来源校验与 CORS 使用同一份列表
+func NewStreamHandler(hub *stream.Hub, origins *middleware.OriginPolicy) *StreamHandler {
+	return &StreamHandler{
+		hub: hub,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin:     origins.CheckWebSocketOrigin,
+		},
+	}
+}
+
+// streamCommand WebSocket 客户端发送的订阅指令
+type streamCommand struct {
+	Action string `json:"action"` // watch 或 unwatch
+	PostID uint   `json:"post_id"`
+}
+
+// Stream 实时推送通知、评论和点赞数变化
+// 请求头包含 WebSocket 升级时使用 WebSocket，否则使用 SSE
+// posts 参数指定正在浏览的帖子，Last-Event-ID 请求头或 last_event_id 参数用于断线续传
+func (h *StreamHandler) Stream(c *gin.Context) {
+	// 从上下文中获取用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	// 解析正在浏览的帖子
+	var postIDs []uint
+	for _, s := range strings.Split(c.Query("posts"), ",") {
+		if id, err := strconv.ParseUint(strings.TrimSpace(s), 10, 32); err == nil {
+			postIDs = append(postIDs, uint(id))
+		}
+	}
+
+	// 解析最后收到的消息ID
+	lastEventIDStr := c.GetHeader("Last-Event-ID")
+	if lastEventIDStr == "" {
+		lastEventIDStr = c.Query("last_event_id")
+	}
+	lastEventID, _ := strconv.ParseUint(lastEventIDStr, 10, 64)
+
+	if websocket.IsWebSocketUpgrade(c.Request) {
+		h.serveWebSocket(c, userID.(uint), postIDs, lastEventID)
+		return
+	}
+	h.serveSSE(c, userID.(uint), postIDs, lastEventID)
+}
+
+// serveSSE 通过 Server-Sent Events 推送消息
+func (h *StreamHandler) serveSSE(c *gin.Context, userID uint, postIDs []uint, lastEventID uint64) {
+	client := h.hub.Register(userID, postIDs, lastEventID)
+	defer h.hub.Unregister(client)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(streamPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-client.Done():
+			return
+		case msg := <-client.Messages():
+			data, err := json.Marshal(msg)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", msg.ID, msg.Type, data)
+			c.Writer.Flush()
+		case <-ticker.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
+
+// serveWebSocket 通过 WebSocket 推送消息，并接收 watch/unwatch 指令
+func (h *StreamHandler) serveWebSocket(c *gin.Context, userID uint, postIDs []uint, lastEventID uint64) {
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	client := h.hub.Register(userID, postIDs, lastEventID)
+	defer h.hub.Unregister(client)
+
+	// 读取客户端指令，连接断开时注销
+	go func() {
+		defer h.hub.Unregister(client)
+
+		conn.SetReadLimit(512)
+		_ = conn.SetReadDeadline(time.Now().Add(2 * streamPingInterval))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(2 * streamPingInterval))
+		})
+
+		for {
+			var cmd streamCommand
+			if err := conn.ReadJSON(&cmd); err != nil {
+				return
+			}
+			switch cmd.Action {
+			case "watch":
+				client.Watch(cmd.PostID)
+			case "unwatch":
+				client.Unwatch(cmd.PostID)
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(streamPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-client.Done():
+			_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(streamWriteTimeout))
+			return
+		case msg := <-client.Messages():
+			_ = conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			if err := conn.WriteJSON(msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
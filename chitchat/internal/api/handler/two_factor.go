@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/service"
+	"github.com/lllllan02/chitchat/internal/utils"
+	"github.com/lllllan02/chitchat/pkg/response"
+)
+
+// TwoFactorHandler 两步验证处理器
+type TwoFactorHandler struct {
+	twoFactorService service.TwoFactorService
+	userService      service.UserService
+	tokenService     service.TokenService
+}
+
+// NewTwoFactorHandler 创建两步验证处理器
+func NewTwoFactorHandler(twoFactorService service.TwoFactorService, userService service.UserService, tokenService service.TokenService) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: twoFactorService,
+		userService:      userService,
+		tokenService:     tokenService,
+	}
+}
+
+// Setup 生成两步验证密钥和 otpauth URI
+func (h *TwoFactorHandler) Setup(c *gin.Context) {
+	// 从上下文中获取用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	setup, err := h.twoFactorService.Setup(c.Request.Context(), userID.(uint))
+	if err != nil {
+		respondError(c, err, "生成两步验证密钥失败")
+		return
+	}
+
+	response.Success(c, setup)
+}
+
+// Enable 确认验证码并启用两步验证，返回备用码和当前客户端的新令牌
+func (h *TwoFactorHandler) Enable(c *gin.Context) {
+	// 从上下文中获取用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	// 绑定请求参数
+	var req model.TwoFactorCodeRequest
+	if !response.BindJSON(c, &req) {
+		return
+	}
+
+	codes, err := h.twoFactorService.Enable(c.Request.Context(), userID.(uint), req.Code)
+	if err != nil {
+		// 未生成密钥时提示先调用 setup，其他错误按通用规则返回
+		if errors.Is(err, utils.ErrTwoFactorNotEnabled) {
+			response.BadRequest(c, "请先生成两步验证密钥")
+			return
+		}
+		respondError(c, err, "启用两步验证失败")
+		return
+	}
+
+	// 其他会话已失效，当前客户端凭刚提交的验证码获得通过两步验证的令牌
+	tokens, ok := h.reissue(c, userID.(uint), true)
+	if !ok {
+		return
+	}
+
+	response.Success(c, model.TwoFactorActivation{
+		BackupCodes: codes,
+		TokenPair:   *tokens,
+	})
+}
+
+// Disable 关闭两步验证，返回当前客户端的新令牌
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	// 从上下文中获取用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	// 绑定请求参数
+	var req model.TwoFactorDisableRequest
+	if !response.BindJSON(c, &req) {
+		return
+	}
+
+	if err := h.twoFactorService.Disable(c.Request.Context(), userID.(uint), req.Password, req.Code); err != nil {
+		respondError(c, err, "关闭两步验证失败")
+		return
+	}
+
+	tokens, ok := h.reissue(c, userID.(uint), false)
+	if !ok {
+		return
+	}
+
+	response.Success(c, tokens)
+}
+
+// RegenerateBackupCodes 重新生成备用码
+func (h *TwoFactorHandler) RegenerateBackupCodes(c *gin.Context) {
+	// 从上下文中获取用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	// 绑定请求参数
+	var req model.TwoFactorCodeRequest
+	if !response.BindJSON(c, &req) {
+		return
+	}
+
+	codes, err := h.twoFactorService.RegenerateBackupCodes(c.Request.Context(), userID.(uint), req.Code)
+	if err != nil {
+		respondError(c, err, "生成备用码失败")
+		return
+	}
+
+	response.Success(c, gin.H{"backup_codes": codes})
+}
+
+// reissue 为当前客户端重新签发令牌，失败时已写入响应
+func (h *TwoFactorHandler) reissue(c *gin.Context, userID uint, twoFactor bool) (*model.TokenPair, bool) {
+	user, err := h.userService.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		respondError(c, err, "获取用户信息失败")
+		return nil, false
+	}
+
+	tokens, err := h.tokenService.IssueTokens(c.Request.Context(), user, clientInfo(c), twoFactor)
+	if err != nil {
+		respondError(c, err, "生成令牌失败")
+		return nil, false
+	}
+	return tokens, true
+}
@@ -1,20 +1,45 @@
 package handler
 
 import (
+	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/pagination"
 	"github.com/lllllan02/chitchat/internal/service"
 	"github.com/lllllan02/chitchat/internal/utils"
 	"github.com/lllllan02/chitchat/pkg/response"
 )
 
-// 初始化帖子服务
-var postService = service.NewPostService()
+// UserHandler 用户处理器
+type UserHandler struct {
+	userService    service.UserService
+	tokenService   service.TokenService
+	postService    service.PostService
+	followService  service.FollowService
+	likeService    service.LikeService
+	mentionService service.MentionService
+	loginGuard     service.LoginGuardService
+	cursors        *pagination.Codec
+}
+
+// NewUserHandler 创建用户处理器
+func NewUserHandler(userService service.UserService, tokenService service.TokenService, postService service.PostService, followService service.FollowService, likeService service.LikeService, mentionService service.MentionService, loginGuard service.LoginGuardService, cursors *pagination.Codec) *UserHandler {
+	return &UserHandler{
+		userService:    userService,
+		tokenService:   tokenService,
+		postService:    postService,
+		followService:  followService,
+		likeService:    likeService,
+		mentionService: mentionService,
+		loginGuard:     loginGuard,
+		cursors:        cursors,
+	}
+}
 
 // GetCurrentUser 获取当前用户信息
-func GetCurrentUser(c *gin.Context) {
+func (h *UserHandler) GetCurrentUser(c *gin.Context) {
 	// 从上下文中获取用户ID
 	userID, exists := c.Get("userID")
 	if !exists {
@@ -23,9 +48,15 @@ func GetCurrentUser(c *gin.Context) {
 	}
 
 	// 查询用户信息
-	user, err := userService.GetUserByID(userID.(uint))
+	user, err := h.userService.GetUserByID(c.Request.Context(), userID.(uint))
 	if err != nil {
-		response.ServerError(c, "获取用户信息失败")
+		respondError(c, err, "获取用户信息失败")
+		return
+	}
+
+	// 填充关注统计
+	if err := h.followService.FillFollowCounts(c.Request.Context(), []*model.User{user}); err != nil {
+		respondError(c, err, "获取关注统计失败")
 		return
 	}
 
@@ -33,7 +64,7 @@ func GetCurrentUser(c *gin.Context) {
 }
 
 // UpdateUser 更新用户信息
-func UpdateUser(c *gin.Context) {
+func (h *UserHandler) UpdateUser(c *gin.Context) {
 	// 从上下文中获取用户ID
 	userID, exists := c.Get("userID")
 	if !exists {
@@ -43,15 +74,14 @@ func UpdateUser(c *gin.Context) {
 
 	// 绑定请求参数
 	var req model.UserUpdateRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "参数错误: "+err.Error())
+	if !response.BindJSON(c, &req) {
 		return
 	}
 
 	// 查询用户信息
-	user, err := userService.GetUserByID(userID.(uint))
+	user, err := h.userService.GetUserByID(c.Request.Context(), userID.(uint))
 	if err != nil {
-		response.ServerError(c, "获取用户信息失败")
+		respondError(c, err, "获取用户信息失败")
 		return
 	}
 
@@ -62,9 +92,12 @@ func UpdateUser(c *gin.Context) {
 	if req.Bio != "" {
 		user.Bio = req.Bio
 	}
+	if req.Locale != "" {
+		user.Locale = req.Locale
+	}
 
-	if err := userService.UpdateUser(user); err != nil {
-		response.ServerError(c, "更新用户信息失败")
+	if err := h.userService.UpdateUser(c.Request.Context(), user); err != nil {
+		respondError(c, err, "更新用户信息失败")
 		return
 	}
 
@@ -72,7 +105,7 @@ func UpdateUser(c *gin.Context) {
 }
 
 // ChangePassword 修改密码
-func ChangePassword(c *gin.Context) {
+func (h *UserHandler) ChangePassword(c *gin.Context) {
 	// 从上下文中获取用户ID
 	userID, exists := c.Get("userID")
 	if !exists {
@@ -82,42 +115,53 @@ func ChangePassword(c *gin.Context) {
 
 	// 绑定请求参数
 	var req model.PasswordChangeRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "参数错误: "+err.Error())
+	if !response.BindJSON(c, &req) {
 		return
 	}
 
 	// 查询用户信息
-	user, err := userService.GetUserByID(userID.(uint))
+	user, err := h.userService.GetUserByID(c.Request.Context(), userID.(uint))
 	if err != nil {
-		response.ServerError(c, "获取用户信息失败")
+		respondError(c, err, "获取用户信息失败")
 		return
 	}
 
 	// 验证旧密码
 	if !utils.CheckPasswordHash(req.OldPassword, user.PasswordHash) {
-		response.BadRequest(c, "旧密码错误")
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidPassword, "旧密码错误")
 		return
 	}
 
 	// 加密新密码
 	newPasswordHash, err := utils.HashPassword(req.NewPassword)
 	if err != nil {
-		response.ServerError(c, "密码加密失败")
+		respondError(c, err, "密码加密失败")
 		return
 	}
 
-	// 更新密码
-	if err := userService.ChangePassword(user.ID, newPasswordHash); err != nil {
-		response.ServerError(c, "修改密码失败")
+	// 更新密码，其他会话随之失效
+	if err := h.userService.ChangePassword(c.Request.Context(), user.ID, newPasswordHash); err != nil {
+		respondError(c, err, "修改密码失败")
+		return
+	}
+
+	// 为当前客户端重新签发令牌，沿用当前会话的两步验证状态
+	user, err = h.userService.GetUserByID(c.Request.Context(), user.ID)
+	if err != nil {
+		respondError(c, err, "获取用户信息失败")
+		return
+	}
+	tokens, err := h.tokenService.IssueTokens(c.Request.Context(), user, clientInfo(c), currentTwoFactor(c))
+	if err != nil {
+		respondError(c, err, "生成令牌失败")
 		return
 	}
 
-	response.Success(c, "密码修改成功")
+	response.Success(c, tokens)
 }
 
 // GetUser 获取用户信息
-func GetUser(c *gin.Context) {
+func (h *UserHandler) GetUser(c *gin.Context) {
 	// 获取用户ID
 	userIDStr := c.Param("id")
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
@@ -127,9 +171,15 @@ func GetUser(c *gin.Context) {
 	}
 
 	// 查询用户信息
-	user, err := userService.GetUserByID(uint(userID))
+	user, err := h.userService.GetUserByID(c.Request.Context(), uint(userID))
 	if err != nil {
-		response.NotFound(c, "用户不存在")
+		respondError(c, err, "获取用户信息失败")
+		return
+	}
+
+	// 填充关注统计
+	if err := h.followService.FillFollowCounts(c.Request.Context(), []*model.User{user}); err != nil {
+		respondError(c, err, "获取关注统计失败")
 		return
 	}
 
@@ -137,30 +187,55 @@ func GetUser(c *gin.Context) {
 }
 
 // ListUsers 获取用户列表
-func ListUsers(c *gin.Context) {
-	// 获取分页参数
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+func (h *UserHandler) ListUsers(c *gin.Context) {
+	// 绑定查询参数
+	query := model.UserListQuery{Page: 1, PageSize: 10}
+	if !response.BindQuery(c, &query) {
+		return
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && query.To.Before(query.From) {
+		response.Error(c, http.StatusBadRequest, response.CodeValidation, "结束日期不能早于开始日期")
+		return
+	}
 
 	// 查询用户列表
-	users, total, err := userService.ListUsers(page, pageSize)
+	users, total, truncated, err := h.userService.ListUsers(c.Request.Context(), &query)
 	if err != nil {
-		response.ServerError(c, "获取用户列表失败")
+		respondError(c, err, "获取用户列表失败")
 		return
 	}
 
-	response.Success(c, gin.H{
-		"users": users,
-		"meta": gin.H{
-			"total":     total,
-			"page":      page,
-			"page_size": pageSize,
-		},
-	})
+	// 填充关注统计
+	if err := h.followService.FillFollowCounts(c.Request.Context(), users); err != nil {
+		respondError(c, err, "获取关注统计失败")
+		return
+	}
+
+	// 匹配过多时只在相关度最高的用户中排序和分页
+	meta := response.PageMeta(query.Page, query.PageSize, total)
+	meta.Truncated = truncated
+	response.List(c, users, meta)
+}
+
+// SuggestUsers 根据用户名前缀联想用户
+func (h *UserHandler) SuggestUsers(c *gin.Context) {
+	// 绑定查询参数
+	query := model.UserSuggestQuery{Limit: 10}
+	if !response.BindQuery(c, &query) {
+		return
+	}
+
+	suggestions, err := h.userService.SuggestUsers(c.Request.Context(), query.Q, query.Limit)
+	if err != nil {
+		respondError(c, err, "获取用户联想失败")
+		return
+	}
+
+	response.Success(c, suggestions)
 }
 
 // UpdateUserRole 更新用户角色
-func UpdateUserRole(c *gin.Context) {
+func (h *UserHandler) UpdateUserRole(c *gin.Context) {
 	// 获取用户ID
 	userIDStr := c.Param("id")
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
@@ -173,29 +248,46 @@ func UpdateUserRole(c *gin.Context) {
 	var req struct {
 		Role string `json:"role" binding:"required,oneof=user moderator admin"`
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, "参数错误: "+err.Error())
+	if !response.BindJSON(c, &req) {
 		return
 	}
 
 	// 查询用户信息
-	user, err := userService.GetUserByID(uint(userID))
+	user, err := h.userService.GetUserByID(c.Request.Context(), uint(userID))
 	if err != nil {
-		response.NotFound(c, "用户不存在")
+		respondError(c, err, "获取用户信息失败")
 		return
 	}
 
 	// 更新用户角色
-	if err := userService.UpdateUserRole(user.ID, req.Role); err != nil {
-		response.ServerError(c, "更新用户角色失败")
+	if err := h.userService.UpdateUserRole(c.Request.Context(), user.ID, req.Role); err != nil {
+		respondError(c, err, "更新用户角色失败")
 		return
 	}
 
 	response.Success(c, "更新用户角色成功")
 }
 
+// UnlockUser 解除因登录失败次数过多导致的账号锁定
+func (h *UserHandler) UnlockUser(c *gin.Context) {
+	// 获取用户ID
+	userIDStr := c.Param("id")
+	userID, err := strconv.ParseUint(userIDStr, 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的用户ID")
+		return
+	}
+
+	if err := h.loginGuard.Unlock(c.Request.Context(), uint(userID)); err != nil {
+		respondError(c, err, "解除锁定失败")
+		return
+	}
+
+	response.Success(c, "解除锁定成功")
+}
+
 // DeleteUser 删除用户
-func DeleteUser(c *gin.Context) {
+func (h *UserHandler) DeleteUser(c *gin.Context) {
 	// 获取用户ID
 	userIDStr := c.Param("id")
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
@@ -205,8 +297,8 @@ func DeleteUser(c *gin.Context) {
 	}
 
 	// 删除用户
-	if err := userService.DeleteUser(uint(userID)); err != nil {
-		response.ServerError(c, "删除用户失败")
+	if err := h.userService.DeleteUser(c.Request.Context(), uint(userID)); err != nil {
+		respondError(c, err, "删除用户失败")
 		return
 	}
 
@@ -214,7 +306,7 @@ func DeleteUser(c *gin.Context) {
 }
 
 // ListUserPosts 获取用户的帖子列表
-func ListUserPosts(c *gin.Context) {
+func (h *UserHandler) ListUserPosts(c *gin.Context) {
 	// 获取用户ID
 	userIDStr := c.Param("id")
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
@@ -224,22 +316,34 @@ func ListUserPosts(c *gin.Context) {
 	}
 
 	// 获取分页参数
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	query := model.CursorQuery{PageSize: 10}
+	if !response.BindQuery(c, &query) {
+		return
+	}
+	req, err := pageRequest(h.cursors, cursorScopeUserPosts, &query)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidCursor, "无效的游标")
+		return
+	}
 
 	// 调用帖子服务
-	posts, total, err := postService.GetPostsByUserID(uint(userID), page, pageSize)
+	posts, page, err := h.postService.GetPostsByUserID(c.Request.Context(), uint(userID), req)
 	if err != nil {
-		response.ServerError(c, "获取用户帖子失败")
+		respondError(c, err, "获取用户帖子失败")
+		return
+	}
+
+	// 填充当前用户的点赞状态
+	if err := h.likeService.MarkLikedPosts(c.Request.Context(), currentUserID(c), posts); err != nil {
+		respondError(c, err, "获取点赞状态失败")
+		return
+	}
+
+	// 填充正文中提及的用户
+	if err := h.mentionService.FillPostMentions(c.Request.Context(), posts); err != nil {
+		respondError(c, err, "获取提及用户失败")
 		return
 	}
 
-	response.Success(c, gin.H{
-		"posts": posts,
-		"meta": gin.H{
-			"total":     total,
-			"page":      page,
-			"page_size": pageSize,
-		},
-	})
+	response.List(c, posts, cursorMeta(h.cursors, cursorScopeUserPosts, req, page))
 }
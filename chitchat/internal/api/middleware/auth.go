@@ -1,18 +1,42 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/lllllan02/chitchat/internal/utils"
+	"github.com/lllllan02/chitchat/pkg/i18n"
+	"github.com/lllllan02/chitchat/pkg/logger"
 	"github.com/lllllan02/chitchat/pkg/response"
 )
 
+// TokenVerifier 访问令牌校验接口
+type TokenVerifier interface {
+	VerifyAccessToken(ctx context.Context, token string) (*utils.CustomClaims, error)
+}
+
 // JWT 认证中间件
-func JWT() gin.HandlerFunc {
+func JWT(verifier TokenVerifier) gin.HandlerFunc {
+	return jwtAuth(verifier, false)
+}
+
+// StreamJWT 实时推送认证中间件
+// 浏览器的 WebSocket 和 EventSource 无法设置请求头，允许通过 token 查询参数传递令牌
+func StreamJWT(verifier TokenVerifier) gin.HandlerFunc {
+	return jwtAuth(verifier, true)
+}
+
+// jwtAuth 校验令牌并将用户信息写入上下文
+func jwtAuth(verifier TokenVerifier, allowQuery bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 从Authorization头中获取token
 		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" && allowQuery && c.Query("token") != "" {
+			authHeader = "Bearer " + c.Query("token")
+		}
 		if authHeader == "" {
 			response.Unauthorized(c, "请提供令牌")
 			c.Abort()
@@ -22,40 +46,88 @@ func JWT() gin.HandlerFunc {
 		// Bearer token格式验证
 		parts := strings.SplitN(authHeader, " ", 2)
 		if !(len(parts) == 2 && parts[0] == "Bearer") {
-			response.Unauthorized(c, "令牌格式错误")
+			response.Error(c, http.StatusUnauthorized, response.CodeInvalidToken, "令牌格式错误")
 			c.Abort()
 			return
 		}
 
-		// 解析token
-		claims, err := utils.ParseToken(parts[1])
-		if err != nil {
-			response.Unauthorized(c, "无效的令牌")
+		// 校验token
+		claims, err := verifier.VerifyAccessToken(c.Request.Context(), parts[1])
+		if errors.Is(err, utils.ErrTokenRevoked) {
+			response.Error(c, http.StatusUnauthorized, response.CodeTokenRevoked, "令牌已失效，请重新登录")
+			c.Abort()
+			return
+		} else if errors.Is(err, utils.ErrInvalidToken) {
+			response.Error(c, http.StatusUnauthorized, response.CodeInvalidToken, "无效的令牌")
+			c.Abort()
+			return
+		} else if err != nil {
+			response.InternalError(c, "校验令牌失败", err)
 			c.Abort()
 			return
 		}
 
 		// 将用户信息保存到上下文中
-		c.Set("userID", claims.UserID)
-		c.Set("role", claims.Role)
+		setClaims(c, claims)
 		c.Next()
 	}
 }
 
-// 管理员权限中间件
-func AdminAuth() gin.HandlerFunc {
+// OptionalJWT 可选认证中间件，携带有效令牌时写入用户信息，否则按游客处理
+func OptionalJWT(verifier TokenVerifier) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 获取用户角色
-		role, exists := c.Get("role")
+		parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+		if len(parts) == 2 && parts[0] == "Bearer" {
+			if claims, err := verifier.VerifyAccessToken(c.Request.Context(), parts[1]); err == nil {
+				setClaims(c, claims)
+			}
+		}
+		c.Next()
+	}
+}
+
+// setClaims 将令牌中的用户信息写入上下文
+func setClaims(c *gin.Context, claims *utils.CustomClaims) {
+	c.Set("userID", claims.UserID)
+	c.Set("role", claims.Role)
+	c.Set("claims", claims)
+
+	// 之后的日志都带上用户ID
+	ctx := c.Request.Context()
+	log := logger.FromContext(ctx).With(logger.Uint("user_id", claims.UserID))
+	c.Request = c.Request.WithContext(logger.NewContext(ctx, log))
+
+	// 用户设置的语言偏好优先于 Accept-Language
+	if locale, ok := i18n.Parse(claims.Locale); ok {
+		i18n.SetLocale(c, locale)
+	}
+}
+
+// EmailVerifier 邮箱验证状态查询接口
+type EmailVerifier interface {
+	IsEmailVerified(ctx context.Context, userID uint) (bool, error)
+}
+
+// VerifiedEmail 邮箱验证中间件，需在 JWT 中间件之后使用
+func VerifiedEmail(verifier EmailVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 获取用户ID
+		userID, exists := c.Get("userID")
 		if !exists {
 			response.Unauthorized(c, "未认证访问")
 			c.Abort()
 			return
 		}
 
-		// 检查是否为管理员
-		if role != "admin" {
-			response.Forbidden(c, "无权限访问")
+		// 检查邮箱是否已验证
+		verified, err := verifier.IsEmailVerified(c.Request.Context(), userID.(uint))
+		if err != nil {
+			response.InternalError(c, "获取用户信息失败", err)
+			c.Abort()
+			return
+		}
+		if !verified {
+			response.Error(c, http.StatusForbidden, response.CodeEmailNotVerified, "请先验证邮箱")
 			c.Abort()
 			return
 		}
@@ -64,20 +136,43 @@ func AdminAuth() gin.HandlerFunc {
 	}
 }
 
-// 版主权限中间件
-func ModeratorAuth() gin.HandlerFunc {
+// 角色
+const (
+	RoleAdmin     = "admin"
+	RoleModerator = "moderator"
+)
+
+// StaffPolicy 管理权限策略，管理员和版主的权限校验都通过它完成
+// RequireTwoFactor 为 true 时，只有登录时通过了两步验证的会话才能行使管理权限
+type StaffPolicy struct {
+	RequireTwoFactor bool
+}
+
+// Allows 当前用户是否可以行使 roles 中任一角色的管理权限，需在 JWT 中间件之后使用
+// 处理器用它判断能否修改或删除他人的内容
+func (p StaffPolicy) Allows(c *gin.Context, roles ...string) bool {
+	return hasRole(c, roles) && (!p.RequireTwoFactor || passedTwoFactor(c))
+}
+
+// Require 要求 roles 中任一角色的中间件，需在 JWT 中间件之后使用
+func (p StaffPolicy) Require(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 获取用户角色
-		role, exists := c.Get("role")
-		if !exists {
+		if _, exists := c.Get("role"); !exists {
 			response.Unauthorized(c, "未认证访问")
 			c.Abort()
 			return
 		}
 
-		// 检查是否为管理员或版主
-		if role != "admin" && role != "moderator" {
-			response.Forbidden(c, "无权限访问")
+		// 检查角色
+		if !hasRole(c, roles) {
+			response.Error(c, http.StatusForbidden, response.CodePermissionDenied, "无权限访问")
+			c.Abort()
+			return
+		}
+
+		// 检查是否通过了两步验证
+		if !p.Allows(c, roles...) {
+			response.Error(c, http.StatusForbidden, response.CodeTwoFactorRequired, "请先启用两步验证并重新登录")
 			c.Abort()
 			return
 		}
@@ -85,3 +180,23 @@ func ModeratorAuth() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// hasRole 当前用户是否具有 roles 中的任一角色
+func hasRole(c *gin.Context, roles []string) bool {
+	role, _ := c.Get("role")
+	for _, r := range roles {
+		if role == r {
+			return true
+		}
+	}
+	return false
+}
+
+// passedTwoFactor 当前会话登录时是否通过了两步验证
+func passedTwoFactor(c *gin.Context) bool {
+	claims, exists := c.Get("claims")
+	if !exists {
+		return false
+	}
+	return claims.(*utils.CustomClaims).TwoFactor
+}
@@ -1,19 +1,69 @@
 package middleware
 
 import (
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
+// OriginPolicy 允许跨域访问的来源，CORS 和 WebSocket 使用同一份列表
+// 列表为空时只允许同源访问，包含 * 时允许任意来源
+type OriginPolicy struct {
+	any     bool
+	origins map[string]struct{}
+}
+
+// NewOriginPolicy 创建跨域来源策略，来源格式为 scheme://host[:port]
+func NewOriginPolicy(origins []string) *OriginPolicy {
+	p := &OriginPolicy{origins: make(map[string]struct{}, len(origins))}
+	for _, origin := range origins {
+		origin = normalizeOrigin(origin)
+		if origin == "*" {
+			p.any = true
+		}
+		p.origins[origin] = struct{}{}
+	}
+	return p
+}
+
+// Allows 是否允许该来源跨域访问
+func (p *OriginPolicy) Allows(origin string) bool {
+	if p.any {
+		return true
+	}
+	_, ok := p.origins[normalizeOrigin(origin)]
+	return ok
+}
+
+// CheckWebSocketOrigin 校验 WebSocket 握手的来源
+// 同源策略不限制 WebSocket，跨站页面发起的连接需要由服务端按来源拒绝；没有 Origin 的请求来自非浏览器客户端
+func (p *OriginPolicy) CheckWebSocketOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return p.Allows(origin)
+}
+
+// normalizeOrigin 统一来源的大小写，去掉结尾的斜杠
+func normalizeOrigin(origin string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(origin), "/"))
+}
+
 // CORS 跨域配置中间件
-func CORS() gin.HandlerFunc {
+func CORS(p *OriginPolicy) gin.HandlerFunc {
 	return cors.New(cors.Config{
-		AllowOrigins:     []string{"*"},
+		AllowOriginFunc:  p.Allows,
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With", RequestIDHeader},
+		ExposeHeaders:    []string{"Content-Length", RequestIDHeader},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	})
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/lllllan02/chitchat/pkg/i18n"
+)
+
+// Locale 语言协商中间件，按 Accept-Language 请求头选择响应使用的语言
+// 登录用户设置了语言偏好时，认证中间件会用偏好覆盖协商结果
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale, ok := i18n.Negotiate(c.GetHeader("Accept-Language"))
+		if !ok {
+			locale = i18n.Default
+		}
+		i18n.SetLocale(c, locale)
+		c.Next()
+	}
+}
@@ -1,41 +1,41 @@
 package middleware
 
 import (
+	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/lllllan02/chitchat/pkg/logger"
 )
 
-// Logger 日志中间件
+// Logger 访问日志中间件，需在请求 ID 中间件之后使用
+// 5xx 记为 error，4xx 记为 warn，其余记为 info；
+// 只记录路径不记录查询参数，避免令牌等敏感参数写入日志
 func Logger() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 开始时间
-		startTime := time.Now()
+		start := time.Now()
 
-		// 处理请求
 		c.Next()
 
-		// 结束时间
-		endTime := time.Now()
-		// 执行时间
-		latencyTime := endTime.Sub(startTime)
-		// 请求方式
-		reqMethod := c.Request.Method
-		// 请求路由
-		reqUri := c.Request.RequestURI
-		// 状态码
-		statusCode := c.Writer.Status()
-		// 请求IP
-		clientIP := c.ClientIP()
+		status := c.Writer.Status()
+		fields := []logger.Field{
+			logger.String("method", c.Request.Method),
+			logger.String("path", c.Request.URL.Path),
+			logger.Int("status", status),
+			logger.Duration("latency", time.Since(start)),
+			logger.String("ip", c.ClientIP()),
+			logger.Int("bytes", c.Writer.Size()),
+		}
 
-		// 日志格式
-		logger.Info("[GIN] %s | %3d | %13v | %15s | %s",
-			reqMethod,
-			statusCode,
-			latencyTime,
-			clientIP,
-			reqUri,
-		)
+		// 认证中间件已将用户ID附加到请求上下文的日志记录器
+		log := logger.FromContext(c.Request.Context())
+		switch {
+		case status >= http.StatusInternalServerError:
+			log.Error("请求完成", fields...)
+		case status >= http.StatusBadRequest:
+			log.Warn("请求完成", fields...)
+		default:
+			log.Info("请求完成", fields...)
+		}
 	}
 }
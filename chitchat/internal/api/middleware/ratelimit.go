@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lllllan02/chitchat/internal/ratelimit"
+	"github.com/lllllan02/chitchat/pkg/logger"
+	"github.com/lllllan02/chitchat/pkg/response"
+)
+
+// RateLimit 限流中间件，需在认证中间件之后使用，以便按用户ID和角色限流
+// 存储不可用时放行请求，避免限流故障导致服务不可用；now 返回令牌桶计算使用的当前时间
+func RateLimit(store ratelimit.Store, policies *ratelimit.Policies, now func() time.Time) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, _ := c.Get("role")
+		roleName, _ := role.(string)
+
+		name, limit := policies.Match(c.Request.Method, c.FullPath(), roleName)
+		if limit == nil {
+			c.Next()
+			return
+		}
+
+		// 已登录用户按用户ID计数，游客按IP计数
+		subject := "ip:" + c.ClientIP()
+		if userID, exists := c.Get("userID"); exists {
+			subject = fmt.Sprintf("user:%d", userID)
+		}
+
+		result, err := store.Take(c.Request.Context(), name+":"+subject, *limit, now())
+		if err != nil {
+			logger.FromContext(c.Request.Context()).Error("限流计数失败", logger.String("limit", name), logger.Err(err))
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			response.TooManyRequests(c, "请求过于频繁，请稍后再试")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ceilSeconds 向上取整的秒数
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
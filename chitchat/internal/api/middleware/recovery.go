@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lllllan02/chitchat/pkg/logger"
+	"github.com/lllllan02/chitchat/pkg/response"
+)
+
+// Recovery 恢复处理请求时发生的 panic，记录错误日志和调用栈后返回 500
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+		logger.FromContext(c.Request.Context()).Error("处理请求时发生panic",
+			logger.String("panic", fmt.Sprint(recovered)),
+			logger.String("stack", string(debug.Stack())))
+		response.Error(c, http.StatusInternalServerError, response.CodeInternal, "服务器内部错误")
+		c.Abort()
+	})
+}
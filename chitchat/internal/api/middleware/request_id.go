@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/lllllan02/chitchat/internal/utils"
+	"github.com/lllllan02/chitchat/pkg/logger"
+)
+
+// RequestIDHeader 请求 ID 的请求头和响应头
+const RequestIDHeader = "X-Request-ID"
+
+// 客户端传入的请求 ID 的最大长度
+const maxRequestIDLength = 64
+
+// RequestID 请求 ID 中间件，应作为第一个中间件使用
+// 沿用客户端或网关传入的合法请求 ID，否则生成新的 ID；请求 ID 写入响应头，
+// 并通过请求上下文中的日志记录器附加到本次请求的所有日志中
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id, _ = utils.RandomToken(12)
+		}
+
+		c.Set("requestID", id)
+		c.Header(RequestIDHeader, id)
+
+		log := logger.With(logger.String("request_id", id))
+		c.Request = c.Request.WithContext(logger.NewContext(c.Request.Context(), log))
+		c.Next()
+	}
+}
+
+// validRequestID 请求 ID 只允许字母、数字和 ._:- 等符号，避免日志注入
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '.', r == '_', r == ':', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -4,22 +4,37 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/lllllan02/chitchat/internal/api/handler"
 	"github.com/lllllan02/chitchat/internal/api/middleware"
-	"github.com/lllllan02/chitchat/internal/utils"
 	"github.com/lllllan02/chitchat/pkg/response"
 )
 
+// Middlewares 依赖服务的中间件，由应用容器组装
+type Middlewares struct {
+	CORS          gin.HandlerFunc // 跨域策略
+	Auth          gin.HandlerFunc // 必须登录
+	OptionalAuth  gin.HandlerFunc // 可选登录
+	StreamAuth    gin.HandlerFunc // 实时推送认证
+	VerifiedEmail gin.HandlerFunc // 发帖和评论前要求邮箱已验证
+	Admin         gin.HandlerFunc // 管理员权限，需在 Auth 之后使用
+	RateLimit     gin.HandlerFunc // 限流，需在认证中间件之后使用
+}
+
 // InitRouter 初始化路由
-func InitRouter() *gin.Engine {
+func InitRouter(mode string, h *handler.Handlers, m *Middlewares) *gin.Engine {
 	// 设置gin模式
-	gin.SetMode(utils.AppConfig.Server.Mode)
+	gin.SetMode(mode)
+
+	// 校验错误中的字段名使用 JSON 或查询参数名
+	response.SetupValidator()
 
 	// 创建默认路由
 	r := gin.New()
 
 	// 使用中间件
-	r.Use(gin.Recovery())
+	r.Use(middleware.RequestID())
+	r.Use(middleware.Recovery())
 	r.Use(middleware.Logger())
-	r.Use(middleware.CORS())
+	r.Use(m.CORS)
+	r.Use(middleware.Locale())
 
 	// 静态文件
 	r.Static("/uploads", "./uploads")
@@ -32,108 +47,150 @@ func InitRouter() *gin.Engine {
 			response.Success(c, "pong")
 		})
 
+		// 实时推送，支持 WebSocket 和 SSE
+		v1.GET("/stream", m.StreamAuth, m.RateLimit, h.Stream.Stream)
+
 		// 认证相关路由
 		auth := v1.Group("/auth")
+		auth.Use(m.RateLimit)
 		{
-			auth.POST("/register", handler.Register)
-			auth.POST("/login", handler.Login)
+			auth.POST("/register", h.Auth.Register)
+			auth.POST("/login", h.Auth.Login)
+			auth.POST("/login/2fa", h.Auth.LoginTwoFactor)
+			auth.GET("/oauth/providers", h.Auth.ListOAuthProviders)
+			auth.GET("/oauth/:provider/authorize", h.Auth.OAuthAuthorize)
+			auth.POST("/oauth/:provider/callback", h.Auth.OAuthCallback)
+			auth.POST("/refresh", h.Auth.RefreshToken)
+			auth.POST("/verify-email", h.Auth.VerifyEmail)
+			auth.POST("/forgot-password", h.Auth.ForgotPassword)
+			auth.POST("/reset-password", h.Auth.ResetPassword)
 		}
 
+		// 认证相关路由 - 需要登录，限流在认证之后按用户计数
+		session := v1.Group("/auth")
+		session.Use(m.Auth, m.RateLimit)
+		{
+			session.POST("/logout", h.Auth.Logout)
+			session.POST("/verify-email/resend", h.Auth.ResendVerificationEmail)
+		}
+
+		// 全文搜索
+		v1.GET("/search", m.OptionalAuth, m.RateLimit, h.Search.Search)
+
 		// 分类相关路由
 		categories := v1.Group("/categories")
+		categories.Use(m.RateLimit)
 		{
-			categories.GET("", handler.ListCategories)
-			categories.GET("/:id", handler.GetCategory)
+			categories.GET("", h.Category.ListCategories)
+			categories.GET("/:id", h.Category.GetCategory)
 		}
 
 		// 帖子相关路由 - 公开部分
 		posts := v1.Group("/posts")
+		posts.Use(m.OptionalAuth, m.RateLimit)
 		{
-			posts.GET("", handler.ListPosts)
-			posts.GET("/:id", handler.GetPost)
-			posts.GET("/:id/comments", handler.ListPostComments)
+			posts.GET("", h.Post.ListPosts)
+			posts.GET("/pinned", h.Post.ListPinnedPosts)
+			posts.GET("/featured", h.Post.ListFeaturedPosts)
+			posts.GET("/:id", h.Post.GetPost)
+			posts.GET("/:id/comments", h.Comment.ListPostComments)
 		}
 
 		// 需要认证的路由
 		authorized := v1.Group("")
-		authorized.Use(middleware.JWT())
+		authorized.Use(m.Auth, m.RateLimit)
 		{
 			// 用户相关
 			users := authorized.Group("/users")
 			{
-				users.GET("/me", handler.GetCurrentUser)
-				users.PUT("/me", handler.UpdateUser)
-				users.PUT("/me/password", handler.ChangePassword)
-				users.GET("/:id", handler.GetUser)
-				users.GET("", handler.ListUsers)
-				users.GET("/:id/posts", handler.ListUserPosts)
+				users.GET("/me", h.User.GetCurrentUser)
+				users.PUT("/me", h.User.UpdateUser)
+				users.PUT("/me/password", h.User.ChangePassword)
+				users.GET("/me/sessions", h.Session.ListSessions)
+				users.DELETE("/me/sessions/:id", h.Session.RevokeSession)
+				users.POST("/me/2fa/setup", h.TwoFactor.Setup)
+				users.POST("/me/2fa/enable", h.TwoFactor.Enable)
+				users.POST("/me/2fa/disable", h.TwoFactor.Disable)
+				users.POST("/me/2fa/backup-codes", h.TwoFactor.RegenerateBackupCodes)
+				users.GET("/suggest", h.User.SuggestUsers)
+				users.GET("/:id", h.User.GetUser)
+				users.GET("", h.User.ListUsers)
+				users.GET("/:id/posts", h.User.ListUserPosts)
+				users.GET("/:id/followers", h.Follow.ListUserFollowers)
+				users.GET("/:id/following", h.Follow.ListUserFollowing)
+				users.GET("/:id/mutual-followers", h.Follow.ListMutualFollowers)
 			}
 
 			// 帖子相关 - 需要认证
 			posts := authorized.Group("/posts")
 			{
-				posts.POST("", handler.CreatePost)
-				posts.PUT("/:id", handler.UpdatePost)
-				posts.DELETE("/:id", handler.DeletePost)
-				posts.POST("/:id/like", handler.LikePost)
-				posts.DELETE("/:id/like", handler.UnlikePost)
+				posts.POST("", m.VerifiedEmail, h.Post.CreatePost)
+				posts.PUT("/:id", h.Post.UpdatePost)
+				posts.DELETE("/:id", h.Post.DeletePost)
+				posts.POST("/:id/like", h.Post.LikePost)
+				posts.DELETE("/:id/like", h.Post.UnlikePost)
 			}
 
 			// 评论相关
 			comments := authorized.Group("/comments")
 			{
-				comments.POST("", handler.CreateComment)
-				comments.PUT("/:id", handler.UpdateComment)
-				comments.DELETE("/:id", handler.DeleteComment)
-				comments.POST("/:id/like", handler.LikeComment)
-				comments.DELETE("/:id/like", handler.UnlikeComment)
+				comments.POST("", m.VerifiedEmail, h.Comment.CreateComment)
+				comments.PUT("/:id", h.Comment.UpdateComment)
+				comments.DELETE("/:id", h.Comment.DeleteComment)
+				comments.POST("/:id/like", h.Comment.LikeComment)
+				comments.DELETE("/:id/like", h.Comment.UnlikeComment)
 			}
 
 			// 通知相关
 			notifications := authorized.Group("/notifications")
 			{
-				notifications.GET("", handler.ListNotifications)
-				notifications.PUT("/:id/read", handler.MarkNotificationAsRead)
-				notifications.PUT("/read-all", handler.MarkAllNotificationsAsRead)
+				notifications.GET("", h.Notification.ListNotifications)
+				notifications.GET("/unread-count", h.Notification.GetUnreadNotificationCount)
+				notifications.PUT("/:id/read", h.Notification.MarkNotificationAsRead)
+				notifications.PUT("/read-all", h.Notification.MarkAllNotificationsAsRead)
 			}
 
 			// 关注相关
 			follows := authorized.Group("/follows")
 			{
-				follows.POST("/:id", handler.FollowUser)
-				follows.DELETE("/:id", handler.UnfollowUser)
-				follows.GET("/followers", handler.ListFollowers)
-				follows.GET("/following", handler.ListFollowing)
+				follows.POST("/:id", h.Follow.FollowUser)
+				follows.DELETE("/:id", h.Follow.UnfollowUser)
+				follows.GET("/followers", h.Follow.ListFollowers)
+				follows.GET("/following", h.Follow.ListFollowing)
 			}
 		}
 
 		// 管理员相关路由
 		admin := v1.Group("/admin")
-		admin.Use(middleware.JWT(), middleware.AdminAuth())
+		admin.Use(m.Auth, m.Admin, m.RateLimit)
 		{
 			// 分类管理
 			categories := admin.Group("/categories")
 			{
-				categories.POST("", handler.CreateCategory)
-				categories.PUT("/:id", handler.UpdateCategory)
-				categories.DELETE("/:id", handler.DeleteCategory)
+				categories.POST("", h.Category.CreateCategory)
+				categories.PUT("/:id", h.Category.UpdateCategory)
+				categories.DELETE("/:id", h.Category.DeleteCategory)
 			}
 
 			// 帖子管理
 			posts := admin.Group("/posts")
 			{
-				posts.PUT("/:id/pin", handler.PinPost)
-				posts.PUT("/:id/unpin", handler.UnpinPost)
-				posts.PUT("/:id/feature", handler.FeaturePost)
-				posts.PUT("/:id/unfeature", handler.UnfeaturePost)
+				posts.PUT("/:id/pin", h.Post.PinPost)
+				posts.PUT("/:id/unpin", h.Post.UnpinPost)
+				posts.PUT("/:id/feature", h.Post.FeaturePost)
+				posts.PUT("/:id/unfeature", h.Post.UnfeaturePost)
 			}
 
 			// 用户管理
 			users := admin.Group("/users")
 			{
-				users.PUT("/:id/role", handler.UpdateUserRole)
-				users.DELETE("/:id", handler.DeleteUser)
+				users.PUT("/:id/role", h.User.UpdateUserRole)
+				users.POST("/:id/unlock", h.User.UnlockUser)
+				users.DELETE("/:id", h.User.DeleteUser)
 			}
+
+			// 缓存命中统计
+			admin.GET("/cache/stats", h.Cache.Stats)
 		}
 	}
 
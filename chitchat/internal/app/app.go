@@ -0,0 +1,237 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/lllllan02/chitchat/internal/api/handler"
+	"github.com/lllllan02/chitchat/internal/api/middleware"
+	"github.com/lllllan02/chitchat/internal/api/router"
+	"github.com/lllllan02/chitchat/internal/cache"
+	"github.com/lllllan02/chitchat/internal/event"
+	"github.com/lllllan02/chitchat/internal/mailer"
+	"github.com/lllllan02/chitchat/internal/oauth"
+	"github.com/lllllan02/chitchat/internal/pagination"
+	"github.com/lllllan02/chitchat/internal/ratelimit"
+	"github.com/lllllan02/chitchat/internal/repository"
+	"github.com/lllllan02/chitchat/internal/service"
+	"github.com/lllllan02/chitchat/internal/stream"
+	"github.com/lllllan02/chitchat/internal/utils"
+	"github.com/lllllan02/chitchat/internal/viewcount"
+	"github.com/lllllan02/chitchat/pkg/logger"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"time"
+)
+
+// App 应用容器，持有一个服务实例的全部依赖
+// 每个 App 使用各自的数据库和事件总线，可以在同一进程中创建多个互不影响的实例
+type App struct {
+	Config  *utils.Config
+	DB      *gorm.DB
+	Redis   *redis.Client // 未启用 Redis 时为 nil
+	Bus     *event.Bus
+	Hub     *stream.Hub
+	Mailer  mailer.Mailer
+	Cache   *cache.Cache // 未启用缓存时为 nil
+	Views   service.ViewService
+	Account service.AccountService
+	Router  *gin.Engine
+
+	stopRebuild context.CancelFunc
+	rebuilt     chan struct{}    // 启动时的搜索索引重建成功或停止重试后关闭
+	now         func() time.Time // 限流使用的时钟
+}
+
+// New 根据配置和数据库连接创建应用，依次组装仓库、服务、处理器和路由
+func New(cfg *utils.Config, db *gorm.DB) (*App, error) {
+	a := &App{now: time.Now}
+	bus := event.NewBus()
+	hub := stream.NewHub(bus)
+
+	m, err := mailer.New(cfg.Mail)
+	if err != nil {
+		return nil, err
+	}
+
+	providers, err := oauth.NewProviders(cfg.OAuth, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := ratelimit.NewPolicies(cfg.RateLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	var rdb *redis.Client
+	if cfg.Redis.Enabled {
+		if rdb, err = utils.InitRedis(cfg.Redis); err != nil {
+			return nil, err
+		}
+	}
+
+	// 仓库
+	userRepo := repository.NewUserRepository(db)
+	categoryRepo := repository.NewCategoryRepository(db)
+	postRepo := repository.NewPostRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	likeRepo := repository.NewLikeRepository(db)
+	followRepo := repository.NewFollowRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	mentionRepo := repository.NewMentionRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+	revokedTokenRepo := repository.NewRevokedTokenRepository(db)
+	backupCodeRepo := repository.NewBackupCodeRepository(db)
+	identityRepo := repository.NewIdentityRepository(db)
+	oauthStateRepo := repository.NewOAuthStateRepository(db)
+	loginAttemptRepo := repository.NewLoginAttemptRepository(db)
+
+	// 热点数据缓存
+	var c *cache.Cache
+	if cfg.Cache.Enabled {
+		var store cache.Store = cache.NewMemoryStore(cfg.Cache.MaxEntries())
+		if rdb != nil {
+			store = cache.NewRedisStore(rdb, "chitchat:cache:")
+		}
+		c = cache.New(store, cfg.Cache.Expiration())
+		categoryRepo = repository.NewCachedCategoryRepository(categoryRepo, c)
+		postRepo = repository.NewCachedPostRepository(postRepo, userRepo, c)
+		likeRepo = repository.NewCachedLikeRepository(likeRepo, c)
+	}
+
+	// 服务
+	tokenService := service.NewTokenService(cfg.JWT, userRepo, sessionRepo, refreshTokenRepo, revokedTokenRepo)
+	searchService := service.NewSearchService(postRepo, commentRepo, userRepo, bus)
+	userService := service.NewUserService(userRepo, tokenService, searchService, bus)
+	accountService := service.NewAccountService(cfg.JWT, cfg.Mail, userRepo, userService, m)
+	loginGuard := service.NewLoginGuardService(cfg.Auth.Lockout, loginAttemptRepo, userRepo, bus)
+	twoFactorService := service.NewTwoFactorService(cfg.JWT, userRepo, backupCodeRepo, revokedTokenRepo, tokenService, loginGuard)
+	oauthService := service.NewOAuthService(providers, userRepo, identityRepo, oauthStateRepo, bus)
+	categoryService := service.NewCategoryService(categoryRepo)
+	mentionService := service.NewMentionService(mentionRepo, userRepo, bus)
+	postService := service.NewPostService(postRepo, categoryRepo, mentionService, searchService, bus)
+	commentService := service.NewCommentService(commentRepo, postRepo, mentionService, bus)
+	likeService := service.NewLikeService(likeRepo, postRepo, commentRepo, bus)
+	followService := service.NewFollowService(followRepo, userRepo, bus)
+	notificationService := service.NewNotificationService(notificationRepo, userRepo, postRepo, commentRepo, bus)
+
+	// 先订阅更新索引的事件，再在后台重建索引，数据量大时不阻塞启动
+	// 重建完成前搜索返回 503，列表的关键词筛选改用数据库查询；重建失败时定期重试
+	searchService.Subscribe()
+	rebuildCtx, stopRebuild := context.WithCancel(context.Background())
+	rebuilt := make(chan struct{})
+	go func() {
+		defer close(rebuilt)
+		retry := cfg.Search.RetryInterval()
+		for {
+			err := searchService.Rebuild(rebuildCtx)
+			if err == nil || errors.Is(err, context.Canceled) {
+				return
+			}
+			logger.Error("重建搜索索引失败", logger.Err(err), logger.Duration("retry_in", retry))
+
+			select {
+			case <-rebuildCtx.Done():
+				return
+			case <-time.After(retry):
+			}
+		}
+	}()
+
+	// 浏览次数先在内存或 Redis 中累计，再定期写入数据库
+	var viewStore viewcount.Store = viewcount.NewMemoryStore()
+	if rdb != nil {
+		viewStore = viewcount.NewRedisStore(rdb, "chitchat:views:")
+	}
+	viewService := service.NewViewService(cfg.ViewCount, viewStore, postRepo)
+	viewService.Start()
+
+	// 订阅通知事件
+	notificationService.Subscribe()
+
+	// 列表翻页使用的签名游标
+	cursors := pagination.NewCodec(cfg.JWT.Secret)
+
+	// 允许跨域访问的来源，CORS 和 WebSocket 共用
+	origins := middleware.NewOriginPolicy(cfg.Server.AllowedOrigins)
+
+	// 管理员和版主的权限
+	staff := middleware.StaffPolicy{RequireTwoFactor: cfg.Auth.RequireStaffTwoFactor}
+
+	// 处理器
+	handlers := &handler.Handlers{
+		Auth:         handler.NewAuthHandler(userService, tokenService, accountService, twoFactorService, oauthService, loginGuard),
+		User:         handler.NewUserHandler(userService, tokenService, postService, followService, likeService, mentionService, loginGuard, cursors),
+		Category:     handler.NewCategoryHandler(categoryService),
+		Post:         handler.NewPostHandler(postService, likeService, mentionService, viewService, staff, cursors),
+		Comment:      handler.NewCommentHandler(commentService, likeService, mentionService, staff, cursors),
+		Follow:       handler.NewFollowHandler(followService, cursors),
+		Session:      handler.NewSessionHandler(tokenService),
+		TwoFactor:    handler.NewTwoFactorHandler(twoFactorService, userService, tokenService),
+		Notification: handler.NewNotificationHandler(notificationService, cursors),
+		Stream:       handler.NewStreamHandler(hub, origins),
+		Search:       handler.NewSearchHandler(searchService, likeService, mentionService),
+		Cache:        handler.NewCacheHandler(c),
+	}
+
+	// 中间件
+	middlewares := &router.Middlewares{
+		CORS:          middleware.CORS(origins),
+		Auth:          middleware.JWT(tokenService),
+		OptionalAuth:  middleware.OptionalJWT(tokenService),
+		StreamAuth:    middleware.StreamJWT(tokenService),
+		Admin:         staff.Require(middleware.RoleAdmin),
+		VerifiedEmail: func(c *gin.Context) { c.Next() },
+		RateLimit:     func(c *gin.Context) { c.Next() },
+	}
+	if cfg.Auth.RequireEmailVerification {
+		middlewares.VerifiedEmail = middleware.VerifiedEmail(accountService)
+	}
+	if cfg.RateLimit.Enabled {
+		var store ratelimit.Store = ratelimit.NewMemoryStore()
+		if rdb != nil {
+			store = ratelimit.NewRedisStore(rdb, "chitchat:ratelimit:")
+		}
+		middlewares.RateLimit = middleware.RateLimit(store, policies, func() time.Time { return a.now() })
+	}
+
+	a.Config = cfg
+	a.DB = db
+	a.Redis = rdb
+	a.Bus = bus
+	a.Hub = hub
+	a.Mailer = m
+	a.Cache = c
+	a.Views = viewService
+	a.Account = accountService
+	a.Router = router.InitRouter(cfg.Server.Mode, handlers, middlewares)
+	a.stopRebuild = stopRebuild
+	a.rebuilt = rebuilt
+	return a, nil
+}
+
+// SetClock 替换限流使用的时钟，需在处理请求之前调用
+func (a *App) SetClock(now func() time.Time) {
+	a.now = now
+}
+
+// WaitSearchIndex 等待启动时的搜索索引重建成功，重建失败时会一直重试直到 Close
+func (a *App) WaitSearchIndex() {
+	<-a.rebuilt
+}
+
+// Close 停止重建搜索索引、断开推送连接、写入剩余的浏览次数、等待后台邮件发送完成并释放应用持有的外部连接
+// 应在停止接收请求之后调用
+func (a *App) Close() error {
+	a.stopRebuild()
+	a.WaitSearchIndex()
+	a.Hub.Close()
+	a.Views.Stop()
+	a.Account.Wait()
+	if a.Redis != nil {
+		return a.Redis.Close()
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+// Package cache 为热点读取提供缓存，数据可以保存在进程内存或 Redis 中
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lllllan02/chitchat/pkg/logger"
+)
+
+// Store 缓存存储接口，值为序列化后的数据
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	DeletePrefix(ctx context.Context, prefix string) error
+}
+
+// Stat 一组缓存键的命中统计
+type Stat struct {
+	Group   string  `json:"group"`
+	Hits    uint64  `json:"hits"`
+	Misses  uint64  `json:"misses"`
+	Errors  uint64  `json:"errors"`   // 读写存储失败的次数，失败时直接查询数据源
+	HitRate float64 `json:"hit_rate"` // 命中次数占读取次数的比例
+}
+
+// counter 命中计数
+type counter struct {
+	hits, misses, errors atomic.Uint64
+}
+
+// 键的失效版本按哈希分散到固定数量的槽中，不随键的数量增长
+const versionSlots = 256
+
+// Cache 带命中统计的缓存
+// 值以 JSON 保存，读取时总是得到新的副本，调用方可以放心修改
+type Cache struct {
+	store Store
+	ttl   time.Duration
+
+	mu     sync.Mutex
+	groups map[string]*counter
+	// 失效版本，删除键或前缀时增加；查询期间版本变化说明查到的可能是旧数据，不写入缓存
+	// 只能发现本进程内的失效，多个实例共享 Redis 时其他实例的失效最多滞后一个 ttl
+	keyVersions    [versionSlots]uint64
+	prefixVersions map[string]uint64
+}
+
+// New 创建缓存，ttl 为所有键的过期时间
+func New(store Store, ttl time.Duration) *Cache {
+	return &Cache{store: store, ttl: ttl, groups: make(map[string]*counter), prefixVersions: make(map[string]uint64)}
+}
+
+// Load 读取缓存，未命中时调用 load 查询并写入缓存
+// group 用于按类别统计命中率；存储出错时不影响查询结果，只记录日志
+func Load[T any](ctx context.Context, c *Cache, group, key string, load func() (T, error)) (T, error) {
+	stat := c.counter(group)
+
+	data, ok, err := c.store.Get(ctx, key)
+	if err != nil {
+		stat.errors.Add(1)
+		logger.FromContext(ctx).Error("读取缓存失败", logger.String("key", key), logger.Err(err))
+	}
+	if ok {
+		var value T
+		if err := json.Unmarshal(data, &value); err == nil {
+			stat.hits.Add(1)
+			return value, nil
+		}
+	}
+	stat.misses.Add(1)
+
+	version := c.version(key)
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+
+	// 查询期间缓存被失效时，查到的可能是修改前的数据，不能写入缓存
+	if c.version(key) != version {
+		return value, nil
+	}
+	if data, err := json.Marshal(value); err == nil {
+		if err := c.store.Set(ctx, key, data, c.ttl); err != nil {
+			stat.errors.Add(1)
+			logger.FromContext(ctx).Error("写入缓存失败", logger.String("key", key), logger.Err(err))
+		}
+	}
+	// 失效发生在检查之后、写入之前时，失效方的删除可能早于写入，由这里补删
+	if c.version(key) != version {
+		c.Delete(ctx, key)
+	}
+	return value, nil
+}
+
+// Delete 删除缓存键
+func (c *Cache) Delete(ctx context.Context, keys ...string) {
+	c.mu.Lock()
+	for _, key := range keys {
+		c.keyVersions[versionSlot(key)]++
+	}
+	c.mu.Unlock()
+
+	if err := c.store.Delete(ctx, keys...); err != nil {
+		logger.FromContext(ctx).Error("删除缓存失败", logger.Any("keys", keys), logger.Err(err))
+	}
+}
+
+// DeletePrefix 删除指定前缀的全部缓存键
+func (c *Cache) DeletePrefix(ctx context.Context, prefix string) {
+	c.mu.Lock()
+	c.prefixVersions[prefix]++
+	c.mu.Unlock()
+
+	if err := c.store.DeletePrefix(ctx, prefix); err != nil {
+		logger.FromContext(ctx).Error("删除缓存失败", logger.String("prefix", prefix), logger.Err(err))
+	}
+}
+
+// Stats 返回各组缓存键的命中统计，按组名排序
+func (c *Cache) Stats() []Stat {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := make([]Stat, 0, len(c.groups))
+	for group, n := range c.groups {
+		stat := Stat{Group: group, Hits: n.hits.Load(), Misses: n.misses.Load(), Errors: n.errors.Load()}
+		if total := stat.Hits + stat.Misses; total > 0 {
+			stat.HitRate = float64(stat.Hits) / float64(total)
+		}
+		stats = append(stats, stat)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Group < stats[j].Group })
+	return stats
+}
+
+// version 键当前的失效版本，包括键本身和覆盖该键的前缀
+func (c *Cache) version(key string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v := c.keyVersions[versionSlot(key)]
+	for prefix, n := range c.prefixVersions {
+		if strings.HasPrefix(key, prefix) {
+			v += n
+		}
+	}
+	return v
+}
+
+// versionSlot 键的失效版本所在的槽
+func versionSlot(key string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return h.Sum32() % versionSlots
+}
+
+// counter 获取分组的计数器
+func (c *Cache) counter(group string) *counter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n, ok := c.groups[group]
+	if !ok {
+		n = &counter{}
+		c.groups[group] = n
+	}
+	return n
+}
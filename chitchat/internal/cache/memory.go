@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// entry 内存缓存条目
+type entry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// MemoryStore 进程内的 LRU 缓存，只在单个实例内生效
+// 条目数超过上限时淘汰最久未使用的条目
+type MemoryStore struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+// NewMemoryStore 创建内存缓存，size 为最大条目数
+func NewMemoryStore(size int) *MemoryStore {
+	return &MemoryStore{size: size, ll: list.New(), items: make(map[string]*list.Element)}
+}
+
+// Get 读取缓存，过期的条目视为不存在
+func (s *MemoryStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	e := el.Value.(*entry)
+	if time.Now().After(e.expiresAt) {
+		s.remove(el)
+		return nil, false, nil
+	}
+
+	s.ll.MoveToFront(el)
+	return e.value, true, nil
+}
+
+// Set 写入缓存
+func (s *MemoryStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt := time.Now().Add(ttl)
+	if el, ok := s.items[key]; ok {
+		e := el.Value.(*entry)
+		e.value, e.expiresAt = value, expiresAt
+		s.ll.MoveToFront(el)
+		return nil
+	}
+
+	s.items[key] = s.ll.PushFront(&entry{key: key, value: value, expiresAt: expiresAt})
+	for s.size > 0 && s.ll.Len() > s.size {
+		s.remove(s.ll.Back())
+	}
+	return nil
+}
+
+// Delete 删除缓存
+func (s *MemoryStore) Delete(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		if el, ok := s.items[key]; ok {
+			s.remove(el)
+		}
+	}
+	return nil
+}
+
+// DeletePrefix 删除指定前缀的全部缓存
+func (s *MemoryStore) DeletePrefix(ctx context.Context, prefix string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, el := range s.items {
+		if strings.HasPrefix(key, prefix) {
+			s.remove(el)
+		}
+	}
+	return nil
+}
+
+// remove 移除条目
+func (s *MemoryStore) remove(el *list.Element) {
+	s.ll.Remove(el)
+	delete(s.items, el.Value.(*entry).key)
+}
@@ -0,0 +1,88 @@
+package event
+
+import (
+	"context"
+	"runtime/debug"
+	"sync"
+
+	"github.com/lllllan02/chitchat/pkg/logger"
+)
+
+// Type 事件类型
+type Type string
+
+const (
+	CommentCreated      Type = "comment.created"
+	PostLiked           Type = "post.liked"
+	CommentLiked        Type = "comment.liked"
+	LikeCountChanged    Type = "like.count_changed"
+	UserFollowed        Type = "user.followed"
+	MentionDetected     Type = "mention.detected"
+	NotificationCreated Type = "notification.created"
+	AccountLocked       Type = "account.locked"
+	PostCreated         Type = "post.created"
+	PostUpdated         Type = "post.updated"
+	PostDeleted         Type = "post.deleted"
+	CommentUpdated      Type = "comment.updated"
+	CommentDeleted      Type = "comment.deleted"
+	UserCreated         Type = "user.created"
+	UserUpdated         Type = "user.updated"
+	UserDeleted         Type = "user.deleted"
+)
+
+// Event 领域事件
+type Event struct {
+	Type      Type
+	ActorID   uint // 触发事件的用户
+	UserID    uint // 被关注、被提及、被锁定或资料变化的用户
+	PostID    uint
+	CommentID uint
+	Payload   interface{} // 事件相关的数据，如新建的评论或通知
+}
+
+// Handler 事件处理函数，ctx 为发布事件时的上下文
+type Handler func(ctx context.Context, e Event) error
+
+// Bus 进程内事件总线
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[Type][]Handler
+}
+
+// NewBus 创建事件总线
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[Type][]Handler)}
+}
+
+// Subscribe 订阅事件
+func (b *Bus) Subscribe(t Type, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[t] = append(b.handlers[t], h)
+}
+
+// Publish 发布事件，按订阅顺序同步调用处理函数
+// 处理函数的错误和panic只记录日志，不影响发布方
+func (b *Bus) Publish(ctx context.Context, e Event) {
+	b.mu.RLock()
+	handlers := b.handlers[e.Type]
+	b.mu.RUnlock()
+
+	for _, h := range handlers {
+		b.dispatch(ctx, h, e)
+	}
+}
+
+// dispatch 调用单个处理函数
+func (b *Bus) dispatch(ctx context.Context, h Handler, e Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.FromContext(ctx).Error("处理事件时发生panic",
+				logger.String("event", string(e.Type)), logger.Any("panic", r), logger.String("stack", string(debug.Stack())))
+		}
+	}()
+
+	if err := h(ctx, e); err != nil {
+		logger.FromContext(ctx).Error("处理事件失败", logger.String("event", string(e.Type)), logger.Err(err))
+	}
+}
@@ -0,0 +1,41 @@
+// Package mailer 提供邮件发送接口及其实现
+package mailer
+
+import (
+	"fmt"
+
+	"github.com/lllllan02/chitchat/internal/utils"
+)
+
+// 支持的邮件驱动
+const (
+	DriverSMTP   = "smtp"
+	DriverFile   = "file"
+	DriverMemory = "memory"
+)
+
+// Message 邮件内容，正文为纯文本
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer 邮件发送接口
+type Mailer interface {
+	Send(msg *Message) error
+}
+
+// New 根据配置创建邮件发送器
+func New(cfg utils.MailConfig) (Mailer, error) {
+	switch cfg.Driver {
+	case DriverSMTP:
+		return NewSMTPMailer(cfg), nil
+	case DriverFile, "":
+		return NewFileMailer(cfg.OutboxDir, cfg.From), nil
+	case DriverMemory:
+		return NewMemoryMailer(), nil
+	default:
+		return nil, fmt.Errorf("不支持的邮件驱动: %s", cfg.Driver)
+	}
+}
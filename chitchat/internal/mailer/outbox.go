@@ -0,0 +1,82 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileMailer 将邮件写入目录而不真正发送，用于本地开发
+type FileMailer struct {
+	dir  string
+	from string
+
+	mu  sync.Mutex
+	seq int
+}
+
+// NewFileMailer 创建写入目录的邮件发送器
+func NewFileMailer(dir, from string) *FileMailer {
+	if dir == "" {
+		dir = "./data/outbox"
+	}
+	return &FileMailer{dir: dir, from: from}
+}
+
+// Send 将邮件保存为 .eml 文件
+func (m *FileMailer) Send(msg *Message) error {
+	if err := os.MkdirAll(m.dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.seq++
+	name := fmt.Sprintf("%s-%d.eml", time.Now().Format("20060102-150405"), m.seq)
+	m.mu.Unlock()
+
+	return os.WriteFile(filepath.Join(m.dir, name), encode(m.from, msg), 0644)
+}
+
+// MemoryMailer 将邮件保存在内存中，用于测试
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []*Message
+}
+
+// NewMemoryMailer 创建内存邮件发送器
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+// Send 保存邮件
+func (m *MemoryMailer) Send(msg *Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	copied := *msg
+	m.messages = append(m.messages, &copied)
+	return nil
+}
+
+// Messages 获取已发送的全部邮件
+func (m *MemoryMailer) Messages() []*Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]*Message(nil), m.messages...)
+}
+
+// Last 获取发给指定收件人的最后一封邮件
+func (m *MemoryMailer) Last(to string) *Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].To == to {
+			return m.messages[i]
+		}
+	}
+	return nil
+}
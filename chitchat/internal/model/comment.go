@@ -18,6 +18,12 @@ type Comment struct {
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// 当前用户是否已点赞，不存储到数据库
+	LikedByMe bool `gorm:"-" json:"liked_by_me"`
+
+	// 内容中提及的用户，不存储到数据库
+	Mentions []MentionedUser `gorm:"-" json:"mentions"`
+
 	// 关联
 	User    User      `gorm:"foreignKey:UserID" json:"user"`
 	Post    Post      `gorm:"foreignKey:PostID" json:"post"`
@@ -9,8 +9,8 @@ import (
 // Follow 用户关注模型
 type Follow struct {
 	ID         uint           `gorm:"primaryKey" json:"id"`
-	FollowerID uint           `gorm:"index;not null" json:"follower_id"`
-	FollowedID uint           `gorm:"index;not null" json:"followed_id"`
+	FollowerID uint           `gorm:"index;uniqueIndex:idx_follows_pair;not null" json:"follower_id"`
+	FollowedID uint           `gorm:"index;uniqueIndex:idx_follows_pair;not null" json:"followed_id"`
 	CreatedAt  time.Time      `json:"created_at"`
 	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
 
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"time"
+)
+
+// UserIdentity 用户在第三方身份提供方的身份，同一提供方的同一用户只能关联一个账号
+type UserIdentity struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"index;not null" json:"user_id"`
+	Provider  string    `gorm:"type:varchar(50);uniqueIndex:idx_identity_subject;not null" json:"provider"`
+	Subject   string    `gorm:"type:varchar(255);uniqueIndex:idx_identity_subject;not null" json:"subject"`
+	Email     string    `gorm:"type:varchar(100)" json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 设置表名
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
+
+// OAuthState 进行中的第三方登录请求，回调时校验并删除
+// PKCE 校验码只保存在服务端，授权码被截获也无法换取令牌
+type OAuthState struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	StateHash    string    `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	Provider     string    `gorm:"type:varchar(50);not null" json:"provider"`
+	CodeVerifier string    `gorm:"type:varchar(128);not null" json:"-"`
+	Nonce        string    `gorm:"type:varchar(64);not null" json:"-"`
+	ExpiresAt    time.Time `gorm:"index;not null" json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// TableName 设置表名
+func (OAuthState) TableName() string {
+	return "oauth_states"
+}
+
+// OAuthProvider 可用的第三方登录方式
+type OAuthProvider struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+// OAuthAuthorization 第三方登录授权地址，前端跳转到该地址
+type OAuthAuthorization struct {
+	AuthorizationURL string `json:"authorization_url"`
+	State            string `json:"state"`
+}
+
+// OAuthCallbackRequest 第三方登录回调请求，参数来自身份提供方重定向到前端的查询字符串
+type OAuthCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
@@ -9,9 +9,9 @@ import (
 // Like 点赞模型
 type Like struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
-	UserID    uint           `gorm:"index;not null" json:"user_id"`
-	PostID    *uint          `gorm:"index" json:"post_id"`
-	CommentID *uint          `gorm:"index" json:"comment_id"`
+	UserID    uint           `gorm:"index;uniqueIndex:idx_likes_user_post;uniqueIndex:idx_likes_user_comment;not null" json:"user_id"`
+	PostID    *uint          `gorm:"index;uniqueIndex:idx_likes_user_post" json:"post_id"`
+	CommentID *uint          `gorm:"index;uniqueIndex:idx_likes_user_comment" json:"comment_id"`
 	CreatedAt time.Time      `json:"created_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
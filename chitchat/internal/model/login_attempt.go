@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+)
+
+// LoginAttempt 登录失败记录，Key 为 "user:用户名" 或 "ip:地址"
+// 按用户名记录时不要求用户存在，避免通过锁定行为探测用户名是否注册
+type LoginAttempt struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	Key          string     `gorm:"column:attempt_key;type:varchar(191);uniqueIndex;not null" json:"key"`
+	Failures     int        `gorm:"default:0;not null" json:"failures"`
+	LastFailedAt time.Time  `gorm:"index;not null" json:"last_failed_at"`
+	LockedUntil  *time.Time `json:"locked_until"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// TableName 设置表名
+func (LoginAttempt) TableName() string {
+	return "login_attempts"
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+)
+
+// Mention 提及模型，记录帖子或评论中提到的用户
+type Mention struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"index;not null" json:"user_id"`
+	PostID    uint      `gorm:"index;not null" json:"post_id"`
+	CommentID *uint     `gorm:"index" json:"comment_id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// 关联
+	User User `gorm:"foreignKey:UserID" json:"user"`
+}
+
+// TableName 设置表名
+func (Mention) TableName() string {
+	return "mentions"
+}
+
+// MentionedUser 被提及的用户，供前端渲染个人主页链接
+type MentionedUser struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+}
@@ -28,6 +28,8 @@ type Notification struct {
 	CommentID *uint            `gorm:"index" json:"comment_id"`
 	Link      string           `gorm:"type:varchar(255)" json:"link"`
 	IsRead    bool             `gorm:"default:false" json:"is_read"`
+	Count     int              `gorm:"default:1" json:"count"`
+	MergeKey  *string          `gorm:"type:varchar(100);uniqueIndex" json:"-"` // 可合并通知的合并键，标记已读后清空
 	CreatedAt time.Time        `json:"created_at"`
 	UpdatedAt time.Time        `json:"updated_at"`
 	DeletedAt gorm.DeletedAt   `gorm:"index" json:"-"`
@@ -44,10 +46,22 @@ func (Notification) TableName() string {
 	return "notifications"
 }
 
+// NotificationActor 合并通知的触发者，每个用户只计一次
+type NotificationActor struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	NotificationID uint      `gorm:"uniqueIndex:idx_notification_actor;not null" json:"notification_id"`
+	UserID         uint      `gorm:"uniqueIndex:idx_notification_actor;not null" json:"user_id"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// TableName 设置表名
+func (NotificationActor) TableName() string {
+	return "notification_actors"
+}
+
 // NotificationListQuery 通知列表查询参数
 type NotificationListQuery struct {
-	Type     string `form:"type" binding:"omitempty,oneof=reply like follow mention system"`
-	IsRead   *bool  `form:"is_read"`
-	Page     int    `form:"page" binding:"min=1"`
-	PageSize int    `form:"page_size" binding:"min=1,max=100"`
+	Type   string `form:"type" binding:"omitempty,oneof=reply like follow mention system"`
+	IsRead *bool  `form:"is_read"`
+	CursorQuery
 }
@@ -0,0 +1,8 @@
+package model
+
+// CursorQuery 游标分页查询参数
+type CursorQuery struct {
+	Cursor    string `form:"cursor" binding:"max=512"` // 上一次响应中的 next_cursor 或 prev_cursor，为空时返回第一页
+	PageSize  int    `form:"page_size" binding:"min=1,max=100"`
+	WithTotal bool   `form:"with_total"` // 是否返回总数
+}
@@ -21,6 +21,12 @@ type Post struct {
 	UpdatedAt  time.Time      `json:"updated_at"`
 	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// 当前用户是否已点赞，不存储到数据库
+	LikedByMe bool `gorm:"-" json:"liked_by_me"`
+
+	// 内容中提及的用户，不存储到数据库
+	Mentions []MentionedUser `gorm:"-" json:"mentions"`
+
 	// 关联
 	User     User     `gorm:"foreignKey:UserID" json:"user"`
 	Category Category `gorm:"foreignKey:CategoryID" json:"category"`
@@ -31,26 +37,31 @@ func (Post) TableName() string {
 	return "posts"
 }
 
-// PostRequest 帖子请求
+// PostRequest 帖子请求，分类可选
 type PostRequest struct {
-	Title      string `json:"title" binding:"required,min=5,max=255"`
-	Content    string `json:"content" binding:"required,min=10"`
-	CategoryID uint   `json:"category_id" binding:"required"`
+	Title      string `json:"title" binding:"required,max=255"`
+	Content    string `json:"content" binding:"required"`
+	CategoryID uint   `json:"category_id"`
 }
 
-// PostUpdateRequest 帖子更新请求
+// PostUpdateRequest 帖子更新请求，只更新非空字段
 type PostUpdateRequest struct {
-	Title      string `json:"title" binding:"required,min=5,max=255"`
-	Content    string `json:"content" binding:"required,min=10"`
-	CategoryID uint   `json:"category_id" binding:"required"`
+	Title      string `json:"title" binding:"max=255"`
+	Content    string `json:"content"`
+	CategoryID uint   `json:"category_id"`
 }
 
 // PostListQuery 帖子列表查询参数
 type PostListQuery struct {
 	CategoryID uint   `form:"category_id"`
 	UserID     uint   `form:"user_id"`
-	Keyword    string `form:"keyword"`
-	OrderBy    string `form:"order_by" binding:"omitempty,oneof=latest popular"`
-	Page       int    `form:"page" binding:"min=1"`
-	PageSize   int    `form:"page_size" binding:"min=1,max=100"`
+	Keyword    string `form:"keyword" binding:"max=200"`
+	OrderBy    string `form:"order_by" binding:"omitempty,oneof=recent popular"` // 默认按发布时间
+	CursorQuery
+}
+
+// HighlightQuery 置顶和精华帖子查询参数
+type HighlightQuery struct {
+	CategoryID uint `form:"category_id"` // 只对置顶帖子有效
+	Limit      int  `form:"limit" binding:"min=1,max=50"`
 }
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// SearchQuery 搜索请求参数
+type SearchQuery struct {
+	Q          string    `form:"q" binding:"required,max=200"`
+	Type       string    `form:"type" binding:"omitempty,oneof=post comment user"` // 为空时搜索全部类型
+	CategoryID uint      `form:"category_id"`                                      // 只搜索该分类的帖子和评论
+	UserID     uint      `form:"user_id"`                                          // 只搜索该用户发布的内容
+	From       time.Time `form:"from" time_format:"2006-01-02"`                    // 创建日期不早于该日期
+	To         time.Time `form:"to" time_format:"2006-01-02"`                      // 创建日期不晚于该日期
+	Page       int       `form:"page" binding:"min=1"`
+	PageSize   int       `form:"page_size" binding:"min=1,max=50"`
+}
+
+// SearchHit 搜索结果，按类型附带帖子、评论或用户
+type SearchHit struct {
+	Type       string            `json:"type"`
+	ID         uint              `json:"id"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"` // 字段名到高亮片段，命中的词用 <em> 标记，其余内容已经过 HTML 转义
+	Post       *Post             `json:"post,omitempty"`
+	Comment    *Comment          `json:"comment,omitempty"`
+	User       *User             `json:"user,omitempty"`
+}
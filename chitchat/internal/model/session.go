@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+)
+
+// Session 登录会话，每次登录创建一个会话，刷新令牌轮换时沿用同一会话
+type Session struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	UserID     uint      `gorm:"index;not null" json:"user_id"`
+	UserAgent  string    `gorm:"type:varchar(255)" json:"user_agent"`
+	IP         string    `gorm:"type:varchar(45)" json:"ip"`
+	ExpiresAt  time.Time `gorm:"index;not null" json:"expires_at"`
+	LastSeenAt time.Time `gorm:"not null" json:"last_seen_at"`
+	TwoFactor  bool      `gorm:"default:false;not null" json:"two_factor"` // 登录时是否通过了两步验证
+	CreatedAt  time.Time `json:"created_at"`
+
+	// 是否为发起请求的会话，不存储到数据库
+	Current bool `gorm:"-" json:"current"`
+}
+
+// TableName 设置表名
+func (Session) TableName() string {
+	return "sessions"
+}
+
+// ClientInfo 发起请求的客户端信息
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+)
+
+// RefreshToken 刷新令牌，只保存令牌的哈希值
+// 每次刷新都会吊销旧令牌并签发新令牌，已吊销的令牌再次使用视为泄露
+// 会话被注销时连同其刷新令牌一起删除
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	SessionID uint       `gorm:"index;not null" json:"session_id"`
+	TokenHash string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"index;not null" json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName 设置表名
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+// RevokedToken 已吊销的访问令牌，过期后即可清理
+type RevokedToken struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	TokenID   string    `gorm:"type:varchar(64);uniqueIndex;not null" json:"token_id"`
+	ExpiresAt time.Time `gorm:"index;not null" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName 设置表名
+func (RevokedToken) TableName() string {
+	return "revoked_tokens"
+}
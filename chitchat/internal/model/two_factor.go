@@ -0,0 +1,55 @@
+package model
+
+import (
+	"time"
+)
+
+// BackupCode 两步验证备用码，只保存哈希，每个备用码只能使用一次
+type BackupCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	CodeHash  string     `gorm:"type:varchar(64);not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName 设置表名
+func (BackupCode) TableName() string {
+	return "backup_codes"
+}
+
+// TwoFactorSetup 两步验证登记信息
+type TwoFactorSetup struct {
+	Secret     string `json:"secret"`      // 供无法扫码时手动输入
+	OTPAuthURI string `json:"otpauth_uri"` // 可直接编码为二维码
+}
+
+// TwoFactorActivation 启用两步验证的结果，备用码只在此时返回一次
+type TwoFactorActivation struct {
+	BackupCodes []string `json:"backup_codes"`
+	TokenPair
+}
+
+// LoginChallenge 启用两步验证的用户通过密码校验后返回的挑战
+type LoginChallenge struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresIn         int64  `json:"expires_in"` // 挑战令牌有效期，单位秒
+}
+
+// TwoFactorLoginRequest 两步验证登录请求，code 可以是验证码或备用码
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+// TwoFactorCodeRequest 提交验证码的请求
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TwoFactorDisableRequest 关闭两步验证请求
+type TwoFactorDisableRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
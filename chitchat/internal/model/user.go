@@ -8,22 +8,39 @@ import (
 
 // User 用户模型
 type User struct {
-	ID           uint           `gorm:"primaryKey" json:"id"`
-	Username     string         `gorm:"type:varchar(50);uniqueIndex;not null" json:"username"`
-	Email        string         `gorm:"type:varchar(100);uniqueIndex;not null" json:"email"`
-	PasswordHash string         `gorm:"type:varchar(255);not null" json:"-"`
-	Avatar       string         `gorm:"type:varchar(255)" json:"avatar"`
-	Bio          string         `gorm:"type:text" json:"bio"`
-	Role         string         `gorm:"type:varchar(20);default:user" json:"role"`
-	CreatedAt    time.Time      `json:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at"`
-	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	Username      string         `gorm:"type:varchar(50);uniqueIndex;not null" json:"username"`
+	Email         string         `gorm:"type:varchar(100);uniqueIndex;not null" json:"email"`
+	EmailVerified bool           `gorm:"default:false;not null" json:"email_verified"`
+	PasswordHash  string         `gorm:"type:varchar(255);not null" json:"-"`
+	Avatar        string         `gorm:"type:varchar(255)" json:"avatar"`
+	Bio           string         `gorm:"type:text" json:"bio"`
+	Role          string         `gorm:"type:varchar(20);default:user" json:"role"`
+	TokenVersion  int            `gorm:"default:0;not null" json:"-"` // 修改密码或角色时递增，使已签发的令牌全部失效
+	TwoFactor     bool           `gorm:"default:false;not null" json:"two_factor"`
+	TOTPSecret    string         `gorm:"type:varchar(64)" json:"-"`      // 两步验证密钥，启用前为待确认的密钥
+	TOTPLastStep  int64          `gorm:"default:0;not null" json:"-"`    // 最近一次使用的验证码时间步，防止验证码重放
+	Locale        string         `gorm:"type:varchar(10)" json:"locale"` // 语言偏好，为空时按 Accept-Language 协商
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// 关注统计，不存储到数据库
+	FollowerCount  int64 `gorm:"-" json:"follower_count"`
+	FollowingCount int64 `gorm:"-" json:"following_count"`
+}
+
+// TokenPair 访问令牌和刷新令牌
+type TokenPair struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // 访问令牌有效期，单位秒
 }
 
 // UserWithToken 带令牌的用户信息
 type UserWithToken struct {
-	User  *User  `json:"user"`
-	Token string `json:"token"`
+	User *User `json:"user"`
+	TokenPair
 }
 
 // TableName 设置表名
@@ -31,6 +48,31 @@ func (User) TableName() string {
 	return "users"
 }
 
+// UserListQuery 用户列表查询参数
+type UserListQuery struct {
+	Keyword  string    `form:"keyword" binding:"max=100"` // 按用户名和简介搜索
+	Role     string    `form:"role" binding:"omitempty,oneof=user moderator admin"`
+	From     time.Time `form:"from" time_format:"2006-01-02"`                             // 注册日期不早于该日期
+	To       time.Time `form:"to" time_format:"2006-01-02"`                               // 注册日期不晚于该日期
+	OrderBy  string    `form:"order_by" binding:"omitempty,oneof=joined posts followers"` // 默认按注册时间
+	Order    string    `form:"order" binding:"omitempty,oneof=asc desc"`                  // 默认降序
+	Page     int       `form:"page" binding:"min=1"`
+	PageSize int       `form:"page_size" binding:"min=1,max=100"`
+}
+
+// UserSuggestQuery 用户名联想查询参数
+type UserSuggestQuery struct {
+	Q     string `form:"q" binding:"required,max=50"`
+	Limit int    `form:"limit" binding:"min=1,max=20"`
+}
+
+// UserSuggestion 用户名联想结果，只包含提及选择器需要的字段
+type UserSuggestion struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+	Avatar   string `json:"avatar"`
+}
+
 // UserRegisterRequest 用户注册请求
 type UserRegisterRequest struct {
 	Username string `json:"username" binding:"required,min=3,max=50"`
@@ -48,6 +90,18 @@ type UserLoginRequest struct {
 type UserUpdateRequest struct {
 	Avatar string `json:"avatar"`
 	Bio    string `json:"bio"`
+	Locale string `json:"locale" binding:"omitempty,oneof=zh-CN en"`
+}
+
+// ForgotPasswordRequest 忘记密码请求
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest 重置密码请求
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
 }
 
 // PasswordChangeRequest 密码修改请求
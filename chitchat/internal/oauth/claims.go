@@ -0,0 +1,55 @@
+package oauth
+
+import (
+	"strconv"
+	"strings"
+)
+
+// fillIdentity 用声明填充身份中缺失的字段
+// 同时兼容 OIDC 标准声明和常见 OAuth2 提供方的字段名（如 id、login）
+func fillIdentity(identity *Identity, claims map[string]interface{}) {
+	if identity.Subject == "" {
+		identity.Subject = subjectOf(claims)
+	}
+	if identity.Email == "" {
+		identity.Email = stringClaim(claims, "email")
+		identity.EmailVerified = boolClaim(claims, "email_verified")
+	}
+	if identity.Username == "" {
+		identity.Username = stringClaim(claims, "preferred_username", "login", "nickname")
+	}
+	if identity.Name == "" {
+		identity.Name = stringClaim(claims, "name")
+	}
+}
+
+// subjectOf 获取用户标识
+func subjectOf(claims map[string]interface{}) string {
+	return stringClaim(claims, "sub", "id")
+}
+
+// stringClaim 按顺序返回第一个非空的声明，数字标识转为字符串
+func stringClaim(claims map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		switch v := claims[key].(type) {
+		case string:
+			if v = strings.TrimSpace(v); v != "" {
+				return v
+			}
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	}
+	return ""
+}
+
+// boolClaim 解析布尔声明，部分提供方以字符串返回
+func boolClaim(claims map[string]interface{}, key string) bool {
+	switch v := claims[key].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
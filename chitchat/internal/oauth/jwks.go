@@ -0,0 +1,177 @@
+package oauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ID Token 接受的签名算法
+var idTokenMethods = []string{"RS256", "RS384", "RS512", "ES256", "ES384"}
+
+// keySet 身份提供方的公钥集合，按 kid 索引
+type keySet struct {
+	keys map[string]crypto.PublicKey
+}
+
+// jsonWebKey JWKS 中的单个公钥
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// verifyIDToken 校验 ID Token 并返回其声明
+func (p *Provider) verifyIDToken(ctx context.Context, ep *endpoints, raw, nonce string) (map[string]interface{}, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(idTokenMethods),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+	}
+	if ep.Issuer != "" {
+		options = append(options, jwt.WithIssuer(ep.Issuer))
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.publicKey(ctx, ep, kid)
+	}, options...)
+	if err != nil {
+		return nil, fmt.Errorf("ID Token 校验失败: %w", err)
+	}
+
+	// nonce 绑定本次授权请求，防止 ID Token 被重放
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return nil, errors.New("ID Token 的 nonce 不匹配")
+	}
+	return claims, nil
+}
+
+// publicKey 按 kid 查找公钥，找不到时重新获取一次以支持密钥轮换
+func (p *Provider) publicKey(ctx context.Context, ep *endpoints, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	cached := p.keys
+	p.mu.Unlock()
+	if cached != nil {
+		if key, ok := cached.lookup(kid); ok {
+			return key, nil
+		}
+	}
+
+	// 未知的 kid 说明密钥可能已轮换，重新获取时同样不持有锁
+	keys, err := p.fetchKeys(ctx, ep.JWKSURL)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+
+	if key, ok := keys.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("未找到公钥 %q", kid)
+}
+
+// fetchKeys 获取 JWKS
+func (p *Provider) fetchKeys(ctx context.Context, jwksURL string) (*keySet, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.doJSON(req, &doc); err != nil {
+		return nil, fmt.Errorf("获取 JWKS 失败: %w", err)
+	}
+
+	keys := &keySet{keys: make(map[string]crypto.PublicKey)}
+	for _, jwk := range doc.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// 跳过无法识别的密钥类型
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue
+		}
+		keys.keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
+
+// lookup 查找公钥，令牌未指定 kid 且只有一个公钥时直接使用
+func (s *keySet) lookup(kid string) (crypto.PublicKey, bool) {
+	if key, ok := s.keys[kid]; ok {
+		return key, true
+	}
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// publicKey 将 JWK 转换为公钥
+func (k *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("不支持的曲线: %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("不支持的密钥类型: %s", k.Kty)
+	}
+}
+
+// decodeBigInt 解码 base64url 编码的大整数
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
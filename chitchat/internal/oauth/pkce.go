@@ -0,0 +1,19 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+
+	"github.com/lllllan02/chitchat/internal/utils"
+)
+
+// GenerateVerifier 生成 PKCE 校验码（RFC 7636）
+func GenerateVerifier() (string, error) {
+	return utils.RandomToken(32)
+}
+
+// CodeChallenge 计算 S256 方式的校验码摘要
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
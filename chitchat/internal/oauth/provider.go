@@ -0,0 +1,301 @@
+// Package oauth 实现 OAuth2 授权码流程（PKCE）和 OIDC 身份校验
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lllllan02/chitchat/internal/utils"
+)
+
+// 请求身份提供方的超时时间
+const requestTimeout = 10 * time.Second
+
+// 响应体读取上限，避免异常响应占用过多内存
+const maxResponseSize = 1 << 20
+
+// Token 授权码换取的令牌
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+}
+
+// Identity 身份提供方返回的用户信息
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Username      string
+	Name          string
+}
+
+// endpoints 身份提供方的各端点
+type endpoints struct {
+	Issuer      string `json:"issuer"`
+	AuthURL     string `json:"authorization_endpoint"`
+	TokenURL    string `json:"token_endpoint"`
+	UserInfoURL string `json:"userinfo_endpoint"`
+	JWKSURL     string `json:"jwks_uri"`
+}
+
+// Provider 身份提供方客户端
+type Provider struct {
+	cfg    utils.OAuthProviderConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	endpoints *endpoints
+	keys      *keySet
+}
+
+// NewProvider 创建身份提供方客户端，client 为 nil 时使用默认客户端
+func NewProvider(cfg utils.OAuthProviderConfig, client *http.Client) *Provider {
+	if client == nil {
+		client = &http.Client{Timeout: requestTimeout}
+	}
+	return &Provider{cfg: cfg, client: client}
+}
+
+// Name 提供方标识
+func (p *Provider) Name() string {
+	return p.cfg.Name
+}
+
+// DisplayName 提供方显示名称
+func (p *Provider) DisplayName() string {
+	if p.cfg.DisplayName != "" {
+		return p.cfg.DisplayName
+	}
+	return p.cfg.Name
+}
+
+// LinkByEmail 是否按邮箱关联已有账号
+func (p *Provider) LinkByEmail() bool {
+	return p.cfg.LinkByEmail
+}
+
+// AuthCodeURL 生成授权地址
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	ep, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	scopes := p.cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "profile", "email"}
+	}
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.cfg.ClientID)
+	query.Set("redirect_uri", p.cfg.RedirectURL)
+	query.Set("scope", strings.Join(scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(ep.AuthURL, "?") {
+		sep = "&"
+	}
+	return ep.AuthURL + sep + query.Encode(), nil
+}
+
+// Exchange 使用授权码和 PKCE 校验码换取令牌
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (*Token, error) {
+	ep, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("client_id", p.cfg.ClientID)
+	form.Set("code_verifier", codeVerifier)
+	if p.cfg.ClientSecret != "" {
+		form.Set("client_secret", p.cfg.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var token Token
+	if err := p.doJSON(req, &token); err != nil {
+		return nil, fmt.Errorf("换取令牌失败: %w", err)
+	}
+	if token.AccessToken == "" && token.IDToken == "" {
+		return nil, errors.New("换取令牌失败: 响应中没有令牌")
+	}
+	return &token, nil
+}
+
+// Identity 获取用户身份
+// 返回 ID Token 时校验签名、发行方、受众和 nonce，配置了 userinfo 端点时补充缺失的字段
+func (p *Provider) Identity(ctx context.Context, token *Token, nonce string) (*Identity, error) {
+	ep, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	identity := &Identity{}
+	if token.IDToken != "" && ep.JWKSURL != "" {
+		claims, err := p.verifyIDToken(ctx, ep, token.IDToken, nonce)
+		if err != nil {
+			return nil, err
+		}
+		fillIdentity(identity, claims)
+	}
+
+	if ep.UserInfoURL != "" && token.AccessToken != "" && (identity.Subject == "" || identity.Email == "") {
+		claims, err := p.userInfo(ctx, ep, token.AccessToken)
+		if err != nil {
+			return nil, err
+		}
+
+		// userinfo 的 sub 必须与 ID Token 一致
+		if identity.Subject != "" && subjectOf(claims) != identity.Subject {
+			return nil, errors.New("userinfo 与 ID Token 的用户不一致")
+		}
+		fillIdentity(identity, claims)
+	}
+
+	if identity.Subject == "" {
+		return nil, errors.New("无法获取用户标识")
+	}
+	return identity, nil
+}
+
+// userInfo 请求 userinfo 端点
+func (p *Provider) userInfo(ctx context.Context, ep *endpoints, accessToken string) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ep.UserInfoURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	var claims map[string]interface{}
+	if err := p.doJSON(req, &claims); err != nil {
+		return nil, fmt.Errorf("获取用户信息失败: %w", err)
+	}
+	return claims, nil
+}
+
+// discover 获取端点，设置了 Issuer 时读取发现文档并缓存
+func (p *Provider) discover(ctx context.Context) (*endpoints, error) {
+	p.mu.Lock()
+	cached := p.endpoints
+	p.mu.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	// 请求发现文档时不持有锁，身份提供方响应缓慢时不阻塞其他请求
+	ep, err := p.fetchEndpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.endpoints == nil {
+		p.endpoints = ep
+	}
+	return p.endpoints, nil
+}
+
+// fetchEndpoints 读取发现文档并合并显式配置的端点
+func (p *Provider) fetchEndpoints(ctx context.Context) (*endpoints, error) {
+	ep := &endpoints{Issuer: p.cfg.Issuer}
+	if p.cfg.Issuer != "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(p.cfg.Issuer, "/")+"/.well-known/openid-configuration", nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.doJSON(req, ep); err != nil {
+			return nil, fmt.Errorf("读取发现文档失败: %w", err)
+		}
+		if ep.Issuer != p.cfg.Issuer {
+			return nil, fmt.Errorf("发现文档的发行方 %q 与配置不一致", ep.Issuer)
+		}
+	}
+
+	// 显式配置的端点优先
+	override(&ep.AuthURL, p.cfg.AuthURL)
+	override(&ep.TokenURL, p.cfg.TokenURL)
+	override(&ep.UserInfoURL, p.cfg.UserInfoURL)
+	override(&ep.JWKSURL, p.cfg.JWKSURL)
+
+	if ep.AuthURL == "" || ep.TokenURL == "" {
+		return nil, fmt.Errorf("身份提供方 %s 缺少授权或令牌端点", p.cfg.Name)
+	}
+	if ep.JWKSURL == "" && ep.UserInfoURL == "" {
+		return nil, fmt.Errorf("身份提供方 %s 缺少 jwks 或 userinfo 端点", p.cfg.Name)
+	}
+	return ep, nil
+}
+
+// doJSON 发送请求并解码 JSON 响应
+func (p *Provider) doJSON(req *http.Request, v interface{}) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("状态码 %d: %s", resp.StatusCode, truncate(string(body), 200))
+	}
+	return json.Unmarshal(body, v)
+}
+
+// override 非空时覆盖
+func override(dst *string, value string) {
+	if value != "" {
+		*dst = value
+	}
+}
+
+// truncate 截断过长的字符串
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// NewProviders 根据配置创建全部身份提供方，按标识索引
+func NewProviders(cfg utils.OAuthConfig, client *http.Client) (map[string]*Provider, error) {
+	providers := make(map[string]*Provider, len(cfg.Providers))
+	for _, pc := range cfg.Providers {
+		if pc.Name == "" || pc.ClientID == "" || pc.RedirectURL == "" {
+			return nil, errors.New("身份提供方缺少 name、client_id 或 redirect_url")
+		}
+		if _, exists := providers[pc.Name]; exists {
+			return nil, fmt.Errorf("身份提供方重复: %s", pc.Name)
+		}
+		providers[pc.Name] = NewProvider(pc, client)
+	}
+	return providers, nil
+}
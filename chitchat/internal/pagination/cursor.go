@@ -0,0 +1,136 @@
+package pagination
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/lllllan02/chitchat/internal/utils"
+)
+
+// Cursor 游标，记录翻页边界上那条记录的排序键
+// 列表只用到其中一部分排序键，未用到的字段保持零值
+type Cursor struct {
+	Value    int64     `json:"v,omitempty"` // 数值排序键，如点赞数
+	Time     time.Time `json:"t"`           // 时间排序键，如创建时间
+	ID       uint      `json:"i"`           // 唯一排序键，保证顺序稳定
+	Backward bool      `json:"b,omitempty"` // 为 true 时返回边界之前的记录，即上一页
+}
+
+// Request 游标分页请求
+type Request struct {
+	Cursor    *Cursor // 为空时返回第一页
+	Limit     int
+	WithTotal bool // 是否统计总数，深分页时统计总数开销较大，只在需要时统计
+}
+
+// Page 游标分页结果
+type Page struct {
+	Next  *Cursor // 没有下一页时为空
+	Prev  *Cursor // 没有上一页时为空
+	Total *int64  // 只在请求统计时返回
+
+	// 候选记录超出上限被截断，只在保留的部分中分页
+	Truncated bool
+}
+
+// Empty 没有任何记录时的分页结果
+func Empty(req *Request) *Page {
+	page := &Page{}
+	if req.WithTotal {
+		var total int64
+		page.Total = &total
+	}
+	return page
+}
+
+// Trim 处理多查询一条的结果：去掉多余的记录，向前翻页时恢复原有顺序，并生成前后页游标
+// rows 必须按请求方向排序且最多比 Limit 多一条
+func Trim[T any](req *Request, rows []T, cursorOf func(T) Cursor) ([]T, *Page) {
+	more := len(rows) > req.Limit
+	if more {
+		rows = rows[:req.Limit]
+	}
+
+	backward := req.Cursor != nil && req.Cursor.Backward
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	page := &Page{}
+	if len(rows) == 0 {
+		return rows, page
+	}
+
+	// 从某一页翻过来时，来的方向一定还有记录
+	if more || backward {
+		next := cursorOf(rows[len(rows)-1])
+		page.Next = &next
+	}
+	if (backward && more) || (!backward && req.Cursor != nil) {
+		prev := cursorOf(rows[0])
+		prev.Backward = true
+		page.Prev = &prev
+	}
+	return rows, page
+}
+
+// Codec 游标编解码器
+// 游标经过签名，客户端只能原样传回，不能伪造或修改；签名包含列表范围，一个列表的游标不能用于另一个列表
+type Codec struct {
+	key []byte
+}
+
+// NewCodec 创建游标编解码器
+func NewCodec(secret string) *Codec {
+	return &Codec{key: []byte(secret + "|cursor")}
+}
+
+// Encode 将游标编码为不透明的字符串，游标为空时返回空字符串
+func (c *Codec) Encode(scope string, cursor *Cursor) string {
+	if cursor == nil {
+		return ""
+	}
+	payload, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(c.sign(scope, payload))
+}
+
+// Decode 解析并校验游标，空字符串表示第一页，返回 nil
+func (c *Codec) Decode(scope, token string) (*Cursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, utils.ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, utils.ErrInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, c.sign(scope, payload)) {
+		return nil, utils.ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(payload, &cursor); err != nil {
+		return nil, utils.ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// sign 计算游标内容和列表范围的签名
+func (c *Codec) sign(scope string, payload []byte) []byte {
+	h := hmac.New(sha256.New, c.key)
+	h.Write([]byte(scope))
+	h.Write([]byte{0})
+	h.Write(payload)
+	return h.Sum(nil)
+}
@@ -0,0 +1,79 @@
+// Package ratelimit 实现令牌桶限流，计数可以保存在内存或 Redis 中
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit 令牌桶参数
+type Limit struct {
+	Rate  float64 // 每秒补充的令牌数
+	Burst int     // 桶容量
+}
+
+// Result 一次请求的限流结果
+type Result struct {
+	Allowed    bool
+	Limit      int           // 桶容量
+	Remaining  int           // 剩余令牌数
+	RetryAfter time.Duration // 被拒绝时，距离下一个令牌的时间
+	ResetAfter time.Duration // 距离令牌桶补满的时间
+}
+
+// Store 令牌桶存储接口
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (*Result, error)
+}
+
+// ParseRate 解析形如 "10/s"、"30/m"、"100/1h" 的速率
+// 返回每秒补充的令牌数和每个周期的请求数
+func ParseRate(rate string) (float64, int, error) {
+	parts := strings.SplitN(strings.TrimSpace(rate), "/", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("无效的速率: %q", rate)
+	}
+
+	count, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || count <= 0 {
+		return 0, 0, fmt.Errorf("无效的速率: %q", rate)
+	}
+
+	// 只有单位时视为 1 个单位
+	period := strings.TrimSpace(parts[1])
+	if period != "" && (period[0] < '0' || period[0] > '9') {
+		period = "1" + period
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return 0, 0, fmt.Errorf("无效的速率: %q", rate)
+	}
+
+	return float64(count) / d.Seconds(), count, nil
+}
+
+// bucketResult 根据取令牌后剩余的令牌数计算结果
+func bucketResult(allowed bool, tokens float64, limit Limit) *Result {
+	result := &Result{
+		Allowed:    allowed,
+		Limit:      limit.Burst,
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: seconds((float64(limit.Burst) - tokens) / limit.Rate),
+	}
+	if !allowed {
+		result.RetryAfter = seconds((1 - tokens) / limit.Rate)
+	}
+	return result
+}
+
+// seconds 将秒数转换为时长
+func seconds(s float64) time.Duration {
+	if s <= 0 {
+		return 0
+	}
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,120 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lllllan02/chitchat/internal/utils"
+)
+
+// 未匹配任何策略的请求使用的策略名
+const defaultPolicy = "default"
+
+// policy 一条限流策略
+type policy struct {
+	name  string
+	limit *Limit
+	roles map[string]*Limit
+}
+
+// Policies 按路由和角色查找限流规则
+type Policies struct {
+	fallback *policy
+	routes   map[string]*policy // 键为 "METHOD 路由模板"，METHOD 为 * 时匹配所有方法
+}
+
+// NewPolicies 根据配置创建限流策略
+func NewPolicies(cfg utils.RateLimitConfig) (*Policies, error) {
+	fallback, err := parseLimit(cfg.Default)
+	if err != nil {
+		return nil, fmt.Errorf("默认限流规则: %w", err)
+	}
+
+	p := &Policies{
+		fallback: &policy{name: defaultPolicy, limit: fallback},
+		routes:   make(map[string]*policy),
+	}
+
+	for _, pc := range cfg.Policies {
+		if pc.Name == "" || pc.Name == defaultPolicy {
+			return nil, fmt.Errorf("限流策略名称无效: %q", pc.Name)
+		}
+
+		limit, err := parseLimit(pc.RateLimitRule)
+		if err != nil {
+			return nil, fmt.Errorf("限流策略 %s: %w", pc.Name, err)
+		}
+		pol := &policy{name: pc.Name, limit: limit, roles: make(map[string]*Limit)}
+		for role, rule := range pc.Roles {
+			if pol.roles[role], err = parseLimit(rule); err != nil {
+				return nil, fmt.Errorf("限流策略 %s 的角色 %s: %w", pc.Name, role, err)
+			}
+		}
+
+		for _, route := range pc.Routes {
+			key, err := routeKey(route)
+			if err != nil {
+				return nil, fmt.Errorf("限流策略 %s: %w", pc.Name, err)
+			}
+			if existing, ok := p.routes[key]; ok {
+				return nil, fmt.Errorf("路由 %s 同时属于限流策略 %s 和 %s", route, existing.name, pc.Name)
+			}
+			p.routes[key] = pol
+		}
+	}
+
+	return p, nil
+}
+
+// Match 查找请求对应的策略名和规则，不限流时规则为 nil
+// route 为路由模板，未匹配路由时为空字符串
+func (p *Policies) Match(method, route, role string) (string, *Limit) {
+	pol, ok := p.routes[method+" "+route]
+	if !ok {
+		pol, ok = p.routes["* "+route]
+	}
+	if !ok {
+		pol = p.fallback
+	}
+
+	if limit, ok := pol.roles[role]; ok {
+		return pol.name, limit
+	}
+	return pol.name, pol.limit
+}
+
+// parseLimit 解析限流规则，速率为空时返回 nil 表示不限流
+func parseLimit(rule utils.RateLimitRule) (*Limit, error) {
+	if strings.TrimSpace(rule.Rate) == "" {
+		return nil, nil
+	}
+
+	rate, count, err := ParseRate(rule.Rate)
+	if err != nil {
+		return nil, err
+	}
+
+	burst := rule.Burst
+	if burst <= 0 {
+		burst = count
+	}
+	return &Limit{Rate: rate, Burst: burst}, nil
+}
+
+// routeKey 解析 "METHOD /path" 形式的路由，只写路径时匹配所有方法
+func routeKey(route string) (string, error) {
+	fields := strings.Fields(route)
+	switch len(fields) {
+	case 1:
+		fields = []string{"*", fields[0]}
+	case 2:
+		fields[0] = strings.ToUpper(fields[0])
+	default:
+		return "", fmt.Errorf("无效的路由: %q", route)
+	}
+
+	if !strings.HasPrefix(fields[1], "/") {
+		return "", fmt.Errorf("无效的路由: %q", route)
+	}
+	return fields[0] + " " + fields[1], nil
+}
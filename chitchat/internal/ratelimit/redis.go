@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeScript 在 Redis 中原子地执行令牌桶计算
+// 令牌桶保存为哈希，补满后自动过期
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local data = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(data[1])
+local ts = tonumber(data[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+
+local elapsed = math.max(0, now - ts)
+tokens = math.min(burst, tokens + elapsed * rate / 1000)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call("HMSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil((burst - tokens) * 1000 / rate) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// RedisStore 基于 Redis 的令牌桶存储，多个实例共享计数
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore 创建 Redis 存储，键名带有 prefix 前缀
+func NewRedisStore(client *redis.Client, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Take 从令牌桶中取一个令牌
+func (s *RedisStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (*Result, error) {
+	values, err := takeScript.Run(ctx, s.client, []string{s.prefix + key},
+		limit.Rate, limit.Burst, now.UnixMilli()).Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	allowed, _ := values[0].(int64)
+	tokens, err := strconv.ParseFloat(values[1].(string), 64)
+	if err != nil {
+		return nil, err
+	}
+	return bucketResult(allowed == 1, tokens, limit), nil
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/lllllan02/chitchat/internal/model"
+	"gorm.io/gorm"
+)
+
+// BackupCodeRepository 两步验证备用码仓库接口
+type BackupCodeRepository interface {
+	Replace(ctx context.Context, userID uint, codeHashes []string) error
+	Use(ctx context.Context, userID uint, codeHash string) (bool, error)
+	CountUnused(ctx context.Context, userID uint) (int64, error)
+	DeleteByUserID(ctx context.Context, userID uint) error
+}
+
+// backupCodeRepository 两步验证备用码仓库实现
+type backupCodeRepository struct {
+	db *gorm.DB
+}
+
+// NewBackupCodeRepository 创建两步验证备用码仓库
+func NewBackupCodeRepository(db *gorm.DB) BackupCodeRepository {
+	return &backupCodeRepository{db: db}
+}
+
+// Replace 用新的备用码替换用户的全部备用码
+func (r *backupCodeRepository) Replace(ctx context.Context, userID uint, codeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.BackupCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]*model.BackupCode, 0, len(codeHashes))
+		for _, hash := range codeHashes {
+			codes = append(codes, &model.BackupCode{UserID: userID, CodeHash: hash})
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// Use 使用备用码，备用码不存在或已使用时返回 false
+func (r *backupCodeRepository) Use(ctx context.Context, userID uint, codeHash string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.BackupCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		UpdateColumn("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// CountUnused 统计用户未使用的备用码数量
+func (r *backupCodeRepository) CountUnused(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.BackupCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// DeleteByUserID 删除用户的全部备用码
+func (r *backupCodeRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&model.BackupCode{}).Error
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/lllllan02/chitchat/internal/cache"
+	"github.com/lllllan02/chitchat/internal/model"
+)
+
+// 分类列表的缓存键
+const categoryListKey = "categories"
+
+// cachedCategoryRepository 带缓存的分类仓库
+// 缓存分类列表，分类或帖子数量变化时主动失效
+type cachedCategoryRepository struct {
+	CategoryRepository
+	cache *cache.Cache
+}
+
+// NewCachedCategoryRepository 为分类仓库添加缓存
+func NewCachedCategoryRepository(repo CategoryRepository, c *cache.Cache) CategoryRepository {
+	return &cachedCategoryRepository{CategoryRepository: repo, cache: c}
+}
+
+// List 获取所有分类
+func (r *cachedCategoryRepository) List(ctx context.Context) ([]*model.Category, error) {
+	return cache.Load(ctx, r.cache, "categories", categoryListKey, func() ([]*model.Category, error) {
+		return r.CategoryRepository.List(ctx)
+	})
+}
+
+// Create 创建分类
+func (r *cachedCategoryRepository) Create(ctx context.Context, category *model.Category) error {
+	defer r.invalidate(ctx, false)
+	return r.CategoryRepository.Create(ctx, category)
+}
+
+// Update 更新分类，帖子中附带的分类信息一并失效
+func (r *cachedCategoryRepository) Update(ctx context.Context, category *model.Category) error {
+	defer r.invalidate(ctx, true)
+	return r.CategoryRepository.Update(ctx, category)
+}
+
+// Delete 删除分类
+func (r *cachedCategoryRepository) Delete(ctx context.Context, id uint) error {
+	defer r.invalidate(ctx, true)
+	return r.CategoryRepository.Delete(ctx, id)
+}
+
+// UpdatePostCount 更新分类帖子数量
+func (r *cachedCategoryRepository) UpdatePostCount(ctx context.Context, id uint, count int) error {
+	defer r.invalidate(ctx, false)
+	return r.CategoryRepository.UpdatePostCount(ctx, id, count)
+}
+
+// IncrementPostCount 增加分类帖子数量
+func (r *cachedCategoryRepository) IncrementPostCount(ctx context.Context, id uint) error {
+	defer r.invalidate(ctx, false)
+	return r.CategoryRepository.IncrementPostCount(ctx, id)
+}
+
+// DecrementPostCount 减少分类帖子数量
+func (r *cachedCategoryRepository) DecrementPostCount(ctx context.Context, id uint) error {
+	defer r.invalidate(ctx, false)
+	return r.CategoryRepository.DecrementPostCount(ctx, id)
+}
+
+// invalidate 删除分类列表缓存，posts 为 true 时同时删除全部帖子缓存
+// 数据库已经修改，即使请求被取消也要删除缓存
+func (r *cachedCategoryRepository) invalidate(ctx context.Context, posts bool) {
+	ctx = context.WithoutCancel(ctx)
+	r.cache.Delete(ctx, categoryListKey)
+	if posts {
+		r.cache.DeletePrefix(ctx, "post")
+	}
+}
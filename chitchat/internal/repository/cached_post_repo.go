@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lllllan02/chitchat/internal/cache"
+	"github.com/lllllan02/chitchat/internal/model"
+)
+
+// 帖子缓存键
+// 置顶和精华列表统一使用 postListPrefix，任意帖子变化时整体失效
+const postListPrefix = "posts:"
+
+// postKey 单个帖子的缓存键，includeUser 不同时分别缓存
+func postKey(id uint, includeUser bool) string {
+	if includeUser {
+		return fmt.Sprintf("post:%d:full", id)
+	}
+	return fmt.Sprintf("post:%d", id)
+}
+
+// invalidatePost 删除帖子及帖子列表的缓存
+// 数据库已经修改，即使请求被取消也要删除缓存
+func invalidatePost(ctx context.Context, c *cache.Cache, id uint) {
+	ctx = context.WithoutCancel(ctx)
+	c.Delete(ctx, postKey(id, false), postKey(id, true))
+	c.DeletePrefix(ctx, postListPrefix)
+}
+
+// cachedPostRepository 带缓存的帖子仓库
+// 缓存帖子详情、置顶和精华列表，帖子修改、删除、置顶、加精和点赞时主动失效
+// 作者资料随时可能修改，缓存中不保存作者，读取后按 UserID 重新查询
+// 浏览次数变化不会使缓存失效，缓存中的浏览次数最多滞后一个缓存有效期
+type cachedPostRepository struct {
+	PostRepository
+	users UserRepository
+	cache *cache.Cache
+}
+
+// NewCachedPostRepository 为帖子仓库添加缓存
+func NewCachedPostRepository(repo PostRepository, users UserRepository, c *cache.Cache) PostRepository {
+	return &cachedPostRepository{PostRepository: repo, users: users, cache: c}
+}
+
+// GetByID 根据ID获取帖子
+func (r *cachedPostRepository) GetByID(ctx context.Context, id uint, includeUser bool) (*model.Post, error) {
+	post, err := cache.Load(ctx, r.cache, "post", postKey(id, includeUser), func() (*model.Post, error) {
+		post, err := r.PostRepository.GetByID(ctx, id, includeUser)
+		if err != nil {
+			return nil, err
+		}
+		return withoutAuthors(post)[0], nil
+	})
+	if err != nil || !includeUser {
+		return post, err
+	}
+	if err := r.loadAuthors(ctx, post); err != nil {
+		return nil, err
+	}
+	return post, nil
+}
+
+// GetPinnedPosts 获取置顶帖子
+func (r *cachedPostRepository) GetPinnedPosts(ctx context.Context, categoryID uint, limit int) ([]*model.Post, error) {
+	key := fmt.Sprintf("%spinned:%d:%d", postListPrefix, categoryID, limit)
+	posts, err := cache.Load(ctx, r.cache, "pinned_posts", key, func() ([]*model.Post, error) {
+		posts, err := r.PostRepository.GetPinnedPosts(ctx, categoryID, limit)
+		return withoutAuthors(posts...), err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return posts, r.loadAuthors(ctx, posts...)
+}
+
+// GetFeaturedPosts 获取精华帖子
+func (r *cachedPostRepository) GetFeaturedPosts(ctx context.Context, limit int) ([]*model.Post, error) {
+	key := fmt.Sprintf("%sfeatured:%d", postListPrefix, limit)
+	posts, err := cache.Load(ctx, r.cache, "featured_posts", key, func() ([]*model.Post, error) {
+		posts, err := r.PostRepository.GetFeaturedPosts(ctx, limit)
+		return withoutAuthors(posts...), err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return posts, r.loadAuthors(ctx, posts...)
+}
+
+// withoutAuthors 清除帖子的作者后再写入缓存
+func withoutAuthors(posts ...*model.Post) []*model.Post {
+	for _, post := range posts {
+		post.User = model.User{}
+	}
+	return posts
+}
+
+// loadAuthors 批量查询帖子的作者，已删除的作者保持为空，与直接查询数据库一致
+func (r *cachedPostRepository) loadAuthors(ctx context.Context, posts ...*model.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.UserID)
+	}
+	users, err := r.users.GetByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[uint]*model.User, len(users))
+	for _, user := range users {
+		byID[user.ID] = user
+	}
+	for _, post := range posts {
+		if user, ok := byID[post.UserID]; ok {
+			post.User = *user
+		}
+	}
+	return nil
+}
+
+// Update 更新帖子
+func (r *cachedPostRepository) Update(ctx context.Context, post *model.Post) error {
+	defer invalidatePost(ctx, r.cache, post.ID)
+	return r.PostRepository.Update(ctx, post)
+}
+
+// Delete 删除帖子
+func (r *cachedPostRepository) Delete(ctx context.Context, id uint) error {
+	defer invalidatePost(ctx, r.cache, id)
+	return r.PostRepository.Delete(ctx, id)
+}
+
+// UpdateLikeCount 更新点赞数
+func (r *cachedPostRepository) UpdateLikeCount(ctx context.Context, id uint, count int) error {
+	defer invalidatePost(ctx, r.cache, id)
+	return r.PostRepository.UpdateLikeCount(ctx, id, count)
+}
+
+// SetPinned 设置置顶状态
+func (r *cachedPostRepository) SetPinned(ctx context.Context, id uint, isPinned bool) error {
+	defer invalidatePost(ctx, r.cache, id)
+	return r.PostRepository.SetPinned(ctx, id, isPinned)
+}
+
+// SetFeatured 设置精华状态
+func (r *cachedPostRepository) SetFeatured(ctx context.Context, id uint, isFeatured bool) error {
+	defer invalidatePost(ctx, r.cache, id)
+	return r.PostRepository.SetFeatured(ctx, id, isFeatured)
+}
+
+// cachedLikeRepository 点赞帖子时使帖子缓存失效的点赞仓库
+type cachedLikeRepository struct {
+	LikeRepository
+	cache *cache.Cache
+}
+
+// NewCachedLikeRepository 为点赞仓库添加帖子缓存失效
+func NewCachedLikeRepository(repo LikeRepository, c *cache.Cache) LikeRepository {
+	return &cachedLikeRepository{LikeRepository: repo, cache: c}
+}
+
+// LikePost 点赞帖子
+func (r *cachedLikeRepository) LikePost(ctx context.Context, userID, postID uint) (bool, error) {
+	created, err := r.LikeRepository.LikePost(ctx, userID, postID)
+	if created {
+		invalidatePost(ctx, r.cache, postID)
+	}
+	return created, err
+}
+
+// UnlikePost 取消点赞帖子
+func (r *cachedLikeRepository) UnlikePost(ctx context.Context, userID, postID uint) (bool, error) {
+	deleted, err := r.LikeRepository.UnlikePost(ctx, userID, postID)
+	if deleted {
+		invalidatePost(ctx, r.cache, postID)
+	}
+	return deleted, err
+}
@@ -1,22 +1,22 @@
 package repository
 
 import (
+	"context"
 	"github.com/lllllan02/chitchat/internal/model"
-	"github.com/lllllan02/chitchat/internal/utils"
 	"gorm.io/gorm"
 )
 
 // CategoryRepository 分类仓库接口
 type CategoryRepository interface {
-	Create(category *model.Category) error
-	GetByID(id uint) (*model.Category, error)
-	GetByName(name string) (*model.Category, error)
-	Update(category *model.Category) error
-	Delete(id uint) error
-	List() ([]*model.Category, error)
-	UpdatePostCount(id uint, count int) error
-	IncrementPostCount(id uint) error
-	DecrementPostCount(id uint) error
+	Create(ctx context.Context, category *model.Category) error
+	GetByID(ctx context.Context, id uint) (*model.Category, error)
+	GetByName(ctx context.Context, name string) (*model.Category, error)
+	Update(ctx context.Context, category *model.Category) error
+	Delete(ctx context.Context, id uint) error
+	List(ctx context.Context) ([]*model.Category, error)
+	UpdatePostCount(ctx context.Context, id uint, count int) error
+	IncrementPostCount(ctx context.Context, id uint) error
+	DecrementPostCount(ctx context.Context, id uint) error
 }
 
 // categoryRepository 分类仓库实现
@@ -25,19 +25,19 @@ type categoryRepository struct {
 }
 
 // NewCategoryRepository 创建分类仓库
-func NewCategoryRepository() CategoryRepository {
-	return &categoryRepository{db: utils.DB}
+func NewCategoryRepository(db *gorm.DB) CategoryRepository {
+	return &categoryRepository{db: db}
 }
 
 // Create 创建分类
-func (r *categoryRepository) Create(category *model.Category) error {
-	return r.db.Create(category).Error
+func (r *categoryRepository) Create(ctx context.Context, category *model.Category) error {
+	return r.db.WithContext(ctx).Create(category).Error
 }
 
 // GetByID 根据ID获取分类
-func (r *categoryRepository) GetByID(id uint) (*model.Category, error) {
+func (r *categoryRepository) GetByID(ctx context.Context, id uint) (*model.Category, error) {
 	var category model.Category
-	err := r.db.First(&category, id).Error
+	err := r.db.WithContext(ctx).First(&category, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -45,9 +45,9 @@ func (r *categoryRepository) GetByID(id uint) (*model.Category, error) {
 }
 
 // GetByName 根据名称获取分类
-func (r *categoryRepository) GetByName(name string) (*model.Category, error) {
+func (r *categoryRepository) GetByName(ctx context.Context, name string) (*model.Category, error) {
 	var category model.Category
-	err := r.db.Where("name = ?", name).First(&category).Error
+	err := r.db.WithContext(ctx).Where("name = ?", name).First(&category).Error
 	if err != nil {
 		return nil, err
 	}
@@ -55,33 +55,33 @@ func (r *categoryRepository) GetByName(name string) (*model.Category, error) {
 }
 
 // Update 更新分类
-func (r *categoryRepository) Update(category *model.Category) error {
-	return r.db.Save(category).Error
+func (r *categoryRepository) Update(ctx context.Context, category *model.Category) error {
+	return r.db.WithContext(ctx).Save(category).Error
 }
 
 // Delete 删除分类
-func (r *categoryRepository) Delete(id uint) error {
-	return r.db.Delete(&model.Category{}, id).Error
+func (r *categoryRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&model.Category{}, id).Error
 }
 
 // List 获取所有分类
-func (r *categoryRepository) List() ([]*model.Category, error) {
+func (r *categoryRepository) List(ctx context.Context) ([]*model.Category, error) {
 	var categories []*model.Category
-	err := r.db.Order("id ASC").Find(&categories).Error
+	err := r.db.WithContext(ctx).Order("id ASC").Find(&categories).Error
 	return categories, err
 }
 
 // UpdatePostCount 更新分类帖子数量
-func (r *categoryRepository) UpdatePostCount(id uint, count int) error {
-	return r.db.Model(&model.Category{}).Where("id = ?", id).Update("post_count", count).Error
+func (r *categoryRepository) UpdatePostCount(ctx context.Context, id uint, count int) error {
+	return r.db.WithContext(ctx).Model(&model.Category{}).Where("id = ?", id).Update("post_count", count).Error
 }
 
 // IncrementPostCount 增加分类帖子数量
-func (r *categoryRepository) IncrementPostCount(id uint) error {
-	return r.db.Model(&model.Category{}).Where("id = ?", id).UpdateColumn("post_count", gorm.Expr("post_count + ?", 1)).Error
+func (r *categoryRepository) IncrementPostCount(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&model.Category{}).Where("id = ?", id).UpdateColumn("post_count", gorm.Expr("post_count + ?", 1)).Error
 }
 
 // DecrementPostCount 减少分类帖子数量
-func (r *categoryRepository) DecrementPostCount(id uint) error {
-	return r.db.Model(&model.Category{}).Where("id = ?", id).UpdateColumn("post_count", gorm.Expr("CASE WHEN post_count > 0 THEN post_count - 1 ELSE 0 END")).Error
+func (r *categoryRepository) DecrementPostCount(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&model.Category{}).Where("id = ?", id).UpdateColumn("post_count", gorm.Expr("CASE WHEN post_count > 0 THEN post_count - 1 ELSE 0 END")).Error
 }
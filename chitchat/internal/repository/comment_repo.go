@@ -1,20 +1,22 @@
 package repository
 
 import (
+	"context"
 	"github.com/lllllan02/chitchat/internal/model"
-	"github.com/lllllan02/chitchat/internal/utils"
+	"github.com/lllllan02/chitchat/internal/pagination"
 	"gorm.io/gorm"
 )
 
 // CommentRepository 评论仓库接口
 type CommentRepository interface {
-	Create(comment *model.Comment) error
-	GetByID(id uint) (*model.Comment, error)
-	Update(comment *model.Comment) error
-	Delete(id uint) error
-	GetByPostID(postID uint, page, pageSize int) ([]*model.Comment, int64, error)
-	GetReplies(parentID uint) ([]*model.Comment, error)
-	UpdateLikeCount(id uint, count int) error
+	Create(ctx context.Context, comment *model.Comment) error
+	GetByID(ctx context.Context, id uint) (*model.Comment, error)
+	Update(ctx context.Context, comment *model.Comment) error
+	Delete(ctx context.Context, id uint) error
+	GetByPostID(ctx context.Context, postID uint, req *pagination.Request) ([]*model.Comment, *pagination.Page, error)
+	GetReplies(ctx context.Context, parentID uint) ([]*model.Comment, error)
+	UpdateLikeCount(ctx context.Context, id uint, count int) error
+	ListAfter(ctx context.Context, afterID uint, limit int) ([]*model.Comment, error)
 }
 
 // commentRepository 评论仓库实现
@@ -23,19 +25,19 @@ type commentRepository struct {
 }
 
 // NewCommentRepository 创建评论仓库
-func NewCommentRepository() CommentRepository {
-	return &commentRepository{db: utils.DB}
+func NewCommentRepository(db *gorm.DB) CommentRepository {
+	return &commentRepository{db: db}
 }
 
 // Create 创建评论
-func (r *commentRepository) Create(comment *model.Comment) error {
-	return r.db.Create(comment).Error
+func (r *commentRepository) Create(ctx context.Context, comment *model.Comment) error {
+	return r.db.WithContext(ctx).Create(comment).Error
 }
 
 // GetByID 根据ID获取评论
-func (r *commentRepository) GetByID(id uint) (*model.Comment, error) {
+func (r *commentRepository) GetByID(ctx context.Context, id uint) (*model.Comment, error) {
 	var comment model.Comment
-	err := r.db.Preload("User").First(&comment, id).Error
+	err := r.db.WithContext(ctx).Preload("User").First(&comment, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -43,52 +45,109 @@ func (r *commentRepository) GetByID(id uint) (*model.Comment, error) {
 }
 
 // Update 更新评论
-func (r *commentRepository) Update(comment *model.Comment) error {
-	return r.db.Save(comment).Error
+func (r *commentRepository) Update(ctx context.Context, comment *model.Comment) error {
+	return r.db.WithContext(ctx).Save(comment).Error
 }
 
-// Delete 删除评论
-func (r *commentRepository) Delete(id uint) error {
-	return r.db.Delete(&model.Comment{}, id).Error
+// Delete 删除评论及其所有回复
+func (r *commentRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ids := []uint{id}
+		parentIDs := []uint{id}
+
+		// 收集所有后代评论ID
+		for len(parentIDs) > 0 {
+			var childIDs []uint
+			if err := tx.Model(&model.Comment{}).Where("parent_id IN ?", parentIDs).Pluck("id", &childIDs).Error; err != nil {
+				return err
+			}
+			ids = append(ids, childIDs...)
+			parentIDs = childIDs
+		}
+
+		return tx.Delete(&model.Comment{}, ids).Error
+	})
 }
 
-// GetByPostID 获取帖子的评论列表
-func (r *commentRepository) GetByPostID(postID uint, page, pageSize int) ([]*model.Comment, int64, error) {
-	var comments []*model.Comment
-	var total int64
+// 评论列表的排序键
+var commentKeys = keyset{time: "created_at", id: "id"}
+
+// commentCursor 评论在列表中的位置
+func commentCursor(comment *model.Comment) pagination.Cursor {
+	return pagination.Cursor{Time: comment.CreatedAt, ID: comment.ID}
+}
 
+// GetByPostID 获取帖子的评论列表
+func (r *commentRepository) GetByPostID(ctx context.Context, postID uint, req *pagination.Request) ([]*model.Comment, *pagination.Page, error) {
 	// 只获取顶级评论（没有父评论的）
-	query := r.db.Where("post_id = ? AND parent_id IS NULL", postID).Preload("User")
+	query := r.db.WithContext(ctx).Model(&model.Comment{}).Preload("User").Where("post_id = ? AND parent_id IS NULL", postID)
+
+	comments, page, err := paginate(query, commentKeys, req, commentCursor)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 为每个顶级评论加载嵌套回复
+	if err := r.loadReplies(ctx, comments); err != nil {
+		return nil, nil, err
+	}
+
+	return comments, page, nil
+}
 
-	// 获取总数
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
+// loadReplies 逐层加载评论的所有回复并组装成树
+func (r *commentRepository) loadReplies(ctx context.Context, roots []*model.Comment) error {
+	children := make(map[uint][]*model.Comment)
+
+	parentIDs := make([]uint, 0, len(roots))
+	for _, c := range roots {
+		parentIDs = append(parentIDs, c.ID)
 	}
 
-	// 分页查询
-	offset := (page - 1) * pageSize
-	if err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&comments).Error; err != nil {
-		return nil, 0, err
+	// 按层查询，避免对每条评论单独查询
+	for len(parentIDs) > 0 {
+		var replies []*model.Comment
+		if err := r.db.WithContext(ctx).Where("parent_id IN ?", parentIDs).Preload("User").Order("created_at ASC").Find(&replies).Error; err != nil {
+			return err
+		}
+
+		parentIDs = parentIDs[:0]
+		for _, reply := range replies {
+			children[*reply.ParentID] = append(children[*reply.ParentID], reply)
+			parentIDs = append(parentIDs, reply.ID)
+		}
 	}
 
-	// 为每个顶级评论加载回复
-	for i := range comments {
-		if err := r.db.Where("parent_id = ?", comments[i].ID).Preload("User").Order("created_at ASC").Find(&comments[i].Replies).Error; err != nil {
-			return nil, 0, err
+	// 自底向上组装回复
+	var attach func(c *model.Comment)
+	attach = func(c *model.Comment) {
+		for _, child := range children[c.ID] {
+			attach(child)
+			c.Replies = append(c.Replies, *child)
 		}
 	}
+	for _, c := range roots {
+		attach(c)
+	}
 
-	return comments, total, nil
+	return nil
 }
 
 // GetReplies 获取评论的回复列表
-func (r *commentRepository) GetReplies(parentID uint) ([]*model.Comment, error) {
+func (r *commentRepository) GetReplies(ctx context.Context, parentID uint) ([]*model.Comment, error) {
 	var replies []*model.Comment
-	err := r.db.Where("parent_id = ?", parentID).Preload("User").Order("created_at ASC").Find(&replies).Error
+	err := r.db.WithContext(ctx).Where("parent_id = ?", parentID).Preload("User").Order("created_at ASC").Find(&replies).Error
 	return replies, err
 }
 
 // UpdateLikeCount 更新点赞数
-func (r *commentRepository) UpdateLikeCount(id uint, count int) error {
-	return r.db.Model(&model.Comment{}).Where("id = ?", id).Update("like_count", count).Error
+func (r *commentRepository) UpdateLikeCount(ctx context.Context, id uint, count int) error {
+	return r.db.WithContext(ctx).Model(&model.Comment{}).Where("id = ?", id).Update("like_count", count).Error
+}
+
+// ListAfter 按ID顺序获取 afterID 之后的评论，用于分批遍历全部评论
+func (r *commentRepository) ListAfter(ctx context.Context, afterID uint, limit int) ([]*model.Comment, error) {
+	var comments []*model.Comment
+	err := r.db.WithContext(ctx).Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&comments).Error
+	return comments, err
 }
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/pagination"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FollowRepository 关注仓库接口
+type FollowRepository interface {
+	Create(ctx context.Context, followerID, followedID uint) (bool, error)
+	Delete(ctx context.Context, followerID, followedID uint) (bool, error)
+	Exists(ctx context.Context, followerID, followedID uint) (bool, error)
+	GetFollowers(ctx context.Context, userID uint, req *pagination.Request) ([]*model.User, *pagination.Page, error)
+	GetFollowing(ctx context.Context, userID uint, req *pagination.Request) ([]*model.User, *pagination.Page, error)
+	GetMutualFollowers(ctx context.Context, viewerID, userID uint, req *pagination.Request) ([]*model.User, *pagination.Page, error)
+	CountFollowers(ctx context.Context, userIDs []uint) (map[uint]int64, error)
+	CountFollowing(ctx context.Context, userIDs []uint) (map[uint]int64, error)
+}
+
+// followRepository 关注仓库实现
+type followRepository struct {
+	db *gorm.DB
+}
+
+// NewFollowRepository 创建关注仓库
+func NewFollowRepository(db *gorm.DB) FollowRepository {
+	return &followRepository{db: db}
+}
+
+// Create 创建关注关系，已存在时不重复创建，返回是否新增
+func (r *followRepository) Create(ctx context.Context, followerID, followedID uint) (bool, error) {
+	follow := &model.Follow{FollowerID: followerID, FollowedID: followedID}
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(follow)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Delete 删除关注关系，返回是否删除
+func (r *followRepository) Delete(ctx context.Context, followerID, followedID uint) (bool, error) {
+	// 物理删除，保证唯一索引允许再次关注
+	result := r.db.WithContext(ctx).Unscoped().Where("follower_id = ? AND followed_id = ?", followerID, followedID).Delete(&model.Follow{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Exists 检查关注关系是否存在
+func (r *followRepository) Exists(ctx context.Context, followerID, followedID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.Follow{}).Where("follower_id = ? AND followed_id = ?", followerID, followedID).Count(&count).Error
+	return count > 0, err
+}
+
+// GetFollowers 获取用户的粉丝列表
+func (r *followRepository) GetFollowers(ctx context.Context, userID uint, req *pagination.Request) ([]*model.User, *pagination.Page, error) {
+	query := r.db.WithContext(ctx).Model(&model.User{}).
+		Joins("JOIN follows ON follows.follower_id = users.id AND follows.deleted_at IS NULL").
+		Where("follows.followed_id = ?", userID)
+
+	return r.paginate(query, req)
+}
+
+// GetFollowing 获取用户的关注列表
+func (r *followRepository) GetFollowing(ctx context.Context, userID uint, req *pagination.Request) ([]*model.User, *pagination.Page, error) {
+	query := r.db.WithContext(ctx).Model(&model.User{}).
+		Joins("JOIN follows ON follows.followed_id = users.id AND follows.deleted_at IS NULL").
+		Where("follows.follower_id = ?", userID)
+
+	return r.paginate(query, req)
+}
+
+// GetMutualFollowers 获取 viewerID 关注的人中同时关注了 userID 的用户
+func (r *followRepository) GetMutualFollowers(ctx context.Context, viewerID, userID uint, req *pagination.Request) ([]*model.User, *pagination.Page, error) {
+	query := r.db.WithContext(ctx).Model(&model.User{}).
+		Joins("JOIN follows ON follows.follower_id = users.id AND follows.deleted_at IS NULL").
+		Joins("JOIN follows AS viewer_follows ON viewer_follows.followed_id = users.id AND viewer_follows.deleted_at IS NULL").
+		Where("follows.followed_id = ? AND viewer_follows.follower_id = ?", userID, viewerID)
+
+	return r.paginate(query, req)
+}
+
+// CountFollowers 批量统计用户的粉丝数
+func (r *followRepository) CountFollowers(ctx context.Context, userIDs []uint) (map[uint]int64, error) {
+	return r.countBy(ctx, "followed_id", userIDs)
+}
+
+// CountFollowing 批量统计用户的关注数
+func (r *followRepository) CountFollowing(ctx context.Context, userIDs []uint) (map[uint]int64, error) {
+	return r.countBy(ctx, "follower_id", userIDs)
+}
+
+// 关注关系列表按关注的先后倒序排列，关注记录的ID与关注时间顺序一致
+var followKeys = keyset{id: "follows.id"}
+
+// followedUser 关注关系中的用户，附带关注记录的ID用于生成游标
+type followedUser struct {
+	model.User
+	FollowID uint
+}
+
+// paginate 对关注关系的用户查询进行分页，按关注时间倒序
+func (r *followRepository) paginate(query *gorm.DB, req *pagination.Request) ([]*model.User, *pagination.Page, error) {
+	query = query.Select("users.*, follows.id AS follow_id")
+	rows, page, err := paginate(query, followKeys, req, func(row *followedUser) pagination.Cursor {
+		return pagination.Cursor{ID: row.FollowID}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	users := make([]*model.User, len(rows))
+	for i, row := range rows {
+		users[i] = &row.User
+	}
+	return users, page, nil
+}
+
+// countBy 按指定列分组统计关注数量
+func (r *followRepository) countBy(ctx context.Context, column string, userIDs []uint) (map[uint]int64, error) {
+	counts := make(map[uint]int64)
+	if len(userIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		UserID uint
+		Count  int64
+	}
+	err := r.db.WithContext(ctx).Model(&model.Follow{}).
+		Select(column+" AS user_id, COUNT(*) AS count").
+		Where(column+" IN ?", userIDs).
+		Group(column).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.UserID] = row.Count
+	}
+	return counts, nil
+}
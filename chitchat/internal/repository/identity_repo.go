@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"github.com/lllllan02/chitchat/internal/model"
+	"gorm.io/gorm"
+)
+
+// IdentityRepository 第三方身份仓库接口
+type IdentityRepository interface {
+	Create(ctx context.Context, identity *model.UserIdentity) error
+	CreateWithUser(ctx context.Context, user *model.User, identity *model.UserIdentity) error
+	GetBySubject(ctx context.Context, provider, subject string) (*model.UserIdentity, error)
+	ListByUserID(ctx context.Context, userID uint) ([]*model.UserIdentity, error)
+}
+
+// identityRepository 第三方身份仓库实现
+type identityRepository struct {
+	db *gorm.DB
+}
+
+// NewIdentityRepository 创建第三方身份仓库
+func NewIdentityRepository(db *gorm.DB) IdentityRepository {
+	return &identityRepository{db: db}
+}
+
+// Create 关联第三方身份
+func (r *identityRepository) Create(ctx context.Context, identity *model.UserIdentity) error {
+	return r.db.WithContext(ctx).Create(identity).Error
+}
+
+// CreateWithUser 在同一事务中创建用户并关联第三方身份
+func (r *identityRepository) CreateWithUser(ctx context.Context, user *model.User, identity *model.UserIdentity) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		return tx.Create(identity).Error
+	})
+}
+
+// GetBySubject 根据提供方和用户标识获取身份
+func (r *identityRepository) GetBySubject(ctx context.Context, provider, subject string) (*model.UserIdentity, error) {
+	var identity model.UserIdentity
+	err := r.db.WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// ListByUserID 获取用户关联的全部第三方身份
+func (r *identityRepository) ListByUserID(ctx context.Context, userID uint) ([]*model.UserIdentity, error) {
+	var identities []*model.UserIdentity
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id").Find(&identities).Error
+	return identities, err
+}
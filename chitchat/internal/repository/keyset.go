@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"strings"
+
+	"github.com/lllllan02/chitchat/internal/pagination"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// keyset 游标分页的排序键，依次按数值、时间和ID降序排列
+// 数值和时间排序键可以为空，ID 必须唯一以保证顺序稳定
+type keyset struct {
+	value string
+	time  string
+	id    string
+}
+
+// paginate 对查询做游标分页，按需统计总数
+// 多查询一条记录用于判断是否还有更多，不使用 OFFSET，翻到深处时开销不变，翻页期间有新记录插入也不会重复或遗漏
+func paginate[T any](query *gorm.DB, keys keyset, req *pagination.Request, cursorOf func(T) pagination.Cursor) ([]T, *pagination.Page, error) {
+	var total *int64
+	if req.WithTotal {
+		var count int64
+		if err := query.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+			return nil, nil, err
+		}
+		total = &count
+	}
+
+	// 向前翻页时反转排序方向，取边界之前最近的记录
+	backward := req.Cursor != nil && req.Cursor.Backward
+	query = query.Session(&gorm.Session{})
+	if req.Cursor != nil {
+		condition, vars := keys.after(req.Cursor, backward)
+		query = query.Where(condition, vars...)
+	}
+	for _, column := range keys.columns() {
+		query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: column, Raw: true}, Desc: !backward})
+	}
+
+	var rows []T
+	if err := query.Limit(req.Limit + 1).Find(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+
+	rows, page := pagination.Trim(req, rows, cursorOf)
+	page.Total = total
+	return rows, page, nil
+}
+
+// columns 按优先级排列的排序列
+func (k keyset) columns() []string {
+	var columns []string
+	if k.value != "" {
+		columns = append(columns, k.value)
+	}
+	if k.time != "" {
+		columns = append(columns, k.time)
+	}
+	return append(columns, k.id)
+}
+
+// after 排在游标之后的记录的条件，backward 为 true 时为排在游标之前的记录
+// 展开为 (a < ?) OR (a = ? AND b < ?) ... 的形式，不依赖各数据库对行值比较的支持
+func (k keyset) after(cursor *pagination.Cursor, backward bool) (string, []interface{}) {
+	op := " < ?"
+	if backward {
+		op = " > ?"
+	}
+
+	var values []interface{}
+	if k.value != "" {
+		values = append(values, cursor.Value)
+	}
+	if k.time != "" {
+		values = append(values, cursor.Time)
+	}
+	values = append(values, cursor.ID)
+
+	columns := k.columns()
+	terms := make([]string, len(columns))
+	var vars []interface{}
+	for i := range columns {
+		var and []string
+		for j := 0; j < i; j++ {
+			and = append(and, columns[j]+" = ?")
+			vars = append(vars, values[j])
+		}
+		and = append(and, columns[i]+op)
+		vars = append(vars, values[i])
+		terms[i] = "(" + strings.Join(and, " AND ") + ")"
+	}
+	// 整体加括号，避免与其他筛选条件组合时改变优先级
+	return "(" + strings.Join(terms, " OR ") + ")", vars
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"github.com/lllllan02/chitchat/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LikeRepository 点赞仓库接口
+type LikeRepository interface {
+	LikePost(ctx context.Context, userID, postID uint) (bool, error)
+	UnlikePost(ctx context.Context, userID, postID uint) (bool, error)
+	LikeComment(ctx context.Context, userID, commentID uint) (bool, error)
+	UnlikeComment(ctx context.Context, userID, commentID uint) (bool, error)
+	GetLikedPostIDs(ctx context.Context, userID uint, postIDs []uint) (map[uint]bool, error)
+	GetLikedCommentIDs(ctx context.Context, userID uint, commentIDs []uint) (map[uint]bool, error)
+}
+
+// likeRepository 点赞仓库实现
+type likeRepository struct {
+	db *gorm.DB
+}
+
+// NewLikeRepository 创建点赞仓库
+func NewLikeRepository(db *gorm.DB) LikeRepository {
+	return &likeRepository{db: db}
+}
+
+// LikePost 点赞帖子，返回是否新增了点赞
+func (r *likeRepository) LikePost(ctx context.Context, userID, postID uint) (bool, error) {
+	like := &model.Like{UserID: userID, PostID: &postID}
+	return r.like(ctx, like, &model.Post{}, postID)
+}
+
+// UnlikePost 取消点赞帖子，返回是否删除了点赞
+func (r *likeRepository) UnlikePost(ctx context.Context, userID, postID uint) (bool, error) {
+	return r.unlike(ctx, "post_id", userID, postID, &model.Post{})
+}
+
+// LikeComment 点赞评论，返回是否新增了点赞
+func (r *likeRepository) LikeComment(ctx context.Context, userID, commentID uint) (bool, error) {
+	like := &model.Like{UserID: userID, CommentID: &commentID}
+	return r.like(ctx, like, &model.Comment{}, commentID)
+}
+
+// UnlikeComment 取消点赞评论，返回是否删除了点赞
+func (r *likeRepository) UnlikeComment(ctx context.Context, userID, commentID uint) (bool, error) {
+	return r.unlike(ctx, "comment_id", userID, commentID, &model.Comment{})
+}
+
+// GetLikedPostIDs 获取用户在给定帖子中已点赞的帖子ID
+func (r *likeRepository) GetLikedPostIDs(ctx context.Context, userID uint, postIDs []uint) (map[uint]bool, error) {
+	return r.likedIDs(ctx, "post_id", userID, postIDs)
+}
+
+// GetLikedCommentIDs 获取用户在给定评论中已点赞的评论ID
+func (r *likeRepository) GetLikedCommentIDs(ctx context.Context, userID uint, commentIDs []uint) (map[uint]bool, error) {
+	return r.likedIDs(ctx, "comment_id", userID, commentIDs)
+}
+
+// like 在同一事务中插入点赞记录并增加目标的点赞数，重复点赞不会重复计数
+func (r *likeRepository) like(ctx context.Context, like *model.Like, target interface{}, targetID uint) (bool, error) {
+	created := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(like)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		created = true
+		return tx.Model(target).Where("id = ?", targetID).UpdateColumn("like_count", gorm.Expr("like_count + ?", 1)).Error
+	})
+	return created, err
+}
+
+// unlike 在同一事务中删除点赞记录并减少目标的点赞数
+func (r *likeRepository) unlike(ctx context.Context, column string, userID, targetID uint, target interface{}) (bool, error) {
+	deleted := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 物理删除，保证唯一索引允许再次点赞
+		result := tx.Unscoped().Where("user_id = ? AND "+column+" = ?", userID, targetID).Delete(&model.Like{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		deleted = true
+		return tx.Model(target).Where("id = ?", targetID).UpdateColumn("like_count", gorm.Expr("CASE WHEN like_count > 0 THEN like_count - 1 ELSE 0 END")).Error
+	})
+	return deleted, err
+}
+
+// likedIDs 查询用户已点赞的目标ID集合
+func (r *likeRepository) likedIDs(ctx context.Context, column string, userID uint, targetIDs []uint) (map[uint]bool, error) {
+	liked := make(map[uint]bool)
+	if len(targetIDs) == 0 {
+		return liked, nil
+	}
+
+	var ids []uint
+	err := r.db.WithContext(ctx).Model(&model.Like{}).Where("user_id = ? AND "+column+" IN ?", userID, targetIDs).Pluck(column, &ids).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		liked[id] = true
+	}
+	return liked, nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lllllan02/chitchat/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LoginAttemptRepository 登录失败记录仓库接口
+type LoginAttemptRepository interface {
+	Get(ctx context.Context, key string) (*model.LoginAttempt, error)
+	Increment(ctx context.Context, key string, now, resetBefore time.Time) (*model.LoginAttempt, error)
+	Lock(ctx context.Context, key string, now, until time.Time) (bool, error)
+	Delete(ctx context.Context, key string) error
+	DeleteStale(ctx context.Context, before time.Time) error
+}
+
+// loginAttemptRepository 登录失败记录仓库实现
+type loginAttemptRepository struct {
+	db *gorm.DB
+}
+
+// NewLoginAttemptRepository 创建登录失败记录仓库
+func NewLoginAttemptRepository(db *gorm.DB) LoginAttemptRepository {
+	return &loginAttemptRepository{db: db}
+}
+
+// Get 获取登录失败记录，不存在时返回 nil
+func (r *loginAttemptRepository) Get(ctx context.Context, key string) (*model.LoginAttempt, error) {
+	var attempt model.LoginAttempt
+	err := r.db.WithContext(ctx).Where("attempt_key = ?", key).First(&attempt).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// Increment 原子地增加失败次数并返回最新的记录，记录不存在时创建
+// 上次失败早于 resetBefore 或锁定已过期时从 1 重新计数
+func (r *loginAttemptRepository) Increment(ctx context.Context, key string, now, resetBefore time.Time) (*model.LoginAttempt, error) {
+	// 条件引用更新前的值；MySQL 按顺序执行赋值，因此 failures 和 locked_until 要在 last_failed_at 之前更新
+	reset := "login_attempts.last_failed_at < ? OR (login_attempts.locked_until IS NOT NULL AND login_attempts.locked_until <= ?)"
+	attempt := &model.LoginAttempt{Key: key, Failures: 1, LastFailedAt: now}
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "attempt_key"}},
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "failures"}, Value: gorm.Expr("CASE WHEN "+reset+" THEN 1 ELSE login_attempts.failures + 1 END", resetBefore, now)},
+			{Column: clause.Column{Name: "locked_until"}, Value: gorm.Expr("CASE WHEN "+reset+" THEN NULL ELSE login_attempts.locked_until END", resetBefore, now)},
+			{Column: clause.Column{Name: "last_failed_at"}, Value: now},
+			{Column: clause.Column{Name: "updated_at"}, Value: now},
+		},
+	}).Create(attempt).Error
+	if err != nil {
+		return nil, err
+	}
+	return r.Get(ctx, key)
+}
+
+// Lock 锁定到 until，已处于锁定中时不修改并返回 false
+func (r *loginAttemptRepository) Lock(ctx context.Context, key string, now, until time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.LoginAttempt{}).
+		Where("attempt_key = ? AND (locked_until IS NULL OR locked_until <= ?)", key, now).
+		UpdateColumn("locked_until", until)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Delete 删除登录失败记录
+func (r *loginAttemptRepository) Delete(ctx context.Context, key string) error {
+	return r.db.WithContext(ctx).Where("attempt_key = ?", key).Delete(&model.LoginAttempt{}).Error
+}
+
+// DeleteStale 删除最近一次失败早于 before 且未处于锁定中的记录
+func (r *loginAttemptRepository) DeleteStale(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).Where("last_failed_at < ? AND (locked_until IS NULL OR locked_until < ?)", before, time.Now()).
+		Delete(&model.LoginAttempt{}).Error
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"github.com/lllllan02/chitchat/internal/model"
+	"gorm.io/gorm"
+)
+
+// MentionRepository 提及仓库接口
+type MentionRepository interface {
+	GetUserIDs(ctx context.Context, postID uint, commentID *uint) ([]uint, error)
+	Replace(ctx context.Context, postID uint, commentID *uint, userIDs []uint) error
+	GetByPostIDs(ctx context.Context, postIDs []uint) ([]*model.Mention, error)
+	GetByCommentIDs(ctx context.Context, commentIDs []uint) ([]*model.Mention, error)
+}
+
+// mentionRepository 提及仓库实现
+type mentionRepository struct {
+	db *gorm.DB
+}
+
+// NewMentionRepository 创建提及仓库
+func NewMentionRepository(db *gorm.DB) MentionRepository {
+	return &mentionRepository{db: db}
+}
+
+// GetUserIDs 获取帖子或评论中已提及的用户ID，commentID 为空时表示帖子正文
+func (r *mentionRepository) GetUserIDs(ctx context.Context, postID uint, commentID *uint) ([]uint, error) {
+	var userIDs []uint
+	err := r.scope(r.db.WithContext(ctx).Model(&model.Mention{}), postID, commentID).Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+// Replace 用新的提及用户替换帖子或评论原有的提及关系
+func (r *mentionRepository) Replace(ctx context.Context, postID uint, commentID *uint, userIDs []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := r.scope(tx, postID, commentID).Delete(&model.Mention{}).Error; err != nil {
+			return err
+		}
+
+		if len(userIDs) == 0 {
+			return nil
+		}
+
+		mentions := make([]*model.Mention, 0, len(userIDs))
+		for _, userID := range userIDs {
+			mentions = append(mentions, &model.Mention{
+				UserID:    userID,
+				PostID:    postID,
+				CommentID: commentID,
+			})
+		}
+		return tx.Create(&mentions).Error
+	})
+}
+
+// GetByPostIDs 批量获取帖子正文中的提及
+func (r *mentionRepository) GetByPostIDs(ctx context.Context, postIDs []uint) ([]*model.Mention, error) {
+	var mentions []*model.Mention
+	if len(postIDs) == 0 {
+		return mentions, nil
+	}
+	err := r.db.WithContext(ctx).Where("post_id IN ? AND comment_id IS NULL", postIDs).Preload("User").Order("id ASC").Find(&mentions).Error
+	return mentions, err
+}
+
+// GetByCommentIDs 批量获取评论中的提及
+func (r *mentionRepository) GetByCommentIDs(ctx context.Context, commentIDs []uint) ([]*model.Mention, error) {
+	var mentions []*model.Mention
+	if len(commentIDs) == 0 {
+		return mentions, nil
+	}
+	err := r.db.WithContext(ctx).Where("comment_id IN ?", commentIDs).Preload("User").Order("id ASC").Find(&mentions).Error
+	return mentions, err
+}
+
+// scope 限定到帖子正文或某条评论
+func (r *mentionRepository) scope(db *gorm.DB, postID uint, commentID *uint) *gorm.DB {
+	if commentID == nil {
+		return db.Where("post_id = ? AND comment_id IS NULL", postID)
+	}
+	return db.Where("post_id = ? AND comment_id = ?", postID, *commentID)
+}
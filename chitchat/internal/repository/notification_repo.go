@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/pagination"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NotificationRepository 通知仓库接口
+type NotificationRepository interface {
+	Create(ctx context.Context, notification *model.Notification) error
+	Update(ctx context.Context, notification *model.Notification) error
+	CreateMerged(ctx context.Context, notification *model.Notification, actorID uint) (bool, error)
+	GetByMergeKey(ctx context.Context, key string) (*model.Notification, error)
+	UpdateMerged(ctx context.Context, notification *model.Notification) (bool, error)
+	AddActor(ctx context.Context, notificationID, userID uint) (bool, error)
+	CountActors(ctx context.Context, notificationID uint) (int64, error)
+	List(ctx context.Context, userID uint, query *model.NotificationListQuery, req *pagination.Request) ([]*model.Notification, *pagination.Page, error)
+	MarkAsRead(ctx context.Context, id, userID uint) (bool, error)
+	MarkAllAsRead(ctx context.Context, userID uint) error
+	CountUnread(ctx context.Context, userID uint) (int64, error)
+}
+
+// notificationRepository 通知仓库实现
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationRepository 创建通知仓库
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+// Create 创建通知
+func (r *notificationRepository) Create(ctx context.Context, notification *model.Notification) error {
+	return r.db.WithContext(ctx).Create(notification).Error
+}
+
+// Update 更新通知
+func (r *notificationRepository) Update(ctx context.Context, notification *model.Notification) error {
+	return r.db.WithContext(ctx).Omit("User", "Sender", "Post", "Comment").Save(notification).Error
+}
+
+// CreateMerged 在同一事务中创建可合并通知并记录首个触发者
+// 合并键已被占用时不创建，返回 false
+func (r *notificationRepository) CreateMerged(ctx context.Context, notification *model.Notification, actorID uint) (bool, error) {
+	created := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(notification)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		created = true
+		return tx.Create(&model.NotificationActor{NotificationID: notification.ID, UserID: actorID}).Error
+	})
+	return created, err
+}
+
+// GetByMergeKey 根据合并键获取未读通知
+func (r *notificationRepository) GetByMergeKey(ctx context.Context, key string) (*model.Notification, error) {
+	var notification model.Notification
+	err := r.db.WithContext(ctx).Where("merge_key = ?", key).First(&notification).Error
+	if err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// UpdateMerged 更新合并通知的计数和内容
+// 只有计数增加时才更新，并发合并时旧的计数不会覆盖新的，未更新时返回 false
+func (r *notificationRepository) UpdateMerged(ctx context.Context, notification *model.Notification) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.Notification{}).
+		Where("id = ? AND is_read = ? AND count < ?", notification.ID, false, notification.Count).
+		UpdateColumns(map[string]interface{}{
+			"count":      notification.Count,
+			"sender_id":  notification.SenderID,
+			"content":    notification.Content,
+			"updated_at": notification.UpdatedAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// AddActor 记录合并通知的触发者，该用户已记录过时返回 false
+func (r *notificationRepository) AddActor(ctx context.Context, notificationID, userID uint) (bool, error) {
+	actor := &model.NotificationActor{NotificationID: notificationID, UserID: userID}
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(actor)
+	return result.RowsAffected > 0, result.Error
+}
+
+// CountActors 统计合并通知的不同触发者数量
+func (r *notificationRepository) CountActors(ctx context.Context, notificationID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.NotificationActor{}).Where("notification_id = ?", notificationID).Count(&count).Error
+	return count, err
+}
+
+// 通知列表的排序键，按创建时间排序
+// 合并通知时会修改 updated_at，用它排序会让通知在翻页期间移动位置，导致重复或遗漏
+var notificationKeys = keyset{time: "created_at", id: "id"}
+
+// notificationCursor 通知在列表中的位置
+func notificationCursor(notification *model.Notification) pagination.Cursor {
+	return pagination.Cursor{Time: notification.CreatedAt, ID: notification.ID}
+}
+
+// List 获取用户的通知列表
+func (r *notificationRepository) List(ctx context.Context, userID uint, query *model.NotificationListQuery, req *pagination.Request) ([]*model.Notification, *pagination.Page, error) {
+	db := r.db.WithContext(ctx).Model(&model.Notification{}).Preload("Sender").Where("user_id = ?", userID)
+
+	// 筛选条件
+	if query.Type != "" {
+		db = db.Where("type = ?", query.Type)
+	}
+	if query.IsRead != nil {
+		db = db.Where("is_read = ?", *query.IsRead)
+	}
+
+	return paginate(db, notificationKeys, req, notificationCursor)
+}
+
+// MarkAsRead 标记通知为已读，返回通知是否存在
+// 标记已读时同时释放合并键，之后的同类通知重新创建
+func (r *notificationRepository) MarkAsRead(ctx context.Context, id, userID uint) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&model.Notification{}).Where("id = ? AND user_id = ?", id, userID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count == 0 {
+		return false, nil
+	}
+
+	err := r.db.WithContext(ctx).Model(&model.Notification{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{"is_read": true, "merge_key": nil}).Error
+	return true, err
+}
+
+// MarkAllAsRead 标记用户所有通知为已读
+func (r *notificationRepository) MarkAllAsRead(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Model(&model.Notification{}).Where("user_id = ? AND is_read = ?", userID, false).UpdateColumns(map[string]interface{}{"is_read": true, "merge_key": nil}).Error
+}
+
+// CountUnread 统计用户的未读通知数
+func (r *notificationRepository) CountUnread(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.Notification{}).Where("user_id = ? AND is_read = ?", userID, false).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/lllllan02/chitchat/internal/model"
+	"gorm.io/gorm"
+)
+
+// OAuthStateRepository 第三方登录请求仓库接口
+type OAuthStateRepository interface {
+	Create(ctx context.Context, state *model.OAuthState) error
+	Consume(ctx context.Context, stateHash string) (*model.OAuthState, error)
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
+
+// oauthStateRepository 第三方登录请求仓库实现
+type oauthStateRepository struct {
+	db *gorm.DB
+}
+
+// NewOAuthStateRepository 创建第三方登录请求仓库
+func NewOAuthStateRepository(db *gorm.DB) OAuthStateRepository {
+	return &oauthStateRepository{db: db}
+}
+
+// Create 保存登录请求
+func (r *oauthStateRepository) Create(ctx context.Context, state *model.OAuthState) error {
+	return r.db.WithContext(ctx).Create(state).Error
+}
+
+// Consume 取出并删除登录请求，并发回调时只有一个请求能取到
+func (r *oauthStateRepository) Consume(ctx context.Context, stateHash string) (*model.OAuthState, error) {
+	var state model.OAuthState
+	if err := r.db.WithContext(ctx).Where("state_hash = ?", stateHash).First(&state).Error; err != nil {
+		return nil, err
+	}
+
+	result := r.db.WithContext(ctx).Delete(&model.OAuthState{}, state.ID)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &state, nil
+}
+
+// DeleteExpired 删除已过期的登录请求
+func (r *oauthStateRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&model.OAuthState{}).Error
+}
@@ -1,24 +1,26 @@
 package repository
 
 import (
+	"context"
 	"github.com/lllllan02/chitchat/internal/model"
-	"github.com/lllllan02/chitchat/internal/utils"
+	"github.com/lllllan02/chitchat/internal/pagination"
 	"gorm.io/gorm"
 )
 
 // PostRepository 帖子仓库接口
 type PostRepository interface {
-	Create(post *model.Post) error
-	GetByID(id uint, includeUser bool) (*model.Post, error)
-	Update(post *model.Post) error
-	Delete(id uint) error
-	List(page, pageSize int, categoryID, userID uint, keyword, orderBy string) ([]*model.Post, int64, error)
-	IncrementViewCount(id uint) error
-	UpdateLikeCount(id uint, count int) error
-	SetPinned(id uint, isPinned bool) error
-	SetFeatured(id uint, isFeatured bool) error
-	GetPinnedPosts(categoryID uint, limit int) ([]*model.Post, error)
-	GetFeaturedPosts(limit int) ([]*model.Post, error)
+	Create(ctx context.Context, post *model.Post) error
+	GetByID(ctx context.Context, id uint, includeUser bool) (*model.Post, error)
+	Update(ctx context.Context, post *model.Post) error
+	Delete(ctx context.Context, id uint) error
+	List(ctx context.Context, categoryID, userID uint, keyword string, ids []uint, orderBy string, req *pagination.Request) ([]*model.Post, *pagination.Page, error)
+	AddViewCounts(ctx context.Context, counts map[uint]int64) error
+	UpdateLikeCount(ctx context.Context, id uint, count int) error
+	SetPinned(ctx context.Context, id uint, isPinned bool) error
+	SetFeatured(ctx context.Context, id uint, isFeatured bool) error
+	GetPinnedPosts(ctx context.Context, categoryID uint, limit int) ([]*model.Post, error)
+	GetFeaturedPosts(ctx context.Context, limit int) ([]*model.Post, error)
+	ListAfter(ctx context.Context, afterID uint, limit int) ([]*model.Post, error)
 }
 
 // postRepository 帖子仓库实现
@@ -27,19 +29,19 @@ type postRepository struct {
 }
 
 // NewPostRepository 创建帖子仓库
-func NewPostRepository() PostRepository {
-	return &postRepository{db: utils.DB}
+func NewPostRepository(db *gorm.DB) PostRepository {
+	return &postRepository{db: db}
 }
 
 // Create 创建帖子
-func (r *postRepository) Create(post *model.Post) error {
-	return r.db.Create(post).Error
+func (r *postRepository) Create(ctx context.Context, post *model.Post) error {
+	return r.db.WithContext(ctx).Create(post).Error
 }
 
 // GetByID 根据ID获取帖子
-func (r *postRepository) GetByID(id uint, includeUser bool) (*model.Post, error) {
+func (r *postRepository) GetByID(ctx context.Context, id uint, includeUser bool) (*model.Post, error) {
 	var post model.Post
-	query := r.db
+	query := r.db.WithContext(ctx)
 
 	if includeUser {
 		query = query.Preload("User").Preload("Category")
@@ -54,21 +56,30 @@ func (r *postRepository) GetByID(id uint, includeUser bool) (*model.Post, error)
 }
 
 // Update 更新帖子
-func (r *postRepository) Update(post *model.Post) error {
-	return r.db.Save(post).Error
+func (r *postRepository) Update(ctx context.Context, post *model.Post) error {
+	// 计数和置顶、精华状态只通过专用方法修改，避免被旧值覆盖
+	return r.db.WithContext(ctx).Omit("view_count", "like_count", "is_pinned", "is_featured").Save(post).Error
 }
 
 // Delete 删除帖子
-func (r *postRepository) Delete(id uint) error {
-	return r.db.Delete(&model.Post{}, id).Error
+func (r *postRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&model.Post{}, id).Error
 }
 
-// List 获取帖子列表
-func (r *postRepository) List(page, pageSize int, categoryID, userID uint, keyword, orderBy string) ([]*model.Post, int64, error) {
-	var posts []*model.Post
-	var total int64
+// 帖子列表的排序键
+var (
+	postRecentKeys  = keyset{time: "created_at", id: "id"}
+	postPopularKeys = keyset{value: "like_count", time: "created_at", id: "id"}
+)
+
+// postCursor 帖子在列表中的位置
+func postCursor(post *model.Post) pagination.Cursor {
+	return pagination.Cursor{Value: int64(post.LikeCount), Time: post.CreatedAt, ID: post.ID}
+}
 
-	query := r.db.Model(&model.Post{}).Preload("User").Preload("Category")
+// List 获取帖子列表，ids 不为 nil 时只返回其中的帖子，否则按 keyword 模糊匹配标题和正文
+func (r *postRepository) List(ctx context.Context, categoryID, userID uint, keyword string, ids []uint, orderBy string, req *pagination.Request) ([]*model.Post, *pagination.Page, error) {
+	query := r.db.WithContext(ctx).Model(&model.Post{}).Preload("User").Preload("Category")
 
 	// 筛选条件
 	if categoryID > 0 {
@@ -79,56 +90,54 @@ func (r *postRepository) List(page, pageSize int, categoryID, userID uint, keywo
 		query = query.Where("user_id = ?", userID)
 	}
 
-	if keyword != "" {
-		query = query.Where("title LIKE ? OR content LIKE ?", "%"+keyword+"%", "%"+keyword+"%")
+	if ids != nil {
+		query = query.Where("id IN ?", ids)
+	} else if keyword != "" {
+		pattern := containsPattern(keyword)
+		query = query.Where(likeCondition("title")+" OR "+likeCondition("content"), pattern, pattern)
 	}
 
 	// 排序
-	switch orderBy {
-	case "popular":
-		query = query.Order("like_count DESC, created_at DESC")
-	default:
-		query = query.Order("created_at DESC")
-	}
-
-	// 获取总数
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
+	keys := postRecentKeys
+	if orderBy == "popular" {
+		keys = postPopularKeys
 	}
 
-	// 分页查询
-	offset := (page - 1) * pageSize
-	if err := query.Offset(offset).Limit(pageSize).Find(&posts).Error; err != nil {
-		return nil, 0, err
-	}
-
-	return posts, total, nil
+	return paginate(query, keys, req, postCursor)
 }
 
-// IncrementViewCount 增加浏览次数
-func (r *postRepository) IncrementViewCount(id uint) error {
-	return r.db.Model(&model.Post{}).Where("id = ?", id).UpdateColumn("view_count", gorm.Expr("view_count + ?", 1)).Error
+// AddViewCounts 在同一事务中批量增加帖子的浏览次数
+func (r *postRepository) AddViewCounts(ctx context.Context, counts map[uint]int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for id, n := range counts {
+			err := tx.Model(&model.Post{}).Where("id = ?", id).UpdateColumn("view_count", gorm.Expr("view_count + ?", n)).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 // UpdateLikeCount 更新点赞数
-func (r *postRepository) UpdateLikeCount(id uint, count int) error {
-	return r.db.Model(&model.Post{}).Where("id = ?", id).Update("like_count", count).Error
+func (r *postRepository) UpdateLikeCount(ctx context.Context, id uint, count int) error {
+	return r.db.WithContext(ctx).Model(&model.Post{}).Where("id = ?", id).Update("like_count", count).Error
 }
 
 // SetPinned 设置置顶状态
-func (r *postRepository) SetPinned(id uint, isPinned bool) error {
-	return r.db.Model(&model.Post{}).Where("id = ?", id).Update("is_pinned", isPinned).Error
+func (r *postRepository) SetPinned(ctx context.Context, id uint, isPinned bool) error {
+	return r.db.WithContext(ctx).Model(&model.Post{}).Where("id = ?", id).Update("is_pinned", isPinned).Error
 }
 
 // SetFeatured 设置精华状态
-func (r *postRepository) SetFeatured(id uint, isFeatured bool) error {
-	return r.db.Model(&model.Post{}).Where("id = ?", id).Update("is_featured", isFeatured).Error
+func (r *postRepository) SetFeatured(ctx context.Context, id uint, isFeatured bool) error {
+	return r.db.WithContext(ctx).Model(&model.Post{}).Where("id = ?", id).Update("is_featured", isFeatured).Error
 }
 
 // GetPinnedPosts 获取置顶帖子
-func (r *postRepository) GetPinnedPosts(categoryID uint, limit int) ([]*model.Post, error) {
+func (r *postRepository) GetPinnedPosts(ctx context.Context, categoryID uint, limit int) ([]*model.Post, error) {
 	var posts []*model.Post
-	query := r.db.Where("is_pinned = ?", true).Preload("User").Preload("Category").Order("updated_at DESC")
+	query := r.db.WithContext(ctx).Where("is_pinned = ?", true).Preload("User").Preload("Category").Order("updated_at DESC")
 
 	if categoryID > 0 {
 		query = query.Where("category_id = ?", categoryID)
@@ -143,9 +152,9 @@ func (r *postRepository) GetPinnedPosts(categoryID uint, limit int) ([]*model.Po
 }
 
 // GetFeaturedPosts 获取精华帖子
-func (r *postRepository) GetFeaturedPosts(limit int) ([]*model.Post, error) {
+func (r *postRepository) GetFeaturedPosts(ctx context.Context, limit int) ([]*model.Post, error) {
 	var posts []*model.Post
-	query := r.db.Where("is_featured = ?", true).Preload("User").Preload("Category").Order("updated_at DESC")
+	query := r.db.WithContext(ctx).Where("is_featured = ?", true).Preload("User").Preload("Category").Order("updated_at DESC")
 
 	if limit > 0 {
 		query = query.Limit(limit)
@@ -154,3 +163,10 @@ func (r *postRepository) GetFeaturedPosts(limit int) ([]*model.Post, error) {
 	err := query.Find(&posts).Error
 	return posts, err
 }
+
+// ListAfter 按ID顺序获取 afterID 之后的帖子，用于分批遍历全部帖子
+func (r *postRepository) ListAfter(ctx context.Context, afterID uint, limit int) ([]*model.Post, error) {
+	var posts []*model.Post
+	err := r.db.WithContext(ctx).Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&posts).Error
+	return posts, err
+}
@@ -0,0 +1,22 @@
+package repository
+
+import "strings"
+
+// likeEscaper 转义 LIKE 模式中的通配符，统一使用 ! 作为转义字符
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// containsPattern 生成"包含关键词"的 LIKE 模式，需配合 likeCondition 使用
+func containsPattern(keyword string) string {
+	return "%" + likeEscaper.Replace(strings.ToLower(keyword)) + "%"
+}
+
+// prefixPattern 生成"以关键词开头"的 LIKE 模式，需配合 likeCondition 使用
+func prefixPattern(keyword string) string {
+	return likeEscaper.Replace(strings.ToLower(keyword)) + "%"
+}
+
+// likeCondition 生成大小写不敏感的 LIKE 条件
+// MySQL、PostgreSQL 和 SQLite 对 LIKE 的大小写和转义处理各不相同，这里统一转为小写并显式指定转义字符
+func likeCondition(column string) string {
+	return "LOWER(" + column + ") LIKE ? ESCAPE '!'"
+}
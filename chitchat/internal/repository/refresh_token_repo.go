@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/lllllan02/chitchat/internal/model"
+	"gorm.io/gorm"
+)
+
+// RefreshTokenRepository 刷新令牌仓库接口
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *model.RefreshToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error)
+	Revoke(ctx context.Context, id uint) (bool, error)
+	Delete(ctx context.Context, id uint) error
+	DeleteBySessionID(ctx context.Context, sessionID uint) error
+	DeleteByUserID(ctx context.Context, userID uint) error
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
+
+// refreshTokenRepository 刷新令牌仓库实现
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewRefreshTokenRepository 创建刷新令牌仓库
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+// Create 保存刷新令牌
+func (r *refreshTokenRepository) Create(ctx context.Context, token *model.RefreshToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+// GetByHash 根据令牌哈希获取刷新令牌
+func (r *refreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Revoke 轮换时吊销刷新令牌，返回是否由本次调用吊销
+// 吊销的记录会保留到过期，用于发现旧令牌被再次使用，并发刷新同一个令牌时只有一个请求能成功
+func (r *refreshTokenRepository) Revoke(ctx context.Context, id uint) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		UpdateColumn("revoked_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// Delete 删除刷新令牌
+func (r *refreshTokenRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&model.RefreshToken{}, id).Error
+}
+
+// DeleteBySessionID 删除会话的全部刷新令牌
+func (r *refreshTokenRepository) DeleteBySessionID(ctx context.Context, sessionID uint) error {
+	return r.db.WithContext(ctx).Where("session_id = ?", sessionID).Delete(&model.RefreshToken{}).Error
+}
+
+// DeleteByUserID 删除用户的全部刷新令牌
+func (r *refreshTokenRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&model.RefreshToken{}).Error
+}
+
+// DeleteExpired 删除已过期的刷新令牌
+func (r *refreshTokenRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&model.RefreshToken{}).Error
+}
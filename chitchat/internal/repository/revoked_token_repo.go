@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/lllllan02/chitchat/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RevokedTokenRepository 访问令牌吊销列表仓库接口
+type RevokedTokenRepository interface {
+	Create(ctx context.Context, tokenID string, expiresAt time.Time) error
+	Exists(ctx context.Context, tokenID string) (bool, error)
+	Consume(ctx context.Context, tokenID string, expiresAt time.Time) (bool, error)
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
+
+// revokedTokenRepository 访问令牌吊销列表仓库实现
+type revokedTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewRevokedTokenRepository 创建访问令牌吊销列表仓库
+func NewRevokedTokenRepository(db *gorm.DB) RevokedTokenRepository {
+	return &revokedTokenRepository{db: db}
+}
+
+// Create 将访问令牌加入吊销列表，重复吊销时忽略
+func (r *revokedTokenRepository) Create(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	token := &model.RevokedToken{TokenID: tokenID, ExpiresAt: expiresAt}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(token).Error
+}
+
+// Exists 检查访问令牌是否已被吊销
+func (r *revokedTokenRepository) Exists(ctx context.Context, tokenID string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.RevokedToken{}).Where("token_id = ?", tokenID).Count(&count).Error
+	return count > 0, err
+}
+
+// Consume 将一次性令牌标记为已使用，令牌之前已被使用时返回 false
+func (r *revokedTokenRepository) Consume(ctx context.Context, tokenID string, expiresAt time.Time) (bool, error) {
+	token := &model.RevokedToken{TokenID: tokenID, ExpiresAt: expiresAt}
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(token)
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteExpired 删除已过期的吊销记录，过期令牌本身已无法通过校验
+func (r *revokedTokenRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&model.RevokedToken{}).Error
+}
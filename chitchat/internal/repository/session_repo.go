@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/lllllan02/chitchat/internal/model"
+	"gorm.io/gorm"
+)
+
+// SessionRepository 登录会话仓库接口
+type SessionRepository interface {
+	Create(ctx context.Context, session *model.Session) error
+	GetByID(ctx context.Context, id uint) (*model.Session, error)
+	ListActive(ctx context.Context, userID uint, now time.Time) ([]*model.Session, error)
+	Update(ctx context.Context, session *model.Session) error
+	Touch(ctx context.Context, id uint, lastSeenAt time.Time) error
+	Delete(ctx context.Context, id uint) error
+	DeleteByUserID(ctx context.Context, userID uint) error
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
+
+// sessionRepository 登录会话仓库实现
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository 创建登录会话仓库
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+// Create 创建会话
+func (r *sessionRepository) Create(ctx context.Context, session *model.Session) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+// GetByID 根据ID获取会话
+func (r *sessionRepository) GetByID(ctx context.Context, id uint) (*model.Session, error) {
+	var session model.Session
+	err := r.db.WithContext(ctx).First(&session, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ListActive 获取用户未过期的会话，最近活跃的在前
+func (r *sessionRepository) ListActive(ctx context.Context, userID uint, now time.Time) ([]*model.Session, error) {
+	var sessions []*model.Session
+	err := r.db.WithContext(ctx).Where("user_id = ? AND expires_at > ?", userID, now).Order("last_seen_at DESC, id DESC").Find(&sessions).Error
+	return sessions, err
+}
+
+// Update 更新会话
+func (r *sessionRepository) Update(ctx context.Context, session *model.Session) error {
+	return r.db.WithContext(ctx).Save(session).Error
+}
+
+// Touch 更新会话的最近活跃时间
+func (r *sessionRepository) Touch(ctx context.Context, id uint, lastSeenAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.Session{}).Where("id = ?", id).UpdateColumn("last_seen_at", lastSeenAt).Error
+}
+
+// Delete 删除会话
+func (r *sessionRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&model.Session{}, id).Error
+}
+
+// DeleteByUserID 删除用户的全部会话
+func (r *sessionRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&model.Session{}).Error
+}
+
+// DeleteExpired 删除已过期的会话
+func (r *sessionRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&model.Session{}).Error
+}
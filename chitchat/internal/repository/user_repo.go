@@ -1,22 +1,31 @@
 package repository
 
 import (
+	"context"
 	"github.com/lllllan02/chitchat/internal/model"
-	"github.com/lllllan02/chitchat/internal/utils"
 	"gorm.io/gorm"
 )
 
 // UserRepository 用户仓库接口
 type UserRepository interface {
-	Create(user *model.User) error
-	GetByID(id uint) (*model.User, error)
-	GetByUsername(username string) (*model.User, error)
-	GetByEmail(email string) (*model.User, error)
-	Update(user *model.User) error
-	Delete(id uint) error
-	List(page, pageSize int) ([]*model.User, int64, error)
-	FindByKeyword(keyword string, page, pageSize int) ([]*model.User, int64, error)
-	UpdatePassword(id uint, passwordHash string) error
+	Create(ctx context.Context, user *model.User) error
+	GetByID(ctx context.Context, id uint) (*model.User, error)
+	GetByUsername(ctx context.Context, username string) (*model.User, error)
+	GetByEmail(ctx context.Context, email string) (*model.User, error)
+	GetByUsernames(ctx context.Context, usernames []string) ([]*model.User, error)
+	GetByIDs(ctx context.Context, ids []uint) ([]*model.User, error)
+	Update(ctx context.Context, user *model.User) error
+	Delete(ctx context.Context, id uint) error
+	List(ctx context.Context, query *model.UserListQuery, ids []uint) ([]*model.User, int64, error)
+	SuggestByPrefix(ctx context.Context, prefix string, limit int) ([]*model.User, error)
+	UpdatePassword(ctx context.Context, id uint, passwordHash string) error
+	IncrementTokenVersion(ctx context.Context, id uint) error
+	MarkEmailVerified(ctx context.Context, id uint) error
+	SetTOTPSecret(ctx context.Context, id uint, secret string) error
+	EnableTwoFactor(ctx context.Context, id uint) error
+	DisableTwoFactor(ctx context.Context, id uint) error
+	UseTOTPStep(ctx context.Context, id uint, step int64) (bool, error)
+	ListAfter(ctx context.Context, afterID uint, limit int) ([]*model.User, error)
 }
 
 // userRepository 用户仓库实现
@@ -25,19 +34,19 @@ type userRepository struct {
 }
 
 // NewUserRepository 创建用户仓库
-func NewUserRepository() UserRepository {
-	return &userRepository{db: utils.DB}
+func NewUserRepository(db *gorm.DB) UserRepository {
+	return &userRepository{db: db}
 }
 
 // Create 创建用户
-func (r *userRepository) Create(user *model.User) error {
-	return r.db.Create(user).Error
+func (r *userRepository) Create(ctx context.Context, user *model.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
 }
 
 // GetByID 根据ID获取用户
-func (r *userRepository) GetByID(id uint) (*model.User, error) {
+func (r *userRepository) GetByID(ctx context.Context, id uint) (*model.User, error) {
 	var user model.User
-	err := r.db.First(&user, id).Error
+	err := r.db.WithContext(ctx).First(&user, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -45,9 +54,9 @@ func (r *userRepository) GetByID(id uint) (*model.User, error) {
 }
 
 // GetByUsername 根据用户名获取用户
-func (r *userRepository) GetByUsername(username string) (*model.User, error) {
+func (r *userRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
 	var user model.User
-	err := r.db.Where("username = ?", username).First(&user).Error
+	err := r.db.WithContext(ctx).Where("username = ?", username).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -55,66 +64,162 @@ func (r *userRepository) GetByUsername(username string) (*model.User, error) {
 }
 
 // GetByEmail 根据邮箱获取用户
-func (r *userRepository) GetByEmail(email string) (*model.User, error) {
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
 	var user model.User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
+// GetByUsernames 根据用户名批量获取用户
+func (r *userRepository) GetByUsernames(ctx context.Context, usernames []string) ([]*model.User, error) {
+	var users []*model.User
+	if len(usernames) == 0 {
+		return users, nil
+	}
+	err := r.db.WithContext(ctx).Where("username IN ?", usernames).Find(&users).Error
+	return users, err
+}
+
+// GetByIDs 根据ID批量获取用户
+func (r *userRepository) GetByIDs(ctx context.Context, ids []uint) ([]*model.User, error) {
+	var users []*model.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&users).Error
+	return users, err
+}
+
 // Update 更新用户
-func (r *userRepository) Update(user *model.User) error {
-	return r.db.Save(user).Error
+func (r *userRepository) Update(ctx context.Context, user *model.User) error {
+	// 令牌版本和验证码时间步只通过专用方法修改，避免并发更新时被旧值覆盖
+	return r.db.WithContext(ctx).Omit("token_version", "totp_last_step").Save(user).Error
 }
 
 // Delete 删除用户
-func (r *userRepository) Delete(id uint) error {
-	return r.db.Delete(&model.User{}, id).Error
+func (r *userRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&model.User{}, id).Error
 }
 
-// List 获取用户列表
-func (r *userRepository) List(page, pageSize int) ([]*model.User, int64, error) {
+// 按发帖数和粉丝数排序时使用的子查询，只统计未删除的记录
+const (
+	userPostCountExpr     = "(SELECT COUNT(*) FROM posts WHERE posts.user_id = users.id AND posts.deleted_at IS NULL)"
+	userFollowerCountExpr = "(SELECT COUNT(*) FROM follows WHERE follows.followed_id = users.id AND follows.deleted_at IS NULL)"
+)
+
+// List 获取用户列表，ids 不为 nil 时只返回其中的用户，否则按 query.Keyword 模糊匹配用户名和简介
+func (r *userRepository) List(ctx context.Context, query *model.UserListQuery, ids []uint) ([]*model.User, int64, error) {
 	var users []*model.User
 	var total int64
 
+	db := r.db.WithContext(ctx).Model(&model.User{})
+
+	// 筛选条件
+	if ids != nil {
+		db = db.Where("id IN ?", ids)
+	} else if query.Keyword != "" {
+		pattern := containsPattern(query.Keyword)
+		db = db.Where(likeCondition("username")+" OR "+likeCondition("bio"), pattern, pattern)
+	}
+	if query.Role != "" {
+		db = db.Where("role = ?", query.Role)
+	}
+	if !query.From.IsZero() {
+		db = db.Where("created_at >= ?", query.From)
+	}
+	if !query.To.IsZero() {
+		// 结束日期当天注册的用户也包含在内
+		db = db.Where("created_at < ?", query.To.AddDate(0, 0, 1))
+	}
+
 	// 获取总数
-	if err := r.db.Model(&model.User{}).Count(&total).Error; err != nil {
+	if err := db.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
+	// 排序，相同时按ID保证分页稳定
+	direction := "DESC"
+	if query.Order == "asc" {
+		direction = "ASC"
+	}
+	switch query.OrderBy {
+	case "posts":
+		db = db.Order(userPostCountExpr + " " + direction)
+	case "followers":
+		db = db.Order(userFollowerCountExpr + " " + direction)
+	default:
+		db = db.Order("created_at " + direction)
+	}
+	db = db.Order("id " + direction)
+
 	// 分页查询
-	offset := (page - 1) * pageSize
-	if err := r.db.Offset(offset).Limit(pageSize).Find(&users).Error; err != nil {
+	offset := (query.Page - 1) * query.PageSize
+	if err := db.Offset(offset).Limit(query.PageSize).Find(&users).Error; err != nil {
 		return nil, 0, err
 	}
 
 	return users, total, nil
 }
 
-// FindByKeyword 根据关键词搜索用户
-func (r *userRepository) FindByKeyword(keyword string, page, pageSize int) ([]*model.User, int64, error) {
+// SuggestByPrefix 获取用户名以 prefix 开头的用户，较短的用户名排在前面
+func (r *userRepository) SuggestByPrefix(ctx context.Context, prefix string, limit int) ([]*model.User, error) {
 	var users []*model.User
-	var total int64
+	err := r.db.WithContext(ctx).Select("id", "username", "avatar").
+		Where(likeCondition("username"), prefixPattern(prefix)).
+		Order("LENGTH(username) ASC, username ASC").
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
 
-	query := r.db.Model(&model.User{}).Where("username LIKE ? OR bio LIKE ?", "%"+keyword+"%", "%"+keyword+"%")
+// UpdatePassword 更新用户密码
+func (r *userRepository) UpdatePassword(ctx context.Context, id uint, passwordHash string) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", id).Update("password_hash", passwordHash).Error
+}
 
-	// 获取总数
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
+// MarkEmailVerified 标记用户邮箱已验证
+func (r *userRepository) MarkEmailVerified(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", id).UpdateColumn("email_verified", true).Error
+}
 
-	// 分页查询
-	offset := (page - 1) * pageSize
-	if err := query.Offset(offset).Limit(pageSize).Find(&users).Error; err != nil {
-		return nil, 0, err
-	}
+// IncrementTokenVersion 递增用户的令牌版本，使已签发的访问令牌失效
+func (r *userRepository) IncrementTokenVersion(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", id).UpdateColumn("token_version", gorm.Expr("token_version + 1")).Error
+}
 
-	return users, total, nil
+// SetTOTPSecret 保存待确认的两步验证密钥
+func (r *userRepository) SetTOTPSecret(ctx context.Context, id uint, secret string) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", id).UpdateColumn("totp_secret", secret).Error
 }
 
-// UpdatePassword 更新用户密码
-func (r *userRepository) UpdatePassword(id uint, passwordHash string) error {
-	return r.db.Model(&model.User{}).Where("id = ?", id).Update("password_hash", passwordHash).Error
+// EnableTwoFactor 启用两步验证
+func (r *userRepository) EnableTwoFactor(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", id).UpdateColumn("two_factor", true).Error
+}
+
+// DisableTwoFactor 关闭两步验证并清除密钥
+func (r *userRepository) DisableTwoFactor(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"two_factor":     false,
+		"totp_secret":    "",
+		"totp_last_step": 0,
+	}).Error
+}
+
+// UseTOTPStep 记录已使用的验证码时间步，时间步不晚于上次使用时返回 false
+func (r *userRepository) UseTOTPStep(ctx context.Context, id uint, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		UpdateColumn("totp_last_step", step)
+	return result.RowsAffected > 0, result.Error
+}
+
+// ListAfter 按ID顺序获取 afterID 之后的用户，用于分批遍历全部用户
+func (r *userRepository) ListAfter(ctx context.Context, afterID uint, limit int) ([]*model.User, error) {
+	var users []*model.User
+	err := r.db.WithContext(ctx).Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&users).Error
+	return users, err
 }
@@ -0,0 +1,97 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+// 高亮片段的最大字符数，以及第一个命中词之前保留的字符数
+const (
+	snippetLength  = 120
+	snippetContext = 20
+)
+
+// highlight 为包含查询词的字段生成高亮片段
+// 原文经过 HTML 转义，命中的词用 <em> 标记，过长的文本截取第一个命中词附近的内容
+func highlight(fields []Field, terms map[string]bool) map[string]string {
+	highlights := make(map[string]string)
+	for _, f := range fields {
+		if ranges := matchRanges(f.Text, terms); len(ranges) > 0 {
+			highlights[f.Name] = snippet(f.Text, ranges)
+		}
+	}
+	return highlights
+}
+
+// span 原文中的字节区间
+type span struct{ start, end int }
+
+// matchRanges 查询词在文本中出现的区间，重叠的区间合并
+func matchRanges(text string, terms map[string]bool) []span {
+	var ranges []span
+	for _, t := range Tokenize(text) {
+		if !terms[t.Term] {
+			continue
+		}
+		if n := len(ranges); n > 0 && t.Start <= ranges[n-1].end {
+			if t.End > ranges[n-1].end {
+				ranges[n-1].end = t.End
+			}
+			continue
+		}
+		ranges = append(ranges, span{t.Start, t.End})
+	}
+	return ranges
+}
+
+// snippet 截取并标记命中区间
+func snippet(text string, ranges []span) string {
+	start, end := 0, len(text)
+	if utf8.RuneCountInString(text) > snippetLength {
+		start = backRunes(text, ranges[0].start, snippetContext)
+		end = forwardRunes(text, start, snippetLength)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+
+	cursor := start
+	for _, r := range ranges {
+		if r.end <= start || r.start >= end {
+			continue
+		}
+		s, e := max(r.start, start), min(r.end, end)
+		b.WriteString(html.EscapeString(text[cursor:s]))
+		b.WriteString("<em>")
+		b.WriteString(html.EscapeString(text[s:e]))
+		b.WriteString("</em>")
+		cursor = e
+	}
+	b.WriteString(html.EscapeString(text[cursor:end]))
+
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+// backRunes 从 offset 向前移动 n 个字符
+func backRunes(text string, offset, n int) int {
+	for ; n > 0 && offset > 0; n-- {
+		_, size := utf8.DecodeLastRuneInString(text[:offset])
+		offset -= size
+	}
+	return offset
+}
+
+// forwardRunes 从 offset 向后移动 n 个字符
+func forwardRunes(text string, offset, n int) int {
+	for ; n > 0 && offset < len(text); n-- {
+		_, size := utf8.DecodeRuneInString(text[offset:])
+		offset += size
+	}
+	return offset
+}
@@ -0,0 +1,343 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 文档类型
+const (
+	TypePost    = "post"
+	TypeComment = "comment"
+	TypeUser    = "user"
+)
+
+// BM25 参数
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Field 文档中参与检索的字段
+type Field struct {
+	Name   string
+	Text   string
+	Weight float64 // 字段权重，标题等短字段通常高于正文
+}
+
+// Document 索引的文档
+type Document struct {
+	Type       string
+	ID         uint
+	Fields     []Field
+	UserID     uint // 作者
+	CategoryID uint // 帖子或评论所属帖子的分类
+	PostID     uint // 评论所属的帖子
+	CreatedAt  time.Time
+}
+
+// Filter 搜索的筛选条件，零值表示不限制
+type Filter struct {
+	Type       string
+	UserID     uint
+	CategoryID uint
+	From, To   time.Time // 创建时间范围，包含 From，不包含 To
+}
+
+// match 文档是否满足筛选条件
+func (f Filter) match(d *Document) bool {
+	switch {
+	case f.Type != "" && d.Type != f.Type:
+		return false
+	case f.UserID != 0 && d.UserID != f.UserID:
+		return false
+	case f.CategoryID != 0 && d.CategoryID != f.CategoryID:
+		return false
+	case !f.From.IsZero() && d.CreatedAt.Before(f.From):
+		return false
+	case !f.To.IsZero() && !d.CreatedAt.Before(f.To):
+		return false
+	}
+	return true
+}
+
+// Hit 搜索命中的文档
+type Hit struct {
+	Type       string
+	ID         uint
+	Score      float64
+	Highlights map[string]string // 字段名到高亮片段，命中的词用 <em> 标记
+}
+
+// docKey 文档的唯一标识
+type docKey struct {
+	typ string
+	id  uint
+}
+
+// postings 一个词在文档各字段中出现的位置
+type postings map[string][]int
+
+// indexedDoc 已索引的文档
+type indexedDoc struct {
+	Document
+	lengths map[string]int // 各字段的词数
+	terms   []string       // 文档包含的词，删除时使用
+}
+
+// Index 倒排索引，可以并发读写
+type Index struct {
+	mu     sync.RWMutex
+	docs   map[docKey]*indexedDoc
+	terms  map[string]map[docKey]postings
+	totals map[string]int // 各字段的总词数，用于计算平均长度
+	counts map[string]int // 包含各字段的文档数
+}
+
+// NewIndex 创建空索引
+func NewIndex() *Index {
+	return &Index{
+		docs:   make(map[docKey]*indexedDoc),
+		terms:  make(map[string]map[docKey]postings),
+		totals: make(map[string]int),
+		counts: make(map[string]int),
+	}
+}
+
+// Put 添加或替换文档
+func (idx *Index) Put(doc Document) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	key := docKey{doc.Type, doc.ID}
+	idx.remove(key)
+
+	d := &indexedDoc{Document: doc, lengths: make(map[string]int)}
+	for _, f := range doc.Fields {
+		tokens := Tokenize(f.Text)
+		if len(tokens) == 0 {
+			continue
+		}
+		d.lengths[f.Name] = len(tokens)
+		idx.totals[f.Name] += len(tokens)
+		idx.counts[f.Name]++
+
+		for _, t := range tokens {
+			docs, ok := idx.terms[t.Term]
+			if !ok {
+				docs = make(map[docKey]postings)
+				idx.terms[t.Term] = docs
+			}
+			p, ok := docs[key]
+			if !ok {
+				p = make(postings)
+				docs[key] = p
+				d.terms = append(d.terms, t.Term)
+			}
+			p[f.Name] = append(p[f.Name], t.Pos)
+		}
+	}
+	idx.docs[key] = d
+}
+
+// Remove 删除文档
+func (idx *Index) Remove(typ string, id uint) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(docKey{typ, id})
+}
+
+// RemoveWhere 删除满足条件的全部文档
+func (idx *Index) RemoveWhere(match func(d Document) bool) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for key, d := range idx.docs {
+		if match(d.Document) {
+			idx.remove(key)
+		}
+	}
+}
+
+// Find 返回满足条件的文档副本
+func (idx *Index) Find(match func(d Document) bool) []Document {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var docs []Document
+	for _, d := range idx.docs {
+		if match(d.Document) {
+			docs = append(docs, d.Document)
+		}
+	}
+	return docs
+}
+
+// Len 已索引的文档数
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docs)
+}
+
+// remove 删除文档，调用方需持有写锁
+func (idx *Index) remove(key docKey) {
+	d, ok := idx.docs[key]
+	if !ok {
+		return
+	}
+
+	for _, term := range d.terms {
+		docs := idx.terms[term]
+		delete(docs, key)
+		if len(docs) == 0 {
+			delete(idx.terms, term)
+		}
+	}
+	for field, n := range d.lengths {
+		idx.totals[field] -= n
+		idx.counts[field]--
+	}
+	delete(idx.docs, key)
+}
+
+// Search 搜索同时满足全部查询条件的文档，按相关度从高到低排序
+// 返回 offset 开始的最多 limit 个结果和满足条件的总数
+func (idx *Index) Search(query string, filter Filter, offset, limit int) ([]Hit, int) {
+	clauses := parseQuery(query)
+	if len(clauses) == 0 {
+		return nil, 0
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	// 按出现次数最少的词确定候选文档
+	var terms []string
+	seen := make(map[string]bool)
+	for _, c := range clauses {
+		for _, t := range c {
+			if !seen[t.Term] {
+				seen[t.Term] = true
+				terms = append(terms, t.Term)
+			}
+		}
+	}
+	sort.Slice(terms, func(i, j int) bool { return len(idx.terms[terms[i]]) < len(idx.terms[terms[j]]) })
+
+	type scored struct {
+		doc   *indexedDoc
+		score float64
+	}
+	var matches []scored
+	for key := range idx.terms[terms[0]] {
+		d := idx.docs[key]
+		if !filter.match(&d.Document) || !idx.matchClauses(key, clauses) {
+			continue
+		}
+		matches = append(matches, scored{doc: d, score: idx.score(key, terms)})
+	}
+
+	// 相关度相同时较新的文档优先
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		if !a.doc.CreatedAt.Equal(b.doc.CreatedAt) {
+			return a.doc.CreatedAt.After(b.doc.CreatedAt)
+		}
+		if a.doc.Type != b.doc.Type {
+			return a.doc.Type < b.doc.Type
+		}
+		return a.doc.ID > b.doc.ID
+	})
+
+	total := len(matches)
+	if offset >= total {
+		return []Hit{}, total
+	}
+	end := total
+	if limit > 0 && offset+limit < total {
+		end = offset + limit
+	}
+
+	hits := make([]Hit, 0, end-offset)
+	for _, m := range matches[offset:end] {
+		hits = append(hits, Hit{
+			Type:       m.doc.Type,
+			ID:         m.doc.ID,
+			Score:      math.Round(m.score*1e4) / 1e4,
+			Highlights: highlight(m.doc.Fields, seen),
+		})
+	}
+	return hits, total
+}
+
+// matchClauses 文档是否满足全部查询条件，短语要求各词在同一字段中按查询的位置差出现
+func (idx *Index) matchClauses(key docKey, clauses []clause) bool {
+	for _, c := range clauses {
+		first, ok := idx.terms[c[0].Term][key]
+		if !ok {
+			return false
+		}
+		if len(c) == 1 {
+			continue
+		}
+
+		matched := false
+		for field, positions := range first {
+			for _, pos := range positions {
+				if idx.matchPhrase(key, field, c, pos-c[0].Pos) {
+					matched = true
+					break
+				}
+			}
+			if matched {
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// matchPhrase 短语的各词是否从 base 开始按查询中的位置出现在字段中
+func (idx *Index) matchPhrase(key docKey, field string, c clause, base int) bool {
+	for _, t := range c[1:] {
+		positions := idx.terms[t.Term][key][field]
+		i := sort.SearchInts(positions, base+t.Pos)
+		if i == len(positions) || positions[i] != base+t.Pos {
+			return false
+		}
+	}
+	return true
+}
+
+// score 使用 BM25 计算相关度，各字段的得分按权重累加
+func (idx *Index) score(key docKey, terms []string) float64 {
+	d := idx.docs[key]
+	n := float64(len(idx.docs))
+
+	var score float64
+	for _, term := range terms {
+		docs := idx.terms[term]
+		df := float64(len(docs))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+
+		for _, f := range d.Fields {
+			tf := float64(len(docs[key][f.Name]))
+			if tf == 0 {
+				continue
+			}
+			avg := float64(idx.totals[f.Name]) / float64(idx.counts[f.Name])
+			norm := 1 - bm25B + bm25B*float64(d.lengths[f.Name])/avg
+			score += f.Weight * idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+		}
+	}
+	return score
+}
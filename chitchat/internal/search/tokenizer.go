@@ -0,0 +1,109 @@
+// Package search 实现进程内的倒排索引，支持中日韩文本、相关度排序、短语查询和高亮
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Token 分词结果
+type Token struct {
+	Term       string
+	Pos        int // 词在文本中的位置，短语查询要求各词的位置差与查询一致
+	Start, End int // 在原文中的字节偏移
+}
+
+// isCJK 是否为中日韩文字，这类文字没有空格分词，按单字和相邻两字切分
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// isWordRune 是否为拉丁字母、数字等组成单词的字符
+func isWordRune(r rune) bool {
+	return (unicode.IsLetter(r) || unicode.IsDigit(r)) && !isCJK(r)
+}
+
+// Tokenize 对索引的文本分词
+// 单词转为小写后作为一个词；中日韩文字同时输出单字和相邻两字，使单字和多字查询都能命中
+func Tokenize(text string) []Token {
+	return tokenize(text, false)
+}
+
+// tokenize 分词，query 为 true 时连续的中日韩文字只输出相邻两字，以便按短语匹配
+func tokenize(text string, query bool) []Token {
+	var tokens []Token
+	pos := 0
+
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+
+		switch {
+		case isWordRune(r):
+			start := i
+			for i < len(text) {
+				r, size := utf8.DecodeRuneInString(text[i:])
+				if !isWordRune(r) {
+					break
+				}
+				i += size
+			}
+			tokens = append(tokens, Token{Term: strings.ToLower(text[start:i]), Pos: pos, Start: start, End: i})
+			pos++
+
+		case isCJK(r):
+			// 收集连续的中日韩文字及其偏移
+			var offsets []int
+			for i < len(text) {
+				r, size := utf8.DecodeRuneInString(text[i:])
+				if !isCJK(r) {
+					break
+				}
+				offsets = append(offsets, i)
+				i += size
+			}
+			offsets = append(offsets, i)
+
+			n := len(offsets) - 1
+			for k := 0; k < n; k++ {
+				if !query || n == 1 {
+					tokens = append(tokens, Token{Term: text[offsets[k]:offsets[k+1]], Pos: pos + k, Start: offsets[k], End: offsets[k+1]})
+				}
+				if k+1 < n {
+					tokens = append(tokens, Token{Term: text[offsets[k]:offsets[k+2]], Pos: pos + k, Start: offsets[k], End: offsets[k+2]})
+				}
+			}
+			pos += n
+
+		default:
+			i += size
+		}
+	}
+	return tokens
+}
+
+// clause 查询中的一个条件，多个词时按短语匹配
+type clause []Token
+
+// parseQuery 解析查询，双引号内的内容作为一个短语，其余按空白分隔，每段连续文字作为一个条件
+// 例如 `go "全文 搜索" 索引` 解析为 go、全文搜索短语和索引三个条件
+func parseQuery(q string) []clause {
+	var clauses []clause
+	add := func(text string) {
+		if tokens := tokenize(text, true); len(tokens) > 0 {
+			clauses = append(clauses, tokens)
+		}
+	}
+
+	for i, part := range strings.Split(q, `"`) {
+		// 奇数段位于引号内
+		if i%2 == 1 {
+			add(part)
+			continue
+		}
+		for _, word := range strings.Fields(part) {
+			add(word)
+		}
+	}
+	return clauses
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lllllan02/chitchat/internal/mailer"
+	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/repository"
+	"github.com/lllllan02/chitchat/internal/utils"
+	"github.com/lllllan02/chitchat/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// 一次性令牌的用途和有效期
+const (
+	purposeVerifyEmail   = "verify_email"
+	purposeResetPassword = "reset_password"
+
+	verifyEmailTokenTTL   = 24 * time.Hour
+	resetPasswordTokenTTL = time.Hour
+)
+
+// AccountService 账号安全服务接口，负责邮箱验证和密码重置
+type AccountService interface {
+	SendVerificationEmail(ctx context.Context, user *model.User) error
+	VerifyEmail(ctx context.Context, token string) error
+	SendPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, newPassword string) error
+	IsEmailVerified(ctx context.Context, userID uint) (bool, error)
+	Wait()
+}
+
+// accountService 账号安全服务实现
+type accountService struct {
+	secret      string
+	baseURL     string
+	userRepo    repository.UserRepository
+	userService UserService
+	mailer      mailer.Mailer
+
+	// 后台发送中的邮件
+	sending sync.WaitGroup
+}
+
+// NewAccountService 创建账号安全服务
+func NewAccountService(jwtCfg utils.JWTConfig, mailCfg utils.MailConfig, userRepo repository.UserRepository, userService UserService, m mailer.Mailer) AccountService {
+	return &accountService{
+		secret:      jwtCfg.Secret,
+		baseURL:     strings.TrimRight(mailCfg.BaseURL, "/"),
+		userRepo:    userRepo,
+		userService: userService,
+		mailer:      m,
+	}
+}
+
+// SendVerificationEmail 发送邮箱验证邮件
+func (s *accountService) SendVerificationEmail(ctx context.Context, user *model.User) error {
+	if user.EmailVerified {
+		return utils.ErrEmailAlreadyVerified
+	}
+
+	// 邮箱变化后旧的验证链接失效
+	token, err := utils.GenerateActionToken(s.secret, purposeVerifyEmail, user.ID, utils.Fingerprint(user.Email), verifyEmailTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(&mailer.Message{
+		To:      user.Email,
+		Subject: "验证你的 chitchat 邮箱",
+		Body: fmt.Sprintf("%s，你好：\n\n请点击以下链接验证邮箱，链接 %d 小时内有效：\n\n%s\n\n如果这不是你的操作，请忽略本邮件。\n",
+			user.Username, int(verifyEmailTokenTTL.Hours()), s.link("/verify-email", token)),
+	})
+}
+
+// VerifyEmail 使用邮件中的令牌验证邮箱
+func (s *accountService) VerifyEmail(ctx context.Context, token string) error {
+	claims, err := utils.ParseActionToken(s.secret, purposeVerifyEmail, token)
+	if err != nil {
+		return utils.ErrInvalidToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return utils.ErrInvalidToken
+	} else if err != nil {
+		return err
+	}
+
+	// 已验证或邮箱已变化时令牌不再可用
+	if user.EmailVerified || claims.Fingerprint != utils.Fingerprint(user.Email) {
+		return utils.ErrInvalidToken
+	}
+
+	return s.userRepo.MarkEmailVerified(ctx, user.ID)
+}
+
+// SendPasswordReset 发送密码重置邮件，邮箱不存在时静默返回，避免泄露注册信息
+func (s *accountService) SendPasswordReset(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// 密码修改后旧的重置链接失效
+	token, err := utils.GenerateActionToken(s.secret, purposeResetPassword, user.ID, utils.Fingerprint(user.PasswordHash), resetPasswordTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	msg := &mailer.Message{
+		To:      user.Email,
+		Subject: "重置你的 chitchat 密码",
+		Body: fmt.Sprintf("%s，你好：\n\n请点击以下链接重置密码，链接 %d 分钟内有效：\n\n%s\n\n如果这不是你的操作，请忽略本邮件，你的密码不会被修改。\n",
+			user.Username, int(resetPasswordTokenTTL.Minutes()), s.link("/reset-password", token)),
+	}
+
+	// 在后台发送，响应时间不随邮箱是否注册而变化，发送失败只记录日志
+	log := logger.FromContext(ctx).With(logger.Uint("user_id", user.ID))
+	s.sending.Add(1)
+	go func() {
+		defer s.sending.Done()
+		if err := s.mailer.Send(msg); err != nil {
+			log.Error("发送密码重置邮件失败", logger.Err(err))
+		}
+	}()
+	return nil
+}
+
+// Wait 等待后台发送的邮件全部完成，关闭服务前调用
+func (s *accountService) Wait() {
+	s.sending.Wait()
+}
+
+// ResetPassword 使用邮件中的令牌重置密码，并注销全部会话
+func (s *accountService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	claims, err := utils.ParseActionToken(s.secret, purposeResetPassword, token)
+	if err != nil {
+		return utils.ErrInvalidToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return utils.ErrInvalidToken
+	} else if err != nil {
+		return err
+	}
+
+	// 令牌签发后密码已修改，说明令牌已被使用过
+	if claims.Fingerprint != utils.Fingerprint(user.PasswordHash) {
+		return utils.ErrInvalidToken
+	}
+
+	passwordHash, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	if err := s.userService.ChangePassword(ctx, user.ID, passwordHash); err != nil {
+		return err
+	}
+
+	// 能收到重置邮件说明邮箱属于该用户
+	if !user.EmailVerified {
+		return s.userRepo.MarkEmailVerified(ctx, user.ID)
+	}
+	return nil
+}
+
+// IsEmailVerified 检查用户邮箱是否已验证
+func (s *accountService) IsEmailVerified(ctx context.Context, userID uint) (bool, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return user.EmailVerified, nil
+}
+
+// link 生成邮件中的前端链接
+func (s *accountService) link(path, token string) string {
+	return s.baseURL + path + "?token=" + url.QueryEscape(token)
+}
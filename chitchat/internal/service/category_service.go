@@ -1,17 +1,22 @@
 package service
 
 import (
+	"context"
+	"errors"
+
 	"github.com/lllllan02/chitchat/internal/model"
 	"github.com/lllllan02/chitchat/internal/repository"
+	"github.com/lllllan02/chitchat/internal/utils"
+	"gorm.io/gorm"
 )
 
 // CategoryService 分类服务接口
 type CategoryService interface {
-	CreateCategory(name, description string) (*model.Category, error)
-	GetCategoryByID(id uint) (*model.Category, error)
-	UpdateCategory(category *model.Category) error
-	DeleteCategory(id uint) error
-	ListCategories() ([]*model.Category, error)
+	CreateCategory(ctx context.Context, name, description string) (*model.Category, error)
+	GetCategoryByID(ctx context.Context, id uint) (*model.Category, error)
+	UpdateCategory(ctx context.Context, category *model.Category) error
+	DeleteCategory(ctx context.Context, id uint) error
+	ListCategories(ctx context.Context) ([]*model.Category, error)
 }
 
 // categoryService 分类服务实现
@@ -20,20 +25,20 @@ type categoryService struct {
 }
 
 // NewCategoryService 创建分类服务
-func NewCategoryService() CategoryService {
+func NewCategoryService(categoryRepo repository.CategoryRepository) CategoryService {
 	return &categoryService{
-		categoryRepo: repository.NewCategoryRepository(),
+		categoryRepo: categoryRepo,
 	}
 }
 
 // CreateCategory 创建分类
-func (s *categoryService) CreateCategory(name, description string) (*model.Category, error) {
+func (s *categoryService) CreateCategory(ctx context.Context, name, description string) (*model.Category, error) {
 	category := &model.Category{
 		Name:        name,
 		Description: description,
 	}
 
-	err := s.categoryRepo.Create(category)
+	err := s.categoryRepo.Create(ctx, category)
 	if err != nil {
 		return nil, err
 	}
@@ -42,21 +47,28 @@ func (s *categoryService) CreateCategory(name, description string) (*model.Categ
 }
 
 // GetCategoryByID 根据ID获取分类
-func (s *categoryService) GetCategoryByID(id uint) (*model.Category, error) {
-	return s.categoryRepo.GetByID(id)
+func (s *categoryService) GetCategoryByID(ctx context.Context, id uint) (*model.Category, error) {
+	category, err := s.categoryRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrCategoryNotFound
+		}
+		return nil, err
+	}
+	return category, nil
 }
 
 // UpdateCategory 更新分类
-func (s *categoryService) UpdateCategory(category *model.Category) error {
-	return s.categoryRepo.Update(category)
+func (s *categoryService) UpdateCategory(ctx context.Context, category *model.Category) error {
+	return s.categoryRepo.Update(ctx, category)
 }
 
 // DeleteCategory 删除分类
-func (s *categoryService) DeleteCategory(id uint) error {
-	return s.categoryRepo.Delete(id)
+func (s *categoryService) DeleteCategory(ctx context.Context, id uint) error {
+	return s.categoryRepo.Delete(ctx, id)
 }
 
 // ListCategories 获取分类列表
-func (s *categoryService) ListCategories() ([]*model.Category, error) {
-	return s.categoryRepo.List()
+func (s *categoryService) ListCategories(ctx context.Context) ([]*model.Category, error) {
+	return s.categoryRepo.List(ctx)
 }
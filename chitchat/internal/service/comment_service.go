@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lllllan02/chitchat/internal/event"
+	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/pagination"
+	"github.com/lllllan02/chitchat/internal/repository"
+	"github.com/lllllan02/chitchat/internal/utils"
+	"gorm.io/gorm"
+)
+
+// CommentService 评论服务接口
+type CommentService interface {
+	CreateComment(ctx context.Context, userID, postID uint, parentID *uint, content string) (*model.Comment, error)
+	GetCommentByID(ctx context.Context, id uint) (*model.Comment, error)
+	UpdateComment(ctx context.Context, id, userID uint, content string, canModerate bool) (*model.Comment, error)
+	DeleteComment(ctx context.Context, id, userID uint, canModerate bool) error
+	ListPostComments(ctx context.Context, postID uint, req *pagination.Request) ([]*model.Comment, *pagination.Page, error)
+}
+
+// commentService 评论服务实现
+type commentService struct {
+	commentRepo    repository.CommentRepository
+	postRepo       repository.PostRepository
+	mentionService MentionService
+	bus            *event.Bus
+}
+
+// NewCommentService 创建评论服务
+func NewCommentService(commentRepo repository.CommentRepository, postRepo repository.PostRepository, mentionService MentionService, bus *event.Bus) CommentService {
+	return &commentService{
+		commentRepo:    commentRepo,
+		postRepo:       postRepo,
+		mentionService: mentionService,
+		bus:            bus,
+	}
+}
+
+// CreateComment 创建评论
+func (s *commentService) CreateComment(ctx context.Context, userID, postID uint, parentID *uint, content string) (*model.Comment, error) {
+	// 检查帖子是否存在
+	if _, err := s.postRepo.GetByID(ctx, postID, false); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrPostNotFound
+		}
+		return nil, err
+	}
+
+	// 检查父评论是否属于同一帖子
+	if parentID != nil {
+		parent, err := s.commentRepo.GetByID(ctx, *parentID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, utils.ErrInvalidParentComment
+			}
+			return nil, err
+		}
+		if parent.PostID != postID {
+			return nil, utils.ErrInvalidParentComment
+		}
+	}
+
+	comment := &model.Comment{
+		UserID:    userID,
+		PostID:    postID,
+		ParentID:  parentID,
+		Content:   content,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	if err := s.commentRepo.Create(ctx, comment); err != nil {
+		return nil, err
+	}
+
+	// 解析并保存评论中的提及
+	mentions, err := s.mentionService.SyncCommentMentions(ctx, userID, postID, comment.ID, content)
+	if err != nil {
+		return nil, err
+	}
+
+	// 重新加载以包含作者信息
+	comment, err = s.commentRepo.GetByID(ctx, comment.ID)
+	if err != nil {
+		return nil, err
+	}
+	comment.Mentions = mentions
+
+	s.bus.Publish(ctx, event.Event{
+		Type:      event.CommentCreated,
+		ActorID:   userID,
+		PostID:    postID,
+		CommentID: comment.ID,
+		Payload:   comment,
+	})
+
+	return comment, nil
+}
+
+// GetCommentByID 根据ID获取评论
+func (s *commentService) GetCommentByID(ctx context.Context, id uint) (*model.Comment, error) {
+	comment, err := s.commentRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrCommentNotFound
+		}
+		return nil, err
+	}
+	return comment, nil
+}
+
+// UpdateComment 更新评论
+func (s *commentService) UpdateComment(ctx context.Context, id, userID uint, content string, canModerate bool) (*model.Comment, error) {
+	// 获取原始评论
+	comment, err := s.GetCommentByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// 检查权限
+	if comment.UserID != userID && !canModerate {
+		return nil, utils.ErrPermissionDenied
+	}
+
+	comment.Content = content
+	comment.UpdatedAt = time.Now()
+
+	if err := s.commentRepo.Update(ctx, comment); err != nil {
+		return nil, err
+	}
+
+	// 重新解析提及，只通知新增的用户
+	// 版主编辑他人的评论时，提及和事件的触发者仍是评论作者
+	comment.Mentions, err = s.mentionService.SyncCommentMentions(ctx, comment.UserID, comment.PostID, comment.ID, content)
+	if err != nil {
+		return nil, err
+	}
+
+	s.bus.Publish(ctx, event.Event{Type: event.CommentUpdated, ActorID: comment.UserID, PostID: comment.PostID, CommentID: comment.ID})
+
+	return comment, nil
+}
+
+// DeleteComment 删除评论
+func (s *commentService) DeleteComment(ctx context.Context, id, userID uint, canModerate bool) error {
+	// 获取原始评论
+	comment, err := s.GetCommentByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// 检查权限
+	if comment.UserID != userID && !canModerate {
+		return utils.ErrPermissionDenied
+	}
+
+	if err := s.commentRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.bus.Publish(ctx, event.Event{Type: event.CommentDeleted, ActorID: userID, PostID: comment.PostID, CommentID: id})
+	return nil
+}
+
+// ListPostComments 获取帖子的评论列表
+func (s *commentService) ListPostComments(ctx context.Context, postID uint, req *pagination.Request) ([]*model.Comment, *pagination.Page, error) {
+	// 检查帖子是否存在
+	if _, err := s.postRepo.GetByID(ctx, postID, false); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, utils.ErrPostNotFound
+		}
+		return nil, nil, err
+	}
+
+	return s.commentRepo.GetByPostID(ctx, postID, req)
+}
+
+// flattenComments 按先序展开评论树，返回评论及其所有嵌套回复
+func flattenComments(comments []*model.Comment) []*model.Comment {
+	var all []*model.Comment
+	var walk func(c *model.Comment)
+	walk = func(c *model.Comment) {
+		all = append(all, c)
+		for i := range c.Replies {
+			walk(&c.Replies[i])
+		}
+	}
+	for _, c := range comments {
+		walk(c)
+	}
+	return all
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lllllan02/chitchat/internal/event"
+	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/pagination"
+	"github.com/lllllan02/chitchat/internal/repository"
+	"github.com/lllllan02/chitchat/internal/utils"
+	"gorm.io/gorm"
+)
+
+// FollowService 关注服务接口
+type FollowService interface {
+	Follow(ctx context.Context, followerID, followedID uint) error
+	Unfollow(ctx context.Context, followerID, followedID uint) error
+	IsFollowing(ctx context.Context, followerID, followedID uint) (bool, error)
+	ListFollowers(ctx context.Context, userID uint, req *pagination.Request) ([]*model.User, *pagination.Page, error)
+	ListFollowing(ctx context.Context, userID uint, req *pagination.Request) ([]*model.User, *pagination.Page, error)
+	ListMutualFollowers(ctx context.Context, viewerID, userID uint, req *pagination.Request) ([]*model.User, *pagination.Page, error)
+	FillFollowCounts(ctx context.Context, users []*model.User) error
+}
+
+// followService 关注服务实现
+type followService struct {
+	followRepo repository.FollowRepository
+	userRepo   repository.UserRepository
+	bus        *event.Bus
+}
+
+// NewFollowService 创建关注服务
+func NewFollowService(followRepo repository.FollowRepository, userRepo repository.UserRepository, bus *event.Bus) FollowService {
+	return &followService{
+		followRepo: followRepo,
+		userRepo:   userRepo,
+		bus:        bus,
+	}
+}
+
+// Follow 关注用户，重复关注不会产生新的关注关系
+func (s *followService) Follow(ctx context.Context, followerID, followedID uint) error {
+	// 不能关注自己
+	if followerID == followedID {
+		return utils.ErrCannotFollowSelf
+	}
+
+	// 检查目标用户是否存在
+	if err := s.checkUser(ctx, followedID); err != nil {
+		return err
+	}
+
+	created, err := s.followRepo.Create(ctx, followerID, followedID)
+	if err != nil {
+		return err
+	}
+	if created {
+		s.bus.Publish(ctx, event.Event{Type: event.UserFollowed, ActorID: followerID, UserID: followedID})
+	}
+
+	return nil
+}
+
+// Unfollow 取消关注用户
+func (s *followService) Unfollow(ctx context.Context, followerID, followedID uint) error {
+	if followerID == followedID {
+		return utils.ErrCannotFollowSelf
+	}
+
+	_, err := s.followRepo.Delete(ctx, followerID, followedID)
+	return err
+}
+
+// IsFollowing 检查是否已关注
+func (s *followService) IsFollowing(ctx context.Context, followerID, followedID uint) (bool, error) {
+	return s.followRepo.Exists(ctx, followerID, followedID)
+}
+
+// ListFollowers 获取用户的粉丝列表
+func (s *followService) ListFollowers(ctx context.Context, userID uint, req *pagination.Request) ([]*model.User, *pagination.Page, error) {
+	if err := s.checkUser(ctx, userID); err != nil {
+		return nil, nil, err
+	}
+
+	users, page, err := s.followRepo.GetFollowers(ctx, userID, req)
+	return s.withCounts(ctx, users, page, err)
+}
+
+// ListFollowing 获取用户的关注列表
+func (s *followService) ListFollowing(ctx context.Context, userID uint, req *pagination.Request) ([]*model.User, *pagination.Page, error) {
+	if err := s.checkUser(ctx, userID); err != nil {
+		return nil, nil, err
+	}
+
+	users, page, err := s.followRepo.GetFollowing(ctx, userID, req)
+	return s.withCounts(ctx, users, page, err)
+}
+
+// ListMutualFollowers 获取当前用户关注的人中也关注了目标用户的人
+func (s *followService) ListMutualFollowers(ctx context.Context, viewerID, userID uint, req *pagination.Request) ([]*model.User, *pagination.Page, error) {
+	if err := s.checkUser(ctx, userID); err != nil {
+		return nil, nil, err
+	}
+
+	users, page, err := s.followRepo.GetMutualFollowers(ctx, viewerID, userID, req)
+	return s.withCounts(ctx, users, page, err)
+}
+
+// FillFollowCounts 为用户填充粉丝数和关注数
+func (s *followService) FillFollowCounts(ctx context.Context, users []*model.User) error {
+	if len(users) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+
+	followers, err := s.followRepo.CountFollowers(ctx, ids)
+	if err != nil {
+		return err
+	}
+	following, err := s.followRepo.CountFollowing(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		user.FollowerCount = followers[user.ID]
+		user.FollowingCount = following[user.ID]
+	}
+	return nil
+}
+
+// checkUser 检查用户是否存在
+func (s *followService) checkUser(ctx context.Context, id uint) error {
+	if _, err := s.userRepo.GetByID(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.ErrUserNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// withCounts 为查询结果填充关注统计
+func (s *followService) withCounts(ctx context.Context, users []*model.User, page *pagination.Page, err error) ([]*model.User, *pagination.Page, error) {
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.FillFollowCounts(ctx, users); err != nil {
+		return nil, nil, err
+	}
+	return users, page, nil
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lllllan02/chitchat/internal/event"
+	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/repository"
+	"github.com/lllllan02/chitchat/internal/utils"
+	"gorm.io/gorm"
+)
+
+// LikeService 点赞服务接口
+type LikeService interface {
+	LikePost(ctx context.Context, userID, postID uint) (*model.Post, error)
+	UnlikePost(ctx context.Context, userID, postID uint) (*model.Post, error)
+	LikeComment(ctx context.Context, userID, commentID uint) (*model.Comment, error)
+	UnlikeComment(ctx context.Context, userID, commentID uint) (*model.Comment, error)
+	MarkLikedPosts(ctx context.Context, userID uint, posts []*model.Post) error
+	MarkLikedComments(ctx context.Context, userID uint, comments []*model.Comment) error
+}
+
+// likeService 点赞服务实现
+type likeService struct {
+	likeRepo    repository.LikeRepository
+	postRepo    repository.PostRepository
+	commentRepo repository.CommentRepository
+	bus         *event.Bus
+}
+
+// NewLikeService 创建点赞服务
+func NewLikeService(likeRepo repository.LikeRepository, postRepo repository.PostRepository, commentRepo repository.CommentRepository, bus *event.Bus) LikeService {
+	return &likeService{
+		likeRepo:    likeRepo,
+		postRepo:    postRepo,
+		commentRepo: commentRepo,
+		bus:         bus,
+	}
+}
+
+// LikePost 点赞帖子，重复点赞不会重复计数
+func (s *likeService) LikePost(ctx context.Context, userID, postID uint) (*model.Post, error) {
+	if _, err := s.getPost(ctx, postID); err != nil {
+		return nil, err
+	}
+
+	created, err := s.likeRepo.LikePost(ctx, userID, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	post, err := s.likedPost(ctx, postID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if created {
+		s.bus.Publish(ctx, event.Event{Type: event.PostLiked, ActorID: userID, PostID: postID})
+		s.publishLikeCount(ctx, userID, post.ID, 0, post.LikeCount)
+	}
+
+	return post, nil
+}
+
+// UnlikePost 取消点赞帖子
+func (s *likeService) UnlikePost(ctx context.Context, userID, postID uint) (*model.Post, error) {
+	if _, err := s.getPost(ctx, postID); err != nil {
+		return nil, err
+	}
+
+	deleted, err := s.likeRepo.UnlikePost(ctx, userID, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	post, err := s.likedPost(ctx, postID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if deleted {
+		s.publishLikeCount(ctx, userID, post.ID, 0, post.LikeCount)
+	}
+
+	return post, nil
+}
+
+// LikeComment 点赞评论，重复点赞不会重复计数
+func (s *likeService) LikeComment(ctx context.Context, userID, commentID uint) (*model.Comment, error) {
+	if _, err := s.getComment(ctx, commentID); err != nil {
+		return nil, err
+	}
+
+	created, err := s.likeRepo.LikeComment(ctx, userID, commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	comment, err := s.likedComment(ctx, commentID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if created {
+		s.bus.Publish(ctx, event.Event{Type: event.CommentLiked, ActorID: userID, CommentID: commentID})
+		s.publishLikeCount(ctx, userID, comment.PostID, comment.ID, comment.LikeCount)
+	}
+
+	return comment, nil
+}
+
+// UnlikeComment 取消点赞评论
+func (s *likeService) UnlikeComment(ctx context.Context, userID, commentID uint) (*model.Comment, error) {
+	if _, err := s.getComment(ctx, commentID); err != nil {
+		return nil, err
+	}
+
+	deleted, err := s.likeRepo.UnlikeComment(ctx, userID, commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	comment, err := s.likedComment(ctx, commentID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if deleted {
+		s.publishLikeCount(ctx, userID, comment.PostID, comment.ID, comment.LikeCount)
+	}
+
+	return comment, nil
+}
+
+// MarkLikedPosts 为帖子列表填充当前用户的点赞状态
+func (s *likeService) MarkLikedPosts(ctx context.Context, userID uint, posts []*model.Post) error {
+	if userID == 0 || len(posts) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.ID)
+	}
+
+	liked, err := s.likeRepo.GetLikedPostIDs(ctx, userID, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, post := range posts {
+		post.LikedByMe = liked[post.ID]
+	}
+	return nil
+}
+
+// MarkLikedComments 为评论及其嵌套回复填充当前用户的点赞状态
+func (s *likeService) MarkLikedComments(ctx context.Context, userID uint, comments []*model.Comment) error {
+	if userID == 0 || len(comments) == 0 {
+		return nil
+	}
+
+	all := flattenComments(comments)
+	ids := make([]uint, 0, len(all))
+	for _, c := range all {
+		ids = append(ids, c.ID)
+	}
+
+	liked, err := s.likeRepo.GetLikedCommentIDs(ctx, userID, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range all {
+		c.LikedByMe = liked[c.ID]
+	}
+	return nil
+}
+
+// publishLikeCount 发布点赞数变化事件，commentID 为0时表示帖子本身
+func (s *likeService) publishLikeCount(ctx context.Context, actorID, postID, commentID uint, likeCount int) {
+	s.bus.Publish(ctx, event.Event{
+		Type:      event.LikeCountChanged,
+		ActorID:   actorID,
+		PostID:    postID,
+		CommentID: commentID,
+		Payload:   likeCount,
+	})
+}
+
+// getPost 获取帖子，不存在时返回 ErrPostNotFound
+func (s *likeService) getPost(ctx context.Context, id uint) (*model.Post, error) {
+	post, err := s.postRepo.GetByID(ctx, id, false)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrPostNotFound
+		}
+		return nil, err
+	}
+	return post, nil
+}
+
+// getComment 获取评论，不存在时返回 ErrCommentNotFound
+func (s *likeService) getComment(ctx context.Context, id uint) (*model.Comment, error) {
+	comment, err := s.commentRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrCommentNotFound
+		}
+		return nil, err
+	}
+	return comment, nil
+}
+
+// likedPost 重新读取帖子的最新点赞数
+func (s *likeService) likedPost(ctx context.Context, id uint, liked bool) (*model.Post, error) {
+	post, err := s.getPost(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	post.LikedByMe = liked
+	return post, nil
+}
+
+// likedComment 重新读取评论的最新点赞数
+func (s *likeService) likedComment(ctx context.Context, id uint, liked bool) (*model.Comment, error) {
+	comment, err := s.getComment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	comment.LikedByMe = liked
+	return comment, nil
+}
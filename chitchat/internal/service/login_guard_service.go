@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/lllllan02/chitchat/internal/event"
+	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/repository"
+	"github.com/lllllan02/chitchat/internal/utils"
+	"gorm.io/gorm"
+)
+
+// 登录失败记录的键前缀
+const (
+	attemptKeyUser      = "user:"
+	attemptKeyIP        = "ip:"
+	attemptKeyTwoFactor = "2fa:"
+	// 键的最大长度，与数据库字段一致
+	maxAttemptKeyLength = 191
+)
+
+// LoginGuardService 登录防暴力破解服务接口
+// 按用户名和客户端 IP 分别记录连续失败次数，失败次数过多时要求等待，同一账号失败过多时临时锁定
+// 两步验证的验证码错误按用户单独计数，失败过多时锁定两步验证登录
+type LoginGuardService interface {
+	Check(ctx context.Context, username, ip string) error
+	RecordFailure(ctx context.Context, username, ip string) error
+	RecordSuccess(ctx context.Context, username string) error
+	CheckTwoFactor(ctx context.Context, userID uint) error
+	RecordTwoFactorFailure(ctx context.Context, userID uint) error
+	RecordTwoFactorSuccess(ctx context.Context, userID uint) error
+	Unlock(ctx context.Context, userID uint) error
+}
+
+// loginGuardService 登录防暴力破解服务实现
+type loginGuardService struct {
+	cfg         utils.LockoutConfig
+	attemptRepo repository.LoginAttemptRepository
+	userRepo    repository.UserRepository
+	bus         *event.Bus
+}
+
+// NewLoginGuardService 创建登录防暴力破解服务
+func NewLoginGuardService(cfg utils.LockoutConfig, attemptRepo repository.LoginAttemptRepository, userRepo repository.UserRepository, bus *event.Bus) LoginGuardService {
+	return &loginGuardService{
+		cfg:         cfg,
+		attemptRepo: attemptRepo,
+		userRepo:    userRepo,
+		bus:         bus,
+	}
+}
+
+// Check 校验密码前调用，账号锁定或需要等待时返回 *utils.RetryAfterError
+func (s *loginGuardService) Check(ctx context.Context, username, ip string) error {
+	now := time.Now()
+
+	attempt, err := s.attemptRepo.Get(ctx, attemptKey(attemptKeyUser, username))
+	if err != nil {
+		return err
+	}
+	if attempt != nil {
+		if attempt.LockedUntil != nil && now.Before(*attempt.LockedUntil) {
+			return &utils.RetryAfterError{Err: utils.ErrAccountLocked, RetryAfter: attempt.LockedUntil.Sub(now)}
+		}
+		if wait := s.wait(attempt, s.cfg.DelayThreshold(), now); wait > 0 {
+			return &utils.RetryAfterError{Err: utils.ErrTooManyAttempts, RetryAfter: wait}
+		}
+	}
+
+	attempt, err = s.attemptRepo.Get(ctx, attemptKey(attemptKeyIP, ip))
+	if err != nil {
+		return err
+	}
+	if attempt != nil {
+		if wait := s.wait(attempt, s.cfg.IPDelayThreshold(), now); wait > 0 {
+			return &utils.RetryAfterError{Err: utils.ErrTooManyAttempts, RetryAfter: wait}
+		}
+	}
+
+	return nil
+}
+
+// RecordFailure 记录一次失败的登录，本次失败导致账号锁定时返回 *utils.RetryAfterError
+func (s *loginGuardService) RecordFailure(ctx context.Context, username, ip string) error {
+	now := time.Now()
+
+	if _, err := s.increment(ctx, attemptKey(attemptKeyIP, ip), now); err != nil {
+		return err
+	}
+
+	attempt, err := s.increment(ctx, attemptKey(attemptKeyUser, username), now)
+	if err != nil {
+		return err
+	}
+
+	// 顺带清理长时间没有失败的记录
+	if err := s.attemptRepo.DeleteStale(ctx, now.Add(-s.cfg.ResetTTL())); err != nil {
+		return err
+	}
+
+	if attempt.Failures < s.cfg.LockThreshold() {
+		return nil
+	}
+
+	// 用户名存在时通知账号所有者
+	return s.lock(ctx, attempt, now, func() (uint, error) {
+		user, err := s.userRepo.GetByUsername(ctx, username)
+		if err != nil {
+			return 0, err
+		}
+		return user.ID, nil
+	})
+}
+
+// RecordSuccess 登录成功后清除该账号的失败记录
+// IP 的失败记录保留，避免攻击者用自己的账号登录来重置计数
+func (s *loginGuardService) RecordSuccess(ctx context.Context, username string) error {
+	return s.attemptRepo.Delete(ctx, attemptKey(attemptKeyUser, username))
+}
+
+// CheckTwoFactor 校验两步验证码前调用，两步验证登录被锁定时返回 *utils.RetryAfterError
+func (s *loginGuardService) CheckTwoFactor(ctx context.Context, userID uint) error {
+	attempt, err := s.attemptRepo.Get(ctx, twoFactorAttemptKey(userID))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if attempt != nil && attempt.LockedUntil != nil && now.Before(*attempt.LockedUntil) {
+		return &utils.RetryAfterError{Err: utils.ErrAccountLocked, RetryAfter: attempt.LockedUntil.Sub(now)}
+	}
+	return nil
+}
+
+// RecordTwoFactorFailure 记录一次错误的两步验证码，本次失败导致锁定时返回 *utils.RetryAfterError
+// 计数不随密码登录成功而清除，重新登录获取新的登录挑战不能绕过限制
+func (s *loginGuardService) RecordTwoFactorFailure(ctx context.Context, userID uint) error {
+	now := time.Now()
+
+	attempt, err := s.increment(ctx, twoFactorAttemptKey(userID), now)
+	if err != nil {
+		return err
+	}
+	if attempt.Failures < s.cfg.TwoFactorLockThreshold() {
+		return nil
+	}
+
+	// 能走到这一步说明密码已泄露，通知账号所有者
+	return s.lock(ctx, attempt, now, func() (uint, error) {
+		return userID, nil
+	})
+}
+
+// RecordTwoFactorSuccess 两步验证通过后清除失败记录
+func (s *loginGuardService) RecordTwoFactorSuccess(ctx context.Context, userID uint) error {
+	return s.attemptRepo.Delete(ctx, twoFactorAttemptKey(userID))
+}
+
+// Unlock 解除账号锁定并清除失败记录
+func (s *loginGuardService) Unlock(ctx context.Context, userID uint) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return utils.ErrUserNotFound
+	} else if err != nil {
+		return err
+	}
+	if err := s.attemptRepo.Delete(ctx, twoFactorAttemptKey(user.ID)); err != nil {
+		return err
+	}
+	return s.attemptRepo.Delete(ctx, attemptKey(attemptKeyUser, user.Username))
+}
+
+// increment 原子地增加失败次数，距上次失败超过重置时间或锁定已过期时重新计数
+func (s *loginGuardService) increment(ctx context.Context, key string, now time.Time) (*model.LoginAttempt, error) {
+	return s.attemptRepo.Increment(ctx, key, now, now.Add(-s.cfg.ResetTTL()))
+}
+
+// lock 锁定失败记录并返回 *utils.RetryAfterError
+// 并发的失败请求中只有一个负责锁定和通知，owner 返回需要通知的用户，用户不存在时不通知
+func (s *loginGuardService) lock(ctx context.Context, attempt *model.LoginAttempt, now time.Time, owner func() (uint, error)) error {
+	lockedUntil := now.Add(s.cfg.LockTTL())
+	locked, err := s.attemptRepo.Lock(ctx, attempt.Key, now, lockedUntil)
+	if err != nil {
+		return err
+	}
+	if !locked {
+		retryAfter := s.cfg.LockTTL()
+		if attempt.LockedUntil != nil && attempt.LockedUntil.After(now) {
+			retryAfter = attempt.LockedUntil.Sub(now)
+		}
+		return &utils.RetryAfterError{Err: utils.ErrAccountLocked, RetryAfter: retryAfter}
+	}
+
+	userID, err := owner()
+	if err == nil {
+		s.bus.Publish(ctx, event.Event{
+			Type:    event.AccountLocked,
+			UserID:  userID,
+			Payload: lockedUntil,
+		})
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	return &utils.RetryAfterError{Err: utils.ErrAccountLocked, RetryAfter: s.cfg.LockTTL()}
+}
+
+// wait 距离允许下一次尝试还需等待的时间
+func (s *loginGuardService) wait(attempt *model.LoginAttempt, threshold int, now time.Time) time.Duration {
+	if now.Sub(attempt.LastFailedAt) > s.cfg.ResetTTL() {
+		return 0
+	}
+	return attempt.LastFailedAt.Add(s.cfg.Delay(attempt.Failures, threshold)).Sub(now)
+}
+
+// twoFactorAttemptKey 两步验证失败记录的键
+func twoFactorAttemptKey(userID uint) string {
+	return attemptKeyTwoFactor + strconv.FormatUint(uint64(userID), 10)
+}
+
+// attemptKey 生成登录失败记录的键
+func attemptKey(prefix, value string) string {
+	key := prefix + value
+	if len(key) > maxAttemptKeyLength {
+		key = key[:maxAttemptKeyLength]
+	}
+	return key
+}
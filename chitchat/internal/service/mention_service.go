@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"github.com/lllllan02/chitchat/internal/event"
+	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/repository"
+	"github.com/lllllan02/chitchat/internal/utils"
+)
+
+// 单条内容最多解析的提及数量
+const maxMentionsPerContent = 20
+
+// MentionService 提及服务接口
+type MentionService interface {
+	SyncPostMentions(ctx context.Context, actorID, postID uint, content string) ([]model.MentionedUser, error)
+	SyncCommentMentions(ctx context.Context, actorID, postID, commentID uint, content string) ([]model.MentionedUser, error)
+	FillPostMentions(ctx context.Context, posts []*model.Post) error
+	FillCommentMentions(ctx context.Context, comments []*model.Comment) error
+}
+
+// mentionService 提及服务实现
+type mentionService struct {
+	mentionRepo repository.MentionRepository
+	userRepo    repository.UserRepository
+	bus         *event.Bus
+}
+
+// NewMentionService 创建提及服务
+func NewMentionService(mentionRepo repository.MentionRepository, userRepo repository.UserRepository, bus *event.Bus) MentionService {
+	return &mentionService{
+		mentionRepo: mentionRepo,
+		userRepo:    userRepo,
+		bus:         bus,
+	}
+}
+
+// SyncPostMentions 解析帖子正文中的提及并保存，只为新增的提及发送通知
+func (s *mentionService) SyncPostMentions(ctx context.Context, actorID, postID uint, content string) ([]model.MentionedUser, error) {
+	return s.sync(ctx, actorID, postID, nil, content)
+}
+
+// SyncCommentMentions 解析评论中的提及并保存，只为新增的提及发送通知
+func (s *mentionService) SyncCommentMentions(ctx context.Context, actorID, postID, commentID uint, content string) ([]model.MentionedUser, error) {
+	return s.sync(ctx, actorID, postID, &commentID, content)
+}
+
+// FillPostMentions 为帖子填充正文中提及的用户
+func (s *mentionService) FillPostMentions(ctx context.Context, posts []*model.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.ID)
+	}
+
+	mentions, err := s.mentionRepo.GetByPostIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	byPost := make(map[uint][]model.MentionedUser)
+	for _, m := range mentions {
+		byPost[m.PostID] = append(byPost[m.PostID], model.MentionedUser{ID: m.User.ID, Username: m.User.Username})
+	}
+
+	for _, post := range posts {
+		post.Mentions = byPost[post.ID]
+	}
+	return nil
+}
+
+// FillCommentMentions 为评论及其嵌套回复填充提及的用户
+func (s *mentionService) FillCommentMentions(ctx context.Context, comments []*model.Comment) error {
+	if len(comments) == 0 {
+		return nil
+	}
+
+	all := flattenComments(comments)
+	ids := make([]uint, 0, len(all))
+	for _, c := range all {
+		ids = append(ids, c.ID)
+	}
+
+	mentions, err := s.mentionRepo.GetByCommentIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	byComment := make(map[uint][]model.MentionedUser)
+	for _, m := range mentions {
+		byComment[*m.CommentID] = append(byComment[*m.CommentID], model.MentionedUser{ID: m.User.ID, Username: m.User.Username})
+	}
+
+	for _, c := range all {
+		c.Mentions = byComment[c.ID]
+	}
+	return nil
+}
+
+// sync 保存内容中的提及关系并为新增提及发布事件
+func (s *mentionService) sync(ctx context.Context, actorID, postID uint, commentID *uint, content string) ([]model.MentionedUser, error) {
+	usernames := utils.ParseMentions(content)
+	if len(usernames) > maxMentionsPerContent {
+		usernames = usernames[:maxMentionsPerContent]
+	}
+
+	// 解析用户名，忽略不存在的用户
+	users, err := s.userRepo.GetByUsernames(ctx, usernames)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*model.User, len(users))
+	for _, user := range users {
+		byName[user.Username] = user
+	}
+
+	mentioned := make([]model.MentionedUser, 0, len(users))
+	userIDs := make([]uint, 0, len(users))
+	for _, username := range usernames {
+		if user, ok := byName[username]; ok {
+			mentioned = append(mentioned, model.MentionedUser{ID: user.ID, Username: user.Username})
+			userIDs = append(userIDs, user.ID)
+		}
+	}
+
+	// 对比原有提及，找出新增的用户
+	previousIDs, err := s.mentionRepo.GetUserIDs(ctx, postID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	previous := make(map[uint]bool, len(previousIDs))
+	for _, id := range previousIDs {
+		previous[id] = true
+	}
+
+	if err := s.mentionRepo.Replace(ctx, postID, commentID, userIDs); err != nil {
+		return nil, err
+	}
+
+	for _, userID := range userIDs {
+		if previous[userID] {
+			continue
+		}
+
+		e := event.Event{Type: event.MentionDetected, ActorID: actorID, UserID: userID, PostID: postID}
+		if commentID != nil {
+			e.CommentID = *commentID
+		}
+		s.bus.Publish(ctx, e)
+	}
+
+	return mentioned, nil
+}
@@ -0,0 +1,326 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lllllan02/chitchat/internal/event"
+	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/pagination"
+	"github.com/lllllan02/chitchat/internal/repository"
+	"github.com/lllllan02/chitchat/internal/utils"
+	"github.com/lllllan02/chitchat/pkg/i18n"
+	"gorm.io/gorm"
+)
+
+// 同一对象的同类未读通知在同一时间窗口内合并为一条
+const notificationMergeWindow = 24 * time.Hour
+
+// NotificationService 通知服务接口
+type NotificationService interface {
+	Subscribe()
+	ListNotifications(ctx context.Context, userID uint, query *model.NotificationListQuery, req *pagination.Request) ([]*model.Notification, *pagination.Page, error)
+	MarkAsRead(ctx context.Context, id, userID uint) error
+	MarkAllAsRead(ctx context.Context, userID uint) error
+	CountUnread(ctx context.Context, userID uint) (int64, error)
+}
+
+// notificationService 通知服务实现
+type notificationService struct {
+	notificationRepo repository.NotificationRepository
+	userRepo         repository.UserRepository
+	postRepo         repository.PostRepository
+	commentRepo      repository.CommentRepository
+	bus              *event.Bus
+}
+
+// NewNotificationService 创建通知服务
+func NewNotificationService(notificationRepo repository.NotificationRepository, userRepo repository.UserRepository, postRepo repository.PostRepository, commentRepo repository.CommentRepository, bus *event.Bus) NotificationService {
+	return &notificationService{
+		notificationRepo: notificationRepo,
+		userRepo:         userRepo,
+		postRepo:         postRepo,
+		commentRepo:      commentRepo,
+		bus:              bus,
+	}
+}
+
+// Subscribe 订阅需要生成通知的事件
+func (s *notificationService) Subscribe() {
+	s.bus.Subscribe(event.CommentCreated, s.onCommentCreated)
+	s.bus.Subscribe(event.PostLiked, s.onPostLiked)
+	s.bus.Subscribe(event.CommentLiked, s.onCommentLiked)
+	s.bus.Subscribe(event.UserFollowed, s.onUserFollowed)
+	s.bus.Subscribe(event.MentionDetected, s.onMentionDetected)
+	s.bus.Subscribe(event.AccountLocked, s.onAccountLocked)
+}
+
+// ListNotifications 获取通知列表
+func (s *notificationService) ListNotifications(ctx context.Context, userID uint, query *model.NotificationListQuery, req *pagination.Request) ([]*model.Notification, *pagination.Page, error) {
+	return s.notificationRepo.List(ctx, userID, query, req)
+}
+
+// MarkAsRead 标记通知为已读
+func (s *notificationService) MarkAsRead(ctx context.Context, id, userID uint) error {
+	found, err := s.notificationRepo.MarkAsRead(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return utils.ErrNotificationNotFound
+	}
+	return nil
+}
+
+// MarkAllAsRead 标记所有通知为已读
+func (s *notificationService) MarkAllAsRead(ctx context.Context, userID uint) error {
+	return s.notificationRepo.MarkAllAsRead(ctx, userID)
+}
+
+// CountUnread 获取未读通知数
+func (s *notificationService) CountUnread(ctx context.Context, userID uint) (int64, error) {
+	return s.notificationRepo.CountUnread(ctx, userID)
+}
+
+// onCommentCreated 评论创建后通知帖子作者或被回复的评论作者
+func (s *notificationService) onCommentCreated(ctx context.Context, e event.Event) error {
+	comment, err := s.commentRepo.GetByID(ctx, e.CommentID)
+	if err != nil {
+		return err
+	}
+	post, err := s.postRepo.GetByID(ctx, comment.PostID, false)
+	if err != nil {
+		return err
+	}
+
+	recipientID := post.UserID
+	message := "%s 评论了你的帖子《%s》"
+	if comment.ParentID != nil {
+		parent, err := s.commentRepo.GetByID(ctx, *comment.ParentID)
+		if err != nil {
+			return err
+		}
+		recipientID = parent.UserID
+		message = "%s 回复了你在《%s》中的评论"
+	}
+
+	if recipientID == e.ActorID {
+		return nil
+	}
+
+	return s.create(ctx, &model.Notification{
+		UserID:    recipientID,
+		SenderID:  &e.ActorID,
+		Type:      model.NotificationTypeReply,
+		Content:   i18n.T(s.localeOf(ctx, recipientID), message, comment.User.Username, post.Title),
+		PostID:    &post.ID,
+		CommentID: &comment.ID,
+		Link:      commentLink(post.ID, comment.ID),
+	})
+}
+
+// onPostLiked 帖子被点赞后通知作者，短时间内的多次点赞合并为一条
+func (s *notificationService) onPostLiked(ctx context.Context, e event.Event) error {
+	post, err := s.postRepo.GetByID(ctx, e.PostID, false)
+	if err != nil {
+		return err
+	}
+
+	return s.notifyMerged(ctx, &model.Notification{
+		UserID: post.UserID,
+		Type:   model.NotificationTypeLike,
+		PostID: &post.ID,
+		Link:   fmt.Sprintf("/posts/%d", post.ID),
+	}, e.ActorID, func(locale i18n.Locale, actors string) string {
+		return i18n.T(locale, "%s 赞了你的帖子《%s》", actors, post.Title)
+	})
+}
+
+// onCommentLiked 评论被点赞后通知作者，短时间内的多次点赞合并为一条
+func (s *notificationService) onCommentLiked(ctx context.Context, e event.Event) error {
+	comment, err := s.commentRepo.GetByID(ctx, e.CommentID)
+	if err != nil {
+		return err
+	}
+
+	return s.notifyMerged(ctx, &model.Notification{
+		UserID:    comment.UserID,
+		Type:      model.NotificationTypeLike,
+		PostID:    &comment.PostID,
+		CommentID: &comment.ID,
+		Link:      commentLink(comment.PostID, comment.ID),
+	}, e.ActorID, func(locale i18n.Locale, actors string) string {
+		return i18n.T(locale, "%s 赞了你的评论", actors)
+	})
+}
+
+// onUserFollowed 被关注后通知用户，短时间内的多次关注合并为一条
+func (s *notificationService) onUserFollowed(ctx context.Context, e event.Event) error {
+	return s.notifyMerged(ctx, &model.Notification{
+		UserID: e.UserID,
+		Type:   model.NotificationTypeFollow,
+		Link:   fmt.Sprintf("/users/%d/followers", e.UserID),
+	}, e.ActorID, func(locale i18n.Locale, actors string) string {
+		return i18n.T(locale, "%s 关注了你", actors)
+	})
+}
+
+// onMentionDetected 被提及后通知用户
+func (s *notificationService) onMentionDetected(ctx context.Context, e event.Event) error {
+	if e.UserID == e.ActorID {
+		return nil
+	}
+
+	actor, err := s.userRepo.GetByID(ctx, e.ActorID)
+	if err != nil {
+		return err
+	}
+	post, err := s.postRepo.GetByID(ctx, e.PostID, false)
+	if err != nil {
+		return err
+	}
+
+	locale := s.localeOf(ctx, e.UserID)
+	notification := &model.Notification{
+		UserID:   e.UserID,
+		SenderID: &e.ActorID,
+		Type:     model.NotificationTypeMention,
+		Content:  i18n.T(locale, "%s 在帖子《%s》中提到了你", actor.Username, post.Title),
+		PostID:   &post.ID,
+		Link:     fmt.Sprintf("/posts/%d", post.ID),
+	}
+	if e.CommentID != 0 {
+		notification.CommentID = &e.CommentID
+		notification.Content = i18n.T(locale, "%s 在《%s》的评论中提到了你", actor.Username, post.Title)
+		notification.Link = commentLink(post.ID, e.CommentID)
+	}
+
+	return s.create(ctx, notification)
+}
+
+// notifyMerged 创建通知，若存在同一对象的同类未读通知则合并计数
+// content 按接收者的语言生成通知内容
+func (s *notificationService) notifyMerged(ctx context.Context, n *model.Notification, actorID uint, content func(locale i18n.Locale, actors string) string) error {
+	if n.UserID == actorID {
+		return nil
+	}
+
+	actor, err := s.userRepo.GetByID(ctx, actorID)
+	if err != nil {
+		return err
+	}
+
+	// 同一合并键只能有一条通知，并发触发时由唯一索引保证只创建一条，其余合并到这条通知
+	key := mergeKey(n, time.Now())
+	n.MergeKey = &key
+	n.SenderID = &actorID
+	n.Count = 1
+	n.Content = content(s.localeOf(ctx, n.UserID), actor.Username)
+
+	var existing *model.Notification
+	for attempt := 0; existing == nil; attempt++ {
+		created, err := s.notificationRepo.CreateMerged(ctx, n, actorID)
+		if err != nil {
+			return err
+		}
+		if created {
+			s.publish(ctx, n, actor)
+			return nil
+		}
+
+		// 合并键可能在两次查询之间因通知被标记已读而释放，此时重新创建一次
+		existing, err = s.notificationRepo.GetByMergeKey(ctx, key)
+		if err != nil && (attempt > 0 || !errors.Is(err, gorm.ErrRecordNotFound)) {
+			return err
+		}
+	}
+
+	// 按不同的触发者计数，同一用户重复触发（如取消后再次点赞）时不重复计数
+	added, err := s.notificationRepo.AddActor(ctx, existing.ID, actorID)
+	if err != nil || !added {
+		return err
+	}
+	count, err := s.notificationRepo.CountActors(ctx, existing.ID)
+	if err != nil {
+		return err
+	}
+
+	existing.Count = int(count)
+	existing.SenderID = &actorID
+	locale := s.localeOf(ctx, n.UserID)
+	existing.Content = content(locale, i18n.T(locale, "%[1]s 等 %[2]d 人", actor.Username, existing.Count, existing.Count-1))
+	existing.UpdatedAt = time.Now()
+	updated, err := s.notificationRepo.UpdateMerged(ctx, existing)
+	if err != nil || !updated {
+		return err
+	}
+
+	s.publish(ctx, existing, actor)
+	return nil
+}
+
+// onAccountLocked 账号因多次登录失败被锁定后通知账号所有者
+func (s *notificationService) onAccountLocked(ctx context.Context, e event.Event) error {
+	lockedUntil, _ := e.Payload.(time.Time)
+	return s.create(ctx, &model.Notification{
+		UserID:  e.UserID,
+		Type:    model.NotificationTypeSystem,
+		Content: i18n.T(s.localeOf(ctx, e.UserID), "你的账号因多次登录失败已被临时锁定至 %s，如果这不是你的操作，请尽快修改密码", lockedUntil.Format("2006-01-02 15:04")),
+		Link:    "/settings/security",
+	})
+}
+
+// localeOf 获取通知接收者的语言偏好，未设置或查询失败时使用默认语言
+func (s *notificationService) localeOf(ctx context.Context, userID uint) i18n.Locale {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return i18n.Default
+	}
+	if locale, ok := i18n.Parse(user.Locale); ok {
+		return locale
+	}
+	return i18n.Default
+}
+
+// create 保存新通知并发布通知创建事件
+func (s *notificationService) create(ctx context.Context, n *model.Notification) error {
+	if err := s.notificationRepo.Create(ctx, n); err != nil {
+		return err
+	}
+
+	var sender *model.User
+	if n.SenderID != nil {
+		sender, _ = s.userRepo.GetByID(ctx, *n.SenderID)
+	}
+	s.publish(ctx, n, sender)
+	return nil
+}
+
+// publish 发布通知事件，供实时推送使用
+func (s *notificationService) publish(ctx context.Context, n *model.Notification, sender *model.User) {
+	n.Sender = sender
+	s.bus.Publish(ctx, event.Event{
+		Type:    event.NotificationCreated,
+		UserID:  n.UserID,
+		Payload: n,
+	})
+}
+
+// mergeKey 生成可合并通知的合并键，同一接收者、类型、对象在同一时间窗口内的通知共用一个键
+func mergeKey(n *model.Notification, now time.Time) string {
+	var postID, commentID uint
+	if n.PostID != nil {
+		postID = *n.PostID
+	}
+	if n.CommentID != nil {
+		commentID = *n.CommentID
+	}
+	return fmt.Sprintf("%d:%s:%d:%d:%d", n.UserID, n.Type, postID, commentID, now.Truncate(notificationMergeWindow).Unix())
+}
+
+// commentLink 生成评论的链接
+func commentLink(postID, commentID uint) string {
+	return fmt.Sprintf("/posts/%d#comment-%d", postID, commentID)
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/lllllan02/chitchat/internal/event"
+	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/oauth"
+	"github.com/lllllan02/chitchat/internal/repository"
+	"github.com/lllllan02/chitchat/internal/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// 第三方登录请求的有效期
+	oauthStateTTL = 10 * time.Minute
+
+	// 自动创建账号时用户名的长度限制，留出追加数字后缀的空间
+	minUsernameLength = 3
+	maxUsernameBase   = 40
+	// 用户名冲突时最多尝试的后缀数量
+	maxUsernameAttempts = 100
+)
+
+// OAuthService 第三方登录服务接口
+type OAuthService interface {
+	Providers() []*model.OAuthProvider
+	Authorize(ctx context.Context, provider string) (*model.OAuthAuthorization, error)
+	Login(ctx context.Context, provider, code, state string) (*model.User, error)
+}
+
+// oauthService 第三方登录服务实现
+type oauthService struct {
+	providers    map[string]*oauth.Provider
+	userRepo     repository.UserRepository
+	identityRepo repository.IdentityRepository
+	stateRepo    repository.OAuthStateRepository
+	bus          *event.Bus
+}
+
+// NewOAuthService 创建第三方登录服务
+func NewOAuthService(providers map[string]*oauth.Provider, userRepo repository.UserRepository, identityRepo repository.IdentityRepository, stateRepo repository.OAuthStateRepository, bus *event.Bus) OAuthService {
+	return &oauthService{
+		providers:    providers,
+		userRepo:     userRepo,
+		identityRepo: identityRepo,
+		stateRepo:    stateRepo,
+		bus:          bus,
+	}
+}
+
+// Providers 获取已配置的第三方登录方式
+func (s *oauthService) Providers() []*model.OAuthProvider {
+	providers := make([]*model.OAuthProvider, 0, len(s.providers))
+	for _, p := range s.providers {
+		providers = append(providers, &model.OAuthProvider{
+			Name:        p.Name(),
+			DisplayName: p.DisplayName(),
+		})
+	}
+	sort.Slice(providers, func(i, j int) bool {
+		return providers[i].Name < providers[j].Name
+	})
+	return providers
+}
+
+// Authorize 创建登录请求并生成身份提供方的授权地址
+func (s *oauthService) Authorize(ctx context.Context, provider string) (*model.OAuthAuthorization, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return nil, utils.ErrProviderNotFound
+	}
+
+	state, err := utils.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := utils.RandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := oauth.GenerateVerifier()
+	if err != nil {
+		return nil, err
+	}
+
+	authURL, err := p.AuthCodeURL(ctx, state, nonce, oauth.CodeChallenge(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", utils.ErrOAuthFailed, err)
+	}
+
+	// 顺带清理已过期的登录请求
+	now := time.Now()
+	if err := s.stateRepo.DeleteExpired(ctx, now); err != nil {
+		return nil, err
+	}
+	if err := s.stateRepo.Create(ctx, &model.OAuthState{
+		StateHash:    utils.HashToken(state),
+		Provider:     provider,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		ExpiresAt:    now.Add(oauthStateTTL),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &model.OAuthAuthorization{
+		AuthorizationURL: authURL,
+		State:            state,
+	}, nil
+}
+
+// Login 校验回调参数，换取身份后返回关联的用户，首次登录时自动创建账号
+func (s *oauthService) Login(ctx context.Context, provider, code, state string) (*model.User, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return nil, utils.ErrProviderNotFound
+	}
+
+	// state 只能使用一次，且必须属于同一提供方
+	stored, err := s.stateRepo.Consume(ctx, utils.HashToken(state))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, utils.ErrInvalidOAuthState
+	} else if err != nil {
+		return nil, err
+	}
+	if stored.Provider != provider || time.Now().After(stored.ExpiresAt) {
+		return nil, utils.ErrInvalidOAuthState
+	}
+
+	token, err := p.Exchange(ctx, code, stored.CodeVerifier)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", utils.ErrOAuthFailed, err)
+	}
+	identity, err := p.Identity(ctx, token, stored.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", utils.ErrOAuthFailed, err)
+	}
+
+	// 已关联的身份直接登录
+	linked, err := s.identityRepo.GetBySubject(ctx, provider, identity.Subject)
+	if err == nil {
+		user, err := s.userRepo.GetByID(ctx, linked.UserID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrUserNotFound
+		}
+		return user, err
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return s.provision(ctx, p, identity)
+}
+
+// provision 为首次登录的身份关联已有账号或创建新账号
+func (s *oauthService) provision(ctx context.Context, p *oauth.Provider, identity *oauth.Identity) (*model.User, error) {
+	if identity.Email == "" {
+		return nil, fmt.Errorf("%w: 身份提供方未返回邮箱", utils.ErrOAuthFailed)
+	}
+
+	link := &model.UserIdentity{
+		Provider: p.Name(),
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	}
+
+	// 邮箱已注册时，只有提供方允许且邮箱已验证才关联，否则可能被冒用
+	existing, err := s.userRepo.GetByEmail(ctx, identity.Email)
+	if err == nil {
+		if !p.LinkByEmail() || !identity.EmailVerified {
+			return nil, utils.ErrEmailInUse
+		}
+		link.UserID = existing.ID
+		if err := s.identityRepo.Create(ctx, link); err != nil {
+			return nil, err
+		}
+		return existing, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	username, err := s.uniqueUsername(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	// 使用随机密码，用户可以通过找回密码设置本地密码
+	password, err := utils.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	passwordHash, err := utils.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &model.User{
+		Username:      username,
+		Email:         identity.Email,
+		EmailVerified: identity.EmailVerified,
+		PasswordHash:  passwordHash,
+		Role:          "user",
+	}
+	if err := s.identityRepo.CreateWithUser(ctx, user, link); err != nil {
+		return nil, err
+	}
+
+	s.bus.Publish(ctx, event.Event{Type: event.UserCreated, UserID: user.ID})
+	return user, nil
+}
+
+// uniqueUsername 根据身份信息生成未被占用的用户名
+func (s *oauthService) uniqueUsername(ctx context.Context, identity *oauth.Identity) (string, error) {
+	base := ""
+	for _, candidate := range []string{identity.Username, strings.Split(identity.Email, "@")[0], identity.Name} {
+		if base = sanitizeUsername(candidate); base != "" {
+			break
+		}
+	}
+	if base == "" {
+		base = "user"
+	}
+
+	for i := 0; i < maxUsernameAttempts; i++ {
+		username := base
+		if i > 0 {
+			username = fmt.Sprintf("%s%d", base, i+1)
+		}
+
+		_, err := s.userRepo.GetByUsername(ctx, username)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return username, nil
+		} else if err != nil {
+			return "", err
+		}
+	}
+
+	// 常见用户名都被占用时使用随机后缀
+	suffix, err := utils.RandomToken(4)
+	if err != nil {
+		return "", err
+	}
+	return base + "_" + sanitizeUsername(suffix), nil
+}
+
+// sanitizeUsername 只保留可以被 @ 提及的字符，过短时返回空字符串
+func sanitizeUsername(name string) string {
+	var b strings.Builder
+	count := 0
+	for _, r := range name {
+		if count >= maxUsernameBase {
+			break
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' {
+			b.WriteRune(r)
+			count++
+		}
+	}
+	if count < minUsernameLength {
+		return ""
+	}
+	return b.String()
+}
@@ -1,47 +1,57 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"time"
 
+	"github.com/lllllan02/chitchat/internal/event"
 	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/pagination"
 	"github.com/lllllan02/chitchat/internal/repository"
 	"github.com/lllllan02/chitchat/internal/utils"
+	"gorm.io/gorm"
 )
 
 // PostService 帖子服务接口
 type PostService interface {
-	CreatePost(userID, categoryID uint, title, content string) (*model.Post, error)
-	GetPostByID(id uint, includeUser bool) (*model.Post, error)
-	UpdatePost(id, userID uint, title, content string, categoryID uint) (*model.Post, error)
-	DeletePost(id, userID uint, isAdmin bool) error
-	ListPosts(page, pageSize int, categoryID, userID uint, keyword, orderBy string) ([]*model.Post, int64, error)
-	GetPostsByUserID(userID uint, page, pageSize int) ([]*model.Post, int64, error)
-	ViewPost(id uint) error
-	SetPostPinned(id uint, isPinned bool) error
-	SetPostFeatured(id uint, isFeatured bool) error
-	GetPinnedPosts(categoryID uint, limit int) ([]*model.Post, error)
-	GetFeaturedPosts(limit int) ([]*model.Post, error)
+	CreatePost(ctx context.Context, userID, categoryID uint, title, content string) (*model.Post, error)
+	GetPostByID(ctx context.Context, id uint, includeUser bool) (*model.Post, error)
+	UpdatePost(ctx context.Context, id, userID uint, title, content string, categoryID uint) (*model.Post, error)
+	DeletePost(ctx context.Context, id, userID uint, isAdmin bool) error
+	ListPosts(ctx context.Context, categoryID, userID uint, keyword, orderBy string, req *pagination.Request) ([]*model.Post, *pagination.Page, error)
+	GetPostsByUserID(ctx context.Context, userID uint, req *pagination.Request) ([]*model.Post, *pagination.Page, error)
+	SetPostPinned(ctx context.Context, id uint, isPinned bool) error
+	SetPostFeatured(ctx context.Context, id uint, isFeatured bool) error
+	GetPinnedPosts(ctx context.Context, categoryID uint, limit int) ([]*model.Post, error)
+	GetFeaturedPosts(ctx context.Context, limit int) ([]*model.Post, error)
 }
 
 // postService 帖子服务实现
 type postService struct {
-	postRepo     repository.PostRepository
-	categoryRepo repository.CategoryRepository
+	postRepo       repository.PostRepository
+	categoryRepo   repository.CategoryRepository
+	mentionService MentionService
+	searchService  SearchService
+	bus            *event.Bus
 }
 
 // NewPostService 创建帖子服务
-func NewPostService() PostService {
+func NewPostService(postRepo repository.PostRepository, categoryRepo repository.CategoryRepository, mentionService MentionService, searchService SearchService, bus *event.Bus) PostService {
 	return &postService{
-		postRepo:     repository.NewPostRepository(),
-		categoryRepo: repository.NewCategoryRepository(),
+		postRepo:       postRepo,
+		categoryRepo:   categoryRepo,
+		mentionService: mentionService,
+		searchService:  searchService,
+		bus:            bus,
 	}
 }
 
 // CreatePost 创建帖子
-func (s *postService) CreatePost(userID, categoryID uint, title, content string) (*model.Post, error) {
+func (s *postService) CreatePost(ctx context.Context, userID, categoryID uint, title, content string) (*model.Post, error) {
 	// 检查分类是否存在
 	if categoryID > 0 {
-		_, err := s.categoryRepo.GetByID(categoryID)
+		_, err := s.categoryRepo.GetByID(ctx, categoryID)
 		if err != nil {
 			return nil, utils.ErrCategoryNotFound
 		}
@@ -56,30 +66,45 @@ func (s *postService) CreatePost(userID, categoryID uint, title, content string)
 		UpdatedAt:  time.Now(),
 	}
 
-	err := s.postRepo.Create(post)
+	err := s.postRepo.Create(ctx, post)
 	if err != nil {
 		return nil, err
 	}
 
 	// 更新分类帖子数量
 	if categoryID > 0 {
-		if err := s.categoryRepo.IncrementPostCount(categoryID); err != nil {
+		if err := s.categoryRepo.IncrementPostCount(ctx, categoryID); err != nil {
 			return nil, err
 		}
 	}
 
+	// 解析并保存正文中的提及
+	post.Mentions, err = s.mentionService.SyncPostMentions(ctx, userID, post.ID, post.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	s.bus.Publish(ctx, event.Event{Type: event.PostCreated, ActorID: userID, PostID: post.ID})
+
 	return post, nil
 }
 
 // GetPostByID 根据ID获取帖子
-func (s *postService) GetPostByID(id uint, includeUser bool) (*model.Post, error) {
-	return s.postRepo.GetByID(id, includeUser)
+func (s *postService) GetPostByID(ctx context.Context, id uint, includeUser bool) (*model.Post, error) {
+	post, err := s.postRepo.GetByID(ctx, id, includeUser)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrPostNotFound
+		}
+		return nil, err
+	}
+	return post, nil
 }
 
 // UpdatePost 更新帖子
-func (s *postService) UpdatePost(id, userID uint, title, content string, categoryID uint) (*model.Post, error) {
+func (s *postService) UpdatePost(ctx context.Context, id, userID uint, title, content string, categoryID uint) (*model.Post, error) {
 	// 获取原始帖子
-	post, err := s.postRepo.GetByID(id, false)
+	post, err := s.GetPostByID(ctx, id, false)
 	if err != nil {
 		return nil, err
 	}
@@ -92,20 +117,20 @@ func (s *postService) UpdatePost(id, userID uint, title, content string, categor
 	// 检查分类是否需要更改
 	if categoryID > 0 && post.CategoryID != categoryID {
 		// 检查新分类是否存在
-		_, err := s.categoryRepo.GetByID(categoryID)
+		_, err := s.categoryRepo.GetByID(ctx, categoryID)
 		if err != nil {
 			return nil, utils.ErrCategoryNotFound
 		}
 
 		// 更新旧分类帖子数量
 		if post.CategoryID > 0 {
-			if err := s.categoryRepo.DecrementPostCount(post.CategoryID); err != nil {
+			if err := s.categoryRepo.DecrementPostCount(ctx, post.CategoryID); err != nil {
 				return nil, err
 			}
 		}
 
 		// 更新新分类帖子数量
-		if err := s.categoryRepo.IncrementPostCount(categoryID); err != nil {
+		if err := s.categoryRepo.IncrementPostCount(ctx, categoryID); err != nil {
 			return nil, err
 		}
 
@@ -122,18 +147,26 @@ func (s *postService) UpdatePost(id, userID uint, title, content string, categor
 	post.UpdatedAt = time.Now()
 
 	// 保存更新
-	err = s.postRepo.Update(post)
+	err = s.postRepo.Update(ctx, post)
 	if err != nil {
 		return nil, err
 	}
 
+	// 重新解析提及，只通知新增的用户
+	post.Mentions, err = s.mentionService.SyncPostMentions(ctx, userID, post.ID, post.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	s.bus.Publish(ctx, event.Event{Type: event.PostUpdated, ActorID: userID, PostID: post.ID})
+
 	return post, nil
 }
 
 // DeletePost 删除帖子
-func (s *postService) DeletePost(id, userID uint, isAdmin bool) error {
+func (s *postService) DeletePost(ctx context.Context, id, userID uint, isAdmin bool) error {
 	// 获取原始帖子
-	post, err := s.postRepo.GetByID(id, false)
+	post, err := s.GetPostByID(ctx, id, false)
 	if err != nil {
 		return err
 	}
@@ -144,52 +177,70 @@ func (s *postService) DeletePost(id, userID uint, isAdmin bool) error {
 	}
 
 	// 删除帖子
-	err = s.postRepo.Delete(id)
+	err = s.postRepo.Delete(ctx, id)
 	if err != nil {
 		return err
 	}
 
 	// 更新分类帖子数量
 	if post.CategoryID > 0 {
-		if err := s.categoryRepo.DecrementPostCount(post.CategoryID); err != nil {
+		if err := s.categoryRepo.DecrementPostCount(ctx, post.CategoryID); err != nil {
 			return err
 		}
 	}
 
+	s.bus.Publish(ctx, event.Event{Type: event.PostDeleted, ActorID: userID, PostID: id})
+
 	return nil
 }
 
 // ListPosts 获取帖子列表
-func (s *postService) ListPosts(page, pageSize int, categoryID, userID uint, keyword, orderBy string) ([]*model.Post, int64, error) {
-	return s.postRepo.List(page, pageSize, categoryID, userID, keyword, orderBy)
-}
+// 指定关键词时通过全文索引筛选帖子，结果仍按 orderBy 排序；索引尚未重建完成时改用数据库模糊匹配
+func (s *postService) ListPosts(ctx context.Context, categoryID, userID uint, keyword, orderBy string, req *pagination.Request) ([]*model.Post, *pagination.Page, error) {
+	// 未指定排序方式时按发布时间排序
+	if orderBy == "" {
+		orderBy = "recent"
+	}
 
-// GetPostsByUserID 获取用户的帖子列表
-func (s *postService) GetPostsByUserID(userID uint, page, pageSize int) ([]*model.Post, int64, error) {
-	return s.postRepo.List(page, pageSize, 0, userID, "", "")
+	var ids []uint
+	var truncated bool
+	if keyword != "" && s.searchService.Ready() {
+		ids, truncated = s.searchService.MatchPostIDs(keyword, categoryID, userID)
+		if len(ids) == 0 {
+			return []*model.Post{}, pagination.Empty(req), nil
+		}
+	}
+
+	posts, page, err := s.postRepo.List(ctx, categoryID, userID, keyword, ids, orderBy, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	// 匹配过多时只在相关度最高的帖子中按 orderBy 排序
+	page.Truncated = truncated
+	return posts, page, nil
 }
 
-// ViewPost 浏览帖子
-func (s *postService) ViewPost(id uint) error {
-	return s.postRepo.IncrementViewCount(id)
+// GetPostsByUserID 获取用户的帖子列表
+func (s *postService) GetPostsByUserID(ctx context.Context, userID uint, req *pagination.Request) ([]*model.Post, *pagination.Page, error) {
+	return s.postRepo.List(ctx, 0, userID, "", nil, "", req)
 }
 
 // SetPostPinned 设置帖子置顶状态
-func (s *postService) SetPostPinned(id uint, isPinned bool) error {
-	return s.postRepo.SetPinned(id, isPinned)
+func (s *postService) SetPostPinned(ctx context.Context, id uint, isPinned bool) error {
+	return s.postRepo.SetPinned(ctx, id, isPinned)
 }
 
 // SetPostFeatured 设置帖子精华状态
-func (s *postService) SetPostFeatured(id uint, isFeatured bool) error {
-	return s.postRepo.SetFeatured(id, isFeatured)
+func (s *postService) SetPostFeatured(ctx context.Context, id uint, isFeatured bool) error {
+	return s.postRepo.SetFeatured(ctx, id, isFeatured)
 }
 
 // GetPinnedPosts 获取置顶帖子
-func (s *postService) GetPinnedPosts(categoryID uint, limit int) ([]*model.Post, error) {
-	return s.postRepo.GetPinnedPosts(categoryID, limit)
+func (s *postService) GetPinnedPosts(ctx context.Context, categoryID uint, limit int) ([]*model.Post, error) {
+	return s.postRepo.GetPinnedPosts(ctx, categoryID, limit)
 }
 
 // GetFeaturedPosts 获取精华帖子
-func (s *postService) GetFeaturedPosts(limit int) ([]*model.Post, error) {
-	return s.postRepo.GetFeaturedPosts(limit)
+func (s *postService) GetFeaturedPosts(ctx context.Context, limit int) ([]*model.Post, error) {
+	return s.postRepo.GetFeaturedPosts(ctx, limit)
 }
@@ -0,0 +1,421 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/lllllan02/chitchat/internal/event"
+	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/repository"
+	"github.com/lllllan02/chitchat/internal/search"
+	"github.com/lllllan02/chitchat/internal/utils"
+	"github.com/lllllan02/chitchat/pkg/logger"
+	"gorm.io/gorm"
+)
+
+const (
+	// 重建索引时每批读取的记录数
+	searchRebuildBatch = 500
+	// 帖子和用户列表按关键词筛选时最多匹配的记录数，超出的部分按相关度截断
+	maxKeywordMatches = 1000
+)
+
+// 各字段的检索权重
+const (
+	weightTitle    = 3
+	weightUsername = 3
+	weightBody     = 1
+)
+
+// SearchService 全文搜索服务接口
+// 启动时从数据库重建索引，之后通过事件在帖子、评论和用户变化时更新索引
+type SearchService interface {
+	Subscribe()
+	Rebuild(ctx context.Context) error
+	Ready() bool
+	Search(ctx context.Context, query *model.SearchQuery) ([]*model.SearchHit, int64, error)
+	MatchPostIDs(keyword string, categoryID, userID uint) ([]uint, bool)
+	MatchUserIDs(keyword string) ([]uint, bool)
+}
+
+// indexHandler 根据事件更新指定的索引
+type indexHandler func(ctx context.Context, idx *search.Index, e event.Event) error
+
+// searchService 基于进程内倒排索引的全文搜索服务
+// 索引只包含本实例启动时的数据和本实例处理的写操作
+type searchService struct {
+	postRepo    repository.PostRepository
+	commentRepo repository.CommentRepository
+	userRepo    repository.UserRepository
+	bus         *event.Bus
+
+	mu    sync.Mutex
+	index *search.Index
+	// 首次重建完成前索引缺少启动前的数据
+	ready bool
+	// 重建期间收到的事件，重建完成后在新索引上重放
+	rebuilding bool
+	pending    []func(idx *search.Index) error
+}
+
+// NewSearchService 创建全文搜索服务
+func NewSearchService(postRepo repository.PostRepository, commentRepo repository.CommentRepository, userRepo repository.UserRepository, bus *event.Bus) SearchService {
+	return &searchService{
+		index:       search.NewIndex(),
+		postRepo:    postRepo,
+		commentRepo: commentRepo,
+		userRepo:    userRepo,
+		bus:         bus,
+	}
+}
+
+// Subscribe 订阅需要更新索引的事件
+func (s *searchService) Subscribe() {
+	s.bus.Subscribe(event.PostCreated, s.apply(s.onPostChanged))
+	s.bus.Subscribe(event.PostUpdated, s.apply(s.onPostChanged))
+	s.bus.Subscribe(event.PostDeleted, s.apply(s.onPostDeleted))
+	s.bus.Subscribe(event.CommentCreated, s.apply(s.onCommentChanged))
+	s.bus.Subscribe(event.CommentUpdated, s.apply(s.onCommentChanged))
+	s.bus.Subscribe(event.CommentDeleted, s.apply(s.onCommentDeleted))
+	s.bus.Subscribe(event.UserCreated, s.apply(s.onUserChanged))
+	s.bus.Subscribe(event.UserUpdated, s.apply(s.onUserChanged))
+	s.bus.Subscribe(event.UserDeleted, s.apply(s.onUserDeleted))
+}
+
+// apply 在当前索引上处理事件，重建期间同时记录事件
+// 各处理函数都从数据库读取最新状态，重复处理或乱序重放的结果相同
+func (s *searchService) apply(handle indexHandler) event.Handler {
+	return func(ctx context.Context, e event.Event) error {
+		s.mu.Lock()
+		idx := s.index
+		if s.rebuilding {
+			// 重放时请求已经结束
+			replayCtx := context.WithoutCancel(ctx)
+			s.pending = append(s.pending, func(idx *search.Index) error {
+				return handle(replayCtx, idx, e)
+			})
+		}
+		s.mu.Unlock()
+
+		return handle(ctx, idx, e)
+	}
+}
+
+// current 当前使用的索引
+func (s *searchService) current() *search.Index {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.index
+}
+
+// Rebuild 从数据库分批读取全部帖子、评论和用户，在新索引中重建后替换当前索引
+// 重建期间继续使用当前索引，期间的写操作在替换前重放到新索引中，不能并发调用
+func (s *searchService) Rebuild(ctx context.Context) error {
+	start := time.Now()
+
+	s.mu.Lock()
+	s.rebuilding = true
+	s.mu.Unlock()
+
+	idx := search.NewIndex()
+	if err := s.load(ctx, idx); err != nil {
+		s.mu.Lock()
+		s.rebuilding = false
+		s.pending = nil
+		s.mu.Unlock()
+		return err
+	}
+
+	// 重放完全部事件后再替换，替换前收到的事件在下一轮重放
+	for {
+		s.mu.Lock()
+		pending := s.pending
+		s.pending = nil
+		if len(pending) == 0 {
+			s.index = idx
+			s.rebuilding = false
+			s.ready = true
+			s.mu.Unlock()
+			break
+		}
+		s.mu.Unlock()
+
+		for _, replay := range pending {
+			if err := replay(idx); err != nil {
+				logger.FromContext(ctx).Error("重放搜索索引更新失败", logger.Err(err))
+			}
+		}
+	}
+
+	logger.FromContext(ctx).Info("搜索索引重建完成", logger.Int("documents", idx.Len()), logger.Duration("elapsed", time.Since(start)))
+	return nil
+}
+
+// Ready 索引是否已经从数据库完成重建
+// 未完成时搜索返回 utils.ErrSearchNotReady，关键词筛选应改用数据库查询
+func (s *searchService) Ready() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ready
+}
+
+// load 从数据库分批读取全部帖子、评论和用户写入索引
+func (s *searchService) load(ctx context.Context, idx *search.Index) error {
+
+	// 记录帖子的分类，评论按所属帖子的分类筛选
+	categories := make(map[uint]uint)
+	for afterID := uint(0); ; {
+		posts, err := s.postRepo.ListAfter(ctx, afterID, searchRebuildBatch)
+		if err != nil {
+			return err
+		}
+		for _, post := range posts {
+			idx.Put(postDocument(post))
+			categories[post.ID] = post.CategoryID
+			afterID = post.ID
+		}
+		if len(posts) < searchRebuildBatch {
+			break
+		}
+	}
+
+	for afterID := uint(0); ; {
+		comments, err := s.commentRepo.ListAfter(ctx, afterID, searchRebuildBatch)
+		if err != nil {
+			return err
+		}
+		for _, comment := range comments {
+			// 跳过已删除帖子下的评论
+			if categoryID, ok := categories[comment.PostID]; ok {
+				idx.Put(commentDocument(comment, categoryID))
+			}
+			afterID = comment.ID
+		}
+		if len(comments) < searchRebuildBatch {
+			break
+		}
+	}
+
+	for afterID := uint(0); ; {
+		users, err := s.userRepo.ListAfter(ctx, afterID, searchRebuildBatch)
+		if err != nil {
+			return err
+		}
+		for _, user := range users {
+			idx.Put(userDocument(user))
+			afterID = user.ID
+		}
+		if len(users) < searchRebuildBatch {
+			break
+		}
+	}
+	return nil
+}
+
+// Search 搜索帖子、评论和用户，按相关度排序
+func (s *searchService) Search(ctx context.Context, query *model.SearchQuery) ([]*model.SearchHit, int64, error) {
+	if !s.Ready() {
+		return nil, 0, utils.ErrSearchNotReady
+	}
+
+	filter := search.Filter{
+		Type:       query.Type,
+		UserID:     query.UserID,
+		CategoryID: query.CategoryID,
+		From:       query.From,
+	}
+	if !query.To.IsZero() {
+		// 包含结束日期当天
+		filter.To = query.To.AddDate(0, 0, 1)
+	}
+
+	idx := s.current()
+	hits, total := idx.Search(query.Q, filter, (query.Page-1)*query.PageSize, query.PageSize)
+
+	results := make([]*model.SearchHit, 0, len(hits))
+	for _, hit := range hits {
+		result := &model.SearchHit{Type: hit.Type, ID: hit.ID, Score: hit.Score, Highlights: hit.Highlights}
+
+		var err error
+		switch hit.Type {
+		case search.TypePost:
+			result.Post, err = s.postRepo.GetByID(ctx, hit.ID, true)
+		case search.TypeComment:
+			result.Comment, err = s.commentRepo.GetByID(ctx, hit.ID)
+		case search.TypeUser:
+			result.User, err = s.userRepo.GetByID(ctx, hit.ID)
+		}
+
+		// 索引中残留已删除的记录时顺便清理
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			idx.Remove(hit.Type, hit.ID)
+			total--
+			continue
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		results = append(results, result)
+	}
+
+	return results, int64(total), nil
+}
+
+// MatchPostIDs 按相关度返回包含关键词的帖子ID，最多 maxKeywordMatches 个
+// 匹配的帖子超过上限时第二个返回值为 true
+func (s *searchService) MatchPostIDs(keyword string, categoryID, userID uint) ([]uint, bool) {
+	filter := search.Filter{Type: search.TypePost, CategoryID: categoryID, UserID: userID}
+	hits, total := s.current().Search(keyword, filter, 0, maxKeywordMatches)
+
+	ids := make([]uint, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	return ids, total > len(hits)
+}
+
+// MatchUserIDs 按相关度返回用户名或简介包含关键词的用户ID，最多 maxKeywordMatches 个
+// 匹配的用户超过上限时第二个返回值为 true
+func (s *searchService) MatchUserIDs(keyword string) ([]uint, bool) {
+	hits, total := s.current().Search(keyword, search.Filter{Type: search.TypeUser}, 0, maxKeywordMatches)
+
+	ids := make([]uint, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	return ids, total > len(hits)
+}
+
+// onPostChanged 帖子创建或修改后更新索引，分类变化时同步其下评论的分类
+func (s *searchService) onPostChanged(ctx context.Context, idx *search.Index, e event.Event) error {
+	post, err := s.postRepo.GetByID(ctx, e.PostID, false)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return s.onPostDeleted(ctx, idx, e)
+	}
+	if err != nil {
+		return err
+	}
+
+	idx.Put(postDocument(post))
+	for _, doc := range idx.Find(func(d search.Document) bool {
+		return d.Type == search.TypeComment && d.PostID == post.ID && d.CategoryID != post.CategoryID
+	}) {
+		doc.CategoryID = post.CategoryID
+		idx.Put(doc)
+	}
+	return nil
+}
+
+// onPostDeleted 帖子删除后移除帖子及其评论
+func (s *searchService) onPostDeleted(_ context.Context, idx *search.Index, e event.Event) error {
+	idx.Remove(search.TypePost, e.PostID)
+	idx.RemoveWhere(func(d search.Document) bool {
+		return d.Type == search.TypeComment && d.PostID == e.PostID
+	})
+	return nil
+}
+
+// onCommentChanged 评论创建或修改后更新索引
+func (s *searchService) onCommentChanged(ctx context.Context, idx *search.Index, e event.Event) error {
+	comment, err := s.commentRepo.GetByID(ctx, e.CommentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		idx.Remove(search.TypeComment, e.CommentID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	post, err := s.postRepo.GetByID(ctx, comment.PostID, false)
+	if err != nil {
+		return err
+	}
+
+	idx.Put(commentDocument(comment, post.CategoryID))
+	return nil
+}
+
+// onCommentDeleted 评论删除后移除评论，其回复随评论一并删除，因此逐个核对该帖子下已索引的评论
+func (s *searchService) onCommentDeleted(ctx context.Context, idx *search.Index, e event.Event) error {
+	idx.Remove(search.TypeComment, e.CommentID)
+
+	for _, doc := range idx.Find(func(d search.Document) bool {
+		return d.Type == search.TypeComment && d.PostID == e.PostID
+	}) {
+		_, err := s.commentRepo.GetByID(ctx, doc.ID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			idx.Remove(search.TypeComment, doc.ID)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// onUserChanged 用户注册或修改资料后更新索引
+func (s *searchService) onUserChanged(ctx context.Context, idx *search.Index, e event.Event) error {
+	user, err := s.userRepo.GetByID(ctx, e.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return s.onUserDeleted(ctx, idx, e)
+	}
+	if err != nil {
+		return err
+	}
+
+	idx.Put(userDocument(user))
+	return nil
+}
+
+// onUserDeleted 用户删除后移除用户
+func (s *searchService) onUserDeleted(_ context.Context, idx *search.Index, e event.Event) error {
+	idx.Remove(search.TypeUser, e.UserID)
+	return nil
+}
+
+// postDocument 帖子的索引文档
+func postDocument(post *model.Post) search.Document {
+	return search.Document{
+		Type: search.TypePost,
+		ID:   post.ID,
+		Fields: []search.Field{
+			{Name: "title", Text: post.Title, Weight: weightTitle},
+			{Name: "content", Text: post.Content, Weight: weightBody},
+		},
+		UserID:     post.UserID,
+		CategoryID: post.CategoryID,
+		CreatedAt:  post.CreatedAt,
+	}
+}
+
+// commentDocument 评论的索引文档
+func commentDocument(comment *model.Comment, categoryID uint) search.Document {
+	return search.Document{
+		Type: search.TypeComment,
+		ID:   comment.ID,
+		Fields: []search.Field{
+			{Name: "content", Text: comment.Content, Weight: weightBody},
+		},
+		UserID:     comment.UserID,
+		CategoryID: categoryID,
+		PostID:     comment.PostID,
+		CreatedAt:  comment.CreatedAt,
+	}
+}
+
+// userDocument 用户的索引文档
+func userDocument(user *model.User) search.Document {
+	return search.Document{
+		Type: search.TypeUser,
+		ID:   user.ID,
+		Fields: []search.Field{
+			{Name: "username", Text: user.Username, Weight: weightUsername},
+			{Name: "bio", Text: user.Bio, Weight: weightBody},
+		},
+		UserID:    user.ID,
+		CreatedAt: user.CreatedAt,
+	}
+}
@@ -0,0 +1,296 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/repository"
+	"github.com/lllllan02/chitchat/internal/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// 刷新令牌的随机字节数
+	refreshTokenBytes = 32
+	// 会话最近活跃时间的更新间隔，避免每个请求都写数据库
+	sessionTouchInterval = time.Minute
+	// 记录的 User-Agent 最大长度
+	maxUserAgentLength = 255
+)
+
+// TokenService 令牌和登录会话服务接口
+type TokenService interface {
+	IssueTokens(ctx context.Context, user *model.User, client model.ClientInfo, twoFactor bool) (*model.TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string, client model.ClientInfo) (*model.TokenPair, error)
+	VerifyAccessToken(ctx context.Context, token string) (*utils.CustomClaims, error)
+	Logout(ctx context.Context, claims *utils.CustomClaims, refreshToken string) error
+	ListSessions(ctx context.Context, userID uint) ([]*model.Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID uint) error
+	RevokeAll(ctx context.Context, userID uint) error
+}
+
+// tokenService 令牌和登录会话服务实现
+type tokenService struct {
+	cfg              utils.JWTConfig
+	userRepo         repository.UserRepository
+	sessionRepo      repository.SessionRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	revokedTokenRepo repository.RevokedTokenRepository
+}
+
+// NewTokenService 创建令牌服务
+func NewTokenService(cfg utils.JWTConfig, userRepo repository.UserRepository, sessionRepo repository.SessionRepository, refreshTokenRepo repository.RefreshTokenRepository, revokedTokenRepo repository.RevokedTokenRepository) TokenService {
+	return &tokenService{
+		cfg:              cfg,
+		userRepo:         userRepo,
+		sessionRepo:      sessionRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		revokedTokenRepo: revokedTokenRepo,
+	}
+}
+
+// IssueTokens 创建新的登录会话，并签发访问令牌和刷新令牌
+// twoFactor 表示本次登录是否通过了两步验证，刷新令牌时沿用
+func (s *tokenService) IssueTokens(ctx context.Context, user *model.User, client model.ClientInfo, twoFactor bool) (*model.TokenPair, error) {
+	now := time.Now()
+	session := &model.Session{
+		UserID:     user.ID,
+		ExpiresAt:  now.Add(s.cfg.RefreshTokenTTL()),
+		LastSeenAt: now,
+		TwoFactor:  twoFactor,
+	}
+	applyClient(session, client)
+
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return s.issue(ctx, user, session)
+}
+
+// Refresh 使用刷新令牌换取新的令牌对，旧的刷新令牌随即失效
+func (s *tokenService) Refresh(ctx context.Context, refreshToken string, client model.ClientInfo) (*model.TokenPair, error) {
+	stored, err := s.refreshTokenRepo.GetByHash(ctx, utils.HashToken(refreshToken))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, utils.ErrInvalidToken
+	} else if err != nil {
+		return nil, err
+	}
+
+	// 已轮换的令牌被再次使用，说明令牌可能已泄露，注销整个会话
+	if stored.RevokedAt != nil {
+		if err := s.deleteSession(ctx, stored.SessionID); err != nil {
+			return nil, err
+		}
+		return nil, utils.ErrTokenRevoked
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, utils.ErrInvalidToken
+	}
+
+	// 并发刷新时只有一个请求能吊销成功
+	revoked, err := s.refreshTokenRepo.Revoke(ctx, stored.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !revoked {
+		return nil, utils.ErrTokenRevoked
+	}
+
+	session, err := s.sessionRepo.GetByID(ctx, stored.SessionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, utils.ErrTokenRevoked
+	} else if err != nil {
+		return nil, err
+	}
+
+	// 使用最新的用户信息签发，角色变更在刷新后生效
+	user, err := s.userRepo.GetByID(ctx, stored.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, utils.ErrInvalidToken
+	} else if err != nil {
+		return nil, err
+	}
+
+	// 延长会话并记录最新的客户端信息
+	now := time.Now()
+	session.ExpiresAt = now.Add(s.cfg.RefreshTokenTTL())
+	session.LastSeenAt = now
+	applyClient(session, client)
+	if err := s.sessionRepo.Update(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return s.issue(ctx, user, session)
+}
+
+// VerifyAccessToken 校验访问令牌的签名、有效期、吊销列表、令牌版本和所属会话
+func (s *tokenService) VerifyAccessToken(ctx context.Context, token string) (*utils.CustomClaims, error) {
+	claims, err := utils.ParseToken(s.cfg, token)
+	if err != nil {
+		return nil, utils.ErrInvalidToken
+	}
+
+	revoked, err := s.revokedTokenRepo.Exists(ctx, claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, utils.ErrTokenRevoked
+	}
+
+	// 用户已删除或令牌版本过旧时拒绝
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, utils.ErrInvalidToken
+	} else if err != nil {
+		return nil, err
+	}
+	if user.TokenVersion != claims.Version {
+		return nil, utils.ErrTokenRevoked
+	}
+	claims.Locale = user.Locale
+
+	// 会话已被注销时拒绝
+	session, err := s.sessionRepo.GetByID(ctx, claims.SessionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, utils.ErrTokenRevoked
+	} else if err != nil {
+		return nil, err
+	}
+	if session.UserID != claims.UserID {
+		return nil, utils.ErrInvalidToken
+	}
+
+	// 按间隔更新最近活跃时间
+	if now := time.Now(); now.Sub(session.LastSeenAt) >= sessionTouchInterval {
+		if err := s.sessionRepo.Touch(ctx, session.ID, now); err != nil {
+			return nil, err
+		}
+	}
+
+	return claims, nil
+}
+
+// Logout 吊销当前访问令牌并注销所属会话
+func (s *tokenService) Logout(ctx context.Context, claims *utils.CustomClaims, refreshToken string) error {
+	if err := s.revokedTokenRepo.Create(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		return err
+	}
+
+	if err := s.deleteSession(ctx, claims.SessionID); err != nil {
+		return err
+	}
+
+	// 只删除属于当前用户的刷新令牌
+	if refreshToken != "" {
+		stored, err := s.refreshTokenRepo.GetByHash(ctx, utils.HashToken(refreshToken))
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil && stored.UserID == claims.UserID {
+			if err := s.deleteSession(ctx, stored.SessionID); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 顺带清理已过期的记录
+	now := time.Now()
+	if err := s.revokedTokenRepo.DeleteExpired(ctx, now); err != nil {
+		return err
+	}
+	if err := s.refreshTokenRepo.DeleteExpired(ctx, now); err != nil {
+		return err
+	}
+	return s.sessionRepo.DeleteExpired(ctx, now)
+}
+
+// ListSessions 获取用户当前有效的登录会话
+func (s *tokenService) ListSessions(ctx context.Context, userID uint) ([]*model.Session, error) {
+	return s.sessionRepo.ListActive(ctx, userID, time.Now())
+}
+
+// RevokeSession 注销用户的某个会话，该会话的令牌立即失效
+func (s *tokenService) RevokeSession(ctx context.Context, userID, sessionID uint) error {
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return utils.ErrSessionNotFound
+	} else if err != nil {
+		return err
+	}
+
+	// 不能注销其他用户的会话
+	if session.UserID != userID {
+		return utils.ErrSessionNotFound
+	}
+
+	return s.deleteSession(ctx, session.ID)
+}
+
+// RevokeAll 注销用户的全部会话，已签发的访问令牌和刷新令牌都将失效
+func (s *tokenService) RevokeAll(ctx context.Context, userID uint) error {
+	if err := s.userRepo.IncrementTokenVersion(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.refreshTokenRepo.DeleteByUserID(ctx, userID); err != nil {
+		return err
+	}
+	return s.sessionRepo.DeleteByUserID(ctx, userID)
+}
+
+// issue 为会话签发访问令牌和刷新令牌
+func (s *tokenService) issue(ctx context.Context, user *model.User, session *model.Session) (*model.TokenPair, error) {
+	accessToken, _, err := utils.GenerateToken(s.cfg, utils.CustomClaims{
+		UserID:    user.ID,
+		Role:      user.Role,
+		Version:   user.TokenVersion,
+		SessionID: session.ID,
+		TwoFactor: session.TwoFactor,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := utils.RandomToken(refreshTokenBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	// 只保存哈希，数据库泄露时刷新令牌也无法被直接使用
+	if err := s.refreshTokenRepo.Create(ctx, &model.RefreshToken{
+		UserID:    user.ID,
+		SessionID: session.ID,
+		TokenHash: utils.HashToken(refreshToken),
+		ExpiresAt: session.ExpiresAt,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &model.TokenPair{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.cfg.AccessTokenTTL() / time.Second),
+	}, nil
+}
+
+// deleteSession 删除会话及其刷新令牌
+func (s *tokenService) deleteSession(ctx context.Context, sessionID uint) error {
+	if err := s.refreshTokenRepo.DeleteBySessionID(ctx, sessionID); err != nil {
+		return err
+	}
+	return s.sessionRepo.Delete(ctx, sessionID)
+}
+
+// applyClient 记录客户端信息
+func applyClient(session *model.Session, client model.ClientInfo) {
+	userAgent := client.UserAgent
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	session.UserAgent = userAgent
+	session.IP = client.IP
+}
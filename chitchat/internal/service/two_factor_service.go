@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/repository"
+	"github.com/lllllan02/chitchat/internal/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// 验证器应用中显示的发行方
+	totpIssuer = "chitchat"
+
+	// 两步验证登录挑战的用途和有效期
+	purposeLoginChallenge = "login_challenge"
+	loginChallengeTTL     = 5 * time.Minute
+
+	// 备用码数量和长度，显示时每 5 个字符用短横线分隔
+	backupCodeCount  = 10
+	backupCodeLength = 10
+	// 备用码字符集，去掉了容易混淆的 0、1、I、O
+	backupCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+// TwoFactorService 两步验证服务接口
+type TwoFactorService interface {
+	Setup(ctx context.Context, userID uint) (*model.TwoFactorSetup, error)
+	Enable(ctx context.Context, userID uint, code string) ([]string, error)
+	Disable(ctx context.Context, userID uint, password, code string) error
+	RegenerateBackupCodes(ctx context.Context, userID uint, code string) ([]string, error)
+	CreateChallenge(ctx context.Context, user *model.User) (*model.LoginChallenge, error)
+	VerifyChallenge(ctx context.Context, challengeToken, code string) (*model.User, error)
+}
+
+// twoFactorService 两步验证服务实现
+type twoFactorService struct {
+	secret           string
+	userRepo         repository.UserRepository
+	backupCodeRepo   repository.BackupCodeRepository
+	revokedTokenRepo repository.RevokedTokenRepository
+	tokenService     TokenService
+	loginGuard       LoginGuardService
+}
+
+// NewTwoFactorService 创建两步验证服务
+func NewTwoFactorService(jwtCfg utils.JWTConfig, userRepo repository.UserRepository, backupCodeRepo repository.BackupCodeRepository, revokedTokenRepo repository.RevokedTokenRepository, tokenService TokenService, loginGuard LoginGuardService) TwoFactorService {
+	return &twoFactorService{
+		secret:           jwtCfg.Secret,
+		userRepo:         userRepo,
+		backupCodeRepo:   backupCodeRepo,
+		revokedTokenRepo: revokedTokenRepo,
+		tokenService:     tokenService,
+		loginGuard:       loginGuard,
+	}
+}
+
+// Setup 生成待确认的密钥，用户在验证器应用中添加后调用 Enable 启用
+func (s *twoFactorService) Setup(ctx context.Context, userID uint) (*model.TwoFactorSetup, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactor {
+		return nil, utils.ErrTwoFactorEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.SetTOTPSecret(ctx, user.ID, secret); err != nil {
+		return nil, err
+	}
+
+	return &model.TwoFactorSetup{
+		Secret:     secret,
+		OTPAuthURI: utils.TOTPURI(totpIssuer, user.Username, secret),
+	}, nil
+}
+
+// Enable 使用验证器应用生成的验证码确认密钥并启用两步验证，返回备用码
+// 启用后用户的全部会话失效，调用方需要为当前客户端重新签发令牌
+func (s *twoFactorService) Enable(ctx context.Context, userID uint, code string) ([]string, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactor {
+		return nil, utils.ErrTwoFactorEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, utils.ErrTwoFactorNotEnabled
+	}
+
+	// 启用时只接受验证码，确保验证器应用已正确添加
+	ok, err := s.verifyTOTP(ctx, user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, utils.ErrInvalidTwoFactorCode
+	}
+
+	if err := s.userRepo.EnableTwoFactor(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	codes, err := s.replaceBackupCodes(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 启用前登录的会话没有经过两步验证，全部注销
+	if err := s.tokenService.RevokeAll(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable 校验密码和验证码后关闭两步验证，用户的全部会话随之失效
+func (s *twoFactorService) Disable(ctx context.Context, userID uint, password, code string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.TwoFactor {
+		return utils.ErrTwoFactorNotEnabled
+	}
+
+	if !utils.CheckPasswordHash(password, user.PasswordHash) {
+		return utils.ErrInvalidPassword
+	}
+	if err := s.verifyCode(ctx, user, code); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.DisableTwoFactor(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := s.backupCodeRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		return err
+	}
+	return s.tokenService.RevokeAll(ctx, user.ID)
+}
+
+// RegenerateBackupCodes 重新生成备用码，旧的备用码全部作废
+func (s *twoFactorService) RegenerateBackupCodes(ctx context.Context, userID uint, code string) ([]string, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TwoFactor {
+		return nil, utils.ErrTwoFactorNotEnabled
+	}
+
+	if err := s.verifyCode(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	return s.replaceBackupCodes(ctx, user.ID)
+}
+
+// CreateChallenge 为通过密码校验的用户签发两步验证登录挑战
+func (s *twoFactorService) CreateChallenge(ctx context.Context, user *model.User) (*model.LoginChallenge, error) {
+	token, err := utils.GenerateActionToken(s.secret, purposeLoginChallenge, user.ID, challengeFingerprint(user), loginChallengeTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.LoginChallenge{
+		TwoFactorRequired: true,
+		ChallengeToken:    token,
+		ExpiresIn:         int64(loginChallengeTTL / time.Second),
+	}, nil
+}
+
+// VerifyChallenge 校验登录挑战和验证码，通过后返回登录用户
+// 验证码错误次数过多时锁定两步验证登录并返回 *utils.RetryAfterError；登录挑战只能成功使用一次
+func (s *twoFactorService) VerifyChallenge(ctx context.Context, challengeToken, code string) (*model.User, error) {
+	claims, err := utils.ParseActionToken(s.secret, purposeLoginChallenge, challengeToken)
+	if err != nil || claims.ID == "" {
+		return nil, utils.ErrInvalidToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, utils.ErrInvalidToken
+	} else if err != nil {
+		return nil, err
+	}
+
+	// 签发挑战后修改了密码或关闭了两步验证时挑战失效
+	if !user.TwoFactor || claims.Fingerprint != challengeFingerprint(user) {
+		return nil, utils.ErrInvalidToken
+	}
+
+	if err := s.loginGuard.CheckTwoFactor(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	if err := s.verifyCode(ctx, user, code); err != nil {
+		if !errors.Is(err, utils.ErrInvalidTwoFactorCode) {
+			return nil, err
+		}
+		if err := s.loginGuard.RecordTwoFactorFailure(ctx, user.ID); err != nil {
+			return nil, err
+		}
+		return nil, utils.ErrInvalidTwoFactorCode
+	}
+
+	// 登录挑战使用后作废，不能再配合新的验证码重放
+	consumed, err := s.revokedTokenRepo.Consume(ctx, claims.ID, claims.ExpiresAt.Time)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, utils.ErrInvalidToken
+	}
+
+	if err := s.loginGuard.RecordTwoFactorSuccess(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// verifyCode 校验验证码或备用码，备用码使用后作废
+func (s *twoFactorService) verifyCode(ctx context.Context, user *model.User, code string) error {
+	code = strings.TrimSpace(code)
+
+	if isTOTPCode(code) {
+		ok, err := s.verifyTOTP(ctx, user, code)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return utils.ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	ok, err := s.backupCodeRepo.Use(ctx, user.ID, utils.HashToken(normalizeBackupCode(code)))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return utils.ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// verifyTOTP 校验验证码，同一时间步的验证码只能使用一次
+func (s *twoFactorService) verifyTOTP(ctx context.Context, user *model.User, code string) (bool, error) {
+	step, ok := utils.ValidateTOTP(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+	return s.userRepo.UseTOTPStep(ctx, user.ID, step)
+}
+
+// replaceBackupCodes 生成一组新的备用码，只保存哈希
+func (s *twoFactorService) replaceBackupCodes(ctx context.Context, userID uint) ([]string, error) {
+	codes := make([]string, backupCodeCount)
+	hashes := make([]string, backupCodeCount)
+	for i := range codes {
+		code, err := generateBackupCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		hashes[i] = utils.HashToken(normalizeBackupCode(code))
+	}
+
+	if err := s.backupCodeRepo.Replace(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// challengeFingerprint 登录挑战绑定的用户状态
+func challengeFingerprint(user *model.User) string {
+	return utils.Fingerprint(user.PasswordHash + "|" + strconv.Itoa(user.TokenVersion))
+}
+
+// generateBackupCode 生成形如 ABCDE-FGHJK 的备用码
+func generateBackupCode() (string, error) {
+	b := make([]byte, backupCodeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	// 字符集长度为 32，取模不会引入偏差
+	code := make([]byte, 0, backupCodeLength+1)
+	for i, v := range b {
+		if i == backupCodeLength/2 {
+			code = append(code, '-')
+		}
+		code = append(code, backupCodeAlphabet[int(v)%len(backupCodeAlphabet)])
+	}
+	return string(code), nil
+}
+
+// normalizeBackupCode 忽略备用码的大小写和分隔符
+func normalizeBackupCode(code string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// isTOTPCode 判断输入是否为六位数字验证码
+func isTOTPCode(code string) bool {
+	if len(code) != 6 {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
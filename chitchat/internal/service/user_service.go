@@ -1,88 +1,160 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/lllllan02/chitchat/internal/event"
 	"github.com/lllllan02/chitchat/internal/model"
 	"github.com/lllllan02/chitchat/internal/repository"
+	"github.com/lllllan02/chitchat/internal/utils"
+	"gorm.io/gorm"
 )
 
 // UserService 用户服务接口
 type UserService interface {
-	CreateUser(user *model.User) error
-	GetUserByID(id uint) (*model.User, error)
-	GetUserByUsername(username string) (*model.User, error)
-	GetUserByEmail(email string) (*model.User, error)
-	UpdateUser(user *model.User) error
-	DeleteUser(id uint) error
-	ListUsers(page, pageSize int) ([]*model.User, int64, error)
-	SearchUsers(keyword string, page, pageSize int) ([]*model.User, int64, error)
-	ChangePassword(id uint, newPasswordHash string) error
-	UpdateUserRole(id uint, role string) error
+	CreateUser(ctx context.Context, user *model.User) error
+	GetUserByID(ctx context.Context, id uint) (*model.User, error)
+	GetUserByUsername(ctx context.Context, username string) (*model.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*model.User, error)
+	UpdateUser(ctx context.Context, user *model.User) error
+	DeleteUser(ctx context.Context, id uint) error
+	ListUsers(ctx context.Context, query *model.UserListQuery) ([]*model.User, int64, bool, error)
+	SuggestUsers(ctx context.Context, prefix string, limit int) ([]*model.UserSuggestion, error)
+	ChangePassword(ctx context.Context, id uint, newPasswordHash string) error
+	UpdateUserRole(ctx context.Context, id uint, role string) error
 }
 
 // userService 用户服务实现
 type userService struct {
-	userRepo repository.UserRepository
+	userRepo      repository.UserRepository
+	tokenService  TokenService
+	searchService SearchService
+	bus           *event.Bus
 }
 
 // NewUserService 创建用户服务
-func NewUserService() UserService {
+func NewUserService(userRepo repository.UserRepository, tokenService TokenService, searchService SearchService, bus *event.Bus) UserService {
 	return &userService{
-		userRepo: repository.NewUserRepository(),
+		userRepo:      userRepo,
+		tokenService:  tokenService,
+		searchService: searchService,
+		bus:           bus,
 	}
 }
 
 // CreateUser 创建用户
-func (s *userService) CreateUser(user *model.User) error {
-	return s.userRepo.Create(user)
+func (s *userService) CreateUser(ctx context.Context, user *model.User) error {
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return err
+	}
+
+	s.bus.Publish(ctx, event.Event{Type: event.UserCreated, UserID: user.ID})
+	return nil
 }
 
 // GetUserByID 根据ID获取用户
-func (s *userService) GetUserByID(id uint) (*model.User, error) {
-	return s.userRepo.GetByID(id)
+func (s *userService) GetUserByID(ctx context.Context, id uint) (*model.User, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
 }
 
 // GetUserByUsername 根据用户名获取用户
-func (s *userService) GetUserByUsername(username string) (*model.User, error) {
-	return s.userRepo.GetByUsername(username)
+func (s *userService) GetUserByUsername(ctx context.Context, username string) (*model.User, error) {
+	return s.userRepo.GetByUsername(ctx, username)
 }
 
 // GetUserByEmail 根据邮箱获取用户
-func (s *userService) GetUserByEmail(email string) (*model.User, error) {
-	return s.userRepo.GetByEmail(email)
+func (s *userService) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	return s.userRepo.GetByEmail(ctx, email)
 }
 
 // UpdateUser 更新用户
-func (s *userService) UpdateUser(user *model.User) error {
-	return s.userRepo.Update(user)
+func (s *userService) UpdateUser(ctx context.Context, user *model.User) error {
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	s.bus.Publish(ctx, event.Event{Type: event.UserUpdated, UserID: user.ID})
+	return nil
 }
 
-// DeleteUser 删除用户
-func (s *userService) DeleteUser(id uint) error {
-	return s.userRepo.Delete(id)
+// DeleteUser 删除用户，同时吊销其全部会话
+func (s *userService) DeleteUser(ctx context.Context, id uint) error {
+	if err := s.userRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.bus.Publish(ctx, event.Event{Type: event.UserDeleted, UserID: id})
+	return s.tokenService.RevokeAll(ctx, id)
 }
 
 // ListUsers 获取用户列表
-func (s *userService) ListUsers(page, pageSize int) ([]*model.User, int64, error) {
-	return s.userRepo.List(page, pageSize)
+// 指定关键词时通过全文索引筛选用户，结果仍按 query.OrderBy 排序；索引尚未重建完成时改用数据库模糊匹配
+// 匹配过多时只在相关度最高的用户中排序，第三个返回值为 true
+func (s *userService) ListUsers(ctx context.Context, query *model.UserListQuery) ([]*model.User, int64, bool, error) {
+	var ids []uint
+	var truncated bool
+	if query.Keyword != "" && s.searchService.Ready() {
+		ids, truncated = s.searchService.MatchUserIDs(query.Keyword)
+		if len(ids) == 0 {
+			return []*model.User{}, 0, false, nil
+		}
+	}
+
+	users, total, err := s.userRepo.List(ctx, query, ids)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	return users, total, truncated, nil
 }
 
-// SearchUsers 搜索用户
-func (s *userService) SearchUsers(keyword string, page, pageSize int) ([]*model.User, int64, error) {
-	return s.userRepo.FindByKeyword(keyword, page, pageSize)
+// SuggestUsers 根据用户名前缀联想用户，用于提及时选择用户
+func (s *userService) SuggestUsers(ctx context.Context, prefix string, limit int) ([]*model.UserSuggestion, error) {
+	users, err := s.userRepo.SuggestByPrefix(ctx, strings.TrimPrefix(strings.TrimSpace(prefix), "@"), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := make([]*model.UserSuggestion, len(users))
+	for i, user := range users {
+		suggestions[i] = &model.UserSuggestion{
+			ID:       user.ID,
+			Username: user.Username,
+			Avatar:   user.Avatar,
+		}
+	}
+	return suggestions, nil
 }
 
-// ChangePassword 修改密码
-func (s *userService) ChangePassword(id uint, newPasswordHash string) error {
-	return s.userRepo.UpdatePassword(id, newPasswordHash)
+// ChangePassword 修改密码，同时吊销全部会话
+func (s *userService) ChangePassword(ctx context.Context, id uint, newPasswordHash string) error {
+	if err := s.userRepo.UpdatePassword(ctx, id, newPasswordHash); err != nil {
+		return err
+	}
+	return s.tokenService.RevokeAll(ctx, id)
 }
 
-// UpdateUserRole 更新用户角色
-func (s *userService) UpdateUserRole(id uint, role string) error {
-	user, err := s.userRepo.GetByID(id)
+// UpdateUserRole 更新用户角色，角色变化时吊销全部会话，使新角色立即生效
+func (s *userService) UpdateUserRole(ctx context.Context, id uint, role string) error {
+	user, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
 		return err
 	}
+	if user.Role == role {
+		return nil
+	}
 
 	user.Role = role
-	return s.userRepo.Update(user)
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+	return s.tokenService.RevokeAll(ctx, id)
 }
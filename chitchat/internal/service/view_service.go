@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lllllan02/chitchat/internal/repository"
+	"github.com/lllllan02/chitchat/internal/utils"
+	"github.com/lllllan02/chitchat/internal/viewcount"
+	"github.com/lllllan02/chitchat/pkg/logger"
+)
+
+// ViewService 帖子浏览次数服务接口
+// 浏览在去重窗口内按浏览者去重，累计后定期批量写入数据库，停止时写入剩余的计数
+type ViewService interface {
+	RecordView(ctx context.Context, postID uint, viewer string) error
+	Flush() error
+	Start()
+	Stop()
+}
+
+// viewService 帖子浏览次数服务实现
+type viewService struct {
+	cfg      utils.ViewCountConfig
+	store    viewcount.Store
+	postRepo repository.PostRepository
+
+	flushMu sync.Mutex // 保证同一时间只有一次写入
+
+	mu       sync.Mutex
+	running  bool
+	stopped  bool
+	done     chan struct{} // 通知定期写入停止
+	finished chan struct{} // 定期写入已经退出
+}
+
+// NewViewService 创建帖子浏览次数服务
+func NewViewService(cfg utils.ViewCountConfig, store viewcount.Store, postRepo repository.PostRepository) ViewService {
+	return &viewService{
+		cfg:      cfg,
+		store:    store,
+		postRepo: postRepo,
+		done:     make(chan struct{}),
+		finished: make(chan struct{}),
+	}
+}
+
+// RecordView 记录一次浏览，viewer 标识浏览者，如 "user:1" 或 "ip:192.0.2.1"
+func (s *viewService) RecordView(ctx context.Context, postID uint, viewer string) error {
+	first, err := s.store.Seen(ctx, postID, viewer, s.cfg.Window())
+	if err != nil || !first {
+		return err
+	}
+	return s.store.Add(ctx, map[uint]int64{postID: 1})
+}
+
+// Flush 将累计的浏览次数写入数据库，写入失败时计数放回暂存等待下次写入
+func (s *viewService) Flush() error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	ctx := context.Background()
+	counts, err := s.store.Drain(ctx)
+	if err != nil || len(counts) == 0 {
+		return err
+	}
+
+	if err := s.postRepo.AddViewCounts(ctx, counts); err != nil {
+		if restoreErr := s.store.Add(ctx, counts); restoreErr != nil {
+			logger.Error("浏览次数放回暂存失败，计数已丢失", logger.Int("posts", len(counts)), logger.Err(restoreErr))
+		}
+		return err
+	}
+	return nil
+}
+
+// Start 启动定期写入
+func (s *viewService) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running || s.stopped {
+		return
+	}
+	s.running = true
+	go s.run()
+}
+
+// Stop 停止定期写入并写入剩余的计数，可以重复调用
+func (s *viewService) Stop() {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return
+	}
+	s.stopped = true
+	running := s.running
+	s.mu.Unlock()
+
+	if !running {
+		if err := s.Flush(); err != nil {
+			logger.Error("停止时写入浏览次数失败", logger.Err(err))
+		}
+		return
+	}
+	close(s.done)
+	<-s.finished
+}
+
+// run 按间隔写入浏览次数，收到停止信号后做最后一次写入
+func (s *viewService) run() {
+	defer close(s.finished)
+
+	ticker := time.NewTicker(s.cfg.Interval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Flush(); err != nil {
+				logger.Error("写入浏览次数失败", logger.Err(err))
+			}
+		case <-s.done:
+			if err := s.Flush(); err != nil {
+				logger.Error("停止时写入浏览次数失败", logger.Err(err))
+			}
+			return
+		}
+	}
+}
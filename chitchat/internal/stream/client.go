@@ -0,0 +1,92 @@
+package stream
+
+import (
+	"sync"
+)
+
+// Client 单个推送连接
+type Client struct {
+	userID uint
+	send   chan *Message
+
+	mu    sync.RWMutex
+	posts map[uint]bool
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// newClient 创建连接
+func newClient(userID uint, postIDs []uint, bufferSize int) *Client {
+	c := &Client{
+		userID: userID,
+		send:   make(chan *Message, bufferSize),
+		posts:  make(map[uint]bool),
+		done:   make(chan struct{}),
+	}
+	for _, id := range postIDs {
+		c.posts[id] = true
+	}
+	return c
+}
+
+// Messages 待发送给客户端的消息
+func (c *Client) Messages() <-chan *Message {
+	return c.send
+}
+
+// Done 连接被关闭时关闭该通道
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Watch 开始接收帖子的实时消息
+func (c *Client) Watch(postID uint) {
+	c.mu.Lock()
+	c.posts[postID] = true
+	c.mu.Unlock()
+}
+
+// Unwatch 停止接收帖子的实时消息
+func (c *Client) Unwatch(postID uint) {
+	c.mu.Lock()
+	delete(c.posts, postID)
+	c.mu.Unlock()
+}
+
+// wants 判断消息是否需要推送给该连接
+func (c *Client) wants(msg *Message) bool {
+	if msg.Type == MessageReset {
+		return true
+	}
+	if msg.userID != 0 {
+		return msg.userID == c.userID
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.posts[msg.PostID]
+}
+
+// enqueue 非阻塞地放入发送缓冲区，缓冲区已满时返回 false
+func (c *Client) enqueue(msg *Message) bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+	}
+
+	select {
+	case c.send <- msg:
+		return true
+	default:
+		return false
+	}
+}
+
+// close 关闭连接
+func (c *Client) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+}
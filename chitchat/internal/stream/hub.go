@@ -0,0 +1,188 @@
+package stream
+
+import (
+	"context"
+	"sync"
+
+	"github.com/lllllan02/chitchat/internal/event"
+)
+
+// 推送消息类型
+const (
+	MessageNotification = "notification"
+	MessageComment      = "comment"
+	MessageLikeCount    = "like_count"
+	MessageReset        = "reset" // 断线期间丢失了部分消息，客户端应重新拉取数据
+)
+
+const (
+	// 保留最近的消息用于断线重连时补发
+	defaultHistorySize = 1024
+	// 每个连接的发送缓冲区大小，写满时断开该连接
+	defaultBufferSize = 256
+)
+
+// Message 推送给客户端的消息
+type Message struct {
+	ID     uint64      `json:"id"`
+	Type   string      `json:"type"`
+	PostID uint        `json:"post_id,omitempty"`
+	Data   interface{} `json:"data,omitempty"`
+
+	// 接收者用户ID，为0时表示按帖子推送给正在浏览的连接
+	userID uint
+}
+
+// LikeCount 点赞数变化消息内容
+type LikeCount struct {
+	PostID    uint `json:"post_id"`
+	CommentID uint `json:"comment_id,omitempty"`
+	LikeCount int  `json:"like_count"`
+}
+
+// Hub 管理所有推送连接，并保留最近的消息用于断线续传
+type Hub struct {
+	mu          sync.Mutex
+	clients     map[*Client]struct{}
+	history     []*Message
+	historySize int
+	bufferSize  int
+	lastID      uint64
+	closed      bool
+}
+
+// NewHub 创建推送中心，并订阅事件总线上需要推送的事件
+func NewHub(bus *event.Bus) *Hub {
+	h := &Hub{
+		clients:     make(map[*Client]struct{}),
+		historySize: defaultHistorySize,
+		bufferSize:  defaultBufferSize,
+	}
+
+	bus.Subscribe(event.NotificationCreated, h.onNotificationCreated)
+	bus.Subscribe(event.CommentCreated, h.onCommentCreated)
+	bus.Subscribe(event.LikeCountChanged, h.onLikeCountChanged)
+
+	return h
+}
+
+// Register 注册连接，并补发 lastEventID 之后该连接可见的消息
+func (h *Hub) Register(userID uint, postIDs []uint, lastEventID uint64) *Client {
+	c := newClient(userID, postIDs, h.bufferSize)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// 推送中心已关闭，连接立即结束
+	if h.closed {
+		c.close()
+		return c
+	}
+
+	// 客户端的消息ID比当前还新，说明服务已重启，历史消息不可用
+	if lastEventID > h.lastID {
+		c.enqueue(&Message{ID: h.lastID, Type: MessageReset})
+	}
+
+	if lastEventID > 0 && lastEventID < h.lastID {
+		// 需要的消息已经不在历史中，通知客户端重新拉取
+		if len(h.history) == 0 || h.history[0].ID > lastEventID+1 {
+			resetID := h.lastID
+			if len(h.history) > 0 {
+				resetID = h.history[0].ID - 1
+			}
+			c.enqueue(&Message{ID: resetID, Type: MessageReset})
+		}
+
+		for _, msg := range h.history {
+			if msg.ID > lastEventID && c.wants(msg) && !c.enqueue(msg) {
+				// 补发的消息超过缓冲区，直接断开让客户端重新拉取
+				c.close()
+				return c
+			}
+		}
+	}
+
+	h.clients[c] = struct{}{}
+	return c
+}
+
+// Unregister 注销连接
+func (h *Hub) Unregister(c *Client) {
+	h.mu.Lock()
+	delete(h.clients, c)
+	h.mu.Unlock()
+
+	c.close()
+}
+
+// Close 关闭所有连接，之后注册的连接也会立即关闭，可以重复调用
+// SSE 和 WebSocket 连接不会随 http.Server.Shutdown 结束，服务器关闭时需要调用它让推送循环退出
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for c := range h.clients {
+		c.close()
+	}
+	h.clients = make(map[*Client]struct{})
+}
+
+// SendToUser 向用户的所有连接推送消息
+func (h *Hub) SendToUser(userID uint, msgType string, data interface{}) {
+	h.publish(&Message{Type: msgType, Data: data, userID: userID})
+}
+
+// SendToPost 向正在浏览帖子的所有连接推送消息
+func (h *Hub) SendToPost(postID uint, msgType string, data interface{}) {
+	h.publish(&Message{Type: msgType, PostID: postID, Data: data})
+}
+
+// publish 分配消息ID、记录历史并投递给相关连接
+func (h *Hub) publish(msg *Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastID++
+	msg.ID = h.lastID
+
+	h.history = append(h.history, msg)
+	if len(h.history) > h.historySize {
+		h.history = h.history[len(h.history)-h.historySize:]
+	}
+
+	for c := range h.clients {
+		if !c.wants(msg) {
+			continue
+		}
+		// 发送缓冲区已满说明客户端消费过慢，断开后由客户端携带最后的消息ID重连
+		if !c.enqueue(msg) {
+			delete(h.clients, c)
+			c.close()
+		}
+	}
+}
+
+// onNotificationCreated 推送新通知给接收者
+func (h *Hub) onNotificationCreated(_ context.Context, e event.Event) error {
+	h.SendToUser(e.UserID, MessageNotification, e.Payload)
+	return nil
+}
+
+// onCommentCreated 推送新评论给正在浏览帖子的连接
+func (h *Hub) onCommentCreated(_ context.Context, e event.Event) error {
+	h.SendToPost(e.PostID, MessageComment, e.Payload)
+	return nil
+}
+
+// onLikeCountChanged 推送点赞数变化给正在浏览帖子的连接
+func (h *Hub) onLikeCountChanged(_ context.Context, e event.Event) error {
+	likeCount, _ := e.Payload.(int)
+	h.SendToPost(e.PostID, MessageLikeCount, LikeCount{
+		PostID:    e.PostID,
+		CommentID: e.CommentID,
+		LikeCount: likeCount,
+	})
+	return nil
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ActionClaims 一次性操作令牌声明，用于邮箱验证和密码重置等通过邮件链接完成的操作
+// Fingerprint 是签发时相关状态的摘要，状态变化后令牌随之失效，从而保证只能使用一次
+type ActionClaims struct {
+	Purpose     string `json:"purpose"`
+	UserID      uint   `json:"user_id"`
+	Fingerprint string `json:"fp"`
+	jwt.RegisteredClaims
+}
+
+// GenerateActionToken 生成一次性操作令牌，每个令牌带有唯一ID，用于需要单独作废的场景
+func GenerateActionToken(secret, purpose string, userID uint, fingerprint string, ttl time.Duration) (string, error) {
+	tokenID, err := RandomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := &ActionClaims{
+		Purpose:     purpose,
+		UserID:      userID,
+		Fingerprint: fingerprint,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    "chitchat",
+			Subject:   purpose,
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(actionKey(secret, purpose))
+}
+
+// ParseActionToken 解析一次性操作令牌，用途不符时视为无效
+func ParseActionToken(secret, purpose, tokenString string) (*ActionClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &ActionClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return actionKey(secret, purpose), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*ActionClaims); ok && token.Valid && claims.Purpose == purpose {
+		return claims, nil
+	}
+
+	return nil, errors.New("无效的令牌")
+}
+
+// Fingerprint 计算状态摘要
+func Fingerprint(state string) string {
+	return HashToken(state)[:16]
+}
+
+// actionKey 按用途派生签名密钥，避免不同用途的令牌以及访问令牌之间互相冒用
+func actionKey(secret, purpose string) []byte {
+	return []byte(secret + "|action|" + purpose)
+}
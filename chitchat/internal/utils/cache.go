@@ -0,0 +1,19 @@
+package utils
+
+import "time"
+
+// 缓存的默认值
+const (
+	defaultCacheSize = 10000
+	defaultCacheTTL  = 5 * time.Minute
+)
+
+// MaxEntries 内存缓存的最大条目数
+func (c CacheConfig) MaxEntries() int {
+	return positiveOr(c.Size, defaultCacheSize)
+}
+
+// Expiration 缓存有效期
+func (c CacheConfig) Expiration() time.Duration {
+	return parseTTL(c.TTL, defaultCacheTTL)
+}
@@ -2,43 +2,56 @@ package utils
 
 import (
 	"fmt"
-	"log"
 
+	"github.com/lllllan02/chitchat/pkg/logger"
 	"github.com/spf13/viper"
 )
 
 // Config 全局配置结构
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	JWT      JWTConfig      `mapstructure:"jwt"`
-	Upload   UploadConfig   `mapstructure:"upload"`
-	Redis    RedisConfig    `mapstructure:"redis"`
+	Server    ServerConfig    `mapstructure:"server"`
+	Database  DatabaseConfig  `mapstructure:"database"`
+	JWT       JWTConfig       `mapstructure:"jwt"`
+	Upload    UploadConfig    `mapstructure:"upload"`
+	Redis     RedisConfig     `mapstructure:"redis"`
+	Mail      MailConfig      `mapstructure:"mail"`
+	Auth      AuthConfig      `mapstructure:"auth"`
+	OAuth     OAuthConfig     `mapstructure:"oauth"`
+	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+	Cache     CacheConfig     `mapstructure:"cache"`
+	ViewCount ViewCountConfig `mapstructure:"view_count"`
+	Search    SearchConfig    `mapstructure:"search"`
+	Log       logger.Config   `mapstructure:"log"`
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	Port int    `mapstructure:"port"`
-	Mode string `mapstructure:"mode"`
+	Port           int      `mapstructure:"port"`
+	Mode           string   `mapstructure:"mode"`
+	AllowedOrigins []string `mapstructure:"allowed_origins"` // 允许跨域访问的来源，为空时只允许同源，* 表示任意来源
 }
 
 // DatabaseConfig 数据库配置
+// Driver 支持 mysql、postgres 和 sqlite，使用 sqlite 时 DBName 为数据库文件路径，":memory:" 表示内存数据库
 type DatabaseConfig struct {
-	Driver      string `mapstructure:"driver"`
-	Host        string `mapstructure:"host"`
-	Port        int    `mapstructure:"port"`
-	Username    string `mapstructure:"username"`
-	Password    string `mapstructure:"password"`
-	DBName      string `mapstructure:"dbname"`
-	Charset     string `mapstructure:"charset"`
-	MaxIdleConn int    `mapstructure:"max_idle_conns"`
-	MaxOpenConn int    `mapstructure:"max_open_conns"`
+	Driver        string `mapstructure:"driver"`
+	Host          string `mapstructure:"host"`
+	Port          int    `mapstructure:"port"`
+	Username      string `mapstructure:"username"`
+	Password      string `mapstructure:"password"`
+	DBName        string `mapstructure:"dbname"`
+	Charset       string `mapstructure:"charset"`
+	SSLMode       string `mapstructure:"sslmode"`
+	MaxIdleConn   int    `mapstructure:"max_idle_conns"`
+	MaxOpenConn   int    `mapstructure:"max_open_conns"`
+	SlowThreshold string `mapstructure:"slow_threshold"` // 慢查询阈值，超过时记录警告日志，默认 1s
 }
 
 // JWTConfig JWT配置
 type JWTConfig struct {
-	Secret string `mapstructure:"secret"`
-	Expire string `mapstructure:"expire"`
+	Secret        string `mapstructure:"secret"`
+	Expire        string `mapstructure:"expire"`         // 访问令牌有效期
+	RefreshExpire string `mapstructure:"refresh_expire"` // 刷新令牌有效期
 }
 
 // UploadConfig 文件上传配置
@@ -57,6 +70,105 @@ type RedisConfig struct {
 	DB       int    `mapstructure:"db"`
 }
 
+// MailConfig 邮件配置
+// Driver 支持 smtp、file 和 memory，file 将邮件写入 OutboxDir 目录，memory 仅用于测试
+type MailConfig struct {
+	Driver    string `mapstructure:"driver"`
+	Host      string `mapstructure:"host"`
+	Port      int    `mapstructure:"port"`
+	Username  string `mapstructure:"username"`
+	Password  string `mapstructure:"password"`
+	From      string `mapstructure:"from"`
+	OutboxDir string `mapstructure:"outbox_dir"`
+	BaseURL   string `mapstructure:"base_url"` // 邮件中链接指向的前端地址
+}
+
+// AuthConfig 账号安全配置
+type AuthConfig struct {
+	RequireEmailVerification bool          `mapstructure:"require_email_verification"` // 邮箱验证前禁止发帖和评论
+	RequireStaffTwoFactor    bool          `mapstructure:"require_staff_two_factor"`   // 管理员和版主必须通过两步验证才能使用管理功能
+	Lockout                  LockoutConfig `mapstructure:"lockout"`
+}
+
+// LockoutConfig 登录防暴力破解配置，未设置的项使用默认值
+// 连续失败达到 DelayAfter 次后，每次失败需要等待的时间翻倍，最长 MaxDelay
+// 同一账号连续失败达到 LockAfter 次后锁定 LockDuration，超过 ResetAfter 没有失败时重新计数
+type LockoutConfig struct {
+	DelayAfter         int    `mapstructure:"delay_after"`           // 同一账号开始延迟的失败次数，默认 3
+	IPDelayAfter       int    `mapstructure:"ip_delay_after"`        // 同一 IP 开始延迟的失败次数，默认 20
+	MaxDelay           string `mapstructure:"max_delay"`             // 默认 1m
+	LockAfter          int    `mapstructure:"lock_after"`            // 默认 10
+	TwoFactorLockAfter int    `mapstructure:"two_factor_lock_after"` // 同一账号两步验证码连续错误多少次后锁定，默认 5
+	LockDuration       string `mapstructure:"lock_duration"`         // 默认 15m
+	ResetAfter         string `mapstructure:"reset_after"`           // 默认 1h
+}
+
+// OAuthConfig 第三方登录配置
+type OAuthConfig struct {
+	Providers []OAuthProviderConfig `mapstructure:"providers"`
+}
+
+// OAuthProviderConfig OAuth2/OIDC 身份提供方配置
+// 设置 Issuer 时通过 OIDC 发现文档获取各端点，显式配置的端点优先
+// 配置了 JWKSURL（或由发现文档提供）时校验 ID Token，否则通过 UserInfoURL 获取用户信息
+type OAuthProviderConfig struct {
+	Name         string   `mapstructure:"name"` // 路由中使用的标识
+	DisplayName  string   `mapstructure:"display_name"`
+	Issuer       string   `mapstructure:"issuer"`
+	AuthURL      string   `mapstructure:"auth_url"`
+	TokenURL     string   `mapstructure:"token_url"`
+	UserInfoURL  string   `mapstructure:"userinfo_url"`
+	JWKSURL      string   `mapstructure:"jwks_url"`
+	ClientID     string   `mapstructure:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret"`
+	RedirectURL  string   `mapstructure:"redirect_url"` // 前端回调页面地址，需在身份提供方处登记
+	Scopes       []string `mapstructure:"scopes"`
+	LinkByEmail  bool     `mapstructure:"link_by_email"` // 首次登录时按已验证的邮箱关联已有账号
+}
+
+// RateLimitConfig 限流配置，使用令牌桶算法
+// 已登录用户按用户ID计数，游客按IP计数；启用 Redis 时计数保存在 Redis 中，多个实例共享
+type RateLimitConfig struct {
+	Enabled  bool              `mapstructure:"enabled"`
+	Default  RateLimitRule     `mapstructure:"default"` // 未匹配任何策略的请求，Rate 为空时不限制
+	Policies []RateLimitPolicy `mapstructure:"policies"`
+}
+
+// RateLimitRule 限流规则
+type RateLimitRule struct {
+	Rate  string `mapstructure:"rate"`  // 如 "10/s"、"30/m"、"100/1h"，为空时不限制
+	Burst int    `mapstructure:"burst"` // 桶容量，默认等于每个周期的请求数
+}
+
+// RateLimitPolicy 针对部分路由的限流策略，可以按角色覆盖规则
+type RateLimitPolicy struct {
+	Name          string   `mapstructure:"name"`
+	Routes        []string `mapstructure:"routes"` // 如 "POST /api/v1/posts"，路径使用路由模板，如 /api/v1/posts/:id
+	RateLimitRule `mapstructure:",squash"`
+	Roles         map[string]RateLimitRule `mapstructure:"roles"` // 角色对应的规则，Rate 为空表示该角色不限制
+}
+
+// CacheConfig 热点数据缓存配置，缓存分类列表、帖子详情、置顶和精华帖子
+// 启用 Redis 时缓存保存在 Redis 中，多个实例共享；否则使用进程内的 LRU 缓存
+type CacheConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Size    int    `mapstructure:"size"` // 内存缓存的最大条目数，默认 10000
+	TTL     string `mapstructure:"ttl"`  // 缓存有效期，默认 5m
+}
+
+// ViewCountConfig 帖子浏览次数统计配置
+// 浏览先在内存或 Redis 中去重和累计，再定期批量写入数据库
+type ViewCountConfig struct {
+	DedupWindow   string `mapstructure:"dedup_window"`   // 同一用户或 IP 重复浏览同一帖子只计一次的时间窗口，默认 30m
+	FlushInterval string `mapstructure:"flush_interval"` // 写入数据库的间隔，默认 10s
+}
+
+// SearchConfig 全文搜索配置
+// 索引在进程内，启动时在后台从数据库重建
+type SearchConfig struct {
+	RebuildRetry string `mapstructure:"rebuild_retry"` // 重建索引失败后的重试间隔，默认 30s
+}
+
 var AppConfig Config
 
 // LoadConfig 加载配置文件
@@ -69,7 +181,7 @@ func LoadConfig(path string) error {
 
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			log.Println("未找到配置文件，将使用默认配置和环境变量")
+			logger.Warn("未找到配置文件，将使用默认配置和环境变量")
 			return createDefaultConfig()
 		}
 		return fmt.Errorf("读取配置文件错误: %v", err)
@@ -86,8 +198,9 @@ func LoadConfig(path string) error {
 func createDefaultConfig() error {
 	AppConfig = Config{
 		Server: ServerConfig{
-			Port: 8080,
-			Mode: "development",
+			Port:           8080,
+			Mode:           "development",
+			AllowedOrigins: []string{"http://localhost:3000"},
 		},
 		Database: DatabaseConfig{
 			Driver:      "mysql",
@@ -101,8 +214,9 @@ func createDefaultConfig() error {
 			MaxOpenConn: 100,
 		},
 		JWT: JWTConfig{
-			Secret: "default_secret_key",
-			Expire: "24h",
+			Secret:        "default_secret_key",
+			Expire:        "15m",
+			RefreshExpire: "720h",
 		},
 		Upload: UploadConfig{
 			MaxSize:      5,
@@ -116,13 +230,44 @@ func createDefaultConfig() error {
 			Password: "",
 			DB:       0,
 		},
+		Mail: MailConfig{
+			Driver:    "file",
+			From:      "chitchat <noreply@localhost>",
+			OutboxDir: "./data/outbox",
+			BaseURL:   "http://localhost:3000",
+		},
+		Auth: AuthConfig{
+			RequireEmailVerification: false,
+			RequireStaffTwoFactor:    false,
+		},
 	}
 	return nil
 }
 
+// 支持的数据库驱动
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
 // GetDSN 获取数据库连接字符串
-func GetDSN() string {
-	db := AppConfig.Database
-	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=True&loc=Local",
-		db.Username, db.Password, db.Host, db.Port, db.DBName, db.Charset)
+func GetDSN(db DatabaseConfig) string {
+	switch db.Driver {
+	case DriverPostgres:
+		sslMode := db.SSLMode
+		if sslMode == "" {
+			sslMode = "disable"
+		}
+		return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+			db.Host, db.Port, db.Username, db.Password, db.DBName, sslMode)
+	case DriverSQLite:
+		if db.DBName == "" || db.DBName == ":memory:" {
+			return ":memory:"
+		}
+		return fmt.Sprintf("%s?_busy_timeout=5000", db.DBName)
+	default:
+		return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=True&loc=Local",
+			db.Username, db.Password, db.Host, db.Port, db.DBName, db.Charset)
+	}
 }
@@ -1,50 +1,73 @@
 package utils
 
 import (
-	"log"
+	"fmt"
+	"os"
+	"path/filepath"
 	"time"
 
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
-var DB *gorm.DB
-
-// InitDB 初始化数据库连接
-func InitDB() error {
-	var err error
-
-	// 配置GORM日志
-	gormLogger := logger.New(
-		log.New(log.Writer(), "\r\n", log.LstdFlags),
-		logger.Config{
-			SlowThreshold: time.Second, // 慢查询阈值
-			LogLevel:      logger.Info, // 日志级别
-			Colorful:      true,        // 彩色输出
-		},
-	)
+// InitDB 根据配置创建数据库连接
+func InitDB(cfg DatabaseConfig) (*gorm.DB, error) {
+	// 根据驱动选择方言
+	dialector, err := openDialector(cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	// 连接数据库
-	DB, err = gorm.Open(mysql.Open(GetDSN()), &gorm.Config{
-		Logger: gormLogger,
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger: newGormLogger(cfg.SlowQueryThreshold()),
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// 配置连接池
-	sqlDB, err := DB.DB()
+	sqlDB, err := db.DB()
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	// SQLite 只允许单个写连接，内存数据库的每个连接都是独立的库，因此只保留一个永不过期的连接
+	if cfg.Driver == DriverSQLite {
+		sqlDB.SetMaxIdleConns(1)
+		sqlDB.SetMaxOpenConns(1)
+		return db, nil
 	}
 
 	// 设置最大空闲连接数
-	sqlDB.SetMaxIdleConns(AppConfig.Database.MaxIdleConn)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConn)
 	// 设置最大打开连接数
-	sqlDB.SetMaxOpenConns(AppConfig.Database.MaxOpenConn)
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConn)
 	// 设置连接最大生存时间
 	sqlDB.SetConnMaxLifetime(time.Hour)
 
-	return nil
+	return db, nil
+}
+
+// openDialector 根据驱动名称创建GORM方言
+func openDialector(cfg DatabaseConfig) (gorm.Dialector, error) {
+	dsn := GetDSN(cfg)
+	switch cfg.Driver {
+	case DriverMySQL, "":
+		return mysql.Open(dsn), nil
+	case DriverPostgres:
+		return postgres.Open(dsn), nil
+	case DriverSQLite:
+		// 确保数据库文件所在目录存在
+		if dsn != ":memory:" {
+			if err := os.MkdirAll(filepath.Dir(cfg.DBName), os.ModePerm); err != nil {
+				return nil, err
+			}
+		}
+		return sqlite.Open(dsn), nil
+	default:
+		return nil, fmt.Errorf("不支持的数据库驱动: %s", cfg.Driver)
+	}
 }
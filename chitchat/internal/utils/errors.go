@@ -1,15 +1,55 @@
 package utils
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 // 定义错误常量
 var (
-	ErrPermissionDenied  = errors.New("permission denied")
-	ErrCategoryNotFound  = errors.New("category not found")
-	ErrUserNotFound      = errors.New("user not found")
-	ErrPostNotFound      = errors.New("post not found")
-	ErrInvalidParameters = errors.New("invalid parameters")
-	ErrInternalServer    = errors.New("internal server error")
-	ErrRecordNotFound    = errors.New("record not found")
-	ErrDuplicateRecord   = errors.New("duplicate record")
+	ErrPermissionDenied     = errors.New("permission denied")
+	ErrCategoryNotFound     = errors.New("category not found")
+	ErrUserNotFound         = errors.New("user not found")
+	ErrPostNotFound         = errors.New("post not found")
+	ErrCommentNotFound      = errors.New("comment not found")
+	ErrInvalidParentComment = errors.New("parent comment does not belong to post")
+	ErrCannotFollowSelf     = errors.New("cannot follow yourself")
+	ErrNotificationNotFound = errors.New("notification not found")
+	ErrInvalidToken         = errors.New("invalid token")
+	ErrTokenRevoked         = errors.New("token revoked")
+	ErrSessionNotFound      = errors.New("session not found")
+	ErrEmailAlreadyVerified = errors.New("email already verified")
+	ErrEmailNotVerified     = errors.New("email not verified")
+	ErrTwoFactorEnabled     = errors.New("two-factor authentication already enabled")
+	ErrTwoFactorNotEnabled  = errors.New("two-factor authentication not enabled")
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	ErrInvalidPassword      = errors.New("invalid password")
+	ErrProviderNotFound     = errors.New("oauth provider not found")
+	ErrInvalidOAuthState    = errors.New("invalid oauth state")
+	ErrOAuthFailed          = errors.New("oauth authentication failed")
+	ErrEmailInUse           = errors.New("email already in use")
+	ErrTooManyAttempts      = errors.New("too many failed attempts")
+	ErrAccountLocked        = errors.New("account locked")
+	ErrInvalidParameters    = errors.New("invalid parameters")
+	ErrInternalServer       = errors.New("internal server error")
+	ErrRecordNotFound       = errors.New("record not found")
+	ErrDuplicateRecord      = errors.New("duplicate record")
+	ErrInvalidCursor        = errors.New("invalid cursor")
+	ErrSearchNotReady       = errors.New("search index not ready")
 )
+
+// RetryAfterError 需要等待一段时间后才能重试的错误
+type RetryAfterError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+// Error 实现 error 接口
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap 支持 errors.Is 判断原始错误
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lllllan02/chitchat/pkg/logger"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// 慢查询阈值的默认值
+const defaultSlowThreshold = time.Second
+
+// SlowQueryThreshold 慢查询阈值
+func (c DatabaseConfig) SlowQueryThreshold() time.Duration {
+	return parseTTL(c.SlowThreshold, defaultSlowThreshold)
+}
+
+// gormLogger 将 GORM 日志写入结构化日志
+// 日志记录器从查询的上下文中获取，请求中执行的 SQL 会带上请求 ID
+// 查询出错记为 error，慢查询记为 warn，其余 SQL 只在 debug 级别记录
+type gormLogger struct {
+	slowThreshold time.Duration
+}
+
+// newGormLogger 创建 GORM 日志适配器
+func newGormLogger(slowThreshold time.Duration) gormlogger.Interface {
+	return &gormLogger{slowThreshold: slowThreshold}
+}
+
+// LogMode 日志级别由 logger 包的配置决定，这里不做处理
+func (l *gormLogger) LogMode(gormlogger.LogLevel) gormlogger.Interface {
+	return l
+}
+
+// Info GORM 内部的信息日志
+func (l *gormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	logger.FromContext(ctx).Info(fmt.Sprintf(msg, args...))
+}
+
+// Warn GORM 内部的警告日志
+func (l *gormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	logger.FromContext(ctx).Warn(fmt.Sprintf(msg, args...))
+}
+
+// Error GORM 内部的错误日志
+func (l *gormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	logger.FromContext(ctx).Error(fmt.Sprintf(msg, args...))
+}
+
+// Trace 记录执行的 SQL，未找到记录不视为错误
+func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	log := logger.FromContext(ctx)
+	elapsed := time.Since(begin)
+
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		log.Error("SQL 执行失败", logger.String("sql", sql), logger.Int64("rows", rows),
+			logger.Duration("elapsed", elapsed), logger.Err(err))
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold:
+		sql, rows := fc()
+		log.Warn("慢查询", logger.String("sql", sql), logger.Int64("rows", rows),
+			logger.Duration("elapsed", elapsed), logger.Duration("threshold", l.slowThreshold))
+	case log.Enabled(logger.LevelDebug):
+		sql, rows := fc()
+		log.Debug("SQL", logger.String("sql", sql), logger.Int64("rows", rows),
+			logger.Duration("elapsed", elapsed))
+	}
+}
@@ -1,51 +1,86 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// 默认令牌有效期
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
 // CustomClaims 自定义JWT声明
 type CustomClaims struct {
-	UserID uint   `json:"user_id"`
-	Role   string `json:"role"`
+	UserID    uint   `json:"user_id"`
+	Role      string `json:"role"`
+	Version   int    `json:"ver"` // 签发时用户的令牌版本
+	SessionID uint   `json:"sid"` // 所属登录会话
+	TwoFactor bool   `json:"mfa"` // 登录时是否通过了两步验证
+	Locale    string `json:"-"`   // 用户的语言偏好，校验令牌时从用户信息读取，不写入令牌
 	jwt.RegisteredClaims
 }
 
-// GenerateToken 生成JWT令牌
-func GenerateToken(userID uint, role string) (string, error) {
-	// 解析过期时间
-	expDuration, err := time.ParseDuration(AppConfig.JWT.Expire)
+// AccessTokenTTL 访问令牌有效期
+func (c JWTConfig) AccessTokenTTL() time.Duration {
+	return parseTTL(c.Expire, defaultAccessTokenTTL)
+}
+
+// RefreshTokenTTL 刷新令牌有效期
+func (c JWTConfig) RefreshTokenTTL() time.Duration {
+	return parseTTL(c.RefreshExpire, defaultRefreshTokenTTL)
+}
+
+// parseTTL 解析有效期，格式错误时使用默认值
+func parseTTL(value string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return d
+}
+
+// GenerateToken 生成JWT访问令牌，每个令牌带有唯一ID用于吊销
+// claims 中只需填写用户和会话信息，签发时间、有效期等由本函数设置
+func GenerateToken(cfg JWTConfig, claims CustomClaims) (string, *CustomClaims, error) {
+	tokenID, err := RandomToken(16)
 	if err != nil {
-		expDuration = time.Hour * 24 // 默认24小时
+		return "", nil, err
 	}
 
 	// 创建声明
-	claims := &CustomClaims{
-		UserID: userID,
-		Role:   role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expDuration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			Issuer:    "chitchat",
-			Subject:   "user_auth",
-		},
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        tokenID,
+		ExpiresAt: jwt.NewNumericDate(now.Add(cfg.AccessTokenTTL())),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+		Issuer:    "chitchat",
+		Subject:   "user_auth",
 	}
 
 	// 创建并签名令牌
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(AppConfig.JWT.Secret))
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &claims)
+	signed, err := token.SignedString([]byte(cfg.Secret))
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, &claims, nil
 }
 
 // ParseToken 解析JWT令牌
-func ParseToken(tokenString string) (*CustomClaims, error) {
-	// 解析令牌
+func ParseToken(cfg JWTConfig, tokenString string) (*CustomClaims, error) {
+	// 解析令牌，只接受 HS256 签名
 	token, err := jwt.ParseWithClaims(tokenString, &CustomClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(AppConfig.JWT.Secret), nil
-	})
+		return []byte(cfg.Secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 
 	if err != nil {
 		return nil, err
@@ -58,3 +93,18 @@ func ParseToken(tokenString string) (*CustomClaims, error) {
 
 	return nil, errors.New("无效的令牌")
 }
+
+// RandomToken 生成 n 字节的随机令牌，使用 URL 安全的 base64 编码
+func RandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken 计算令牌的 SHA-256 哈希，用于存储不可逆的令牌摘要
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
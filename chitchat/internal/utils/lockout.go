@@ -0,0 +1,72 @@
+package utils
+
+import "time"
+
+// 登录防暴力破解的默认值
+const (
+	defaultDelayAfter         = 3
+	defaultIPDelayAfter       = 20
+	defaultMaxDelay           = time.Minute
+	defaultLockAfter          = 10
+	defaultTwoFactorLockAfter = 5
+	defaultLockDuration       = 15 * time.Minute
+	defaultResetAfter         = time.Hour
+
+	// 第一次延迟的时长，之后每次失败翻倍
+	baseLoginDelay = time.Second
+)
+
+// DelayThreshold 同一账号开始延迟的失败次数
+func (c LockoutConfig) DelayThreshold() int {
+	return positiveOr(c.DelayAfter, defaultDelayAfter)
+}
+
+// IPDelayThreshold 同一 IP 开始延迟的失败次数
+func (c LockoutConfig) IPDelayThreshold() int {
+	return positiveOr(c.IPDelayAfter, defaultIPDelayAfter)
+}
+
+// LockThreshold 锁定账号的失败次数
+func (c LockoutConfig) LockThreshold() int {
+	return positiveOr(c.LockAfter, defaultLockAfter)
+}
+
+// TwoFactorLockThreshold 锁定两步验证登录的验证码错误次数
+func (c LockoutConfig) TwoFactorLockThreshold() int {
+	return positiveOr(c.TwoFactorLockAfter, defaultTwoFactorLockAfter)
+}
+
+// LockTTL 账号锁定时长
+func (c LockoutConfig) LockTTL() time.Duration {
+	return parseTTL(c.LockDuration, defaultLockDuration)
+}
+
+// ResetTTL 失败计数的保留时长
+func (c LockoutConfig) ResetTTL() time.Duration {
+	return parseTTL(c.ResetAfter, defaultResetAfter)
+}
+
+// Delay 连续失败 failures 次后下一次尝试前需要等待的时间
+func (c LockoutConfig) Delay(failures, threshold int) time.Duration {
+	if failures < threshold {
+		return 0
+	}
+
+	maxDelay := parseTTL(c.MaxDelay, defaultMaxDelay)
+	delay := baseLoginDelay
+	for i := threshold; i < failures && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		return maxDelay
+	}
+	return delay
+}
+
+// positiveOr 非正数时使用默认值
+func positiveOr(value, fallback int) int {
+	if value <= 0 {
+		return fallback
+	}
+	return value
+}
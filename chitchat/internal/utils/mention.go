@@ -0,0 +1,23 @@
+package utils
+
+import "regexp"
+
+// 匹配 @用户名，@ 前不能是字母数字，以免把邮箱地址识别为提及
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@])@([\p{L}\p{N}_-]{1,50})`)
+
+// ParseMentions 从内容中解析被提及的用户名，按出现顺序去重
+func ParseMentions(content string) []string {
+	var usernames []string
+	seen := make(map[string]bool)
+
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		username := match[1]
+		if seen[username] {
+			continue
+		}
+		seen[username] = true
+		usernames = append(usernames, username)
+	}
+
+	return usernames
+}
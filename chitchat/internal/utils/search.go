@@ -0,0 +1,11 @@
+package utils
+
+import "time"
+
+// 重建搜索索引失败后的默认重试间隔
+const defaultSearchRebuildRetry = 30 * time.Second
+
+// RetryInterval 重建索引失败后的重试间隔
+func (c SearchConfig) RetryInterval() time.Duration {
+	return parseTTL(c.RebuildRetry, defaultSearchRebuildRetry)
+}
@@ -0,0 +1,19 @@
+package utils
+
+import "time"
+
+// 浏览次数统计的默认值
+const (
+	defaultViewDedupWindow   = 30 * time.Minute
+	defaultViewFlushInterval = 10 * time.Second
+)
+
+// Window 重复浏览的去重窗口
+func (c ViewCountConfig) Window() time.Duration {
+	return parseTTL(c.DedupWindow, defaultViewDedupWindow)
+}
+
+// Interval 写入数据库的间隔
+func (c ViewCountConfig) Interval() time.Duration {
+	return parseTTL(c.FlushInterval, defaultViewFlushInterval)
+}
@@ -0,0 +1,73 @@
+package viewcount
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 内存存储每记录多少次浏览清理一次过期的去重记录
+const sweepInterval = 1024
+
+// MemoryStore 进程内的浏览次数暂存，只在单个实例内去重
+type MemoryStore struct {
+	mu      sync.Mutex
+	seen    map[string]time.Time // 去重记录的过期时间
+	pending map[uint]int64
+	calls   int
+}
+
+// NewMemoryStore 创建内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{seen: make(map[string]time.Time), pending: make(map[uint]int64)}
+}
+
+// Seen 记录一次浏览
+func (s *MemoryStore) Seen(ctx context.Context, postID uint, viewer string, window time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.calls++
+	if s.calls%sweepInterval == 0 {
+		s.sweep(now)
+	}
+
+	key := fmt.Sprintf("%d:%s", postID, viewer)
+	if expiresAt, ok := s.seen[key]; ok && now.Before(expiresAt) {
+		return false, nil
+	}
+	s.seen[key] = now.Add(window)
+	return true, nil
+}
+
+// Add 增加待写入的浏览次数
+func (s *MemoryStore) Add(ctx context.Context, counts map[uint]int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for postID, n := range counts {
+		s.pending[postID] += n
+	}
+	return nil
+}
+
+// Drain 取出并清空待写入的浏览次数
+func (s *MemoryStore) Drain(ctx context.Context) (map[uint]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := s.pending
+	s.pending = make(map[uint]int64)
+	return counts, nil
+}
+
+// sweep 删除过期的去重记录
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, expiresAt := range s.seen {
+		if !now.Before(expiresAt) {
+			delete(s.seen, key)
+		}
+	}
+}
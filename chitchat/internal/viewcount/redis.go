@@ -0,0 +1,75 @@
+package viewcount
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// drainScript 原子地读取并删除待写入的浏览次数，避免读取和删除之间新增的计数丢失
+var drainScript = redis.NewScript(`
+local counts = redis.call("HGETALL", KEYS[1])
+redis.call("DEL", KEYS[1])
+return counts
+`)
+
+// RedisStore 基于 Redis 的浏览次数暂存，多个实例共享去重记录和计数
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore 创建 Redis 存储，prefix 为所有键的公共前缀
+func NewRedisStore(client *redis.Client, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Seen 记录一次浏览，去重记录在窗口结束后自动过期
+func (s *RedisStore) Seen(ctx context.Context, postID uint, viewer string, window time.Duration) (bool, error) {
+	key := fmt.Sprintf("%sseen:%d:%s", s.prefix, postID, viewer)
+	return s.client.SetNX(ctx, key, 1, window).Result()
+}
+
+// Add 增加待写入的浏览次数
+func (s *RedisStore) Add(ctx context.Context, counts map[uint]int64) error {
+	if len(counts) == 0 {
+		return nil
+	}
+
+	pipe := s.client.TxPipeline()
+	for postID, n := range counts {
+		pipe.HIncrBy(ctx, s.pendingKey(), strconv.FormatUint(uint64(postID), 10), n)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Drain 取出并清空待写入的浏览次数
+func (s *RedisStore) Drain(ctx context.Context) (map[uint]int64, error) {
+	values, err := drainScript.Run(ctx, s.client, []string{s.pendingKey()}).StringSlice()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uint]int64, len(values)/2)
+	for i := 0; i+1 < len(values); i += 2 {
+		postID, err := strconv.ParseUint(values[i], 10, 64)
+		if err != nil {
+			continue
+		}
+		n, err := strconv.ParseInt(values[i+1], 10, 64)
+		if err != nil {
+			continue
+		}
+		counts[uint(postID)] = n
+	}
+	return counts, nil
+}
+
+// pendingKey 待写入计数的哈希键
+func (s *RedisStore) pendingKey() string {
+	return s.prefix + "pending"
+}
@@ -0,0 +1,17 @@
+// Package viewcount 暂存帖子浏览次数，去重后批量写入数据库
+package viewcount
+
+import (
+	"context"
+	"time"
+)
+
+// Store 浏览次数暂存接口
+type Store interface {
+	// Seen 记录一次浏览，同一浏览者在 window 内再次浏览同一帖子时返回 false
+	Seen(ctx context.Context, postID uint, viewer string, window time.Duration) (bool, error)
+	// Add 增加帖子待写入的浏览次数
+	Add(ctx context.Context, counts map[uint]int64) error
+	// Drain 取出并清空全部待写入的浏览次数
+	Drain(ctx context.Context) (map[uint]int64, error)
+}
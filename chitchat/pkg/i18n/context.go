@@ -0,0 +1,22 @@
+package i18n
+
+import "github.com/gin-gonic/gin"
+
+// contextKey 请求上下文中保存语言的键
+const contextKey = "locale"
+
+// SetLocale 设置当前请求使用的语言，并通过 Content-Language 响应头告知客户端
+func SetLocale(c *gin.Context, locale Locale) {
+	c.Set(contextKey, locale)
+	c.Header("Content-Language", string(locale))
+}
+
+// FromContext 获取当前请求使用的语言，未设置时返回默认语言
+func FromContext(c *gin.Context) Locale {
+	if value, exists := c.Get(contextKey); exists {
+		if locale, ok := value.(Locale); ok {
+			return locale
+		}
+	}
+	return Default
+}
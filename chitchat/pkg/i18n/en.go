@@ -0,0 +1,191 @@
+package i18n
+
+// en 英文消息目录
+var en = map[string]string{
+	// 通用
+	"服务器内部错误":      "Internal server error",
+	"服务器错误":        "Internal server error",
+	"请求参数错误":       "Invalid request parameters",
+	"未授权访问":        "Unauthorized",
+	"禁止访问":         "Forbidden",
+	"资源不存在":        "Resource not found",
+	"请求过于频繁":       "Too many requests",
+	"请求过于频繁，请稍后再试": "Too many requests, please try again later",
+	"功能未实现":        "Not implemented",
+	"接口不存在":        "API endpoint not found",
+	"没有权限执行该操作":    "You do not have permission to perform this action",
+	"无效的游标":        "Invalid cursor",
+
+	// 参数校验
+	"请求格式不正确":          "Malformed request",
+	"参数校验失败: %s":       "Validation failed: %s",
+	"%s 不能为空":          "%s is required",
+	"%s 长度不能少于 %s 个字符": "%s must be at least %s characters long",
+	"%s 不能小于 %s":       "%s must be at least %s",
+	"%s 长度不能超过 %s 个字符": "%s must be at most %s characters long",
+	"%s 不能大于 %s":       "%s must be at most %s",
+	"%s 长度必须为 %s":      "%s must be exactly %s characters long",
+	"%s 不是有效的邮箱地址":     "%s must be a valid email address",
+	"%s 不是有效的网址":       "%s must be a valid URL",
+	"%s 只能包含数字":        "%s must contain only digits",
+	"%s 必须是以下值之一: %s":  "%s must be one of: %s",
+	"%s 格式不正确":         "%s is invalid",
+	"结束日期不能早于开始日期":     "The end date cannot be earlier than the start date",
+
+	// 认证
+	"用户未认证":          "Authentication required",
+	"未认证访问":          "Authentication required",
+	"请提供令牌":          "Please provide a token",
+	"令牌格式错误":         "Malformed token",
+	"无效的令牌":          "Invalid token",
+	"令牌已失效，请重新登录":    "Your token has been revoked, please log in again",
+	"校验令牌失败":         "Failed to verify token",
+	"无权限访问":          "Access denied",
+	"请先验证邮箱":         "Please verify your email address first",
+	"请先启用两步验证并重新登录":  "Please enable two-factor authentication and log in again",
+	"用户名已存在":         "Username is already taken",
+	"邮箱已存在":          "Email is already in use",
+	"该邮箱已注册":         "This email is already registered",
+	"该邮箱已注册，请使用密码登录": "This email is already registered, please log in with your password",
+	"创建用户失败":         "Failed to create user",
+	"用户名或密码错误":       "Incorrect username or password",
+	"登录失败次数过多，账号已被临时锁定，请 %d 分钟后再试": "Too many failed login attempts. Your account is temporarily locked, please try again in %d minutes",
+	"登录失败次数过多，请 %d 秒后再试":           "Too many failed login attempts, please try again in %d seconds",
+	"账号已被临时锁定，请稍后再试":               "Your account is temporarily locked, please try again later",
+	"失败次数过多，请稍后再试":                 "Too many failed attempts, please try again later",
+	"登录已过期，请重新登录":                  "Your login has expired, please log in again",
+	"两步验证失败":                       "Two-factor authentication failed",
+	"不支持的登录方式":                     "Unsupported login provider",
+	"生成授权地址失败":                     "Failed to generate the authorization URL",
+	"登录请求无效或已过期，请重新登录":             "The login request is invalid or has expired, please log in again",
+	"第三方登录失败":                      "Third-party login failed",
+	"账号不存在或已被删除":                   "The account does not exist or has been deleted",
+	"生成登录挑战失败":                     "Failed to create the login challenge",
+	"生成令牌失败":                       "Failed to generate token",
+	"刷新令牌无效或已失效":                   "The refresh token is invalid or has expired",
+	"刷新令牌失败":                       "Failed to refresh token",
+	"退出登录失败":                       "Failed to log out",
+	"退出登录成功":                       "Logged out successfully",
+	"验证链接无效或已过期":                   "The verification link is invalid or has expired",
+	"邮箱已验证":                        "Email is already verified",
+	"验证邮箱失败":                       "Failed to verify email",
+	"邮箱验证成功":                       "Email verified successfully",
+	"发送验证邮件失败":                     "Failed to send the verification email",
+	"验证邮件已发送":                      "Verification email sent",
+	"发送重置邮件失败":                     "Failed to send the password reset email",
+	"如果该邮箱已注册，重置邮件已发送":             "If the email is registered, a password reset email has been sent",
+	"重置链接无效或已过期":                   "The reset link is invalid or has expired",
+	"重置密码失败":                       "Failed to reset password",
+	"密码重置成功，请重新登录":                 "Password reset successfully, please log in again",
+
+	// 两步验证
+	"已启用两步验证":    "Two-factor authentication is already enabled",
+	"未启用两步验证":    "Two-factor authentication is not enabled",
+	"生成两步验证密钥失败": "Failed to generate the two-factor secret",
+	"请先生成两步验证密钥": "Please generate a two-factor secret first",
+	"验证码错误":      "Incorrect verification code",
+	"启用两步验证失败":   "Failed to enable two-factor authentication",
+	"密码错误":       "Incorrect password",
+	"关闭两步验证失败":   "Failed to disable two-factor authentication",
+	"生成备用码失败":    "Failed to generate backup codes",
+
+	// 会话
+	"获取会话列表失败": "Failed to list sessions",
+	"无效的会话ID":  "Invalid session ID",
+	"会话不存在":    "Session not found",
+	"注销会话失败":   "Failed to revoke session",
+	"注销会话成功":   "Session revoked",
+
+	// 用户
+	"用户不存在":    "User not found",
+	"无效的用户ID":  "Invalid user ID",
+	"获取用户信息失败": "Failed to get user information",
+	"获取关注统计失败": "Failed to get follow statistics",
+	"更新用户信息失败": "Failed to update user information",
+	"旧密码错误":    "Incorrect old password",
+	"密码加密失败":   "Failed to hash password",
+	"修改密码失败":   "Failed to change password",
+	"获取用户列表失败": "Failed to list users",
+	"获取用户联想失败": "Failed to get user suggestions",
+	"更新用户角色失败": "Failed to update user role",
+	"更新用户角色成功": "User role updated",
+	"解除锁定失败":   "Failed to unlock account",
+	"解除锁定成功":   "Account unlocked",
+	"删除用户失败":   "Failed to delete user",
+	"删除用户成功":   "User deleted",
+	"获取用户帖子失败": "Failed to list user posts",
+
+	// 关注
+	"关注用户失败": "Failed to follow user",
+	"关注成功":   "Followed successfully",
+	"不能关注自己": "You cannot follow yourself",
+	"取消关注失败": "Failed to unfollow user",
+	"取消关注成功": "Unfollowed successfully",
+
+	// 分类
+	"获取分类列表失败": "Failed to list categories",
+	"无效的分类ID":  "Invalid category ID",
+	"分类不存在":    "Category not found",
+	"获取分类失败":   "Failed to get category",
+	"创建分类失败":   "Failed to create category",
+	"更新分类失败":   "Failed to update category",
+	"删除分类失败":   "Failed to delete category",
+	"删除分类成功":   "Category deleted",
+
+	// 帖子
+	"帖子不存在":          "Post not found",
+	"无效的帖子ID":        "Invalid post ID",
+	"创建帖子失败":         "Failed to create post",
+	"获取帖子失败":         "Failed to get post",
+	"获取提及用户失败":       "Failed to get mentioned users",
+	"更新帖子失败":         "Failed to update post",
+	"删除帖子失败":         "Failed to delete post",
+	"删除帖子成功":         "Post deleted",
+	"获取帖子列表失败":       "Failed to list posts",
+	"获取点赞状态失败":       "Failed to get like status",
+	"点赞帖子失败":         "Failed to like post",
+	"取消点赞失败":         "Failed to unlike",
+	"置顶帖子失败":         "Failed to pin post",
+	"置顶帖子成功":         "Post pinned",
+	"取消置顶失败":         "Failed to unpin post",
+	"取消置顶成功":         "Post unpinned",
+	"设置精华失败":         "Failed to feature post",
+	"设置精华成功":         "Post featured",
+	"取消精华失败":         "Failed to unfeature post",
+	"取消精华成功":         "Post unfeatured",
+	"获取置顶帖子失败":       "Failed to list pinned posts",
+	"获取精华帖子失败":       "Failed to list featured posts",
+	"搜索失败":           "Search failed",
+	"搜索索引正在重建，请稍后再试": "The search index is being rebuilt, please try again later",
+
+	// 评论
+	"评论不存在":     "Comment not found",
+	"无效的评论ID":   "Invalid comment ID",
+	"获取评论列表失败":  "Failed to list comments",
+	"父评论不属于该帖子": "The parent comment does not belong to this post",
+	"创建评论失败":    "Failed to create comment",
+	"更新评论失败":    "Failed to update comment",
+	"删除评论失败":    "Failed to delete comment",
+	"删除评论成功":    "Comment deleted",
+	"点赞评论失败":    "Failed to like comment",
+
+	// 通知
+	"通知不存在":     "Notification not found",
+	"无效的通知ID":   "Invalid notification ID",
+	"获取通知列表失败":  "Failed to list notifications",
+	"获取未读通知数失败": "Failed to count unread notifications",
+	"标记通知失败":    "Failed to mark notifications as read",
+	"标记通知成功":    "Notification marked as read",
+	"标记所有通知成功":  "All notifications marked as read",
+
+	// 通知内容
+	"%s 评论了你的帖子《%s》":   "%s commented on your post \"%s\"",
+	"%s 回复了你在《%s》中的评论": "%s replied to your comment on \"%s\"",
+	"%s 赞了你的帖子《%s》":    "%s liked your post \"%s\"",
+	"%s 赞了你的评论":        "%s liked your comment",
+	"%s 关注了你":          "%s followed you",
+	"%s 在帖子《%s》中提到了你":  "%s mentioned you in the post \"%s\"",
+	"%s 在《%s》的评论中提到了你": "%s mentioned you in a comment on \"%s\"",
+	"%[1]s 等 %[2]d 人":  "%[1]s and %[3]d more", // 参数依次为最近的用户、总人数和其他人数
+	"你的账号因多次登录失败已被临时锁定至 %s，如果这不是你的操作，请尽快修改密码": "Your account has been temporarily locked until %s after too many failed login attempts. If this wasn't you, please change your password as soon as possible",
+}
@@ -0,0 +1,92 @@
+// Package i18n 提供接口提示信息和通知内容的多语言翻译
+// 消息以中文原文作为键，其他语言在消息目录中查找译文，找不到译文时返回原文
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Locale 语言标识
+type Locale string
+
+// 支持的语言
+const (
+	ZhCN Locale = "zh-CN"
+	En   Locale = "en"
+
+	// Default 无法协商出语言时使用的默认语言
+	Default = ZhCN
+)
+
+// catalogs 各语言的消息目录，中文原文不需要目录
+var catalogs = map[Locale]map[string]string{
+	En: en,
+}
+
+// Supported 返回支持的语言列表
+func Supported() []Locale {
+	return []Locale{ZhCN, En}
+}
+
+// Parse 将语言标签解析为支持的语言，如 en-US 解析为 en，zh、zh-Hans 解析为 zh-CN
+func Parse(tag string) (Locale, bool) {
+	primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	switch primary {
+	case "zh":
+		return ZhCN, true
+	case "en":
+		return En, true
+	default:
+		return "", false
+	}
+}
+
+// Negotiate 按 Accept-Language 请求头的权重选择支持的语言
+func Negotiate(header string) (Locale, bool) {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if tag == "" || q <= 0 {
+			continue
+		}
+		candidates = append(candidates, candidate{tag: tag, q: q})
+	}
+
+	// 权重相同时保持请求头中的顺序
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	for _, c := range candidates {
+		if locale, ok := Parse(c.tag); ok {
+			return locale, true
+		}
+	}
+	return "", false
+}
+
+// T 将中文消息翻译为指定语言，args 不为空时按翻译后的格式化字符串格式化
+func T(locale Locale, message string, args ...interface{}) string {
+	if translated, ok := catalogs[locale][message]; ok {
+		message = translated
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
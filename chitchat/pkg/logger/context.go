@@ -0,0 +1,22 @@
+package logger
+
+import "context"
+
+// contextKey 上下文中保存日志记录器的键
+type contextKey struct{}
+
+// NewContext 返回携带日志记录器的上下文
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext 获取上下文中的日志记录器，没有时返回默认日志记录器
+// 请求处理过程中的上下文由请求 ID 中间件设置，记录的日志会带上请求 ID
+func FromContext(ctx context.Context) *Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(contextKey{}).(*Logger); ok {
+			return l
+		}
+	}
+	return Default()
+}
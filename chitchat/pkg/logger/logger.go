@@ -1,107 +1,269 @@
+// Package logger 提供结构化日志，支持 JSON 和控制台两种格式、按级别过滤和日志文件切分
+// 请求处理过程中应通过 FromContext 获取日志记录器，日志会自动带上请求 ID
 package logger
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// Level 日志级别
+type Level = slog.Level
+
 // 日志级别
 const (
-	DEBUG   = "DEBUG"
-	INFO    = "INFO"
-	WARNING = "WARNING"
-	ERROR   = "ERROR"
-	FATAL   = "FATAL"
+	LevelDebug = slog.LevelDebug
+	LevelInfo  = slog.LevelInfo
+	LevelWarn  = slog.LevelWarn
+	LevelError = slog.LevelError
 )
 
+// 日志格式
+const (
+	FormatConsole = "console"
+	FormatJSON    = "json"
+)
+
+// Config 日志配置
+type Config struct {
+	Level      string `mapstructure:"level"`       // debug、info、warn、error，默认 info
+	Format     string `mapstructure:"format"`      // console 或 json，默认 console
+	File       string `mapstructure:"file"`        // 日志文件路径，为空时输出到标准输出
+	MaxSize    int    `mapstructure:"max_size"`    // 单个日志文件的最大大小，单位 MB，0 表示不按大小切分
+	Daily      bool   `mapstructure:"daily"`       // 每天切分日志文件
+	MaxAge     int    `mapstructure:"max_age"`     // 切分出的旧文件保留天数，0 表示不按时间清理
+	MaxBackups int    `mapstructure:"max_backups"` // 最多保留的旧文件数，0 表示不限制
+}
+
+// Logger 结构化日志记录器
+type Logger struct {
+	l *slog.Logger
+}
+
 var (
-	defaultLogger *log.Logger
-	logLevel      string
-	logFile       *os.File
+	defaultLogger atomic.Pointer[Logger]
+
+	outputMu sync.Mutex
+	output   io.Closer // 当前打开的日志文件
 )
 
-// 初始化日志
-func Init(level string, filePath string) error {
-	logLevel = level
+func init() {
+	defaultLogger.Store(newLogger(os.Stdout, LevelInfo, FormatConsole))
+}
 
-	// 如果指定了日志文件路径，则创建日志文件
-	if filePath != "" {
-		var err error
-		// 确保日志文件所在目录存在
-		err = os.MkdirAll(filePath[:len(filePath)-len(fmt.Sprintf("%s.log", time.Now().Format("2006-01-02")))], os.ModePerm)
-		if err != nil {
-			return err
-		}
+// Init 按配置初始化默认日志记录器，可重复调用，之前打开的日志文件会被关闭
+func Init(cfg Config) error {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return err
+	}
+
+	format := strings.ToLower(cfg.Format)
+	switch format {
+	case "":
+		format = FormatConsole
+	case FormatConsole, FormatJSON:
+	default:
+		return fmt.Errorf("不支持的日志格式: %s", cfg.Format)
+	}
 
-		// 打开日志文件，如果不存在则创建
-		logFile, err = os.OpenFile(
-			fmt.Sprintf("%s%s.log", filePath, time.Now().Format("2006-01-02")),
-			os.O_CREATE|os.O_WRONLY|os.O_APPEND,
-			0666,
-		)
+	var w io.Writer = os.Stdout
+	var file io.Closer
+	if cfg.File != "" {
+		rf, err := openRotatingFile(cfg)
 		if err != nil {
 			return err
 		}
-
-		// 创建日志记录器
-		defaultLogger = log.New(logFile, "", log.LstdFlags)
-	} else {
-		// 如果没有指定日志文件，则使用标准输出
-		defaultLogger = log.New(os.Stdout, "", log.LstdFlags)
+		w, file = rf, rf
 	}
 
+	defaultLogger.Store(newLogger(w, level, format))
+
+	outputMu.Lock()
+	previous := output
+	output = file
+	outputMu.Unlock()
+	if previous != nil {
+		previous.Close()
+	}
 	return nil
 }
 
-// 关闭日志文件
+// Close 关闭日志文件，之后的日志输出到标准输出
 func Close() {
-	if logFile != nil {
-		logFile.Close()
+	outputMu.Lock()
+	defer outputMu.Unlock()
+
+	if output != nil {
+		defaultLogger.Store(newLogger(os.Stdout, LevelInfo, FormatConsole))
+		output.Close()
+		output = nil
 	}
 }
 
-// Debug 调试日志
-func Debug(format string, v ...interface{}) {
-	if logLevel == DEBUG {
-		logMessage(DEBUG, format, v...)
+// ParseLevel 解析日志级别，空字符串表示 info
+func ParseLevel(level string) (Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return LevelDebug, nil
+	case "info", "":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	default:
+		return LevelInfo, fmt.Errorf("不支持的日志级别: %s", level)
 	}
 }
 
-// Info 信息日志
-func Info(format string, v ...interface{}) {
-	if logLevel == DEBUG || logLevel == INFO {
-		logMessage(INFO, format, v...)
+// newLogger 创建输出到 w 的日志记录器
+func newLogger(w io.Writer, level Level, format string) *Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if format == FormatJSON {
+		return &Logger{l: slog.New(slog.NewJSONHandler(w, opts))}
+	}
+
+	// 控制台格式使用更易读的时间
+	opts.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.TimeKey && len(groups) == 0 {
+			a.Value = slog.StringValue(a.Value.Time().Format("2006-01-02 15:04:05.000"))
+		}
+		return a
 	}
+	return &Logger{l: slog.New(slog.NewTextHandler(w, opts))}
 }
 
-// Warning 警告日志
-func Warning(format string, v ...interface{}) {
-	if logLevel == DEBUG || logLevel == INFO || logLevel == WARNING {
-		logMessage(WARNING, format, v...)
+// Default 返回默认日志记录器
+func Default() *Logger {
+	return defaultLogger.Load()
+}
+
+// With 返回带有固定字段的日志记录器
+func (l *Logger) With(fields ...Field) *Logger {
+	args := make([]any, len(fields))
+	for i, f := range fields {
+		args[i] = f
 	}
+	return &Logger{l: l.l.With(args...)}
+}
+
+// Enabled 是否记录该级别的日志，用于跳过代价较高的字段计算
+func (l *Logger) Enabled(level Level) bool {
+	return l.l.Enabled(context.Background(), level)
+}
+
+// Debug 调试日志
+func (l *Logger) Debug(msg string, fields ...Field) {
+	l.log(LevelDebug, msg, fields)
+}
+
+// Info 信息日志
+func (l *Logger) Info(msg string, fields ...Field) {
+	l.log(LevelInfo, msg, fields)
+}
+
+// Warn 警告日志
+func (l *Logger) Warn(msg string, fields ...Field) {
+	l.log(LevelWarn, msg, fields)
 }
 
 // Error 错误日志
-func Error(format string, v ...interface{}) {
-	if logLevel == DEBUG || logLevel == INFO || logLevel == WARNING || logLevel == ERROR {
-		logMessage(ERROR, format, v...)
-	}
+func (l *Logger) Error(msg string, fields ...Field) {
+	l.log(LevelError, msg, fields)
+}
+
+// log 记录日志
+func (l *Logger) log(level Level, msg string, fields []Field) {
+	l.l.LogAttrs(context.Background(), level, msg, fields...)
+}
+
+// With 返回带有固定字段的默认日志记录器
+func With(fields ...Field) *Logger {
+	return Default().With(fields...)
+}
+
+// Debug 使用默认日志记录器记录调试日志
+func Debug(msg string, fields ...Field) {
+	Default().log(LevelDebug, msg, fields)
+}
+
+// Info 使用默认日志记录器记录信息日志
+func Info(msg string, fields ...Field) {
+	Default().log(LevelInfo, msg, fields)
+}
+
+// Warn 使用默认日志记录器记录警告日志
+func Warn(msg string, fields ...Field) {
+	Default().log(LevelWarn, msg, fields)
+}
+
+// Error 使用默认日志记录器记录错误日志
+func Error(msg string, fields ...Field) {
+	Default().log(LevelError, msg, fields)
 }
 
-// Fatal 致命错误日志，记录后终止程序
-func Fatal(format string, v ...interface{}) {
-	logMessage(FATAL, format, v...)
+// Fatal 记录错误日志后终止程序
+func Fatal(msg string, fields ...Field) {
+	Default().log(LevelError, msg, fields)
+	Close()
 	os.Exit(1)
 }
 
-// 记录日志
-func logMessage(level, format string, v ...interface{}) {
-	if defaultLogger == nil {
-		defaultLogger = log.New(os.Stdout, "", log.LstdFlags)
+// Field 日志字段
+type Field = slog.Attr
+
+// String 字符串字段
+func String(key, value string) Field {
+	return slog.String(key, value)
+}
+
+// Int 整数字段
+func Int(key string, value int) Field {
+	return slog.Int(key, value)
+}
+
+// Int64 64位整数字段
+func Int64(key string, value int64) Field {
+	return slog.Int64(key, value)
+}
+
+// Uint 无符号整数字段，用于ID
+func Uint(key string, value uint) Field {
+	return slog.Uint64(key, uint64(value))
+}
+
+// Bool 布尔字段
+func Bool(key string, value bool) Field {
+	return slog.Bool(key, value)
+}
+
+// Duration 时长字段
+func Duration(key string, value time.Duration) Field {
+	return slog.Duration(key, value)
+}
+
+// Time 时间字段
+func Time(key string, value time.Time) Field {
+	return slog.Time(key, value)
+}
+
+// Err 错误字段，键固定为 error
+func Err(err error) Field {
+	if err == nil {
+		return slog.String("error", "")
 	}
+	return slog.String("error", err.Error())
+}
 
-	logContent := fmt.Sprintf("[%s] %s", level, fmt.Sprintf(format, v...))
-	defaultLogger.Println(logContent)
+// Any 任意类型的字段
+func Any(key string, value any) Field {
+	return slog.Any(key, value)
 }
@@ -0,0 +1,162 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 切分出的旧文件名中的时间格式，如 chitchat-2024-01-02T15-04-05.000.log
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// rotatingFile 按大小和日期切分的日志文件
+// 切分时将当前文件重命名为带时间的旧文件，再按保留天数和数量清理旧文件
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	daily      bool
+	maxAge     time.Duration
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+	day  string // 当前文件开始写入的日期
+}
+
+// openRotatingFile 打开日志文件，文件已存在时追加写入
+func openRotatingFile(cfg Config) (*rotatingFile, error) {
+	f := &rotatingFile{
+		path:       cfg.File,
+		maxSize:    int64(cfg.MaxSize) * 1024 * 1024,
+		daily:      cfg.Daily,
+		maxAge:     time.Duration(cfg.MaxAge) * 24 * time.Hour,
+		maxBackups: cfg.MaxBackups,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write 写入一条日志，超过大小或跨天时先切分
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	now := time.Now()
+	if (f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize) ||
+		(f.daily && now.Format(time.DateOnly) != f.day) {
+		// 切分失败时继续写入当前文件，下次写入时重试
+		if err := f.rotate(now); err != nil {
+			fmt.Fprintf(os.Stderr, "切分日志文件失败: %v\n", err)
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Close 关闭日志文件
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// open 打开或创建日志文件，记录已有的大小和最后写入的日期
+func (f *rotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), os.ModePerm); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.day = time.Now().Format(time.DateOnly)
+	if f.size > 0 {
+		f.day = info.ModTime().Format(time.DateOnly)
+	}
+	return nil
+}
+
+// rotate 将当前文件重命名为旧文件并打开新文件
+// 新文件打开成功后才关闭旧文件，失败时保留旧文件句柄，日志不会中断
+func (f *rotatingFile) rotate(now time.Time) error {
+	old := f.file
+	if err := os.Rename(f.path, f.backupName(now)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	old.Close()
+
+	f.cleanup(now)
+	return nil
+}
+
+// backupName 旧文件的路径，同一毫秒内多次切分时顺延时间，保证文件名按切分顺序排列
+func (f *rotatingFile) backupName(now time.Time) string {
+	ext := filepath.Ext(f.path)
+	base := strings.TrimSuffix(f.path, ext) + "-"
+	for {
+		name := base + now.Format(backupTimeFormat) + ext
+		// 目录不可访问时交给 Rename 报错，不能无限顺延
+		if _, err := os.Stat(name); err != nil {
+			return name
+		}
+		now = now.Add(time.Millisecond)
+	}
+}
+
+// cleanup 删除超过保留天数或数量的旧文件，清理失败不影响写入
+func (f *rotatingFile) cleanup(now time.Time) {
+	if f.maxAge <= 0 && f.maxBackups <= 0 {
+		return
+	}
+
+	ext := filepath.Ext(f.path)
+	backups, err := filepath.Glob(strings.TrimSuffix(f.path, ext) + "-*" + ext)
+	if err != nil {
+		return
+	}
+
+	// 文件名中的时间可以按字典序比较，最新的排在前面
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+	for i, name := range backups {
+		expired := false
+		if f.maxAge > 0 {
+			if info, err := os.Stat(name); err == nil && now.Sub(info.ModTime()) > f.maxAge {
+				expired = true
+			}
+		}
+		if expired || (f.maxBackups > 0 && i >= f.maxBackups) {
+			os.Remove(name)
+		}
+	}
+}
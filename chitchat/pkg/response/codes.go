@@ -0,0 +1,78 @@
+package response
+
+import "net/http"
+
+// ErrorCode 稳定的错误码，客户端应根据错误码而不是提示信息判断错误类型
+type ErrorCode string
+
+// 通用错误码
+const (
+	CodeBadRequest      ErrorCode = "BAD_REQUEST"       // 请求格式不正确，如 JSON 无法解析
+	CodeValidation      ErrorCode = "VALIDATION_FAILED" // 参数校验失败，details 中为各字段的错误
+	CodeUnauthorized    ErrorCode = "UNAUTHORIZED"
+	CodeForbidden       ErrorCode = "FORBIDDEN"
+	CodeNotFound        ErrorCode = "NOT_FOUND"
+	CodeTooManyRequests ErrorCode = "TOO_MANY_REQUESTS"
+	CodeInternal        ErrorCode = "INTERNAL_ERROR"
+	CodeNotImplemented  ErrorCode = "NOT_IMPLEMENTED"
+	CodeUnavailable     ErrorCode = "SERVICE_UNAVAILABLE"
+)
+
+// 认证和账号相关错误码
+const (
+	CodeInvalidCredentials   ErrorCode = "INVALID_CREDENTIALS"
+	CodeInvalidToken         ErrorCode = "INVALID_TOKEN"
+	CodeTokenRevoked         ErrorCode = "TOKEN_REVOKED"
+	CodePermissionDenied     ErrorCode = "PERMISSION_DENIED"
+	CodeAccountLocked        ErrorCode = "ACCOUNT_LOCKED"
+	CodeTooManyAttempts      ErrorCode = "TOO_MANY_ATTEMPTS"
+	CodeUsernameTaken        ErrorCode = "USERNAME_TAKEN"
+	CodeEmailInUse           ErrorCode = "EMAIL_IN_USE"
+	CodeEmailNotVerified     ErrorCode = "EMAIL_NOT_VERIFIED"
+	CodeEmailAlreadyVerified ErrorCode = "EMAIL_ALREADY_VERIFIED"
+	CodeInvalidPassword      ErrorCode = "INVALID_PASSWORD"
+	CodeTwoFactorRequired    ErrorCode = "TWO_FACTOR_REQUIRED"
+	CodeTwoFactorEnabled     ErrorCode = "TWO_FACTOR_ENABLED"
+	CodeTwoFactorNotEnabled  ErrorCode = "TWO_FACTOR_NOT_ENABLED"
+	CodeInvalidTwoFactorCode ErrorCode = "INVALID_TWO_FACTOR_CODE"
+	CodeProviderNotFound     ErrorCode = "PROVIDER_NOT_FOUND"
+	CodeInvalidOAuthState    ErrorCode = "INVALID_OAUTH_STATE"
+	CodeOAuthFailed          ErrorCode = "OAUTH_FAILED"
+)
+
+// 业务相关错误码
+const (
+	CodeUserNotFound         ErrorCode = "USER_NOT_FOUND"
+	CodePostNotFound         ErrorCode = "POST_NOT_FOUND"
+	CodeCommentNotFound      ErrorCode = "COMMENT_NOT_FOUND"
+	CodeCategoryNotFound     ErrorCode = "CATEGORY_NOT_FOUND"
+	CodeNotificationNotFound ErrorCode = "NOTIFICATION_NOT_FOUND"
+	CodeSessionNotFound      ErrorCode = "SESSION_NOT_FOUND"
+	CodeInvalidParent        ErrorCode = "INVALID_PARENT_COMMENT"
+	CodeCannotFollowSelf     ErrorCode = "CANNOT_FOLLOW_SELF"
+	CodeInvalidCursor        ErrorCode = "INVALID_CURSOR"
+	CodeSearchNotReady       ErrorCode = "SEARCH_NOT_READY"
+)
+
+// statusCodes 各状态码的通用错误码
+var statusCodes = map[int]ErrorCode{
+	http.StatusBadRequest:          CodeBadRequest,
+	http.StatusUnauthorized:        CodeUnauthorized,
+	http.StatusForbidden:           CodeForbidden,
+	http.StatusNotFound:            CodeNotFound,
+	http.StatusTooManyRequests:     CodeTooManyRequests,
+	http.StatusInternalServerError: CodeInternal,
+	http.StatusNotImplemented:      CodeNotImplemented,
+	http.StatusServiceUnavailable:  CodeUnavailable,
+}
+
+// codeForStatus 获取状态码对应的通用错误码
+func codeForStatus(status int) ErrorCode {
+	if code, ok := statusCodes[status]; ok {
+		return code
+	}
+	if status >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
@@ -0,0 +1,37 @@
+package response
+
+import "github.com/gin-gonic/gin"
+
+// Meta 列表响应的分页信息，所有列表接口的含义一致
+//   - page_size: 本次请求的每页数量
+//   - page: 按页码分页的列表返回当前页码，游标分页的列表不返回
+//   - total: 总数，游标分页的列表只在请求时返回
+//   - next_cursor、prev_cursor: 游标分页的列表中下一页和上一页的游标，没有时不返回
+//   - truncated: 按关键词筛选时匹配的记录超出上限，列表只包含相关度最高的部分，未截断时不返回
+type Meta struct {
+	Page       int     `json:"page,omitempty"`
+	PageSize   int     `json:"page_size"`
+	Total      *int64  `json:"total,omitempty"`
+	NextCursor *string `json:"next_cursor,omitempty"`
+	PrevCursor *string `json:"prev_cursor,omitempty"`
+	Truncated  bool    `json:"truncated,omitempty"`
+}
+
+// Paginated 列表响应
+type Paginated[T any] struct {
+	Items []T  `json:"items"`
+	Meta  Meta `json:"meta"`
+}
+
+// PageMeta 按页码分页的列表的分页信息
+func PageMeta(page, pageSize int, total int64) Meta {
+	return Meta{Page: page, PageSize: pageSize, Total: &total}
+}
+
+// List 返回列表响应，没有记录时 items 为空数组
+func List[T any](c *gin.Context, items []T, meta Meta) {
+	if items == nil {
+		items = []T{}
+	}
+	Success(c, Paginated[T]{Items: items, Meta: meta})
+}
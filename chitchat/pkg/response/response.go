@@ -4,17 +4,25 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lllllan02/chitchat/pkg/i18n"
+	"github.com/lllllan02/chitchat/pkg/logger"
 )
 
 // Response 标准响应结构
 type Response struct {
-	Code    int         `json:"code"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
+	Code    int          `json:"code"`              // HTTP 状态码
+	Error   ErrorCode    `json:"error,omitempty"`   // 失败时的错误码
+	Message string       `json:"message"`           // 面向用户的提示信息
+	Details []FieldError `json:"details,omitempty"` // 参数校验失败时各字段的错误
+	Debug   string       `json:"debug,omitempty"`   // 内部错误信息，只在非 release 模式下返回
+	Data    interface{}  `json:"data,omitempty"`
 }
 
-// Success 返回成功响应
+// Success 返回成功响应，data 为提示信息时按请求的语言翻译
 func Success(c *gin.Context, data interface{}) {
+	if message, ok := data.(string); ok {
+		data = i18n.T(i18n.FromContext(c), message)
+	}
 	c.JSON(http.StatusOK, Response{
 		Code:    http.StatusOK,
 		Message: "success",
@@ -22,14 +30,48 @@ func Success(c *gin.Context, data interface{}) {
 	})
 }
 
-// Fail 返回失败响应
-func Fail(c *gin.Context, code int, message string) {
-	c.JSON(code, Response{
-		Code:    code,
-		Message: message,
+// Fail 返回失败响应，使用状态码对应的通用错误码
+func Fail(c *gin.Context, status int, message string) {
+	Error(c, status, codeForStatus(status), message)
+}
+
+// Error 返回带错误码的失败响应，message 为中文原文，按请求的语言翻译
+func Error(c *gin.Context, status int, code ErrorCode, message string) {
+	c.JSON(status, Response{
+		Code:    status,
+		Error:   code,
+		Message: i18n.T(i18n.FromContext(c), message),
 	})
 }
 
+// Errorf 返回带错误码的失败响应，format 翻译后再格式化
+func Errorf(c *gin.Context, status int, code ErrorCode, format string, args ...interface{}) {
+	c.JSON(status, Response{
+		Code:    status,
+		Error:   code,
+		Message: i18n.T(i18n.FromContext(c), format, args...),
+	})
+}
+
+// InternalError 返回500错误并记录原始错误
+// 原始错误可能包含 SQL 等内部细节，只在非 release 模式下随响应返回，便于调试
+func InternalError(c *gin.Context, message string, err error) {
+	if message == "" {
+		message = "服务器内部错误"
+	}
+	logger.FromContext(c.Request.Context()).Error(message, logger.Err(err))
+
+	resp := Response{
+		Code:    http.StatusInternalServerError,
+		Error:   CodeInternal,
+		Message: i18n.T(i18n.FromContext(c), message),
+	}
+	if gin.Mode() != gin.ReleaseMode && err != nil {
+		resp.Debug = err.Error()
+	}
+	c.JSON(http.StatusInternalServerError, resp)
+}
+
 // BadRequest 返回400错误
 func BadRequest(c *gin.Context, message string) {
 	if message == "" {
@@ -62,6 +104,14 @@ func NotFound(c *gin.Context, message string) {
 	Fail(c, http.StatusNotFound, message)
 }
 
+// TooManyRequests 返回429错误
+func TooManyRequests(c *gin.Context, message string) {
+	if message == "" {
+		message = "请求过于频繁"
+	}
+	Fail(c, http.StatusTooManyRequests, message)
+}
+
 // ServerError 返回500错误
 func ServerError(c *gin.Context, message string) {
 	if message == "" {
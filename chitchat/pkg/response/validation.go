@@ -0,0 +1,129 @@
+package response
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/lllllan02/chitchat/pkg/i18n"
+)
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Field   string `json:"field"`           // 请求中的字段名，与 JSON 或查询参数的名称一致
+	Rule    string `json:"rule"`            // 未通过的校验规则，如 required、max
+	Param   string `json:"param,omitempty"` // 校验规则的参数，如 max=100 中的 100
+	Message string `json:"message"`
+}
+
+// SetupValidator 让校验错误中的字段名使用 JSON 或查询参数的名称，而不是 Go 结构体字段名
+func SetupValidator() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "form"} {
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return field.Name
+	})
+}
+
+// BindJSON 绑定并校验 JSON 请求体，失败时返回 400 和 false
+func BindJSON(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		ValidationError(c, err)
+		return false
+	}
+	return true
+}
+
+// BindQuery 绑定并校验查询参数，失败时返回 400 和 false
+// 默认值应在调用前设置在 query 中，未传入的参数保持默认值；每页数量等上限由 query 的 binding 规则约束
+func BindQuery(c *gin.Context, query interface{}) bool {
+	if err := c.ShouldBindQuery(query); err != nil {
+		ValidationError(c, err)
+		return false
+	}
+	return true
+}
+
+// ValidationError 返回参数绑定或校验失败的响应
+// 校验规则未通过时返回 VALIDATION_FAILED 和各字段的错误，请求无法解析时返回 BAD_REQUEST
+func ValidationError(c *gin.Context, err error) {
+	locale := i18n.FromContext(c)
+
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		resp := Response{
+			Code:    http.StatusBadRequest,
+			Error:   CodeBadRequest,
+			Message: i18n.T(locale, "请求格式不正确"),
+		}
+		if gin.Mode() != gin.ReleaseMode {
+			resp.Debug = err.Error()
+		}
+		c.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	details := make([]FieldError, len(errs))
+	for i, fe := range errs {
+		details[i] = FieldError{
+			Field:   fe.Field(),
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: fieldMessage(locale, fe),
+		}
+	}
+	c.JSON(http.StatusBadRequest, Response{
+		Code:    http.StatusBadRequest,
+		Error:   CodeValidation,
+		Message: i18n.T(locale, "参数校验失败: %s", details[0].Message),
+		Details: details,
+	})
+}
+
+// fieldMessage 将校验错误翻译为指定语言的提示信息
+func fieldMessage(locale i18n.Locale, fe validator.FieldError) string {
+	field := fe.Field()
+	isString := fe.Kind() == reflect.String
+
+	switch fe.Tag() {
+	case "required":
+		return i18n.T(locale, "%s 不能为空", field)
+	case "min":
+		if isString {
+			return i18n.T(locale, "%s 长度不能少于 %s 个字符", field, fe.Param())
+		}
+		return i18n.T(locale, "%s 不能小于 %s", field, fe.Param())
+	case "max":
+		if isString {
+			return i18n.T(locale, "%s 长度不能超过 %s 个字符", field, fe.Param())
+		}
+		return i18n.T(locale, "%s 不能大于 %s", field, fe.Param())
+	case "len":
+		return i18n.T(locale, "%s 长度必须为 %s", field, fe.Param())
+	case "email":
+		return i18n.T(locale, "%s 不是有效的邮箱地址", field)
+	case "url":
+		return i18n.T(locale, "%s 不是有效的网址", field)
+	case "numeric":
+		return i18n.T(locale, "%s 只能包含数字", field)
+	case "oneof":
+		return i18n.T(locale, "%s 必须是以下值之一: %s", field, strings.ReplaceAll(fe.Param(), " ", ", "))
+	default:
+		return i18n.T(locale, "%s 格式不正确", field)
+	}
+}
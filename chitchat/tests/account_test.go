@@ -0,0 +1,204 @@
+package tests
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lllllan02/chitchat/internal/mailer"
+	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/utils"
+)
+
+var linkPattern = regexp.MustCompile(`http://chitchat\.test(/[a-z-]+)\?token=(\S+)`)
+
+// mailToken 从发给收件人的最后一封邮件中提取链接路径和令牌
+func mailToken(s *Server, to string) (string, string) {
+	s.t.Helper()
+
+	msg := s.Mailbox().Last(to)
+	if msg == nil {
+		s.t.Fatalf("没有发给 %s 的邮件", to)
+	}
+	match := linkPattern.FindStringSubmatch(msg.Body)
+	if match == nil {
+		s.t.Fatalf("邮件中没有链接: %s", msg.Body)
+	}
+	token, err := url.QueryUnescape(match[2])
+	if err != nil {
+		s.t.Fatalf("链接中的令牌格式错误: %v", err)
+	}
+	return match[1], token
+}
+
+func TestVerifyEmail(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	user, token := s.Register("alice", "secret1")
+	if user.EmailVerified {
+		t.Fatal("新注册用户的邮箱不应已验证")
+	}
+
+	path, verifyToken := mailToken(s, "alice@example.com")
+	if path != "/verify-email" {
+		t.Fatalf("验证邮件链接错误: %s", path)
+	}
+
+	s.Post("/auth/verify-email", map[string]string{"token": verifyToken}, "").ExpectStatus(http.StatusOK)
+
+	var me model.User
+	s.Get("/users/me", token).ExpectStatus(http.StatusOK).Decode(&me)
+	if !me.EmailVerified {
+		t.Fatal("验证后邮箱应为已验证")
+	}
+
+	// 令牌只能使用一次，已验证后不能重新发送
+	s.Post("/auth/verify-email", map[string]string{"token": verifyToken}, "").ExpectStatus(http.StatusBadRequest)
+	s.Post("/auth/verify-email/resend", nil, token).ExpectStatus(http.StatusBadRequest)
+	s.Post("/auth/verify-email", map[string]string{"token": "invalid"}, "").ExpectStatus(http.StatusBadRequest)
+}
+
+func TestResendVerificationEmail(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, token := s.Register("alice", "secret1")
+
+	s.Post("/auth/verify-email/resend", nil, "").ExpectStatus(http.StatusUnauthorized)
+	s.Post("/auth/verify-email/resend", nil, token).ExpectStatus(http.StatusOK)
+	if n := len(s.Mailbox().Messages()); n != 2 {
+		t.Fatalf("期望 2 封邮件，实际为 %d", n)
+	}
+
+	_, verifyToken := mailToken(s, "alice@example.com")
+	s.Post("/auth/verify-email", map[string]string{"token": verifyToken}, "").ExpectStatus(http.StatusOK)
+}
+
+func TestRequireEmailVerification(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t, func(cfg *utils.Config) {
+		cfg.Auth.RequireEmailVerification = true
+	})
+	_, token := s.Register("alice", "secret1")
+
+	post := map[string]string{"title": "未验证邮箱的帖子", "content": "邮箱验证前不能发帖"}
+	s.Post("/posts", post, token).ExpectStatus(http.StatusForbidden)
+	s.Post("/comments", map[string]interface{}{"post_id": 1, "content": "评论"}, token).ExpectStatus(http.StatusForbidden)
+
+	_, verifyToken := mailToken(s, "alice@example.com")
+	s.Post("/auth/verify-email", map[string]string{"token": verifyToken}, "").ExpectStatus(http.StatusOK)
+	s.Post("/posts", post, token).ExpectStatus(http.StatusOK)
+
+	// 种子管理员的邮箱视为已验证
+	s.CreatePost(s.AdminToken(), "管理员的帖子", "管理员不需要验证邮箱")
+}
+
+func TestPasswordReset(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, oldToken := s.Register("alice", "secret1")
+
+	// 未注册的邮箱返回相同结果但不发送邮件
+	sent := len(s.Mailbox().Messages())
+	s.Post("/auth/forgot-password", map[string]string{"email": "nobody@example.com"}, "").ExpectStatus(http.StatusOK)
+	if n := len(s.Mailbox().Messages()); n != sent {
+		t.Fatalf("不应向未注册邮箱发送邮件")
+	}
+
+	s.Post("/auth/forgot-password", map[string]string{"email": "alice@example.com"}, "").ExpectStatus(http.StatusOK)
+	path, resetToken := mailToken(s, "alice@example.com")
+	if path != "/reset-password" {
+		t.Fatalf("重置邮件链接错误: %s", path)
+	}
+
+	s.Post("/auth/reset-password", map[string]string{"token": resetToken, "new_password": "123"}, "").ExpectStatus(http.StatusBadRequest)
+	s.Post("/auth/reset-password", map[string]string{"token": resetToken, "new_password": "secret2"}, "").ExpectStatus(http.StatusOK)
+
+	// 旧密码和旧会话失效，重置链接只能使用一次
+	s.Post("/auth/login", map[string]string{"username": "alice", "password": "secret1"}, "").ExpectStatus(http.StatusBadRequest)
+	s.Get("/users/me", oldToken).ExpectStatus(http.StatusUnauthorized)
+	s.Post("/auth/reset-password", map[string]string{"token": resetToken, "new_password": "secret3"}, "").ExpectStatus(http.StatusBadRequest)
+
+	var me model.User
+	s.Get("/users/me", s.Login("alice", "secret2")).ExpectStatus(http.StatusOK).Decode(&me)
+	if !me.EmailVerified {
+		t.Fatal("通过邮件重置密码后邮箱应为已验证")
+	}
+}
+
+// stalledSMTP 模拟 SMTP 服务器，stall 为 true 时保持连接不响应，否则立即断开
+type stalledSMTP struct {
+	net.Listener
+	stall   atomic.Bool
+	release chan struct{}
+}
+
+// newStalledSMTP 在本地端口上启动模拟 SMTP 服务器，测试结束时断开全部连接
+func newStalledSMTP(t *testing.T) *stalledSMTP {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("监听端口失败: %v", err)
+	}
+	srv := &stalledSMTP{Listener: ln, release: make(chan struct{})}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				if srv.stall.Load() {
+					<-srv.release
+				}
+				conn.Close()
+			}()
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return srv
+}
+
+func TestPasswordResetDoesNotWaitForMail(t *testing.T) {
+	t.Parallel()
+	smtp := newStalledSMTP(t)
+	s := NewServer(t, func(cfg *utils.Config) {
+		addr := smtp.Addr().(*net.TCPAddr)
+		cfg.Mail.Driver = mailer.DriverSMTP
+		cfg.Mail.Host = addr.IP.String()
+		cfg.Mail.Port = addr.Port
+	})
+	s.Register("alice", "secret1")
+
+	// 邮件服务器无响应时，已注册和未注册的邮箱同样立即返回
+	smtp.stall.Store(true)
+	for _, email := range []string{"alice@example.com", "nobody@example.com"} {
+		done := make(chan *Response, 1)
+		go func() {
+			done <- s.Post("/auth/forgot-password", map[string]string{"email": email}, "")
+		}()
+		select {
+		case resp := <-done:
+			resp.ExpectStatus(http.StatusOK)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: 响应等待了邮件发送", email)
+		}
+	}
+
+	// 发送失败只记录日志
+	close(smtp.release)
+	s.App.Account.Wait()
+}
+
+func TestActionTokenPurpose(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, token := s.Register("alice", "secret1")
+	_, verifyToken := mailToken(s, "alice@example.com")
+
+	// 验证邮箱的令牌不能用于重置密码，访问令牌也不能用于验证邮箱
+	s.Post("/auth/reset-password", map[string]string{"token": verifyToken, "new_password": "secret2"}, "").ExpectStatus(http.StatusBadRequest)
+	s.Post("/auth/verify-email", map[string]string{"token": token}, "").ExpectStatus(http.StatusBadRequest)
+}
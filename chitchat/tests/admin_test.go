@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/lllllan02/chitchat/internal/model"
+)
+
+func TestAdminPinAndFeature(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, token := s.Register("alice", "secret1")
+	admin := s.AdminToken()
+
+	post := s.CreatePost(token, "值得置顶的帖子", "这篇帖子会被置顶和加精")
+	path := fmt.Sprintf("/posts/%d", post.ID)
+	adminPath := fmt.Sprintf("/admin/posts/%d", post.ID)
+
+	s.Put(adminPath+"/pin", nil, admin).ExpectStatus(http.StatusOK)
+	s.Put(adminPath+"/feature", nil, admin).ExpectStatus(http.StatusOK)
+
+	var got model.Post
+	s.Get(path, "").ExpectStatus(http.StatusOK).Decode(&got)
+	if !got.IsPinned || !got.IsFeatured {
+		t.Fatalf("帖子应已置顶并加精: pinned=%v featured=%v", got.IsPinned, got.IsFeatured)
+	}
+
+	s.Put(adminPath+"/unpin", nil, admin).ExpectStatus(http.StatusOK)
+	s.Put(adminPath+"/unfeature", nil, admin).ExpectStatus(http.StatusOK)
+
+	s.Get(path, "").ExpectStatus(http.StatusOK).Decode(&got)
+	if got.IsPinned || got.IsFeatured {
+		t.Fatalf("帖子应已取消置顶和加精: pinned=%v featured=%v", got.IsPinned, got.IsFeatured)
+	}
+}
+
+func TestAdminRoutesRequireAdmin(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, token := s.Register("alice", "secret1")
+
+	post := s.CreatePost(token, "普通用户的帖子", "普通用户不能置顶自己的帖子")
+	adminPath := fmt.Sprintf("/admin/posts/%d", post.ID)
+
+	for _, action := range []string{"pin", "unpin", "feature", "unfeature"} {
+		s.Put(adminPath+"/"+action, nil, "").ExpectStatus(http.StatusUnauthorized)
+		s.Put(adminPath+"/"+action, nil, token).ExpectStatus(http.StatusForbidden)
+	}
+
+	s.Post("/admin/categories", map[string]string{"name": "新分类"}, token).ExpectStatus(http.StatusForbidden)
+	s.Put("/admin/users/1/role", map[string]string{"role": "user"}, token).ExpectStatus(http.StatusForbidden)
+	s.Delete("/admin/users/1", token).ExpectStatus(http.StatusForbidden)
+
+	var got model.Post
+	s.Get(fmt.Sprintf("/posts/%d", post.ID), "").ExpectStatus(http.StatusOK).Decode(&got)
+	if got.IsPinned || got.IsFeatured {
+		t.Fatalf("普通用户不应能置顶或加精: pinned=%v featured=%v", got.IsPinned, got.IsFeatured)
+	}
+}
+
+func TestPromotedAdminGainsAccess(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	user, _ := s.Register("alice", "secret1")
+
+	s.Put(fmt.Sprintf("/admin/users/%d/role", user.ID), map[string]string{"role": "admin"}, s.AdminToken()).ExpectStatus(http.StatusOK)
+
+	// 角色写在令牌中，重新登录后获得管理员权限
+	token := s.Login("alice", "secret1")
+	s.Post("/admin/categories", map[string]string{"name": "新分类"}, token).ExpectStatus(http.StatusOK)
+}
@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/lllllan02/chitchat/internal/model"
+)
+
+func TestRegisterAndLogin(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+
+	user, token := s.Register("alice", "secret1")
+	if user.ID == 0 || user.Username != "alice" || user.Role != "user" {
+		t.Fatalf("注册返回的用户不正确: %+v", user)
+	}
+	if token == "" {
+		t.Fatal("注册未返回令牌")
+	}
+
+	// 使用登录令牌获取当前用户
+	token = s.Login("alice", "secret1")
+	var me model.User
+	s.Get("/users/me", token).ExpectStatus(http.StatusOK).Decode(&me)
+	if me.ID != user.ID {
+		t.Fatalf("当前用户ID为 %d，期望 %d", me.ID, user.ID)
+	}
+}
+
+func TestRegisterValidation(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	s.Register("alice", "secret1")
+
+	cases := []struct {
+		name string
+		body map[string]string
+	}{
+		{"用户名重复", map[string]string{"username": "alice", "email": "other@example.com", "password": "secret1"}},
+		{"邮箱重复", map[string]string{"username": "bob", "email": "alice@example.com", "password": "secret1"}},
+		{"密码过短", map[string]string{"username": "bob", "email": "bob@example.com", "password": "123"}},
+		{"邮箱格式错误", map[string]string{"username": "bob", "email": "bob", "password": "secret1"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s.Post("/auth/register", tc.body, "").ExpectStatus(http.StatusBadRequest)
+		})
+	}
+}
+
+func TestLoginFailures(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	s.Register("alice", "secret1")
+
+	s.Post("/auth/login", map[string]string{"username": "alice", "password": "wrong"}, "").ExpectStatus(http.StatusBadRequest)
+	s.Post("/auth/login", map[string]string{"username": "nobody", "password": "secret1"}, "").ExpectStatus(http.StatusBadRequest)
+	s.Post("/auth/login", map[string]string{"username": "alice"}, "").ExpectStatus(http.StatusBadRequest)
+}
+
+func TestAuthRequired(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+
+	s.Get("/users/me", "").ExpectStatus(http.StatusUnauthorized)
+	s.Get("/users/me", "invalid").ExpectStatus(http.StatusUnauthorized)
+}
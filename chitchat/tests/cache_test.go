@@ -0,0 +1,233 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/lllllan02/chitchat/internal/cache"
+	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/utils"
+)
+
+// enableCache 开启缓存
+func enableCache(cfg *utils.Config) {
+	cfg.Cache.Enabled = true
+}
+
+// cacheStats 获取各组缓存的命中统计
+func cacheStats(s *Server) map[string]cache.Stat {
+	s.t.Helper()
+
+	var result struct {
+		Enabled bool         `json:"enabled"`
+		Groups  []cache.Stat `json:"groups"`
+	}
+	s.Get("/admin/cache/stats", s.AdminToken()).ExpectStatus(http.StatusOK).Decode(&result)
+
+	stats := make(map[string]cache.Stat)
+	for _, stat := range result.Groups {
+		stats[stat.Group] = stat
+	}
+	return stats
+}
+
+// highlightIDs 获取置顶或精华帖子的ID
+func highlightIDs(s *Server, path string) []uint {
+	s.t.Helper()
+
+	var result struct {
+		Posts []model.Post `json:"items"`
+	}
+	s.Get(path, "").ExpectStatus(http.StatusOK).Decode(&result)
+
+	ids := make([]uint, len(result.Posts))
+	for i, p := range result.Posts {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+// firstCategory 获取第一个分类
+func firstCategory(s *Server) model.Category {
+	s.t.Helper()
+
+	var categories []model.Category
+	s.Get("/categories", "").ExpectStatus(http.StatusOK).Decode(&categories)
+	if len(categories) == 0 {
+		s.t.Fatal("缺少初始分类")
+	}
+	return categories[0]
+}
+
+// exerciseCache 读取热点数据，并确认写操作后读到的是最新数据
+func exerciseCache(t *testing.T, s *Server) {
+	_, alice := s.Register("alice", "secret1")
+	_, bob := s.Register("bob", "secret1")
+	admin := s.AdminToken()
+
+	category := firstCategory(s)
+	var post model.Post
+	s.Post("/posts", map[string]interface{}{
+		"title":       "缓存中的帖子",
+		"content":     "这篇帖子会被缓存起来",
+		"category_id": category.ID,
+	}, alice).ExpectStatus(http.StatusOK).Decode(&post)
+	path := fmt.Sprintf("/posts/%d", post.ID)
+	adminPath := fmt.Sprintf("/admin/posts/%d", post.ID)
+
+	// 新帖子计入分类的帖子数
+	if got := firstCategory(s); got.PostCount != category.PostCount+1 {
+		t.Fatalf("分类帖子数应为 %d，实际为 %d", category.PostCount+1, got.PostCount)
+	}
+
+	// 置顶和加精后立即出现在列表中
+	if ids := highlightIDs(s, "/posts/pinned"); len(ids) != 0 {
+		t.Fatalf("不应有置顶帖子: %v", ids)
+	}
+	s.Put(adminPath+"/pin", nil, admin).ExpectStatus(http.StatusOK)
+	s.Put(adminPath+"/feature", nil, admin).ExpectStatus(http.StatusOK)
+	if ids := highlightIDs(s, "/posts/pinned"); len(ids) != 1 || ids[0] != post.ID {
+		t.Fatalf("置顶帖子不正确: %v", ids)
+	}
+	if ids := highlightIDs(s, fmt.Sprintf("/posts/pinned?category_id=%d", category.ID)); len(ids) != 1 {
+		t.Fatalf("分类置顶帖子不正确: %v", ids)
+	}
+	if ids := highlightIDs(s, "/posts/featured"); len(ids) != 1 || ids[0] != post.ID {
+		t.Fatalf("精华帖子不正确: %v", ids)
+	}
+
+	// 修改和点赞后读到最新数据
+	var got model.Post
+	s.Get(path, "").ExpectStatus(http.StatusOK)
+	s.Put(path, map[string]string{"title": "修改后的标题"}, alice).ExpectStatus(http.StatusOK)
+	s.Post(path+"/like", nil, bob).ExpectStatus(http.StatusOK)
+	s.Get(path, bob).ExpectStatus(http.StatusOK).Decode(&got)
+	if got.Title != "修改后的标题" || got.LikeCount != 1 || !got.LikedByMe || !got.IsPinned {
+		t.Fatalf("帖子不是最新数据: %+v", got)
+	}
+	s.Get(path, "").ExpectStatus(http.StatusOK).Decode(&got)
+	if got.LikedByMe {
+		t.Fatal("点赞状态不应被缓存")
+	}
+
+	// 作者修改资料后，帖子详情和列表中的作者同步更新
+	s.Put("/users/me", map[string]string{"bio": "新的签名"}, alice).ExpectStatus(http.StatusOK)
+	s.Get(path, "").ExpectStatus(http.StatusOK).Decode(&got)
+	if got.User.Username != "alice" || got.User.Bio != "新的签名" {
+		t.Fatalf("帖子作者不是最新数据: %+v", got.User)
+	}
+	var pinned struct {
+		Posts []model.Post `json:"items"`
+	}
+	s.Get("/posts/pinned", "").ExpectStatus(http.StatusOK).Decode(&pinned)
+	if len(pinned.Posts) != 1 || pinned.Posts[0].User.Bio != "新的签名" {
+		t.Fatalf("置顶帖子作者不是最新数据: %+v", pinned.Posts)
+	}
+
+	// 取消置顶后从列表中移除
+	s.Put(adminPath+"/unpin", nil, admin).ExpectStatus(http.StatusOK)
+	if ids := highlightIDs(s, "/posts/pinned"); len(ids) != 0 {
+		t.Fatalf("取消置顶后不应有置顶帖子: %v", ids)
+	}
+
+	// 删除后不再返回
+	s.Delete(path, alice).ExpectStatus(http.StatusOK)
+	s.Get(path, "").ExpectStatus(http.StatusNotFound)
+	if ids := highlightIDs(s, "/posts/featured"); len(ids) != 0 {
+		t.Fatalf("删除后不应有精华帖子: %v", ids)
+	}
+	if got := firstCategory(s); got.PostCount != category.PostCount {
+		t.Fatalf("删除后分类帖子数应为 %d，实际为 %d", category.PostCount, got.PostCount)
+	}
+
+	stats := cacheStats(s)
+	for _, group := range []string{"categories", "post", "pinned_posts", "featured_posts"} {
+		if stats[group].Hits+stats[group].Misses == 0 {
+			t.Fatalf("缺少 %s 的缓存统计: %+v", group, stats)
+		}
+	}
+	if stats["post"].Hits == 0 {
+		t.Fatalf("帖子缓存应有命中: %+v", stats["post"])
+	}
+}
+
+func TestCacheLoadRacingInvalidation(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	c := cache.New(cache.NewMemoryStore(100), time.Minute)
+
+	// load 查到旧数据后、写入缓存前，键被失效，旧数据不会写入缓存
+	interleave := func(key string, invalidate func()) {
+		loaded, release, done := make(chan struct{}), make(chan struct{}), make(chan struct{})
+		go func() {
+			defer close(done)
+			_, _ = cache.Load(ctx, c, "post", key, func() (string, error) {
+				close(loaded)
+				<-release
+				return "修改前", nil
+			})
+		}()
+		<-loaded
+		invalidate()
+		close(release)
+		<-done
+
+		got, err := cache.Load(ctx, c, "post", key, func() (string, error) {
+			return "修改后", nil
+		})
+		if err != nil || got != "修改后" {
+			t.Fatalf("%s = %q, %v, want 修改后", key, got, err)
+		}
+	}
+
+	interleave("post:1", func() { c.Delete(ctx, "post:1") })
+	interleave("posts:pinned", func() { c.DeletePrefix(ctx, "posts:") })
+
+	// 其他键的失效不影响写入
+	_, _ = cache.Load(ctx, c, "post", "post:2", func() (string, error) {
+		c.Delete(ctx, "post:3")
+		return "帖子 2", nil
+	})
+	got, _ := cache.Load(ctx, c, "post", "post:2", func() (string, error) {
+		return "重新查询", nil
+	})
+	if got != "帖子 2" {
+		t.Fatalf("post:2 = %q, 应命中缓存", got)
+	}
+}
+
+func TestCacheMemoryStore(t *testing.T) {
+	t.Parallel()
+	exerciseCache(t, NewServer(t, enableCache))
+}
+
+func TestCacheRedisStore(t *testing.T) {
+	t.Parallel()
+	mr := miniredis.RunT(t)
+	s := NewServer(t, enableCache, func(cfg *utils.Config) {
+		cfg.Redis = utils.RedisConfig{Enabled: true, Host: mr.Host(), Port: mustPort(t, mr.Port())}
+	})
+
+	exerciseCache(t, s)
+
+	if keys := mr.Keys(); len(keys) == 0 {
+		t.Fatal("缓存应保存在 Redis 中")
+	}
+}
+
+func TestCacheDisabled(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+
+	var result struct {
+		Enabled bool `json:"enabled"`
+	}
+	s.Get("/admin/cache/stats", s.AdminToken()).ExpectStatus(http.StatusOK).Decode(&result)
+	if result.Enabled {
+		t.Fatal("默认不应启用缓存")
+	}
+}
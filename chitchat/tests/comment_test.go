@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/lllllan02/chitchat/internal/model"
+)
+
+// listComments 获取帖子的顶级评论，按ID索引
+func listComments(s *Server, postID uint, token string) map[uint]model.Comment {
+	s.t.Helper()
+
+	var result struct {
+		Items []model.Comment `json:"items"`
+	}
+	s.Get(fmt.Sprintf("/posts/%d/comments", postID), token).ExpectStatus(http.StatusOK).Decode(&result)
+
+	comments := make(map[uint]model.Comment, len(result.Items))
+	for _, comment := range result.Items {
+		comments[comment.ID] = comment
+	}
+	return comments
+}
+
+func TestCommentThreading(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, alice := s.Register("alice", "secret1")
+	_, bob := s.Register("bob", "secret1")
+	_, carol := s.Register("carol", "secret1")
+	post := s.CreatePost(alice, "标题", "内容")
+	other := s.CreatePost(alice, "另一篇", "内容")
+
+	root := s.CreateComment(bob, post.ID, "顶级评论", 0)
+	reply := s.CreateComment(carol, post.ID, "回复", root.ID)
+	nested := s.CreateComment(alice, post.ID, "回复的回复", reply.ID)
+	sibling := s.CreateComment(bob, post.ID, "另一条顶级评论", 0)
+
+	// 父评论必须属于同一帖子
+	resp := s.Post("/comments", map[string]interface{}{
+		"post_id": other.ID, "content": "串帖", "parent_id": root.ID,
+	}, carol).ExpectStatus(http.StatusBadRequest)
+	if resp.Error != "INVALID_PARENT_COMMENT" {
+		t.Errorf("error = %q", resp.Error)
+	}
+
+	// 列表只包含顶级评论，回复按层嵌套
+	comments := listComments(s, post.ID, "")
+	if len(comments) != 2 {
+		t.Fatalf("期望 2 条顶级评论，实际为 %d", len(comments))
+	}
+	if len(comments[sibling.ID].Replies) != 0 {
+		t.Errorf("sibling replies = %+v", comments[sibling.ID].Replies)
+	}
+	replies := comments[root.ID].Replies
+	if len(replies) != 1 || replies[0].ID != reply.ID || replies[0].User.Username != "carol" {
+		t.Fatalf("root replies = %+v", replies)
+	}
+	if nestedReplies := replies[0].Replies; len(nestedReplies) != 1 || nestedReplies[0].ID != nested.ID {
+		t.Fatalf("nested replies = %+v", nestedReplies)
+	}
+}
+
+func TestDeleteCommentCascades(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, alice := s.Register("alice", "secret1")
+	_, bob := s.Register("bob", "secret1")
+	_, carol := s.Register("carol", "secret1")
+	post := s.CreatePost(alice, "标题", "内容")
+
+	root := s.CreateComment(bob, post.ID, "顶级评论", 0)
+	reply := s.CreateComment(carol, post.ID, "回复", root.ID)
+	nested := s.CreateComment(alice, post.ID, "回复的回复", reply.ID)
+	sibling := s.CreateComment(carol, post.ID, "另一条顶级评论", 0)
+
+	// 只有作者可以删除
+	s.Delete(fmt.Sprintf("/comments/%d", root.ID), carol).ExpectStatus(http.StatusForbidden)
+	s.Delete(fmt.Sprintf("/comments/%d", root.ID), bob).ExpectStatus(http.StatusOK)
+
+	// 删除评论时一并删除所有后代回复，其他评论不受影响
+	comments := listComments(s, post.ID, "")
+	if _, ok := comments[sibling.ID]; len(comments) != 1 || !ok {
+		t.Fatalf("comments = %+v", comments)
+	}
+	for _, id := range []uint{reply.ID, nested.ID} {
+		resp := s.Put(fmt.Sprintf("/comments/%d", id), map[string]string{"content": "改"}, alice).ExpectStatus(http.StatusNotFound)
+		if resp.Error != "COMMENT_NOT_FOUND" {
+			t.Errorf("comment %d: error = %q", id, resp.Error)
+		}
+	}
+
+	// 不能回复已删除的评论
+	s.Post("/comments", map[string]interface{}{
+		"post_id": post.ID, "content": "回复", "parent_id": nested.ID,
+	}, carol).ExpectStatus(http.StatusBadRequest)
+}
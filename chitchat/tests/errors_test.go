@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lllllan02/chitchat/internal/model"
+)
+
+func TestErrorCodes(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, alice := s.Register("alice", "secret1")
+	_, bob := s.Register("bob", "secret1")
+	post := s.CreatePost(alice, "标题", "内容")
+
+	for _, tc := range []struct {
+		name   string
+		resp   *Response
+		status int
+		code   string
+	}{
+		{"帖子不存在", s.Get("/posts/999", ""), http.StatusNotFound, "POST_NOT_FOUND"},
+		{"用户不存在", s.Get("/users/999", alice), http.StatusNotFound, "USER_NOT_FOUND"},
+		{"无权删除", s.Delete(fmt.Sprintf("/posts/%d", post.ID), bob), http.StatusForbidden, "PERMISSION_DENIED"},
+		{"用户名已存在", s.Post("/auth/register", map[string]string{
+			"username": "alice", "email": "other@example.com", "password": "secret1",
+		}, ""), http.StatusBadRequest, "USERNAME_TAKEN"},
+		{"密码错误", s.Post("/auth/login", map[string]string{
+			"username": "alice", "password": "wrong-password",
+		}, ""), http.StatusBadRequest, "INVALID_CREDENTIALS"},
+		{"未登录", s.Get("/notifications", ""), http.StatusUnauthorized, "UNAUTHORIZED"},
+		{"无效的游标", s.Get("/posts?cursor=bogus", ""), http.StatusBadRequest, "INVALID_CURSOR"},
+		{"接口不存在", s.Get("/nothing-here", ""), http.StatusNotFound, "NOT_FOUND"},
+	} {
+		tc.resp.ExpectStatus(tc.status)
+		if tc.resp.Error != tc.code {
+			t.Errorf("%s: error = %q, want %q", tc.name, tc.resp.Error, tc.code)
+		}
+		if tc.resp.Message == "" {
+			t.Errorf("%s: 缺少提示信息", tc.name)
+		}
+	}
+}
+
+func TestValidationDetails(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+
+	// JSON 请求体中的字段使用 JSON 名称
+	resp := s.Post("/auth/register", map[string]string{
+		"username": "ab",
+		"email":    "not-an-email",
+	}, "")
+	resp.ExpectStatus(http.StatusBadRequest)
+	if resp.Error != "VALIDATION_FAILED" {
+		t.Fatalf("error = %q, want VALIDATION_FAILED", resp.Error)
+	}
+	rules := map[string]string{}
+	for _, d := range resp.Details {
+		rules[d.Field] = d.Rule
+		if d.Message == "" {
+			t.Errorf("%s: 缺少提示信息", d.Field)
+		}
+	}
+	for field, rule := range map[string]string{"username": "min", "email": "email", "password": "required"} {
+		if rules[field] != rule {
+			t.Errorf("%s: rule = %q, want %q (details %+v)", field, rules[field], rule, resp.Details)
+		}
+	}
+
+	// 查询参数使用参数名，嵌入的分页参数同样不带结构体前缀
+	resp = s.Get("/posts?page_size=101", "")
+	resp.ExpectStatus(http.StatusBadRequest)
+	if len(resp.Details) != 1 {
+		t.Fatalf("details = %+v, want 1", resp.Details)
+	}
+	if d := resp.Details[0]; d.Field != "page_size" || d.Rule != "max" || d.Param != "100" {
+		t.Errorf("detail = %+v, want page_size max 100", d)
+	}
+}
+
+func TestMalformedRequest(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(`{"username":`))
+	req.Header.Set("Content-Type", "application/json")
+	resp := s.Send(req)
+	resp.ExpectStatus(http.StatusBadRequest)
+	if resp.Error != "BAD_REQUEST" {
+		t.Errorf("error = %q, want BAD_REQUEST", resp.Error)
+	}
+	if len(resp.Details) != 0 {
+		t.Errorf("details = %+v, want none", resp.Details)
+	}
+}
+
+func TestUnexpectedErrors(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, alice := s.Register("alice", "secret1")
+	post := s.CreatePost(alice, "标题", "内容")
+
+	// 数据库故障不能被当作资源不存在
+	if err := s.App.DB.Migrator().DropTable(&model.Post{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, resp := range []*Response{
+		s.Get(fmt.Sprintf("/posts/%d", post.ID), ""),
+		s.Put(fmt.Sprintf("/posts/%d", post.ID), map[string]string{"title": "新标题"}, alice),
+		s.Delete(fmt.Sprintf("/posts/%d", post.ID), alice),
+	} {
+		resp.ExpectStatus(http.StatusInternalServerError)
+		if resp.Error != "INTERNAL_ERROR" {
+			t.Errorf("error = %q, want INTERNAL_ERROR", resp.Error)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"testing"
+
+	"github.com/lllllan02/chitchat/internal/model"
+)
+
+// followUsernames 获取关注关系用户列表的第一页，返回排序后的用户名
+func followUsernames(s *Server, path, token string) []string {
+	s.t.Helper()
+
+	var result struct {
+		Items []model.User `json:"items"`
+	}
+	s.Get(path, token).ExpectStatus(http.StatusOK).Decode(&result)
+
+	names := make([]string, 0, len(result.Items))
+	for _, user := range result.Items {
+		names = append(names, user.Username)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// followCounts 获取用户资料中的粉丝数和关注数
+func followCounts(s *Server, userID uint, token string) (int64, int64) {
+	s.t.Helper()
+
+	var user model.User
+	s.Get(fmt.Sprintf("/users/%d", userID), token).ExpectStatus(http.StatusOK).Decode(&user)
+	return user.FollowerCount, user.FollowingCount
+}
+
+func TestFollow(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	alice, aliceToken := s.Register("alice", "secret1")
+	bob, bobToken := s.Register("bob", "secret1")
+	_, carolToken := s.Register("carol", "secret1")
+
+	// 不能关注自己，也不能关注不存在的用户
+	resp := s.Post(fmt.Sprintf("/follows/%d", alice.ID), nil, aliceToken).ExpectStatus(http.StatusBadRequest)
+	if resp.Error != "CANNOT_FOLLOW_SELF" {
+		t.Errorf("error = %q", resp.Error)
+	}
+	s.Post("/follows/999", nil, aliceToken).ExpectStatus(http.StatusNotFound)
+
+	// 重复关注不会产生重复的关注关系
+	s.Post(fmt.Sprintf("/follows/%d", bob.ID), nil, aliceToken).ExpectStatus(http.StatusOK)
+	s.Post(fmt.Sprintf("/follows/%d", bob.ID), nil, aliceToken).ExpectStatus(http.StatusOK)
+	s.Post(fmt.Sprintf("/follows/%d", bob.ID), nil, carolToken).ExpectStatus(http.StatusOK)
+	s.Post(fmt.Sprintf("/follows/%d", alice.ID), nil, bobToken).ExpectStatus(http.StatusOK)
+
+	if followers, following := followCounts(s, bob.ID, carolToken); followers != 2 || following != 1 {
+		t.Errorf("bob: followers = %d, following = %d", followers, following)
+	}
+	if got := followUsernames(s, fmt.Sprintf("/users/%d/followers", bob.ID), carolToken); fmt.Sprint(got) != "[alice carol]" {
+		t.Errorf("bob followers = %v", got)
+	}
+	if got := followUsernames(s, "/follows/following", aliceToken); fmt.Sprint(got) != "[bob]" {
+		t.Errorf("alice following = %v", got)
+	}
+
+	// 取消关注后计数和列表同步更新
+	s.Delete(fmt.Sprintf("/follows/%d", bob.ID), aliceToken).ExpectStatus(http.StatusOK)
+	if followers, _ := followCounts(s, bob.ID, carolToken); followers != 1 {
+		t.Errorf("bob followers after unfollow = %d", followers)
+	}
+	if got := followUsernames(s, "/follows/followers", bobToken); fmt.Sprint(got) != "[carol]" {
+		t.Errorf("bob followers after unfollow = %v", got)
+	}
+}
+
+func TestMutualFollowers(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, alice := s.Register("alice", "secret1")
+	bob, bobToken := s.Register("bob", "secret1")
+	carol, carolToken := s.Register("carol", "secret1")
+	dave, _ := s.Register("dave", "secret1")
+	_, eveToken := s.Register("eve", "secret1")
+
+	// alice 关注了 bob 和 carol，dave 的粉丝是 bob、carol 和 eve
+	for _, follow := range []struct {
+		token  string
+		target uint
+	}{
+		{alice, bob.ID},
+		{alice, carol.ID},
+		{bobToken, dave.ID},
+		{carolToken, dave.ID},
+		{eveToken, dave.ID},
+	} {
+		s.Post(fmt.Sprintf("/follows/%d", follow.target), nil, follow.token).ExpectStatus(http.StatusOK)
+	}
+
+	// alice 认识的人中关注了 dave 的只有 bob 和 carol
+	path := fmt.Sprintf("/users/%d/mutual-followers", dave.ID)
+	if got := followUsernames(s, path, alice); fmt.Sprint(got) != "[bob carol]" {
+		t.Errorf("mutual followers = %v", got)
+	}
+	if got := followUsernames(s, path, eveToken); len(got) != 0 {
+		t.Errorf("eve mutual followers = %v", got)
+	}
+	s.Get("/users/999/mutual-followers", alice).ExpectStatus(http.StatusNotFound)
+}
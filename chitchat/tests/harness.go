@@ -0,0 +1,266 @@
+// Package tests 提供端到端 HTTP 测试工具
+// 每个测试服务器使用独立的内存 SQLite 数据库，启动完整的路由和中间件，测试之间可以并行运行
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/lllllan02/chitchat/internal/app"
+	"github.com/lllllan02/chitchat/internal/mailer"
+	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/utils"
+	"github.com/lllllan02/chitchat/pkg/logger"
+	"github.com/lllllan02/chitchat/pkg/response"
+)
+
+// 种子数据中的默认管理员账号
+const (
+	AdminUsername = "admin"
+	AdminPassword = "admin123"
+)
+
+var setupOnce sync.Once
+
+// setup 初始化进程级的日志配置，只执行一次
+func setup() {
+	setupOnce.Do(func() {
+		_ = logger.Init(logger.Config{Level: "error"})
+	})
+}
+
+// Server 测试服务器
+type Server struct {
+	t   *testing.T
+	App *app.App
+}
+
+// NewServer 创建使用内存数据库的测试服务器，已完成迁移和初始数据填充
+// configure 可以在启动前修改配置
+func NewServer(t *testing.T, configure ...func(cfg *utils.Config)) *Server {
+	t.Helper()
+	setup()
+
+	cfg := &utils.Config{
+		Server: utils.ServerConfig{Mode: "test"},
+		JWT: utils.JWTConfig{
+			Secret:        "test_secret_key",
+			Expire:        "15m",
+			RefreshExpire: "24h",
+		},
+		Database: utils.DatabaseConfig{
+			Driver: utils.DriverSQLite,
+			DBName: ":memory:",
+		},
+		Mail: utils.MailConfig{
+			Driver:  mailer.DriverMemory,
+			BaseURL: "http://chitchat.test",
+		},
+	}
+	for _, fn := range configure {
+		fn(cfg)
+	}
+
+	db, err := utils.InitDB(cfg.Database)
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	if err := model.AutoMigrate(db); err != nil {
+		t.Fatalf("数据库迁移失败: %v", err)
+	}
+	if err := model.SeedData(db); err != nil {
+		t.Fatalf("填充初始数据失败: %v", err)
+	}
+
+	application, err := app.New(cfg, db)
+	if err != nil {
+		t.Fatalf("初始化应用失败: %v", err)
+	}
+	t.Cleanup(func() { application.Close() })
+	application.WaitSearchIndex()
+	return &Server{t: t, App: application}
+}
+
+// Response 测试请求的响应
+type Response struct {
+	t       *testing.T
+	Status  int
+	Header  http.Header
+	Code    int                   `json:"code"`
+	Error   string                `json:"error"`
+	Message string                `json:"message"`
+	Details []response.FieldError `json:"details"`
+	Data    json.RawMessage       `json:"data"`
+}
+
+// Do 发送请求，body 不为 nil 时编码为 JSON，token 不为空时携带认证头
+func (s *Server) Do(method, path string, body interface{}, token string) *Response {
+	s.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatalf("编码请求失败: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, "/api/v1"+path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return s.Send(req)
+}
+
+// Send 发送自定义请求，用于设置额外的请求头
+func (s *Server) Send(req *http.Request) *Response {
+	s.t.Helper()
+
+	w := httptest.NewRecorder()
+	s.App.Router.ServeHTTP(w, req)
+
+	resp := &Response{t: s.t, Status: w.Code, Header: w.Header()}
+	if err := json.Unmarshal(w.Body.Bytes(), resp); err != nil {
+		s.t.Fatalf("%s %s 响应不是合法的JSON: %v\n%s", req.Method, req.URL.Path, err, w.Body.String())
+	}
+	return resp
+}
+
+// Get 发送 GET 请求
+func (s *Server) Get(path, token string) *Response {
+	s.t.Helper()
+	return s.Do(http.MethodGet, path, nil, token)
+}
+
+// Post 发送 POST 请求
+func (s *Server) Post(path string, body interface{}, token string) *Response {
+	s.t.Helper()
+	return s.Do(http.MethodPost, path, body, token)
+}
+
+// Put 发送 PUT 请求
+func (s *Server) Put(path string, body interface{}, token string) *Response {
+	s.t.Helper()
+	return s.Do(http.MethodPut, path, body, token)
+}
+
+// Delete 发送 DELETE 请求
+func (s *Server) Delete(path, token string) *Response {
+	s.t.Helper()
+	return s.Do(http.MethodDelete, path, nil, token)
+}
+
+// Register 注册用户，返回用户和令牌
+func (s *Server) Register(username, password string) (*model.User, string) {
+	s.t.Helper()
+
+	resp := s.Post("/auth/register", map[string]string{
+		"username": username,
+		"email":    username + "@example.com",
+		"password": password,
+	}, "")
+	resp.ExpectStatus(http.StatusOK)
+
+	var result model.UserWithToken
+	resp.Decode(&result)
+	return result.User, result.Token
+}
+
+// Login 登录，返回访问令牌
+func (s *Server) Login(username, password string) string {
+	s.t.Helper()
+	return s.LoginTokens(username, password).Token
+}
+
+// LoginTokens 登录，返回访问令牌和刷新令牌
+func (s *Server) LoginTokens(username, password string) model.TokenPair {
+	s.t.Helper()
+
+	resp := s.Post("/auth/login", map[string]string{
+		"username": username,
+		"password": password,
+	}, "")
+	resp.ExpectStatus(http.StatusOK)
+
+	var result model.UserWithToken
+	resp.Decode(&result)
+	return result.TokenPair
+}
+
+// AdminToken 使用种子数据中的管理员账号登录
+func (s *Server) AdminToken() string {
+	s.t.Helper()
+	return s.Login(AdminUsername, AdminPassword)
+}
+
+// CreatePost 创建帖子
+func (s *Server) CreatePost(token, title, content string) *model.Post {
+	s.t.Helper()
+
+	resp := s.Post("/posts", map[string]interface{}{
+		"title":   title,
+		"content": content,
+	}, token)
+	resp.ExpectStatus(http.StatusOK)
+
+	var post model.Post
+	resp.Decode(&post)
+	return &post
+}
+
+// CreateComment 发表评论，parentID 不为 0 时回复该评论
+func (s *Server) CreateComment(token string, postID uint, content string, parentID uint) *model.Comment {
+	s.t.Helper()
+
+	body := map[string]interface{}{
+		"post_id": postID,
+		"content": content,
+	}
+	if parentID != 0 {
+		body["parent_id"] = parentID
+	}
+
+	var comment model.Comment
+	s.Post("/comments", body, token).ExpectStatus(http.StatusOK).Decode(&comment)
+	return &comment
+}
+
+// ExpectStatus 断言 HTTP 状态码
+func (r *Response) ExpectStatus(status int) *Response {
+	r.t.Helper()
+	if r.Status != status {
+		r.t.Fatalf("期望状态码 %d，实际为 %d: %s", status, r.Status, r.Message)
+	}
+	return r
+}
+
+// Decode 将响应数据解码到 v
+func (r *Response) Decode(v interface{}) {
+	r.t.Helper()
+	if err := json.Unmarshal(r.Data, v); err != nil {
+		r.t.Fatalf("解码响应数据失败: %v\n%s", err, r.Data)
+	}
+}
+
+// Mailbox 测试服务器的内存邮箱，返回前等待后台发送的邮件完成
+func (s *Server) Mailbox() *mailer.MemoryMailer {
+	s.App.Account.Wait()
+	return s.App.Mailer.(*mailer.MemoryMailer)
+}
@@ -0,0 +1,142 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lllllan02/chitchat/internal/model"
+)
+
+// localized 使用指定的 Accept-Language 发送请求
+func localized(s *Server, method, path, acceptLanguage string, body interface{}, token string) *Response {
+	s.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatalf("编码请求失败: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, "/api/v1"+path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if acceptLanguage != "" {
+		req.Header.Set("Accept-Language", acceptLanguage)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return s.Send(req)
+}
+
+func TestAcceptLanguage(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+
+	for _, tc := range []struct {
+		header   string
+		language string
+		message  string
+	}{
+		{"", "zh-CN", "帖子不存在"},
+		{"en-US,en;q=0.9", "en", "Post not found"},
+		{"fr-FR, en;q=0.5, zh;q=0.8", "zh-CN", "帖子不存在"},
+		{"fr-FR, zh;q=0, en;q=0.3", "en", "Post not found"},
+		{"fr-FR", "zh-CN", "帖子不存在"},
+	} {
+		resp := localized(s, http.MethodGet, "/posts/999", tc.header, nil, "")
+		resp.ExpectStatus(http.StatusNotFound)
+		if resp.Message != tc.message {
+			t.Errorf("%q: message = %q, want %q", tc.header, resp.Message, tc.message)
+		}
+		if got := resp.Header.Get("Content-Language"); got != tc.language {
+			t.Errorf("%q: Content-Language = %q, want %q", tc.header, got, tc.language)
+		}
+		if resp.Error != "POST_NOT_FOUND" {
+			t.Errorf("%q: 错误码不应随语言变化, got %q", tc.header, resp.Error)
+		}
+	}
+
+	// 校验错误同样按语言翻译
+	resp := localized(s, http.MethodPost, "/auth/register", "en", map[string]string{
+		"username": "ab",
+		"email":    "ab@example.com",
+		"password": "secret1",
+	}, "")
+	resp.ExpectStatus(http.StatusBadRequest)
+	if len(resp.Details) != 1 || resp.Details[0].Message != "username must be at least 3 characters long" {
+		t.Errorf("details = %+v", resp.Details)
+	}
+	if want := "Validation failed: username must be at least 3 characters long"; resp.Message != want {
+		t.Errorf("message = %q, want %q", resp.Message, want)
+	}
+}
+
+func TestLocalePreference(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, alice := s.Register("alice", "secret1")
+
+	s.Put("/users/me", map[string]string{"locale": "fr"}, alice).ExpectStatus(http.StatusBadRequest)
+
+	var user model.User
+	s.Put("/users/me", map[string]string{"locale": "en"}, alice).ExpectStatus(http.StatusOK).Decode(&user)
+	if user.Locale != "en" {
+		t.Fatalf("locale = %q, want en", user.Locale)
+	}
+
+	// 用户的语言偏好优先于 Accept-Language
+	for _, header := range []string{"", "zh-CN"} {
+		resp := localized(s, http.MethodGet, "/posts/999", header, nil, alice)
+		if resp.Message != "Post not found" {
+			t.Errorf("%q: message = %q, want Post not found", header, resp.Message)
+		}
+	}
+}
+
+func TestNotificationLocale(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	alice, aliceToken := s.Register("alice", "secret1")
+	bob, bobToken := s.Register("bob", "secret1")
+	_, carolToken := s.Register("carol", "secret1")
+
+	// 通知内容使用接收者的语言，而不是触发者的语言
+	s.Put("/users/me", map[string]string{"locale": "en"}, bobToken).ExpectStatus(http.StatusOK)
+	s.Post(fmt.Sprintf("/follows/%d", bob.ID), nil, aliceToken).ExpectStatus(http.StatusOK)
+	s.Post(fmt.Sprintf("/follows/%d", alice.ID), nil, bobToken).ExpectStatus(http.StatusOK)
+
+	if got := latestNotification(s, bobToken); got != "alice followed you" {
+		t.Errorf("bob: content = %q", got)
+	}
+	if got := latestNotification(s, aliceToken); got != "bob 关注了你" {
+		t.Errorf("alice: content = %q", got)
+	}
+
+	// 合并后的通知同样使用接收者的语言
+	s.Post(fmt.Sprintf("/follows/%d", bob.ID), nil, carolToken).ExpectStatus(http.StatusOK)
+	if got := latestNotification(s, bobToken); got != "carol and 1 more followed you" {
+		t.Errorf("bob: merged content = %q", got)
+	}
+}
+
+// latestNotification 获取最新一条通知的内容
+func latestNotification(s *Server, token string) string {
+	s.t.Helper()
+
+	var page struct {
+		Items []model.Notification `json:"items"`
+	}
+	s.Get("/notifications", token).ExpectStatus(http.StatusOK).Decode(&page)
+	if len(page.Items) == 0 {
+		s.t.Fatal("没有通知")
+	}
+	return page.Items[0].Content
+}
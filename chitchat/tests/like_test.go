@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/lllllan02/chitchat/internal/model"
+)
+
+// likeState 点赞接口返回的点赞数和点赞状态
+type likeState struct {
+	LikeCount int  `json:"like_count"`
+	LikedByMe bool `json:"liked_by_me"`
+}
+
+// toggleLike 点赞或取消点赞，返回最新状态
+func toggleLike(s *Server, method, path, token string) likeState {
+	s.t.Helper()
+
+	var state likeState
+	s.Do(method, path, nil, token).ExpectStatus(http.StatusOK).Decode(&state)
+	return state
+}
+
+func TestPostLikes(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, alice := s.Register("alice", "secret1")
+	_, bob := s.Register("bob", "secret1")
+	_, carol := s.Register("carol", "secret1")
+	post := s.CreatePost(alice, "标题", "内容")
+	like := fmt.Sprintf("/posts/%d/like", post.ID)
+
+	// 重复点赞和重复取消都是幂等的
+	for _, step := range []struct {
+		method string
+		token  string
+		want   likeState
+	}{
+		{http.MethodPost, bob, likeState{1, true}},
+		{http.MethodPost, bob, likeState{1, true}},
+		{http.MethodPost, carol, likeState{2, true}},
+		{http.MethodDelete, bob, likeState{1, false}},
+		{http.MethodDelete, bob, likeState{1, false}},
+	} {
+		if got := toggleLike(s, step.method, like, step.token); got != step.want {
+			t.Fatalf("%s %s: got %+v, want %+v", step.method, like, got, step.want)
+		}
+	}
+
+	// 帖子详情中的点赞状态按当前用户计算
+	for token, liked := range map[string]bool{carol: true, bob: false, "": false} {
+		var got model.Post
+		s.Get(fmt.Sprintf("/posts/%d", post.ID), token).ExpectStatus(http.StatusOK).Decode(&got)
+		if got.LikeCount != 1 || got.LikedByMe != liked {
+			t.Errorf("like_count = %d, liked_by_me = %v, want 1 %v", got.LikeCount, got.LikedByMe, liked)
+		}
+	}
+
+	s.Post("/posts/999/like", nil, bob).ExpectStatus(http.StatusNotFound)
+}
+
+func TestCommentLikes(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, alice := s.Register("alice", "secret1")
+	_, bob := s.Register("bob", "secret1")
+	post := s.CreatePost(alice, "标题", "内容")
+	comment := s.CreateComment(alice, post.ID, "评论", 0)
+	like := fmt.Sprintf("/comments/%d/like", comment.ID)
+
+	if got := toggleLike(s, http.MethodPost, like, bob); got != (likeState{1, true}) {
+		t.Fatalf("like: %+v", got)
+	}
+	if got := toggleLike(s, http.MethodPost, like, bob); got != (likeState{1, true}) {
+		t.Fatalf("repeat like: %+v", got)
+	}
+
+	// 评论列表中的点赞状态按当前用户计算
+	for token, liked := range map[string]bool{bob: true, alice: false} {
+		got := listComments(s, post.ID, token)[comment.ID]
+		if got.LikeCount != 1 || got.LikedByMe != liked {
+			t.Errorf("like_count = %d, liked_by_me = %v, want 1 %v", got.LikeCount, got.LikedByMe, liked)
+		}
+	}
+
+	if got := toggleLike(s, http.MethodDelete, like, bob); got != (likeState{0, false}) {
+		t.Fatalf("unlike: %+v", got)
+	}
+	s.Post("/comments/999/like", nil, bob).ExpectStatus(http.StatusNotFound)
+}
+
+func TestConcurrentLikes(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, alice := s.Register("alice", "secret1")
+	post := s.CreatePost(alice, "标题", "内容")
+	like := fmt.Sprintf("/posts/%d/like", post.ID)
+
+	var tokens []string
+	for i := 0; i < 5; i++ {
+		_, token := s.Register(fmt.Sprintf("user%d", i), "secret1")
+		tokens = append(tokens, token)
+	}
+
+	// 多个用户同时点赞，每个用户还重复点赞，点赞数只按用户计数
+	var wg sync.WaitGroup
+	for _, token := range tokens {
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func(token string) {
+				defer wg.Done()
+				s.Post(like, nil, token)
+			}(token)
+		}
+	}
+	wg.Wait()
+
+	var got model.Post
+	s.Get(fmt.Sprintf("/posts/%d", post.ID), "").ExpectStatus(http.StatusOK).Decode(&got)
+	if got.LikeCount != len(tokens) {
+		t.Fatalf("like_count = %d, want %d", got.LikeCount, len(tokens))
+	}
+}
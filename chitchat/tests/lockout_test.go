@@ -0,0 +1,169 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/utils"
+)
+
+// loginAttempt 使用指定的客户端 IP 登录
+func loginAttempt(s *Server, username, password, ip string) *Response {
+	s.t.Helper()
+
+	body := fmt.Sprintf(`{"username":%q,"password":%q}`, username, password)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = ip + ":12345"
+	return s.Send(req)
+}
+
+func TestLoginProgressiveDelay(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t, func(cfg *utils.Config) {
+		cfg.Auth.Lockout.DelayAfter = 2
+	})
+	s.Register("alice", "secret1")
+
+	loginAttempt(s, "alice", "wrong", "10.0.0.1").ExpectStatus(http.StatusBadRequest)
+	loginAttempt(s, "alice", "wrong", "10.0.0.1").ExpectStatus(http.StatusBadRequest)
+
+	// 达到阈值后需要等待，即使密码正确、换了 IP 也不能立即重试
+	resp := loginAttempt(s, "alice", "secret1", "10.0.0.2").ExpectStatus(http.StatusTooManyRequests)
+	if resp.Header.Get("Retry-After") != "1" {
+		t.Fatalf("期望 Retry-After 为 1，实际为 %q", resp.Header.Get("Retry-After"))
+	}
+
+	// 其他账号不受影响
+	s.Register("bob", "secret1")
+	loginAttempt(s, "bob", "secret1", "10.0.0.1").ExpectStatus(http.StatusOK)
+}
+
+func TestLoginDelayByIP(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t, func(cfg *utils.Config) {
+		cfg.Auth.Lockout.IPDelayAfter = 3
+	})
+	s.Register("alice", "secret1")
+
+	// 同一 IP 尝试不同的用户名
+	for _, username := range []string{"u1", "u2", "u3"} {
+		loginAttempt(s, username, "wrong", "10.0.0.1").ExpectStatus(http.StatusBadRequest)
+	}
+	loginAttempt(s, "alice", "secret1", "10.0.0.1").ExpectStatus(http.StatusTooManyRequests)
+	loginAttempt(s, "alice", "secret1", "10.0.0.2").ExpectStatus(http.StatusOK)
+}
+
+func TestAccountLockout(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t, func(cfg *utils.Config) {
+		cfg.Auth.Lockout.DelayAfter = 100
+		cfg.Auth.Lockout.IPDelayAfter = 100
+		cfg.Auth.Lockout.LockAfter = 3
+	})
+	alice, token := s.Register("alice", "secret1")
+
+	loginAttempt(s, "alice", "wrong", "10.0.0.1").ExpectStatus(http.StatusBadRequest)
+	loginAttempt(s, "alice", "wrong", "10.0.0.2").ExpectStatus(http.StatusBadRequest)
+
+	// 达到阈值的这次失败即锁定账号
+	resp := loginAttempt(s, "alice", "wrong", "10.0.0.3").ExpectStatus(http.StatusForbidden)
+	if resp.Header.Get("Retry-After") != "900" {
+		t.Fatalf("期望 Retry-After 为 900，实际为 %q", resp.Header.Get("Retry-After"))
+	}
+	loginAttempt(s, "alice", "secret1", "10.0.0.4").ExpectStatus(http.StatusForbidden)
+
+	// 账号所有者收到系统通知
+	var result struct {
+		Notifications []model.Notification `json:"items"`
+	}
+	s.Get("/notifications?type=system", token).ExpectStatus(http.StatusOK).Decode(&result)
+	if notifications := result.Notifications; len(notifications) != 1 || notifications[0].Type != model.NotificationTypeSystem || notifications[0].UserID != alice.ID {
+		t.Fatalf("期望 1 条系统通知，实际为 %+v", result.Notifications)
+	}
+
+	// 普通用户不能解锁
+	s.Post(fmt.Sprintf("/admin/users/%d/unlock", alice.ID), nil, token).ExpectStatus(http.StatusForbidden)
+
+	s.Post(fmt.Sprintf("/admin/users/%d/unlock", alice.ID), nil, s.AdminToken()).ExpectStatus(http.StatusOK)
+	loginAttempt(s, "alice", "secret1", "10.0.0.4").ExpectStatus(http.StatusOK)
+	s.Post("/admin/users/9999/unlock", nil, s.AdminToken()).ExpectStatus(http.StatusNotFound)
+}
+
+func TestLockoutUnknownUsername(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t, func(cfg *utils.Config) {
+		cfg.Auth.Lockout.DelayAfter = 100
+		cfg.Auth.Lockout.IPDelayAfter = 100
+		cfg.Auth.Lockout.LockAfter = 2
+	})
+
+	// 不存在的用户名同样会被锁定，无法据此判断用户名是否注册
+	loginAttempt(s, "ghost", "wrong", "10.0.0.1").ExpectStatus(http.StatusBadRequest)
+	loginAttempt(s, "ghost", "wrong", "10.0.0.1").ExpectStatus(http.StatusForbidden)
+	loginAttempt(s, "ghost", "wrong", "10.0.0.1").ExpectStatus(http.StatusForbidden)
+}
+
+func TestSuccessfulLoginResetsFailures(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t, func(cfg *utils.Config) {
+		cfg.Auth.Lockout.DelayAfter = 100
+		cfg.Auth.Lockout.IPDelayAfter = 100
+		cfg.Auth.Lockout.LockAfter = 3
+	})
+	s.Register("alice", "secret1")
+
+	loginAttempt(s, "alice", "wrong", "10.0.0.1").ExpectStatus(http.StatusBadRequest)
+	loginAttempt(s, "alice", "wrong", "10.0.0.1").ExpectStatus(http.StatusBadRequest)
+	loginAttempt(s, "alice", "secret1", "10.0.0.1").ExpectStatus(http.StatusOK)
+
+	// 重新计数，不会因为之前的失败而锁定
+	loginAttempt(s, "alice", "wrong", "10.0.0.1").ExpectStatus(http.StatusBadRequest)
+	loginAttempt(s, "alice", "wrong", "10.0.0.1").ExpectStatus(http.StatusBadRequest)
+	loginAttempt(s, "alice", "secret1", "10.0.0.1").ExpectStatus(http.StatusOK)
+}
+
+func TestConcurrentLoginFailures(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t, func(cfg *utils.Config) {
+		cfg.Auth.Lockout.DelayAfter = 100
+		cfg.Auth.Lockout.IPDelayAfter = 100
+		cfg.Auth.Lockout.LockAfter = 5
+	})
+	_, token := s.Register("alice", "secret1")
+
+	// 并发的失败请求不能丢失计数，也不能因为同时创建记录而出错
+	const attempts = 12
+	statuses := make(chan int, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			statuses <- loginAttempt(s, "alice", "wrong", fmt.Sprintf("10.0.1.%d", i)).Status
+		}(i)
+	}
+	wg.Wait()
+	close(statuses)
+
+	for status := range statuses {
+		if status != http.StatusBadRequest && status != http.StatusForbidden {
+			t.Errorf("并发登录失败返回了 %d", status)
+		}
+	}
+	loginAttempt(s, "alice", "secret1", "10.0.2.1").ExpectStatus(http.StatusForbidden)
+
+	// 只锁定一次，只通知一次
+	var result struct {
+		Notifications []model.Notification `json:"items"`
+	}
+	s.Get("/notifications?type=system", token).ExpectStatus(http.StatusOK).Decode(&result)
+	if len(result.Notifications) != 1 {
+		t.Fatalf("期望 1 条系统通知，实际为 %d", len(result.Notifications))
+	}
+}
@@ -0,0 +1,212 @@
+package tests
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lllllan02/chitchat/pkg/logger"
+)
+
+// captureLogs 将日志以 JSON 格式写入临时文件，测试结束后恢复默认配置
+// 日志配置是进程级的，使用它的测试不能并行运行
+func captureLogs(t *testing.T, cfg logger.Config) string {
+	t.Helper()
+
+	cfg.File = filepath.Join(t.TempDir(), "chitchat.log")
+	cfg.Format = logger.FormatJSON
+	if err := logger.Init(cfg); err != nil {
+		t.Fatalf("初始化日志失败: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = logger.Init(logger.Config{Level: "error"})
+	})
+	return cfg.File
+}
+
+// readLogs 读取 JSON 日志文件中的全部记录
+func readLogs(t *testing.T, path string) []map[string]any {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("打开日志文件失败: %v", err)
+	}
+	defer file.Close()
+
+	var records []map[string]any
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var record map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("日志不是合法的 JSON: %s", scanner.Text())
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestRequestLogging(t *testing.T) {
+	s := NewServer(t)
+	_, token := s.Register("alice", "secret1")
+	path := captureLogs(t, logger.Config{Level: "debug"})
+
+	// 沿用客户端传入的请求 ID
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/me?token=secret", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("X-Request-ID", "trace-123")
+	resp := s.Send(req).ExpectStatus(http.StatusOK)
+	if got := resp.Header.Get("X-Request-ID"); got != "trace-123" {
+		t.Fatalf("X-Request-ID = %q, want trace-123", got)
+	}
+
+	var access map[string]any
+	sqlLogged := false
+	for _, record := range readLogs(t, path) {
+		if record["request_id"] != "trace-123" {
+			continue
+		}
+		switch record["msg"] {
+		case "请求完成":
+			access = record
+		case "SQL":
+			sqlLogged = true
+		}
+	}
+	if access == nil {
+		t.Fatal("没有带请求 ID 的访问日志")
+	}
+	if access["level"] != "INFO" || access["status"] != float64(http.StatusOK) || access["method"] != http.MethodGet {
+		t.Errorf("access log = %v", access)
+	}
+	if access["path"] != "/api/v1/users/me" {
+		t.Errorf("访问日志不应包含查询参数, path = %v", access["path"])
+	}
+	if access["user_id"] == nil {
+		t.Errorf("访问日志缺少用户ID: %v", access)
+	}
+	if !sqlLogged {
+		t.Error("没有带请求 ID 的 SQL 日志")
+	}
+
+	// 非法的请求 ID 被替换为新生成的 ID
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/posts/999", nil)
+	req.Header.Set("X-Request-ID", "bad id\nforged")
+	resp = s.Send(req).ExpectStatus(http.StatusNotFound)
+	generated := resp.Header.Get("X-Request-ID")
+	if generated == "" || strings.ContainsAny(generated, " \n") {
+		t.Fatalf("generated X-Request-ID = %q", generated)
+	}
+
+	found := false
+	for _, record := range readLogs(t, path) {
+		if record["request_id"] == generated && record["msg"] == "请求完成" {
+			found = true
+			if record["level"] != "WARN" {
+				t.Errorf("4xx 应记为 warn, got %v", record["level"])
+			}
+		}
+	}
+	if !found {
+		t.Error("没有使用生成的请求 ID 记录访问日志")
+	}
+}
+
+func TestPostDetailSQLLogging(t *testing.T) {
+	s := NewServer(t)
+	_, token := s.Register("alice", "secret1")
+	post := s.CreatePost(token, "标题", "内容")
+	path := captureLogs(t, logger.Config{Level: "debug"})
+
+	// 查询帖子详情的 SQL 日志带上请求 ID
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/v1/posts/%d", post.ID), nil)
+	req.Header.Set("X-Request-ID", "post-detail-1")
+	s.Send(req).ExpectStatus(http.StatusOK)
+
+	found := false
+	for _, record := range readLogs(t, path) {
+		sql, _ := record["sql"].(string)
+		if record["msg"] == "SQL" && strings.Contains(sql, "FROM `posts`") {
+			if record["request_id"] != "post-detail-1" {
+				t.Errorf("帖子查询的 SQL 日志缺少请求 ID: %v", record)
+			}
+			found = true
+		}
+	}
+	if !found {
+		t.Error("没有查询帖子详情的 SQL 日志")
+	}
+}
+
+func TestLogRotation(t *testing.T) {
+	path := captureLogs(t, logger.Config{MaxSize: 1, MaxBackups: 2})
+
+	// 写入约 4MB 日志，按 1MB 切分后只保留 2 个旧文件
+	payload := strings.Repeat("x", 1024)
+	for i := 0; i < 4*1024; i++ {
+		logger.Info("rotation", logger.String("payload", payload))
+	}
+
+	backups, err := filepath.Glob(filepath.Join(filepath.Dir(path), "chitchat-*.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("backups = %v, want 2", backups)
+	}
+	for _, name := range append(backups, path) {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() > 1024*1024 {
+			t.Errorf("%s size = %d, 超过 1MB", name, info.Size())
+		}
+	}
+}
+
+func TestLogRotationRecovers(t *testing.T) {
+	path := captureLogs(t, logger.Config{MaxSize: 1})
+	dir := filepath.Dir(path)
+
+	// 日志目录被替换为普通文件，写满 1MB 后切分失败，日志继续写入旧文件
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	payload := strings.Repeat("x", 1024)
+	for i := 0; i < 1100; i++ {
+		logger.Info("rotation", logger.String("payload", payload))
+	}
+
+	// 目录恢复后下一次写入重新切分，日志写入新文件
+	if err := os.Remove(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("recovered")
+
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("切分失败后日志没有恢复写入: %v", err)
+	}
+	found := false
+	for _, record := range readLogs(t, path) {
+		if record["msg"] == "recovered" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("新日志文件中没有切分失败后写入的日志")
+	}
+}
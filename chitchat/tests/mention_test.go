@@ -0,0 +1,144 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/lllllan02/chitchat/internal/model"
+)
+
+// mentionNames 提及的用户名，按出现顺序排列
+func mentionNames(mentions []model.MentionedUser) string {
+	names := make([]string, 0, len(mentions))
+	for _, m := range mentions {
+		names = append(names, m.Username)
+	}
+	return fmt.Sprint(names)
+}
+
+// mentionNotifications 获取用户收到的提及通知
+func mentionNotifications(s *Server, token string) []model.Notification {
+	s.t.Helper()
+	items, _ := listNotifications(s, token, url.Values{"type": {"mention"}})
+	return items
+}
+
+func TestPostMentions(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, alice := s.Register("alice", "secret1")
+	_, bob := s.Register("bob", "secret1")
+	_, carol := s.Register("carol", "secret1")
+	_, dave := s.Register("dave", "secret1")
+
+	// 忽略不存在的用户、邮箱地址和重复提及
+	post := s.CreatePost(alice, "标题", "你好 @bob 和 @carol，还有 @nobody 以及 mail@dave.example @bob @alice")
+	if got := mentionNames(post.Mentions); got != "[bob carol alice]" {
+		t.Fatalf("mentions = %s", got)
+	}
+
+	var got model.Post
+	s.Get(fmt.Sprintf("/posts/%d", post.ID), "").ExpectStatus(http.StatusOK).Decode(&got)
+	if names := mentionNames(got.Mentions); names != "[bob carol alice]" {
+		t.Fatalf("post mentions = %s", names)
+	}
+
+	// 被提及的用户收到通知，提及自己不通知
+	for token, want := range map[string]int{bob: 1, carol: 1, alice: 0, dave: 0} {
+		if items := mentionNotifications(s, token); len(items) != want {
+			t.Errorf("notifications = %+v, want %d", items, want)
+		}
+	}
+	if items := mentionNotifications(s, bob); items[0].Content != "alice 在帖子《标题》中提到了你" {
+		t.Errorf("content = %q", items[0].Content)
+	}
+
+	// 编辑后只通知新增的提及
+	s.Put(fmt.Sprintf("/posts/%d", post.ID), map[string]interface{}{
+		"title":   "标题",
+		"content": "改为提及 @bob 和 @dave",
+	}, alice).ExpectStatus(http.StatusOK).Decode(&got)
+	if names := mentionNames(got.Mentions); names != "[bob dave]" {
+		t.Fatalf("updated mentions = %s", names)
+	}
+	for token, want := range map[string]int{bob: 1, carol: 1, dave: 1} {
+		if items := mentionNotifications(s, token); len(items) != want {
+			t.Errorf("notifications after edit = %+v, want %d", items, want)
+		}
+	}
+}
+
+func TestPostListMentions(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	alice, aliceToken := s.Register("alice", "secret1")
+	s.Register("bob", "secret1")
+	post := s.CreatePost(aliceToken, "列表中的提及", "你好 @bob")
+	s.Put(fmt.Sprintf("/admin/posts/%d/feature", post.ID), nil, s.AdminToken()).ExpectStatus(http.StatusOK)
+
+	// 帖子列表、精华帖子、用户的帖子和搜索结果都带有提及的用户
+	for _, path := range []string{
+		"/posts",
+		"/posts/featured",
+		fmt.Sprintf("/users/%d/posts", alice.ID),
+		"/search?" + url.Values{"q": {"列表中的提及"}, "type": {"post"}}.Encode(),
+	} {
+		var result struct {
+			Items []struct {
+				model.Post
+				Hit *model.Post `json:"post"`
+			} `json:"items"`
+		}
+		s.Get(path, aliceToken).ExpectStatus(http.StatusOK).Decode(&result)
+		if len(result.Items) != 1 {
+			t.Fatalf("%s: items = %+v", path, result.Items)
+		}
+		mentions := result.Items[0].Mentions
+		if hit := result.Items[0].Hit; hit != nil {
+			mentions = hit.Mentions
+		}
+		if names := mentionNames(mentions); names != "[bob]" {
+			t.Errorf("%s: mentions = %s", path, names)
+		}
+	}
+}
+
+func TestCommentMentions(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, alice := s.Register("alice", "secret1")
+	author, bob := s.Register("bob", "secret1")
+	_, carol := s.Register("carol", "secret1")
+	_, dave := s.Register("dave", "secret1")
+	post := s.CreatePost(alice, "标题", "内容")
+
+	comment := s.CreateComment(bob, post.ID, "@carol 来看看", 0)
+	if got := mentionNames(comment.Mentions); got != "[carol]" {
+		t.Fatalf("mentions = %s", got)
+	}
+	items := mentionNotifications(s, carol)
+	if len(items) != 1 || *items[0].CommentID != comment.ID || items[0].Content != "bob 在《标题》的评论中提到了你" {
+		t.Fatalf("notifications = %+v", items)
+	}
+
+	// 评论列表中的回复同样返回提及的用户
+	reply := s.CreateComment(carol, post.ID, "谢谢 @bob，也叫上 @alice", comment.ID)
+	replies := listComments(s, post.ID, "")[comment.ID].Replies
+	if len(replies) != 1 || replies[0].ID != reply.ID || mentionNames(replies[0].Mentions) != "[bob alice]" {
+		t.Fatalf("replies = %+v", replies)
+	}
+
+	// 编辑评论时已提及的用户不重复通知
+	s.Put(fmt.Sprintf("/comments/%d", comment.ID), map[string]string{"content": "@carol 再看看"}, bob).ExpectStatus(http.StatusOK)
+	if items := mentionNotifications(s, carol); len(items) != 1 {
+		t.Fatalf("notifications after edit = %+v", items)
+	}
+	// 管理员编辑评论时，新提及的通知仍以评论作者的名义发出
+	s.Put(fmt.Sprintf("/comments/%d", comment.ID), map[string]string{"content": "@carol 和 @dave 再看看"}, s.AdminToken()).ExpectStatus(http.StatusOK)
+	items = mentionNotifications(s, dave)
+	if len(items) != 1 || *items[0].SenderID != author.ID || items[0].Content != "bob 在《标题》的评论中提到了你" {
+		t.Fatalf("notifications after moderator edit = %+v", items)
+	}
+}
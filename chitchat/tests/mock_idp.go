@@ -0,0 +1,283 @@
+package tests
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lllllan02/chitchat/internal/utils"
+)
+
+// 模拟身份提供方登记的客户端
+const (
+	MockClientID     = "chitchat"
+	MockClientSecret = "mock_secret"
+	MockRedirectURL  = "http://chitchat.test/oauth/callback"
+)
+
+// MockUser 模拟身份提供方中的用户
+type MockUser struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Username      string
+	Name          string
+}
+
+// MockIdP 本地模拟的 OIDC 身份提供方，支持授权码流程和 PKCE
+type MockIdP struct {
+	t      *testing.T
+	Server *httptest.Server
+	key    *rsa.PrivateKey
+
+	// User 下一次授权时登录的用户
+	User MockUser
+	// DisableIDToken 为 true 时只返回访问令牌，模拟不支持 OIDC 的 OAuth2 提供方
+	DisableIDToken bool
+
+	mu    sync.Mutex
+	codes map[string]*mockGrant
+}
+
+// mockGrant 授权码对应的授权信息
+type mockGrant struct {
+	user          MockUser
+	nonce         string
+	codeChallenge string
+	redirectURI   string
+}
+
+// NewMockIdP 启动模拟身份提供方
+func NewMockIdP(t *testing.T) *MockIdP {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("生成密钥失败: %v", err)
+	}
+
+	idp := &MockIdP{
+		t:     t,
+		key:   key,
+		codes: make(map[string]*mockGrant),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("/authorize", idp.authorize)
+	mux.HandleFunc("/token", idp.token)
+	mux.HandleFunc("/userinfo", idp.userInfo)
+	mux.HandleFunc("/jwks", idp.jwks)
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Server.Close)
+	return idp
+}
+
+// Issuer 发行方地址
+func (idp *MockIdP) Issuer() string {
+	return idp.Server.URL
+}
+
+// ProviderConfig 使用 OIDC 发现文档的提供方配置
+func (idp *MockIdP) ProviderConfig(name string) utils.OAuthProviderConfig {
+	return utils.OAuthProviderConfig{
+		Name:         name,
+		DisplayName:  "Mock " + name,
+		Issuer:       idp.Issuer(),
+		ClientID:     MockClientID,
+		ClientSecret: MockClientSecret,
+		RedirectURL:  MockRedirectURL,
+	}
+}
+
+// Consent 模拟用户在身份提供方登录并同意授权，返回重定向到前端的授权码和 state
+func (idp *MockIdP) Consent(authorizationURL string) (code, state string) {
+	idp.t.Helper()
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Get(authorizationURL)
+	if err != nil {
+		idp.t.Fatalf("请求授权地址失败: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		idp.t.Fatalf("授权失败，状态码 %d", resp.StatusCode)
+	}
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		idp.t.Fatalf("重定向地址错误: %v", err)
+	}
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+// discovery 返回 OIDC 发现文档
+func (idp *MockIdP) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                 idp.Issuer(),
+		"authorization_endpoint": idp.Issuer() + "/authorize",
+		"token_endpoint":         idp.Issuer() + "/token",
+		"userinfo_endpoint":      idp.Issuer() + "/userinfo",
+		"jwks_uri":               idp.Issuer() + "/jwks",
+	})
+}
+
+// authorize 校验授权请求，直接以当前用户同意授权
+func (idp *MockIdP) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != MockClientID || query.Get("response_type") != "code" ||
+		query.Get("code_challenge") == "" || query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	code := randomString(idp.t)
+	idp.mu.Lock()
+	idp.codes[code] = &mockGrant{
+		user:          idp.User,
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+		redirectURI:   query.Get("redirect_uri"),
+	}
+	idp.mu.Unlock()
+
+	redirect := query.Get("redirect_uri") + "?" + url.Values{
+		"code":  {code},
+		"state": {query.Get("state")},
+	}.Encode()
+	http.Redirect(w, r, redirect, http.StatusFound)
+}
+
+// token 使用授权码换取令牌，校验客户端凭据和 PKCE
+func (idp *MockIdP) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	if r.PostForm.Get("client_id") != MockClientID || r.PostForm.Get("client_secret") != MockClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	// 授权码只能使用一次
+	idp.mu.Lock()
+	grant, ok := idp.codes[r.PostForm.Get("code")]
+	delete(idp.codes, r.PostForm.Get("code"))
+	idp.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || grant.redirectURI != r.PostForm.Get("redirect_uri") ||
+		base64.RawURLEncoding.EncodeToString(sum[:]) != grant.codeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	accessToken := randomString(idp.t)
+	idp.mu.Lock()
+	idp.codes["access:"+accessToken] = grant
+	idp.mu.Unlock()
+
+	result := map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+	}
+	if !idp.DisableIDToken {
+		result["id_token"] = idp.signIDToken(grant)
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// userInfo 返回访问令牌对应的用户信息
+func (idp *MockIdP) userInfo(w http.ResponseWriter, r *http.Request) {
+	const prefix = "Bearer "
+	auth := r.Header.Get("Authorization")
+	if len(auth) <= len(prefix) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	idp.mu.Lock()
+	grant, ok := idp.codes["access:"+auth[len(prefix):]]
+	idp.mu.Unlock()
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	writeJSON(w, http.StatusOK, userClaims(grant.user))
+}
+
+// jwks 返回签名公钥
+func (idp *MockIdP) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := idp.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "mock-key",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+// signIDToken 签发 ID Token
+func (idp *MockIdP) signIDToken(grant *mockGrant) string {
+	now := time.Now()
+	claims := jwt.MapClaims(userClaims(grant.user))
+	claims["iss"] = idp.Issuer()
+	claims["aud"] = MockClientID
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(time.Hour).Unix()
+	claims["nonce"] = grant.nonce
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "mock-key"
+	signed, err := token.SignedString(idp.key)
+	if err != nil {
+		idp.t.Errorf("签发 ID Token 失败: %v", err)
+	}
+	return signed
+}
+
+// userClaims 用户的标准声明
+func userClaims(user MockUser) map[string]interface{} {
+	return map[string]interface{}{
+		"sub":                user.Subject,
+		"email":              user.Email,
+		"email_verified":     user.EmailVerified,
+		"preferred_username": user.Username,
+		"name":               user.Name,
+	}
+}
+
+// randomString 生成随机字符串
+func randomString(t *testing.T) string {
+	s, err := utils.RandomToken(16)
+	if err != nil {
+		t.Errorf("生成随机字符串失败: %v", err)
+	}
+	return s
+}
+
+// writeJSON 写入 JSON 响应
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
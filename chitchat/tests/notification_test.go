@@ -0,0 +1,196 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/lllllan02/chitchat/internal/model"
+)
+
+// listNotifications 获取通知列表的一页
+func listNotifications(s *Server, token string, params url.Values) ([]model.Notification, pageMeta) {
+	s.t.Helper()
+
+	var result struct {
+		Items []model.Notification `json:"items"`
+		Meta  pageMeta             `json:"meta"`
+	}
+	s.Get("/notifications?"+params.Encode(), token).ExpectStatus(http.StatusOK).Decode(&result)
+	return result.Items, result.Meta
+}
+
+func TestNotificationMergeCountsDistinctActors(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, alice := s.Register("alice", "secret1")
+	_, bob := s.Register("bob", "secret1")
+	_, carol := s.Register("carol", "secret1")
+	post := s.CreatePost(alice, "alice 的帖子", "内容")
+	like := fmt.Sprintf("/posts/%d/like", post.ID)
+
+	// bob 取消点赞后再次点赞，不重复计数
+	s.Post(like, nil, bob).ExpectStatus(http.StatusOK)
+	s.Post(like, nil, carol).ExpectStatus(http.StatusOK)
+	s.Delete(like, bob).ExpectStatus(http.StatusOK)
+	s.Post(like, nil, bob).ExpectStatus(http.StatusOK)
+
+	items, _ := listNotifications(s, alice, url.Values{"type": {"like"}})
+	if len(items) != 1 || items[0].Count != 2 {
+		t.Fatalf("期望 1 条计数为 2 的通知，实际为 %+v", items)
+	}
+	if want := "carol 等 2 人 赞了你的帖子《alice 的帖子》"; items[0].Content != want {
+		t.Errorf("content = %q, want %q", items[0].Content, want)
+	}
+}
+
+func TestConcurrentLikesMergeNotification(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, alice := s.Register("alice", "secret1")
+	post := s.CreatePost(alice, "标题", "内容")
+	like := fmt.Sprintf("/posts/%d/like", post.ID)
+
+	var tokens []string
+	for i := 0; i < 20; i++ {
+		_, token := s.Register(fmt.Sprintf("user%d", i), "secret1")
+		tokens = append(tokens, token)
+	}
+
+	// 多个用户同时点赞，只产生一条通知，计数为点赞人数
+	var wg sync.WaitGroup
+	for _, token := range tokens {
+		wg.Add(1)
+		go func(token string) {
+			defer wg.Done()
+			s.Post(like, nil, token)
+		}(token)
+	}
+	wg.Wait()
+
+	items, _ := listNotifications(s, alice, url.Values{"type": {"like"}})
+	if len(items) != 1 || items[0].Count != len(tokens) {
+		t.Fatalf("期望 1 条计数为 %d 的通知，实际为 %+v", len(tokens), items)
+	}
+
+	// 标记已读后新的点赞重新产生通知
+	s.Put("/notifications/read-all", nil, alice).ExpectStatus(http.StatusOK)
+	_, bob := s.Register("bob", "secret1")
+	s.Post(like, nil, bob).ExpectStatus(http.StatusOK)
+	items, _ = listNotifications(s, alice, url.Values{"type": {"like"}, "is_read": {"false"}})
+	if len(items) != 1 || items[0].Count != 1 {
+		t.Fatalf("已读后的新通知: %+v", items)
+	}
+}
+
+func TestNotificationCursorStableAcrossMerge(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, alice := s.Register("alice", "secret1")
+	_, bob := s.Register("bob", "secret1")
+	_, carol := s.Register("carol", "secret1")
+
+	var posts []*model.Post
+	for i := 0; i < 3; i++ {
+		post := s.CreatePost(alice, fmt.Sprintf("帖子 %d", i), "内容")
+		s.Post(fmt.Sprintf("/posts/%d/like", post.ID), nil, bob).ExpectStatus(http.StatusOK)
+		posts = append(posts, post)
+	}
+
+	first, meta := listNotifications(s, alice, url.Values{"page_size": {"2"}})
+	if len(first) != 2 || meta.NextCursor == nil {
+		t.Fatalf("first page: %+v %+v", first, meta)
+	}
+
+	// 翻页期间最早的通知被合并，不会移动到第一页而在第二页遗漏
+	s.Post(fmt.Sprintf("/posts/%d/like", posts[0].ID), nil, carol).ExpectStatus(http.StatusOK)
+
+	second, _ := listNotifications(s, alice, url.Values{"page_size": {"2"}, "cursor": {*meta.NextCursor}})
+	if len(second) != 1 || *second[0].PostID != posts[0].ID || second[0].Count != 2 {
+		t.Fatalf("second page: %+v", second)
+	}
+}
+
+// unreadCount 获取未读通知数
+func unreadCount(s *Server, token string) int64 {
+	s.t.Helper()
+
+	var result struct {
+		UnreadCount int64 `json:"unread_count"`
+	}
+	s.Get("/notifications/unread-count", token).ExpectStatus(http.StatusOK).Decode(&result)
+	return result.UnreadCount
+}
+
+func TestReplyNotifications(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, alice := s.Register("alice", "secret1")
+	_, bob := s.Register("bob", "secret1")
+	_, carol := s.Register("carol", "secret1")
+	post := s.CreatePost(alice, "标题", "内容")
+
+	// 评论通知帖子作者，回复通知被回复的评论作者，自己的操作不产生通知
+	comment := s.CreateComment(bob, post.ID, "评论", 0)
+	s.CreateComment(alice, post.ID, "作者自己的评论", 0)
+	reply := s.CreateComment(carol, post.ID, "回复", comment.ID)
+	s.CreateComment(bob, post.ID, "回复自己", comment.ID)
+
+	items, _ := listNotifications(s, alice, url.Values{})
+	if len(items) != 1 || *items[0].CommentID != comment.ID || items[0].Content != "bob 评论了你的帖子《标题》" {
+		t.Fatalf("alice notifications = %+v", items)
+	}
+	items, _ = listNotifications(s, bob, url.Values{})
+	if len(items) != 1 || *items[0].CommentID != reply.ID || items[0].Content != "carol 回复了你在《标题》中的评论" {
+		t.Fatalf("bob notifications = %+v", items)
+	}
+	if items, _ := listNotifications(s, carol, url.Values{}); len(items) != 0 {
+		t.Fatalf("carol notifications = %+v", items)
+	}
+}
+
+func TestNotificationReadState(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	alice, aliceToken := s.Register("alice", "secret1")
+	_, bob := s.Register("bob", "secret1")
+	_, carol := s.Register("carol", "secret1")
+	post := s.CreatePost(aliceToken, "标题", "内容")
+
+	s.Post(fmt.Sprintf("/follows/%d", alice.ID), nil, bob).ExpectStatus(http.StatusOK)
+	s.CreateComment(bob, post.ID, "评论", 0)
+	s.Post(fmt.Sprintf("/posts/%d/like", post.ID), nil, bob).ExpectStatus(http.StatusOK)
+	if got := unreadCount(s, aliceToken); got != 3 {
+		t.Fatalf("unread_count = %d, want 3", got)
+	}
+
+	// 标记单条通知为已读，不能标记别人的通知
+	items, _ := listNotifications(s, aliceToken, url.Values{"type": {"like"}})
+	read := fmt.Sprintf("/notifications/%d/read", items[0].ID)
+	s.Put(read, nil, carol).ExpectStatus(http.StatusNotFound)
+	s.Put(read, nil, aliceToken).ExpectStatus(http.StatusOK)
+	if got := unreadCount(s, aliceToken); got != 2 {
+		t.Fatalf("unread_count = %d, want 2", got)
+	}
+	if items, _ := listNotifications(s, aliceToken, url.Values{"is_read": {"true"}}); len(items) != 1 || !items[0].IsRead {
+		t.Fatalf("read notifications = %+v", items)
+	}
+
+	// 已读的通知不再合并，新的点赞产生新通知
+	s.Post(fmt.Sprintf("/posts/%d/like", post.ID), nil, carol).ExpectStatus(http.StatusOK)
+	items, _ = listNotifications(s, aliceToken, url.Values{"type": {"like"}})
+	if len(items) != 2 || items[0].IsRead || items[0].Count != 1 {
+		t.Fatalf("like notifications = %+v", items)
+	}
+
+	// 全部标记为已读
+	s.Put("/notifications/read-all", nil, aliceToken).ExpectStatus(http.StatusOK)
+	if got := unreadCount(s, aliceToken); got != 0 {
+		t.Fatalf("unread_count = %d, want 0", got)
+	}
+	if items, _ := listNotifications(s, aliceToken, url.Values{"is_read": {"false"}}); len(items) != 0 {
+		t.Fatalf("unread notifications = %+v", items)
+	}
+}
@@ -0,0 +1,224 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/utils"
+)
+
+// newSSOServer 创建配置了模拟身份提供方的测试服务器
+func newSSOServer(t *testing.T, providers ...utils.OAuthProviderConfig) *Server {
+	t.Helper()
+	return NewServer(t, func(cfg *utils.Config) {
+		cfg.OAuth.Providers = providers
+	})
+}
+
+// authorize 获取授权地址，并检查 state 同时写入了 Cookie
+func authorize(s *Server, provider string) model.OAuthAuthorization {
+	s.t.Helper()
+
+	var authorization model.OAuthAuthorization
+	resp := s.Get("/auth/oauth/"+provider+"/authorize", "").ExpectStatus(http.StatusOK)
+	resp.Decode(&authorization)
+
+	cookies := (&http.Response{Header: resp.Header}).Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "oauth_state" || cookies[0].Value != authorization.State ||
+		!cookies[0].HttpOnly || cookies[0].SameSite != http.SameSiteLaxMode || cookies[0].Path != "/api/v1/auth/oauth" {
+		s.t.Fatalf("state Cookie 错误: %v", resp.Header.Values("Set-Cookie"))
+	}
+	return authorization
+}
+
+// oauthCallback 提交回调参数，cookie 为浏览器携带的 state Cookie，为空时不携带
+func oauthCallback(s *Server, provider, code, state, cookie string) *Response {
+	s.t.Helper()
+
+	data, _ := json.Marshal(map[string]string{"code": code, "state": state})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/oauth/"+provider+"/callback", bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	if cookie != "" {
+		req.AddCookie(&http.Cookie{Name: "oauth_state", Value: cookie})
+	}
+	return s.Send(req)
+}
+
+// ssoLogin 完成一次第三方登录：获取授权地址、在身份提供方同意授权、提交回调参数
+func ssoLogin(s *Server, idp *MockIdP, provider string) *Response {
+	s.t.Helper()
+
+	code, state := idp.Consent(authorize(s, provider).AuthorizationURL)
+	return oauthCallback(s, provider, code, state, state)
+}
+
+func TestOAuthProvisionsUserOnFirstLogin(t *testing.T) {
+	t.Parallel()
+	idp := NewMockIdP(t)
+	s := newSSOServer(t, idp.ProviderConfig("corp"))
+
+	var providers []model.OAuthProvider
+	s.Get("/auth/oauth/providers", "").ExpectStatus(http.StatusOK).Decode(&providers)
+	if len(providers) != 1 || providers[0].Name != "corp" || providers[0].DisplayName != "Mock corp" {
+		t.Fatalf("登录方式列表错误: %+v", providers)
+	}
+
+	idp.User = MockUser{Subject: "emp-1", Email: "alice@corp.example", EmailVerified: true, Username: "alice.smith"}
+
+	var first model.UserWithToken
+	ssoLogin(s, idp, "corp").ExpectStatus(http.StatusOK).Decode(&first)
+	if first.User.Username != "alicesmith" || first.User.Email != "alice@corp.example" || !first.User.EmailVerified {
+		t.Fatalf("自动创建的用户错误: %+v", first.User)
+	}
+
+	// 签发的是普通令牌
+	var me model.User
+	s.Get("/users/me", first.Token).ExpectStatus(http.StatusOK).Decode(&me)
+	if me.ID != first.User.ID {
+		t.Fatalf("期望用户 %d，实际为 %d", first.User.ID, me.ID)
+	}
+
+	// 再次登录使用已关联的账号
+	var second model.UserWithToken
+	ssoLogin(s, idp, "corp").ExpectStatus(http.StatusOK).Decode(&second)
+	if second.User.ID != first.User.ID {
+		t.Fatalf("再次登录应为同一用户，实际为 %d 和 %d", first.User.ID, second.User.ID)
+	}
+}
+
+func TestOAuthUsernameConflict(t *testing.T) {
+	t.Parallel()
+	idp := NewMockIdP(t)
+	s := newSSOServer(t, idp.ProviderConfig("corp"))
+	s.Register("alice", "secret1")
+
+	idp.User = MockUser{Subject: "emp-1", Email: "alice@corp.example", Username: "alice"}
+
+	var result model.UserWithToken
+	ssoLogin(s, idp, "corp").ExpectStatus(http.StatusOK).Decode(&result)
+	if result.User.Username != "alice2" || result.User.EmailVerified {
+		t.Fatalf("自动创建的用户错误: %+v", result.User)
+	}
+}
+
+func TestOAuthState(t *testing.T) {
+	t.Parallel()
+	idp := NewMockIdP(t)
+	s := newSSOServer(t, idp.ProviderConfig("corp"), idp.ProviderConfig("other"))
+	idp.User = MockUser{Subject: "emp-1", Email: "alice@corp.example", Username: "alice"}
+
+	s.Get("/auth/oauth/unknown/authorize", "").ExpectStatus(http.StatusNotFound)
+
+	// 伪造的 state 无效
+	code, state := idp.Consent(authorize(s, "corp").AuthorizationURL)
+	oauthCallback(s, "corp", code, "forged", "forged").ExpectStatus(http.StatusBadRequest)
+
+	// state 属于其他提供方时无效，且随即作废
+	oauthCallback(s, "other", code, state, state).ExpectStatus(http.StatusBadRequest)
+	oauthCallback(s, "corp", code, state, state).ExpectStatus(http.StatusBadRequest)
+
+	// state 只能使用一次
+	code, state = idp.Consent(authorize(s, "corp").AuthorizationURL)
+	oauthCallback(s, "corp", code, state, state).ExpectStatus(http.StatusOK)
+	oauthCallback(s, "corp", code, state, state).ExpectStatus(http.StatusBadRequest)
+
+	// 授权码与 state 不属于同一请求时，PKCE 校验失败
+	code, _ = idp.Consent(authorize(s, "corp").AuthorizationURL)
+	_, state = idp.Consent(authorize(s, "corp").AuthorizationURL)
+	oauthCallback(s, "corp", code, state, state).ExpectStatus(http.StatusUnauthorized)
+}
+
+func TestOAuthStateCookie(t *testing.T) {
+	t.Parallel()
+	idp := NewMockIdP(t)
+	s := newSSOServer(t, idp.ProviderConfig("corp"))
+	idp.User = MockUser{Subject: "emp-1", Email: "alice@corp.example", Username: "alice"}
+
+	// 攻击者发起授权，把自己的回调参数交给受害者的浏览器提交
+	code, state := idp.Consent(authorize(s, "corp").AuthorizationURL)
+	victim := authorize(s, "corp").State
+
+	// 未携带 Cookie 或 Cookie 属于另一次授权时拒绝，回调同时清除 Cookie
+	resp := oauthCallback(s, "corp", code, state, "").ExpectStatus(http.StatusBadRequest)
+	if resp.Error != "INVALID_OAUTH_STATE" {
+		t.Errorf("error = %q", resp.Error)
+	}
+	resp = oauthCallback(s, "corp", code, state, victim).ExpectStatus(http.StatusBadRequest)
+	if cookies := (&http.Response{Header: resp.Header}).Cookies(); len(cookies) != 1 || cookies[0].Name != "oauth_state" || cookies[0].MaxAge >= 0 {
+		t.Errorf("Set-Cookie = %v", resp.Header.Values("Set-Cookie"))
+	}
+
+	// 被拒绝的回调不会消耗登录请求，发起授权的浏览器仍可完成登录
+	oauthCallback(s, "corp", code, state, state).ExpectStatus(http.StatusOK)
+}
+
+func TestOAuthExistingEmail(t *testing.T) {
+	t.Parallel()
+	idp := NewMockIdP(t)
+	linking := idp.ProviderConfig("linking")
+	linking.LinkByEmail = true
+	s := newSSOServer(t, idp.ProviderConfig("corp"), linking)
+	alice, _ := s.Register("alice", "secret1")
+
+	// 默认不关联已有账号
+	idp.User = MockUser{Subject: "emp-1", Email: alice.Email, EmailVerified: true, Username: "alice"}
+	ssoLogin(s, idp, "corp").ExpectStatus(http.StatusBadRequest)
+
+	// 邮箱未验证时不关联
+	idp.User.EmailVerified = false
+	ssoLogin(s, idp, "linking").ExpectStatus(http.StatusBadRequest)
+
+	idp.User.EmailVerified = true
+	var result model.UserWithToken
+	ssoLogin(s, idp, "linking").ExpectStatus(http.StatusOK).Decode(&result)
+	if result.User.ID != alice.ID {
+		t.Fatalf("期望关联到用户 %d，实际为 %d", alice.ID, result.User.ID)
+	}
+}
+
+func TestOAuthWithoutIDToken(t *testing.T) {
+	t.Parallel()
+	idp := NewMockIdP(t)
+	idp.DisableIDToken = true
+
+	// 不使用发现文档，只配置 OAuth2 端点
+	s := newSSOServer(t, utils.OAuthProviderConfig{
+		Name:         "plain",
+		AuthURL:      idp.Issuer() + "/authorize",
+		TokenURL:     idp.Issuer() + "/token",
+		UserInfoURL:  idp.Issuer() + "/userinfo",
+		ClientID:     MockClientID,
+		ClientSecret: MockClientSecret,
+		RedirectURL:  MockRedirectURL,
+		Scopes:       []string{"read:user", "user:email"},
+	})
+	idp.User = MockUser{Subject: "42", Email: "bob@corp.example", Name: "Bob"}
+
+	var result model.UserWithToken
+	ssoLogin(s, idp, "plain").ExpectStatus(http.StatusOK).Decode(&result)
+	if result.User.Username != "bob" {
+		t.Fatalf("期望用户名 bob，实际为 %s", result.User.Username)
+	}
+}
+
+func TestOAuthLoginWithTwoFactor(t *testing.T) {
+	t.Parallel()
+	idp := NewMockIdP(t)
+	s := newSSOServer(t, idp.ProviderConfig("corp"))
+	idp.User = MockUser{Subject: "emp-1", Email: "alice@corp.example", Username: "alice"}
+
+	var result model.UserWithToken
+	ssoLogin(s, idp, "corp").ExpectStatus(http.StatusOK).Decode(&result)
+	enableTwoFactor(s, result.Token)
+
+	// 启用两步验证后，第三方登录同样需要提交验证码
+	var challenge model.LoginChallenge
+	ssoLogin(s, idp, "corp").ExpectStatus(http.StatusOK).Decode(&challenge)
+	if !challenge.TwoFactorRequired || challenge.ChallengeToken == "" {
+		t.Fatalf("期望返回两步验证挑战: %+v", challenge)
+	}
+}
@@ -0,0 +1,291 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lllllan02/chitchat/internal/model"
+)
+
+// pageMeta 游标分页响应的 meta
+type pageMeta struct {
+	NextCursor *string `json:"next_cursor"`
+	PrevCursor *string `json:"prev_cursor"`
+	Total      *int64  `json:"total"`
+	Truncated  bool    `json:"truncated"`
+}
+
+// listPostIDs 获取帖子列表的一页，返回帖子ID和 meta
+func listPostIDs(s *Server, path, token string, params url.Values) ([]uint, pageMeta) {
+	s.t.Helper()
+
+	var result struct {
+		Posts []model.Post `json:"items"`
+		Meta  pageMeta     `json:"meta"`
+	}
+	s.Get(path+"?"+params.Encode(), token).ExpectStatus(http.StatusOK).Decode(&result)
+
+	ids := make([]uint, len(result.Posts))
+	for i, post := range result.Posts {
+		ids[i] = post.ID
+	}
+	return ids, result.Meta
+}
+
+func TestCursorPagination(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, token := s.Register("alice", "secret1")
+
+	// 按创建时间倒序，最新的帖子在前
+	var want []uint
+	for i := 0; i < 7; i++ {
+		post := s.CreatePost(token, fmt.Sprintf("帖子 %d", i), "内容")
+		want = append([]uint{post.ID}, want...)
+	}
+
+	first, meta := listPostIDs(s, "/posts", "", url.Values{"page_size": {"3"}})
+	if !reflect.DeepEqual(first, want[:3]) || meta.PrevCursor != nil || meta.NextCursor == nil || meta.Total != nil {
+		t.Fatalf("first page: %v %+v", first, meta)
+	}
+
+	// 翻页期间发布的新帖子不会导致重复或遗漏
+	s.CreatePost(token, "新帖子", "内容")
+
+	second, meta := listPostIDs(s, "/posts", "", url.Values{"page_size": {"3"}, "cursor": {*meta.NextCursor}})
+	if !reflect.DeepEqual(second, want[3:6]) || meta.PrevCursor == nil || meta.NextCursor == nil {
+		t.Fatalf("second page: %v %+v", second, meta)
+	}
+	prevCursor := *meta.PrevCursor
+
+	last, meta := listPostIDs(s, "/posts", "", url.Values{"page_size": {"3"}, "cursor": {*meta.NextCursor}})
+	if !reflect.DeepEqual(last, want[6:]) || meta.NextCursor != nil || meta.PrevCursor == nil {
+		t.Fatalf("last page: %v %+v", last, meta)
+	}
+
+	// 上一页回到第一页
+	prev, meta := listPostIDs(s, "/posts", "", url.Values{"page_size": {"3"}, "cursor": {prevCursor}})
+	if !reflect.DeepEqual(prev, want[:3]) || meta.NextCursor == nil {
+		t.Fatalf("previous page: %v %+v", prev, meta)
+	}
+
+	// 只在请求时返回总数
+	if _, meta := listPostIDs(s, "/posts", "", url.Values{"page_size": {"3"}, "with_total": {"true"}}); meta.Total == nil || *meta.Total != 8 {
+		t.Fatalf("total: %+v", meta)
+	}
+
+	// 用户的帖子列表同样支持游标
+	user := fmt.Sprintf("/users/%d/posts", 2)
+	ids, meta := listPostIDs(s, user, token, url.Values{"page_size": {"5"}, "with_total": {"true"}})
+	if len(ids) != 5 || meta.NextCursor == nil || meta.Total == nil || *meta.Total != 8 {
+		t.Fatalf("user posts: %v %+v", ids, meta)
+	}
+	if ids, _ := listPostIDs(s, user, token, url.Values{"page_size": {"5"}, "cursor": {*meta.NextCursor}}); len(ids) != 3 {
+		t.Fatalf("user posts second page: %v", ids)
+	}
+}
+
+func TestCursorPaginationPopular(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, alice := s.Register("alice", "secret1")
+	_, bob := s.Register("bob", "secret1")
+
+	a := s.CreatePost(alice, "帖子 A", "内容")
+	b := s.CreatePost(alice, "帖子 B", "内容")
+	c := s.CreatePost(alice, "帖子 C", "内容")
+	d := s.CreatePost(alice, "帖子 D", "内容")
+	for _, post := range []*model.Post{a, c} {
+		s.Post(fmt.Sprintf("/posts/%d/like", post.ID), nil, bob).ExpectStatus(http.StatusOK)
+	}
+	s.Post(fmt.Sprintf("/posts/%d/like", a.ID), nil, alice).ExpectStatus(http.StatusOK)
+
+	// 点赞数相同时按创建时间倒序
+	want := []uint{a.ID, c.ID, d.ID, b.ID}
+	var got []uint
+	params := url.Values{"order_by": {"popular"}, "page_size": {"1"}}
+	for i := 0; i < len(want)+1; i++ {
+		ids, meta := listPostIDs(s, "/posts", "", params)
+		got = append(got, ids...)
+		if meta.NextCursor == nil {
+			break
+		}
+		params.Set("cursor", *meta.NextCursor)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("popular order: got %v, want %v", got, want)
+	}
+
+	// 游标不能用于排序方式不同的列表
+	s.Get("/posts?order_by=recent&cursor="+url.QueryEscape(params.Get("cursor")), "").ExpectStatus(http.StatusBadRequest)
+
+	// 空的排序方式按发布时间排序
+	if ids, _ := listPostIDs(s, "/posts", "", url.Values{"order_by": {""}}); !reflect.DeepEqual(ids, []uint{d.ID, c.ID, b.ID, a.ID}) {
+		t.Fatalf("default order: got %v", ids)
+	}
+}
+
+func TestInvalidCursor(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, token := s.Register("alice", "secret1")
+	bob, _ := s.Register("bob", "secret1")
+	s.Post(fmt.Sprintf("/follows/%d", bob.ID), nil, token).ExpectStatus(http.StatusOK)
+	for i := 0; i < 2; i++ {
+		s.CreatePost(token, fmt.Sprintf("帖子 %d", i), "内容")
+	}
+
+	_, meta := listPostIDs(s, "/posts", "", url.Values{"page_size": {"1"}})
+	cursor := *meta.NextCursor
+
+	// 篡改内容或签名的游标无效
+	payload, signature, _ := strings.Cut(cursor, ".")
+	tampered := []string{"garbage", payload, payload + ".AAAA", strings.ToUpper(payload) + "." + signature}
+	for _, c := range tampered {
+		s.Get("/posts?cursor="+url.QueryEscape(c), "").ExpectStatus(http.StatusBadRequest)
+	}
+
+	// 帖子列表的游标不能用于其他列表
+	s.Get("/notifications?cursor="+url.QueryEscape(cursor), token).ExpectStatus(http.StatusBadRequest)
+	s.Get(fmt.Sprintf("/users/%d/followers?cursor=%s", bob.ID, url.QueryEscape(cursor)), token).ExpectStatus(http.StatusBadRequest)
+	s.Get("/posts?page_size=1000", "").ExpectStatus(http.StatusBadRequest)
+}
+
+func TestCursorPaginationFollowsAndComments(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	target, targetToken := s.Register("target", "secret1")
+	post := s.CreatePost(targetToken, "帖子", "内容")
+
+	var followers []string
+	for i := 0; i < 5; i++ {
+		name := fmt.Sprintf("fan%d", i)
+		_, token := s.Register(name, "secret1")
+		s.Post(fmt.Sprintf("/follows/%d", target.ID), nil, token).ExpectStatus(http.StatusOK)
+		createComment(s, token, post.ID, nil, "评论 "+name)
+		s.CreatePost(token, "提及 "+name, "你好 @target")
+		followers = append([]string{name}, followers...)
+	}
+
+	// 粉丝按关注时间倒序翻页
+	var got []string
+	params := url.Values{"page_size": {"2"}, "with_total": {"true"}}
+	for i := 0; i < 5; i++ {
+		var result struct {
+			Users []model.User `json:"items"`
+			Meta  pageMeta     `json:"meta"`
+		}
+		s.Get(fmt.Sprintf("/users/%d/followers?%s", target.ID, params.Encode()), targetToken).ExpectStatus(http.StatusOK).Decode(&result)
+		if result.Meta.Total == nil || *result.Meta.Total != 5 {
+			t.Fatalf("followers total: %+v", result.Meta)
+		}
+		for _, user := range result.Users {
+			got = append(got, user.Username)
+		}
+		if result.Meta.NextCursor == nil {
+			break
+		}
+		params.Set("cursor", *result.Meta.NextCursor)
+	}
+	if !reflect.DeepEqual(got, followers) {
+		t.Fatalf("followers: got %v, want %v", got, followers)
+	}
+
+	// 评论按时间倒序翻页
+	var comments []string
+	params = url.Values{"page_size": {"3"}}
+	for i := 0; i < 5; i++ {
+		var result struct {
+			Comments []model.Comment `json:"items"`
+			Meta     pageMeta        `json:"meta"`
+		}
+		s.Get(fmt.Sprintf("/posts/%d/comments?%s", post.ID, params.Encode()), "").ExpectStatus(http.StatusOK).Decode(&result)
+		for _, comment := range result.Comments {
+			comments = append(comments, strings.TrimPrefix(comment.Content, "评论 "))
+		}
+		if result.Meta.NextCursor == nil {
+			break
+		}
+		params.Set("cursor", *result.Meta.NextCursor)
+	}
+	if !reflect.DeepEqual(comments, followers) {
+		t.Fatalf("comments: got %v, want %v", comments, followers)
+	}
+
+	// 通知按时间倒序翻页，提及通知来自不同帖子，不会被合并
+	var notifications int
+	params = url.Values{"page_size": {"4"}, "type": {"mention"}}
+	for i := 0; i < 5; i++ {
+		var result struct {
+			Notifications []model.Notification `json:"items"`
+			Meta          pageMeta             `json:"meta"`
+		}
+		s.Get("/notifications?"+params.Encode(), targetToken).ExpectStatus(http.StatusOK).Decode(&result)
+		notifications += len(result.Notifications)
+		if result.Meta.NextCursor == nil {
+			break
+		}
+		params.Set("cursor", *result.Meta.NextCursor)
+	}
+	if notifications != 5 {
+		t.Fatalf("mention notifications: got %d, want 5", notifications)
+	}
+}
+
+func TestListEnvelope(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, token := s.Register("alice", "secret1")
+
+	// 没有记录时 items 为空数组，游标分页的列表不返回页码，没有下一页时不返回游标
+	var raw map[string]json.RawMessage
+	s.Get("/posts?with_total=true", "").ExpectStatus(http.StatusOK).Decode(&raw)
+	if string(raw["items"]) != "[]" || string(raw["meta"]) != `{"page_size":10,"total":0}` {
+		t.Fatalf("empty cursor list: %s", s.Get("/posts?with_total=true", "").Data)
+	}
+
+	// 按页码分页的列表返回页码和总数
+	s.Get("/users?keyword=alice", token).ExpectStatus(http.StatusOK).Decode(&raw)
+	if string(raw["meta"]) != `{"page":1,"page_size":10,"total":1}` {
+		t.Fatalf("paged list meta: %s", raw["meta"])
+	}
+
+	s.Get("/posts/pinned?limit=5", "").ExpectStatus(http.StatusOK).Decode(&raw)
+	if string(raw["items"]) != "[]" || string(raw["meta"]) != `{"page_size":5}` {
+		t.Fatalf("pinned list: %s %s", raw["items"], raw["meta"])
+	}
+}
+
+func TestListQueryValidation(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, token := s.Register("alice", "secret1")
+
+	// 页码、每页数量和排序方式超出范围时返回 400，而不是交给数据库
+	for _, path := range []string{
+		"/posts?page_size=0",
+		"/posts?page_size=101",
+		"/posts?page_size=abc",
+		"/posts?order_by=oldest",
+		"/posts?category_id=-1",
+		"/posts/pinned?limit=0",
+		"/posts/featured?limit=51",
+		"/users?page=0",
+		"/users?page_size=100000",
+		"/users/1/posts?page_size=-5",
+		"/users/1/followers?page_size=1000",
+		"/notifications?page_size=0",
+		"/notifications?type=unknown",
+		"/posts/1/comments?page_size=101",
+		"/search?q=go&page=0",
+	} {
+		if resp := s.Get(path, token); resp.Status != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", path, resp.Status)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lllllan02/chitchat/internal/model"
+)
+
+func TestPostCRUD(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, token := s.Register("alice", "secret1")
+
+	post := s.CreatePost(token, "第一篇帖子", "这是第一篇帖子的内容")
+	if post.ID == 0 || post.Title != "第一篇帖子" {
+		t.Fatalf("创建返回的帖子不正确: %+v", post)
+	}
+	path := fmt.Sprintf("/posts/%d", post.ID)
+
+	// 游客可以查看帖子
+	var got model.Post
+	s.Get(path, "").ExpectStatus(http.StatusOK).Decode(&got)
+	if got.Content != "这是第一篇帖子的内容" || got.User.Username != "alice" {
+		t.Fatalf("获取的帖子不正确: %+v", got)
+	}
+
+	// 作者更新帖子
+	s.Put(path, map[string]string{"title": "修改后的标题"}, token).ExpectStatus(http.StatusOK)
+	s.Get(path, "").ExpectStatus(http.StatusOK).Decode(&got)
+	if got.Title != "修改后的标题" || got.Content != "这是第一篇帖子的内容" {
+		t.Fatalf("更新后的帖子不正确: %+v", got)
+	}
+
+	// 列表中可以看到帖子
+	var list struct {
+		Posts []model.Post `json:"items"`
+		Meta  struct {
+			Total int64 `json:"total"`
+		} `json:"meta"`
+	}
+	s.Get("/posts?with_total=true", "").ExpectStatus(http.StatusOK).Decode(&list)
+	if list.Meta.Total != 1 || len(list.Posts) != 1 || list.Posts[0].ID != post.ID {
+		t.Fatalf("帖子列表不正确: %+v", list)
+	}
+
+	// 作者删除帖子
+	s.Delete(path, token).ExpectStatus(http.StatusOK)
+	s.Get(path, "").ExpectStatus(http.StatusNotFound)
+	s.Delete(path, token).ExpectStatus(http.StatusNotFound)
+}
+
+func TestCreatePostValidation(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, token := s.Register("alice", "secret1")
+
+	s.Post("/posts", map[string]string{"title": "标题", "content": "内容"}, "").ExpectStatus(http.StatusUnauthorized)
+	s.Post("/posts", map[string]string{"content": "内容"}, token).ExpectStatus(http.StatusBadRequest)
+	s.Post("/posts", map[string]string{"title": "标题"}, token).ExpectStatus(http.StatusBadRequest)
+	long := strings.Repeat("长", 256)
+	s.Post("/posts", map[string]string{"title": long, "content": "内容"}, token).ExpectStatus(http.StatusBadRequest)
+	post := s.CreatePost(token, "标题", "内容")
+	s.Put(fmt.Sprintf("/posts/%d", post.ID), map[string]string{"title": long}, token).ExpectStatus(http.StatusBadRequest)
+	s.Get("/posts/abc", "").ExpectStatus(http.StatusBadRequest)
+	s.Get("/posts/999", "").ExpectStatus(http.StatusNotFound)
+}
+
+func TestPostPermissions(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, alice := s.Register("alice", "secret1")
+	_, bob := s.Register("bob", "secret1")
+
+	post := s.CreatePost(alice, "alice 的帖子", "只有 alice 可以修改")
+	path := fmt.Sprintf("/posts/%d", post.ID)
+
+	// 其他用户不能修改或删除
+	s.Put(path, map[string]string{"title": "bob 的修改"}, bob).ExpectStatus(http.StatusForbidden)
+	s.Delete(path, bob).ExpectStatus(http.StatusForbidden)
+
+	// 未登录不能修改或删除
+	s.Put(path, map[string]string{"title": "游客的修改"}, "").ExpectStatus(http.StatusUnauthorized)
+	s.Delete(path, "").ExpectStatus(http.StatusUnauthorized)
+
+	var got model.Post
+	s.Get(path, "").ExpectStatus(http.StatusOK).Decode(&got)
+	if got.Title != "alice 的帖子" {
+		t.Fatalf("帖子被未授权修改: %+v", got)
+	}
+
+	// 管理员可以删除任何帖子
+	s.Delete(path, s.AdminToken()).ExpectStatus(http.StatusOK)
+	s.Get(path, "").ExpectStatus(http.StatusNotFound)
+}
@@ -0,0 +1,164 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/lllllan02/chitchat/internal/utils"
+)
+
+// registerFrom 使用指定的客户端 IP 注册
+func registerFrom(s *Server, username, ip string) *Response {
+	s.t.Helper()
+
+	body := fmt.Sprintf(`{"username":%q,"email":"%s@example.com","password":"secret1"}`, username, username)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/register", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = ip + ":12345"
+	return s.Send(req)
+}
+
+// registerPolicy 注册接口每个 IP 每分钟 2 次
+func registerPolicy(cfg *utils.Config) {
+	cfg.RateLimit.Enabled = true
+	cfg.RateLimit.Policies = []utils.RateLimitPolicy{{
+		Name:          "register",
+		Routes:        []string{"POST /api/v1/auth/register"},
+		RateLimitRule: utils.RateLimitRule{Rate: "2/m"},
+	}}
+}
+
+func TestRateLimitByIP(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t, registerPolicy)
+	now := time.Now()
+	s.App.SetClock(func() time.Time { return now })
+
+	resp := registerFrom(s, "user1", "10.0.0.1").ExpectStatus(http.StatusOK)
+	if resp.Header.Get("X-RateLimit-Limit") != "2" || resp.Header.Get("X-RateLimit-Remaining") != "1" {
+		t.Fatalf("限流响应头错误: %v", resp.Header)
+	}
+	registerFrom(s, "user2", "10.0.0.1").ExpectStatus(http.StatusOK)
+
+	resp = registerFrom(s, "user3", "10.0.0.1").ExpectStatus(http.StatusTooManyRequests)
+	// 每 30 秒补充一个令牌，时钟固定时需等待整 30 秒
+	if resp.Header.Get("Retry-After") != "30" || resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		t.Fatalf("限流响应头错误: %v", resp.Header)
+	}
+
+	// 10 秒后还需等待 20 秒，30 秒后恢复一次请求
+	now = now.Add(10 * time.Second)
+	resp = registerFrom(s, "user3", "10.0.0.1").ExpectStatus(http.StatusTooManyRequests)
+	if resp.Header.Get("Retry-After") != "20" {
+		t.Fatalf("限流响应头错误: %v", resp.Header)
+	}
+	now = now.Add(20 * time.Second)
+	registerFrom(s, "user3", "10.0.0.1").ExpectStatus(http.StatusOK)
+
+	// 其他 IP 不受影响，其他接口不限流
+	registerFrom(s, "user4", "10.0.0.2").ExpectStatus(http.StatusOK)
+	s.Post("/auth/login", map[string]string{"username": "user1", "password": "secret1"}, "").ExpectStatus(http.StatusOK)
+}
+
+func TestRateLimitByUserAndRole(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t, func(cfg *utils.Config) {
+		cfg.RateLimit.Enabled = true
+		cfg.RateLimit.Policies = []utils.RateLimitPolicy{{
+			Name:          "create_post",
+			Routes:        []string{"POST /api/v1/posts"},
+			RateLimitRule: utils.RateLimitRule{Rate: "2/h"},
+			Roles:         map[string]utils.RateLimitRule{"admin": {}},
+		}}
+	})
+	_, alice := s.Register("alice", "secret1")
+	_, bob := s.Register("bob", "secret1")
+
+	s.CreatePost(alice, "标题一", "内容")
+	s.CreatePost(alice, "标题二", "内容")
+	s.Post("/posts", map[string]string{"title": "标题三", "content": "内容"}, alice).ExpectStatus(http.StatusTooManyRequests)
+
+	// 同一 IP 的其他用户单独计数
+	s.CreatePost(bob, "标题一", "内容")
+
+	// 管理员不限制
+	admin := s.AdminToken()
+	for i := 0; i < 3; i++ {
+		resp := s.Post("/posts", map[string]string{"title": "公告", "content": "内容"}, admin).ExpectStatus(http.StatusOK)
+		if resp.Header.Get("X-RateLimit-Limit") != "" {
+			t.Fatal("不限流的请求不应返回限流响应头")
+		}
+	}
+}
+
+func TestRateLimitDefaultRule(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t, func(cfg *utils.Config) {
+		cfg.RateLimit.Enabled = true
+		cfg.RateLimit.Default = utils.RateLimitRule{Rate: "1/m", Burst: 2}
+	})
+
+	s.Get("/categories", "").ExpectStatus(http.StatusOK)
+	s.Get("/posts", "").ExpectStatus(http.StatusOK)
+	s.Get("/categories", "").ExpectStatus(http.StatusTooManyRequests)
+}
+
+func TestRateLimitRedisStore(t *testing.T) {
+	t.Parallel()
+	mr := miniredis.RunT(t)
+
+	// 两个实例共享同一个 Redis，计数合并
+	useRedis := func(cfg *utils.Config) {
+		registerPolicy(cfg)
+		cfg.Redis = utils.RedisConfig{Enabled: true, Host: mr.Host(), Port: mustPort(t, mr.Port())}
+	}
+	first := NewServer(t, useRedis)
+	second := NewServer(t, useRedis)
+
+	registerFrom(first, "user1", "10.0.0.1").ExpectStatus(http.StatusOK)
+	registerFrom(second, "user2", "10.0.0.1").ExpectStatus(http.StatusOK)
+	registerFrom(first, "user3", "10.0.0.1").ExpectStatus(http.StatusTooManyRequests)
+	registerFrom(second, "user3", "10.0.0.1").ExpectStatus(http.StatusTooManyRequests)
+	registerFrom(second, "user3", "10.0.0.2").ExpectStatus(http.StatusOK)
+
+	if len(mr.Keys()) == 0 {
+		t.Fatal("令牌桶应保存在 Redis 中")
+	}
+}
+
+// mustPort 解析端口号
+func mustPort(t *testing.T, port string) int {
+	t.Helper()
+	var p int
+	if _, err := fmt.Sscanf(port, "%d", &p); err != nil {
+		t.Fatalf("无效的端口: %s", port)
+	}
+	return p
+}
+
+func TestRateLimitAuthenticatedAuthRoutes(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t, func(cfg *utils.Config) {
+		cfg.RateLimit.Enabled = true
+		cfg.RateLimit.Policies = []utils.RateLimitPolicy{{
+			Name:          "resend",
+			Routes:        []string{"POST /api/v1/auth/verify-email/resend"},
+			RateLimitRule: utils.RateLimitRule{Rate: "1/m"},
+		}}
+	})
+	_, alice := s.Register("alice", "secret1")
+	_, bob := s.Register("bob", "secret1")
+
+	// 登录后的接口按用户计数，同一 IP 的用户互不影响
+	s.Post("/auth/verify-email/resend", nil, alice).ExpectStatus(http.StatusOK)
+	s.Post("/auth/verify-email/resend", nil, bob).ExpectStatus(http.StatusOK)
+	s.Post("/auth/verify-email/resend", nil, alice).ExpectStatus(http.StatusTooManyRequests)
+
+	// 未登录的请求先被认证拒绝，不消耗限流次数
+	s.Post("/auth/verify-email/resend", nil, "").ExpectStatus(http.StatusUnauthorized)
+}
@@ -0,0 +1,332 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/lllllan02/chitchat/internal/app"
+	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/utils"
+)
+
+// searchResult 搜索接口的响应
+type searchResult struct {
+	Hits []model.SearchHit `json:"items"`
+	Meta struct {
+		Total int64 `json:"total"`
+	} `json:"meta"`
+}
+
+// searchFor 使用查询参数搜索
+func searchFor(s *Server, params url.Values) searchResult {
+	s.t.Helper()
+
+	var result searchResult
+	s.Get("/search?"+params.Encode(), "").ExpectStatus(http.StatusOK).Decode(&result)
+	return result
+}
+
+// hitKeys 命中结果的类型和ID，按顺序排列
+func hitKeys(result searchResult) []string {
+	keys := make([]string, len(result.Hits))
+	for i, hit := range result.Hits {
+		keys[i] = fmt.Sprintf("%s:%d", hit.Type, hit.ID)
+	}
+	return keys
+}
+
+// createComment 发表评论
+func createComment(s *Server, token string, postID uint, parentID *uint, content string) *model.Comment {
+	s.t.Helper()
+
+	var comment model.Comment
+	s.Post("/comments", map[string]interface{}{
+		"post_id":   postID,
+		"parent_id": parentID,
+		"content":   content,
+	}, token).ExpectStatus(http.StatusOK).Decode(&comment)
+	return &comment
+}
+
+func TestSearchRankingAndHighlights(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, alice := s.Register("alice", "secret1")
+
+	inTitle := s.CreatePost(alice, "全文搜索入门指南", "介绍倒排索引的基本原理和实现")
+	inContent := s.CreatePost(alice, "数据库优化经验", "这里顺便提到了全文搜索，但不是重点")
+	s.CreatePost(alice, "搜索引擎的全文检索", "标题中的词不相邻，不应按短语命中")
+
+	// 中文按短语匹配，标题命中排在正文命中之前
+	result := searchFor(s, url.Values{"q": {"全文搜索"}})
+	keys := hitKeys(result)
+	want := []string{fmt.Sprintf("post:%d", inTitle.ID), fmt.Sprintf("post:%d", inContent.ID)}
+	if len(keys) != 2 || keys[0] != want[0] || keys[1] != want[1] {
+		t.Fatalf("搜索结果应为 %v，实际为 %v", want, keys)
+	}
+	if result.Meta.Total != 2 || result.Hits[0].Score <= result.Hits[1].Score {
+		t.Fatalf("结果应按相关度排序: %+v", result.Hits)
+	}
+	if got := result.Hits[0].Highlights["title"]; got != "<em>全文搜索</em>入门指南" {
+		t.Fatalf("标题高亮不正确: %q", got)
+	}
+	if result.Hits[0].Post == nil || result.Hits[0].Post.User.Username != "alice" {
+		t.Fatalf("结果应附带帖子和作者: %+v", result.Hits[0])
+	}
+
+	// 单字也能命中
+	if got := searchFor(s, url.Values{"q": {"搜"}}); got.Meta.Total != 3 {
+		t.Fatalf("单字搜索应命中 3 篇帖子，实际为 %v", hitKeys(got))
+	}
+
+	// 英文短语和大小写
+	post := s.CreatePost(alice, "Learning Go generics", "Type parameters make <generic> code simpler")
+	if got := searchFor(s, url.Values{"q": {`"type parameters"`}}); len(got.Hits) != 1 || got.Hits[0].ID != post.ID {
+		t.Fatalf("短语搜索结果不正确: %v", hitKeys(got))
+	}
+	if got := searchFor(s, url.Values{"q": {`"parameters type"`}}); len(got.Hits) != 0 {
+		t.Fatalf("顺序不同的短语不应命中: %v", hitKeys(got))
+	}
+	got := searchFor(s, url.Values{"q": {"GENERIC"}})
+	if len(got.Hits) != 1 || got.Hits[0].Highlights["content"] != "Type parameters make &lt;<em>generic</em>&gt; code simpler" {
+		t.Fatalf("高亮应转义 HTML: %+v", got.Hits)
+	}
+
+	// 全部条件都需命中
+	if got := searchFor(s, url.Values{"q": {"go 数据库"}}); len(got.Hits) != 0 {
+		t.Fatalf("不应有同时包含两个词的帖子: %v", hitKeys(got))
+	}
+}
+
+func TestSearchTypesAndFilters(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	alice, aliceToken := s.Register("alice", "secret1")
+	_, bobToken := s.Register("bob", "secret1")
+
+	category := firstCategory(s)
+	var post model.Post
+	s.Post("/posts", map[string]interface{}{
+		"title":       "周末徒步路线推荐",
+		"content":     "分享几条适合新手的徒步路线",
+		"category_id": category.ID,
+	}, aliceToken).ExpectStatus(http.StatusOK).Decode(&post)
+	other := s.CreatePost(bobToken, "徒步装备清单", "鞋子和背包的选择")
+	comment := createComment(s, bobToken, post.ID, nil, "我也喜欢徒步")
+	s.Put("/users/me", map[string]string{"bio": "热爱徒步和摄影"}, aliceToken).ExpectStatus(http.StatusOK)
+
+	all := searchFor(s, url.Values{"q": {"徒步"}})
+	if all.Meta.Total != 4 {
+		t.Fatalf("应命中两篇帖子、一条评论和一个用户，实际为 %v", hitKeys(all))
+	}
+
+	byType := func(typ string) []string {
+		return hitKeys(searchFor(s, url.Values{"q": {"徒步"}, "type": {typ}}))
+	}
+	if keys := byType("comment"); len(keys) != 1 || keys[0] != fmt.Sprintf("comment:%d", comment.ID) {
+		t.Fatalf("评论搜索结果不正确: %v", keys)
+	}
+	if keys := byType("user"); len(keys) != 1 || keys[0] != fmt.Sprintf("user:%d", alice.ID) {
+		t.Fatalf("用户搜索结果不正确: %v", keys)
+	}
+
+	// 按作者和分类筛选
+	byAuthor := searchFor(s, url.Values{"q": {"徒步"}, "type": {"post"}, "user_id": {fmt.Sprint(alice.ID)}})
+	if len(byAuthor.Hits) != 1 || byAuthor.Hits[0].ID != post.ID {
+		t.Fatalf("按作者筛选结果不正确: %v", hitKeys(byAuthor))
+	}
+	byCategory := searchFor(s, url.Values{"q": {"徒步"}, "category_id": {fmt.Sprint(category.ID)}})
+	if keys := hitKeys(byCategory); len(keys) != 2 {
+		t.Fatalf("按分类筛选应命中帖子和其下的评论，实际为 %v", keys)
+	}
+
+	// 按日期筛选
+	today := post.CreatedAt.Format("2006-01-02")
+	if got := searchFor(s, url.Values{"q": {"徒步"}, "from": {today}, "to": {today}}); got.Meta.Total != 4 {
+		t.Fatalf("按当天筛选应命中全部结果，实际为 %v", hitKeys(got))
+	}
+	tomorrow := post.CreatedAt.AddDate(0, 0, 1).Format("2006-01-02")
+	if got := searchFor(s, url.Values{"q": {"徒步"}, "from": {tomorrow}}); got.Meta.Total != 0 {
+		t.Fatalf("按明天筛选不应有结果，实际为 %v", hitKeys(got))
+	}
+
+	// 分页
+	page := searchFor(s, url.Values{"q": {"徒步"}, "page": {"2"}, "page_size": {"3"}})
+	if len(page.Hits) != 1 || page.Meta.Total != 4 {
+		t.Fatalf("第二页应有 1 条结果: %v", hitKeys(page))
+	}
+
+	// 帖子列表的关键词筛选使用全文索引
+	var list struct {
+		Posts []model.Post `json:"items"`
+	}
+	s.Get("/posts?keyword="+url.QueryEscape("装备"), "").ExpectStatus(http.StatusOK).Decode(&list)
+	if len(list.Posts) != 1 || list.Posts[0].ID != other.ID {
+		t.Fatalf("帖子列表关键词筛选不正确: %+v", list.Posts)
+	}
+
+	// 参数校验
+	s.Get("/search", "").ExpectStatus(http.StatusBadRequest)
+	s.Get("/search?q=a&type=topic", "").ExpectStatus(http.StatusBadRequest)
+	s.Get("/search?q=a&from="+tomorrow+"&to="+today, "").ExpectStatus(http.StatusBadRequest)
+}
+
+func TestSearchIndexUpdates(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, alice := s.Register("alice", "secret1")
+	_, bob := s.Register("bob", "secret1")
+
+	post := s.CreatePost(alice, "原来的标题内容", "原来的正文内容")
+	path := fmt.Sprintf("/posts/%d", post.ID)
+	parent := createComment(s, bob, post.ID, nil, "一条关于咖啡的评论")
+	reply := createComment(s, alice, post.ID, &parent.ID, "回复里也提到了咖啡")
+
+	// 修改帖子后按新内容命中
+	s.Put(path, map[string]string{"title": "修改后的新标题"}, alice).ExpectStatus(http.StatusOK)
+	if got := searchFor(s, url.Values{"q": {"原来的标题"}}); got.Meta.Total != 0 {
+		t.Fatalf("旧标题不应命中: %v", hitKeys(got))
+	}
+	if got := searchFor(s, url.Values{"q": {"新标题"}}); got.Meta.Total != 1 {
+		t.Fatalf("新标题应命中: %v", hitKeys(got))
+	}
+
+	// 修改评论
+	s.Put(fmt.Sprintf("/comments/%d", reply.ID), map[string]string{"content": "回复改成了茶"}, alice).ExpectStatus(http.StatusOK)
+	if got := searchFor(s, url.Values{"q": {"咖啡"}}); got.Meta.Total != 1 {
+		t.Fatalf("修改后只有一条评论提到咖啡: %v", hitKeys(got))
+	}
+
+	// 删除评论时其回复一并移除
+	s.Delete(fmt.Sprintf("/comments/%d", parent.ID), bob).ExpectStatus(http.StatusOK)
+	if got := searchFor(s, url.Values{"q": {"茶"}, "type": {"comment"}}); got.Meta.Total != 0 {
+		t.Fatalf("已删除评论的回复不应命中: %v", hitKeys(got))
+	}
+
+	// 删除帖子
+	createComment(s, bob, post.ID, nil, "帖子删除后评论也不再出现")
+	s.Delete(path, alice).ExpectStatus(http.StatusOK)
+	if got := searchFor(s, url.Values{"q": {"新标题"}}); got.Meta.Total != 0 {
+		t.Fatalf("已删除的帖子不应命中: %v", hitKeys(got))
+	}
+	if got := searchFor(s, url.Values{"q": {"不再出现"}}); got.Meta.Total != 0 {
+		t.Fatalf("已删除帖子下的评论不应命中: %v", hitKeys(got))
+	}
+
+	// 修改资料
+	s.Put("/users/me", map[string]string{"bio": "喜欢研究编译器"}, bob).ExpectStatus(http.StatusOK)
+	if got := searchFor(s, url.Values{"q": {"编译器"}, "type": {"user"}}); len(got.Hits) != 1 || got.Hits[0].User.Username != "bob" {
+		t.Fatalf("修改资料后应按简介命中: %v", hitKeys(got))
+	}
+}
+
+func TestSearchIndexRebuiltOnStartup(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, alice := s.Register("alice", "secret1")
+	post := s.CreatePost(alice, "重启之后仍能搜索", "索引在启动时从数据库重建")
+	createComment(s, alice, post.ID, nil, "评论同样会重建")
+
+	// 使用同一个数据库创建新的应用实例
+	restarted, err := app.New(s.App.Config, s.App.DB)
+	if err != nil {
+		t.Fatalf("初始化应用失败: %v", err)
+	}
+	t.Cleanup(func() { restarted.Close() })
+	restarted.WaitSearchIndex()
+	fresh := &Server{t: t, App: restarted}
+
+	if got := searchFor(fresh, url.Values{"q": {"重建"}}); got.Meta.Total != 2 {
+		t.Fatalf("重建后应命中帖子和评论: %v", hitKeys(got))
+	}
+	if got := searchFor(fresh, url.Values{"q": {"alice"}, "type": {"user"}}); got.Meta.Total != 1 {
+		t.Fatalf("重建后应命中用户: %v", hitKeys(got))
+	}
+}
+
+func TestSearchIndexRebuiltInBackground(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	alice, token := s.Register("alice", "secret1")
+	doomed := s.CreatePost(token, "即将删除的帖子", "海量")
+
+	// 直接写入数据库的帖子只能通过重建进入索引，数量超过关键词筛选的上限
+	category := firstCategory(s)
+	posts := make([]model.Post, 1000)
+	for i := range posts {
+		posts[i] = model.Post{Title: fmt.Sprintf("批量帖子 %d", i), Content: "海量", UserID: alice.ID, CategoryID: category.ID}
+	}
+	if err := s.App.DB.CreateInBatches(posts, 200).Error; err != nil {
+		t.Fatalf("写入帖子失败: %v", err)
+	}
+
+	// 不等待重建完成就开始写入，重建期间的修改不会丢失
+	restarted, err := app.New(s.App.Config, s.App.DB)
+	if err != nil {
+		t.Fatalf("初始化应用失败: %v", err)
+	}
+	t.Cleanup(func() { restarted.Close() })
+	fresh := &Server{t: t, App: restarted}
+	created := fresh.CreatePost(token, "重建期间的新帖", "海量")
+	fresh.Delete(fmt.Sprintf("/posts/%d", doomed.ID), token).ExpectStatus(http.StatusOK)
+	restarted.WaitSearchIndex()
+
+	if got := searchFor(fresh, url.Values{"q": {"重建期间的新帖"}}); len(got.Hits) != 1 || got.Hits[0].ID != created.ID {
+		t.Fatalf("重建期间创建的帖子应能搜到: %v", hitKeys(got))
+	}
+	if got := searchFor(fresh, url.Values{"q": {"即将删除的帖子"}}); got.Meta.Total != 0 {
+		t.Fatalf("重建期间删除的帖子不应搜到: %v", hitKeys(got))
+	}
+
+	// 匹配超过上限时列表只包含相关度最高的部分，并标记为已截断
+	_, meta := listPostIDs(fresh, "/posts", "", url.Values{"keyword": {"海量"}, "with_total": {"true"}})
+	if !meta.Truncated || meta.Total == nil || *meta.Total != 1000 {
+		t.Fatalf("meta = %+v", meta)
+	}
+	if _, meta := listPostIDs(fresh, "/posts", "", url.Values{"keyword": {"新帖"}}); meta.Truncated {
+		t.Fatalf("未超出上限时不应截断: %+v", meta)
+	}
+}
+
+func TestSearchFallbackUntilIndexReady(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t, func(cfg *utils.Config) {
+		cfg.Search.RebuildRetry = "20ms"
+	})
+	_, alice := s.Register("alice", "secret1")
+	post := s.CreatePost(alice, "启动前的帖子", "索引就绪前按数据库查询")
+
+	// 评论表暂时不可用，重建索引失败
+	if err := s.App.DB.Exec("ALTER TABLE comments RENAME TO comments_unavailable").Error; err != nil {
+		t.Fatalf("重命名评论表失败: %v", err)
+	}
+	restarted, err := app.New(s.App.Config, s.App.DB)
+	if err != nil {
+		t.Fatalf("初始化应用失败: %v", err)
+	}
+	t.Cleanup(func() { restarted.Close() })
+	fresh := &Server{t: t, App: restarted}
+
+	// 索引就绪前搜索返回 503，列表的关键词筛选改用数据库模糊匹配
+	resp := fresh.Get("/search?"+url.Values{"q": {"启动前"}}.Encode(), "").ExpectStatus(http.StatusServiceUnavailable)
+	if resp.Error != "SEARCH_NOT_READY" {
+		t.Errorf("error = %q, want SEARCH_NOT_READY", resp.Error)
+	}
+	if ids, _ := listPostIDs(fresh, "/posts", "", url.Values{"keyword": {"启动前"}}); len(ids) != 1 || ids[0] != post.ID {
+		t.Fatalf("索引就绪前按关键词筛选帖子: %v", ids)
+	}
+	if names, _ := listUsernames(fresh, alice, url.Values{"keyword": {"ali"}}); len(names) != 1 || names[0] != "alice" {
+		t.Fatalf("索引就绪前按关键词筛选用户: %v", names)
+	}
+
+	// 评论表恢复后重试成功
+	if err := s.App.DB.Exec("ALTER TABLE comments_unavailable RENAME TO comments").Error; err != nil {
+		t.Fatalf("恢复评论表失败: %v", err)
+	}
+	restarted.WaitSearchIndex()
+	if got := searchFor(fresh, url.Values{"q": {"启动前"}}); got.Meta.Total != 1 || got.Hits[0].ID != post.ID {
+		t.Fatalf("重试重建后应能搜到: %v", hitKeys(got))
+	}
+}
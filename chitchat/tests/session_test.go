@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lllllan02/chitchat/internal/model"
+)
+
+// loginFrom 使用指定的 User-Agent 登录
+func loginFrom(s *Server, userAgent string) model.TokenPair {
+	s.t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(`{"username":"alice","password":"secret1"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+
+	var result model.UserWithToken
+	s.Send(req).ExpectStatus(http.StatusOK).Decode(&result)
+	return result.TokenPair
+}
+
+func TestListSessions(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	s.Register("alice", "secret1")
+	phone := loginFrom(s, "Phone/1.0")
+	laptop := loginFrom(s, "Laptop/1.0")
+
+	var sessions []model.Session
+	s.Get("/users/me/sessions", laptop.Token).ExpectStatus(http.StatusOK).Decode(&sessions)
+
+	// 注册时签发的令牌也是一个会话
+	if len(sessions) != 3 {
+		t.Fatalf("期望 3 个会话，实际为 %d", len(sessions))
+	}
+	agents := map[string]bool{}
+	for _, session := range sessions {
+		agents[session.UserAgent] = true
+		if session.IP == "" || session.LastSeenAt.IsZero() {
+			t.Fatalf("会话缺少客户端信息: %+v", session)
+		}
+		if session.Current != (session.UserAgent == "Laptop/1.0") {
+			t.Fatalf("当前会话标记错误: %+v", session)
+		}
+	}
+	if !agents["Phone/1.0"] || !agents["Laptop/1.0"] {
+		t.Fatalf("会话未记录 User-Agent: %+v", sessions)
+	}
+
+	// 其他用户看不到这些会话
+	_, bob := s.Register("bob", "secret1")
+	s.Get("/users/me/sessions", bob).ExpectStatus(http.StatusOK).Decode(&sessions)
+	if len(sessions) != 1 {
+		t.Fatalf("bob 期望 1 个会话，实际为 %d", len(sessions))
+	}
+
+	s.Get("/users/me", phone.Token).ExpectStatus(http.StatusOK)
+}
+
+func TestRevokeSession(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	s.Register("alice", "secret1")
+	phone := loginFrom(s, "Phone/1.0")
+	laptop := loginFrom(s, "Laptop/1.0")
+
+	var sessions []model.Session
+	s.Get("/users/me/sessions", laptop.Token).ExpectStatus(http.StatusOK).Decode(&sessions)
+	var phoneID uint
+	for _, session := range sessions {
+		if session.UserAgent == "Phone/1.0" {
+			phoneID = session.ID
+		}
+	}
+
+	// 其他用户不能注销该会话
+	_, bob := s.Register("bob", "secret1")
+	s.Delete(fmt.Sprintf("/users/me/sessions/%d", phoneID), bob).ExpectStatus(http.StatusNotFound)
+	s.Get("/users/me", phone.Token).ExpectStatus(http.StatusOK)
+
+	// 远程注销后手机的访问令牌和刷新令牌都失效
+	s.Delete(fmt.Sprintf("/users/me/sessions/%d", phoneID), laptop.Token).ExpectStatus(http.StatusOK)
+	s.Get("/users/me", phone.Token).ExpectStatus(http.StatusUnauthorized)
+	s.Post("/auth/refresh", map[string]string{"refresh_token": phone.RefreshToken}, "").ExpectStatus(http.StatusUnauthorized)
+	s.Get("/users/me", laptop.Token).ExpectStatus(http.StatusOK)
+
+	s.Delete(fmt.Sprintf("/users/me/sessions/%d", phoneID), laptop.Token).ExpectStatus(http.StatusNotFound)
+}
+
+func TestRefreshKeepsSession(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	s.Register("alice", "secret1")
+	tokens := loginFrom(s, "Phone/1.0")
+
+	var before []model.Session
+	s.Get("/users/me/sessions", tokens.Token).ExpectStatus(http.StatusOK).Decode(&before)
+
+	var refreshed model.TokenPair
+	s.Post("/auth/refresh", map[string]string{"refresh_token": tokens.RefreshToken}, "").ExpectStatus(http.StatusOK).Decode(&refreshed)
+
+	var after []model.Session
+	s.Get("/users/me/sessions", refreshed.Token).ExpectStatus(http.StatusOK).Decode(&after)
+	if len(after) != len(before) {
+		t.Fatalf("刷新不应创建新会话: %d -> %d", len(before), len(after))
+	}
+
+	// 退出登录后会话从列表中移除
+	s.Post("/auth/logout", nil, refreshed.Token).ExpectStatus(http.StatusOK)
+	other := s.Login("alice", "secret1")
+	s.Get("/users/me/sessions", other).ExpectStatus(http.StatusOK).Decode(&after)
+	if len(after) != len(before) {
+		t.Fatalf("退出登录后期望 %d 个会话，实际为 %d", len(before), len(after))
+	}
+}
@@ -0,0 +1,360 @@
+package tests
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/stream"
+	"github.com/lllllan02/chitchat/internal/utils"
+)
+
+// 等待推送消息的超时时间
+const streamTimeout = 2 * time.Second
+
+// listen 在本地端口上启动测试服务器，用于需要真实连接的 SSE 和 WebSocket 测试
+// 结束时先关闭推送中心，否则未结束的 SSE 请求会让服务器无法关闭
+func listen(s *Server) *httptest.Server {
+	srv := httptest.NewServer(s.App.Router)
+	s.t.Cleanup(func() {
+		s.App.Hub.Close()
+		srv.Close()
+	})
+	return srv
+}
+
+// dialStream 建立 WebSocket 推送连接，origin 为空时不携带 Origin 请求头
+func dialStream(srv *httptest.Server, token, origin, query string) (*websocket.Conn, *http.Response, error) {
+	header := http.Header{}
+	if origin != "" {
+		header.Set("Origin", origin)
+	}
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/v1/stream?token=" + token
+	if query != "" {
+		url += "&" + query
+	}
+	return websocket.DefaultDialer.Dial(url, header)
+}
+
+// openSSE 建立 SSE 推送连接
+func openSSE(s *Server, srv *httptest.Server, token, query, lastEventID string) *bufio.Reader {
+	s.t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/api/v1/stream?"+query, nil)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		s.t.Fatalf("建立 SSE 连接失败: %v", err)
+	}
+	s.t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		s.t.Fatalf("SSE 响应错误: %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	return bufio.NewReader(resp.Body)
+}
+
+// streamMessage 推送的消息，Data 保留原始 JSON 以便按类型解码
+type streamMessage struct {
+	ID     uint64          `json:"id"`
+	Type   string          `json:"type"`
+	PostID uint            `json:"post_id"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// nextSSE 读取下一条 SSE 消息，跳过心跳注释
+func nextSSE(s *Server, r *bufio.Reader) streamMessage {
+	s.t.Helper()
+
+	result := make(chan streamMessage, 1)
+	go func() {
+		var msg streamMessage
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				close(result)
+				return
+			}
+			line = strings.TrimSuffix(line, "\n")
+			switch {
+			case line == "" && msg.Type != "":
+				result <- msg
+				return
+			case strings.HasPrefix(line, "data: "):
+				_ = json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &msg)
+			}
+		}
+	}()
+
+	select {
+	case msg, ok := <-result:
+		if !ok {
+			s.t.Fatal("SSE 连接已结束")
+		}
+		return msg
+	case <-time.After(streamTimeout):
+		s.t.Fatal("等待 SSE 消息超时")
+	}
+	return streamMessage{}
+}
+
+// nextWebSocket 读取下一条 WebSocket 消息
+func nextWebSocket(s *Server, conn *websocket.Conn) streamMessage {
+	s.t.Helper()
+
+	var msg streamMessage
+	_ = conn.SetReadDeadline(time.Now().Add(streamTimeout))
+	if err := conn.ReadJSON(&msg); err != nil {
+		s.t.Fatalf("读取 WebSocket 消息失败: %v", err)
+	}
+	return msg
+}
+
+func TestStreamWebSocketOrigin(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t, func(cfg *utils.Config) {
+		cfg.Server.AllowedOrigins = []string{"https://app.example"}
+	})
+	_, token := s.Register("alice", "secret1")
+	srv := listen(s)
+
+	// 跨站页面发起的连接被拒绝
+	_, resp, err := dialStream(srv, token, "https://evil.example", "")
+	if err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("期望拒绝未允许的来源: %v", err)
+	}
+
+	// 允许列表中的来源、同源和非浏览器客户端可以连接
+	for _, origin := range []string{"https://app.example", srv.URL, ""} {
+		conn, _, err := dialStream(srv, token, origin, "")
+		if err != nil {
+			t.Fatalf("%q: 连接失败: %v", origin, err)
+		}
+		conn.Close()
+	}
+}
+
+func TestCORSAllowedOrigins(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t, func(cfg *utils.Config) {
+		cfg.Server.AllowedOrigins = []string{"https://app.example"}
+	})
+
+	for origin, allowed := range map[string]bool{
+		"https://app.example":  true,
+		"https://evil.example": false,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/ping", nil)
+		req.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		s.App.Router.ServeHTTP(w, req)
+
+		got := w.Header().Get("Access-Control-Allow-Origin")
+		if allowed && got != origin {
+			t.Errorf("%s: Access-Control-Allow-Origin = %q", origin, got)
+		}
+		if !allowed && got != "" {
+			t.Errorf("%s: 不应允许跨域, got %q", origin, got)
+		}
+	}
+}
+
+func TestStreamClosedOnShutdown(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, token := s.Register("alice", "secret1")
+	srv := listen(s)
+
+	sse := openSSE(s, srv, token, "", "")
+	ws, _, err := dialStream(srv, token, "", "")
+	if err != nil {
+		t.Fatalf("建立 WebSocket 连接失败: %v", err)
+	}
+	defer ws.Close()
+
+	// 关闭推送中心后两种连接都立即结束，不需要等待关闭超时
+	s.App.Hub.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.ReadAll(sse)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("读取 SSE 失败: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("关闭推送中心后 SSE 连接没有结束")
+	}
+
+	_ = ws.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, _, err := ws.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Fatalf("期望收到关闭帧: %v", err)
+	}
+
+	// 关闭后建立的连接同样立即结束
+	if _, err := io.ReadAll(openSSE(s, srv, token, "", "")); err != nil {
+		t.Fatalf("读取 SSE 失败: %v", err)
+	}
+}
+
+func TestStreamDelivery(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, alice := s.Register("alice", "secret1")
+	_, bob := s.Register("bob", "secret1")
+	_, carol := s.Register("carol", "secret1")
+	post := s.CreatePost(alice, "标题", "内容")
+	srv := listen(s)
+
+	// alice 接收自己的通知，bob 正在浏览帖子
+	aliceStream := openSSE(s, srv, alice, "", "")
+	bobStream := openSSE(s, srv, bob, fmt.Sprintf("posts=%d", post.ID), "")
+
+	comment := s.CreateComment(carol, post.ID, "评论", 0)
+
+	msg := nextSSE(s, aliceStream)
+	var notification model.Notification
+	if err := json.Unmarshal(msg.Data, &notification); err != nil || msg.Type != stream.MessageNotification || *notification.CommentID != comment.ID {
+		t.Fatalf("alice: %+v %v", msg, err)
+	}
+
+	msg = nextSSE(s, bobStream)
+	var pushed model.Comment
+	if err := json.Unmarshal(msg.Data, &pushed); err != nil || msg.Type != stream.MessageComment || msg.PostID != post.ID || pushed.ID != comment.ID {
+		t.Fatalf("bob: %+v %v", msg, err)
+	}
+
+	// 点赞数变化推送给正在浏览的连接，不会推送给无关连接
+	s.Post(fmt.Sprintf("/posts/%d/like", post.ID), nil, carol).ExpectStatus(http.StatusOK)
+	msg = nextSSE(s, bobStream)
+	var likes stream.LikeCount
+	if err := json.Unmarshal(msg.Data, &likes); err != nil || msg.Type != stream.MessageLikeCount || likes != (stream.LikeCount{PostID: post.ID, LikeCount: 1}) {
+		t.Fatalf("bob: %+v %v", msg, err)
+	}
+	if msg := nextSSE(s, aliceStream); msg.Type != stream.MessageNotification {
+		t.Fatalf("alice: %+v", msg)
+	}
+}
+
+func TestStreamWebSocketWatch(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, alice := s.Register("alice", "secret1")
+	_, bob := s.Register("bob", "secret1")
+	post := s.CreatePost(alice, "标题", "内容")
+	other := s.CreatePost(alice, "另一篇", "内容")
+	srv := listen(s)
+
+	conn, _, err := dialStream(srv, bob, "", "")
+	if err != nil {
+		t.Fatalf("建立 WebSocket 连接失败: %v", err)
+	}
+	defer conn.Close()
+
+	pong := make(chan struct{})
+	conn.SetPongHandler(func(string) error {
+		close(pong)
+		return nil
+	})
+	messages := make(chan streamMessage, 8)
+	go func() {
+		defer close(messages)
+		for {
+			var msg streamMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			messages <- msg
+		}
+	}()
+
+	// 订阅帖子，服务端按顺序处理指令，收到 pong 时订阅已生效
+	if err := conn.WriteJSON(map[string]interface{}{"action": "watch", "post_id": post.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamTimeout)); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-pong:
+	case <-time.After(streamTimeout):
+		t.Fatal("等待 pong 超时")
+	}
+
+	// 只收到已订阅帖子的新评论
+	s.CreateComment(alice, other.ID, "未订阅的帖子", 0)
+	comment := s.CreateComment(alice, post.ID, "评论", 0)
+	select {
+	case msg := <-messages:
+		var pushed model.Comment
+		if err := json.Unmarshal(msg.Data, &pushed); err != nil || msg.Type != stream.MessageComment || pushed.ID != comment.ID {
+			t.Fatalf("msg = %+v %v", msg, err)
+		}
+	case <-time.After(streamTimeout):
+		t.Fatal("订阅后没有收到评论")
+	}
+}
+
+func TestStreamResume(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	alice, aliceToken := s.Register("alice", "secret1")
+	_, bob := s.Register("bob", "secret1")
+	_, carol := s.Register("carol", "secret1")
+	srv := listen(s)
+
+	conn, _, err := dialStream(srv, aliceToken, "", "")
+	if err != nil {
+		t.Fatalf("建立 WebSocket 连接失败: %v", err)
+	}
+	s.Post(fmt.Sprintf("/follows/%d", alice.ID), nil, bob).ExpectStatus(http.StatusOK)
+	first := nextWebSocket(s, conn)
+	conn.Close()
+
+	// 断线期间的消息在重连时按顺序补发，已收到的消息不重复
+	post := s.CreatePost(aliceToken, "标题", "内容")
+	s.Post(fmt.Sprintf("/follows/%d", alice.ID), nil, carol).ExpectStatus(http.StatusOK)
+	s.CreateComment(carol, post.ID, "评论", 0)
+
+	resumed := openSSE(s, srv, aliceToken, "", strconv.FormatUint(first.ID, 10))
+	var contents []string
+	for i := 0; i < 2; i++ {
+		msg := nextSSE(s, resumed)
+		var notification model.Notification
+		if err := json.Unmarshal(msg.Data, &notification); err != nil || msg.Type != stream.MessageNotification || msg.ID <= first.ID {
+			t.Fatalf("msg = %+v %v", msg, err)
+		}
+		contents = append(contents, notification.Content)
+	}
+	if want := "[carol 等 2 人 关注了你 carol 评论了你的帖子《标题》]"; fmt.Sprint(contents) != want {
+		t.Fatalf("contents = %v, want %s", contents, want)
+	}
+
+	// 客户端的消息ID比服务端还新，说明服务已重启，要求客户端重新拉取
+	conn, _, err = dialStream(srv, aliceToken, "", "last_event_id=1000")
+	if err != nil {
+		t.Fatalf("建立 WebSocket 连接失败: %v", err)
+	}
+	defer conn.Close()
+	if msg := nextWebSocket(s, conn); msg.Type != stream.MessageReset {
+		t.Fatalf("msg = %+v", msg)
+	}
+}
@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/lllllan02/chitchat/internal/model"
+)
+
+func TestRefreshTokenRotation(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	s.Register("alice", "secret1")
+	first := s.LoginTokens("alice", "secret1")
+	if first.RefreshToken == "" || first.ExpiresIn <= 0 {
+		t.Fatalf("登录未返回刷新令牌: %+v", first)
+	}
+
+	// 刷新后获得新的令牌对
+	var second model.TokenPair
+	s.Post("/auth/refresh", map[string]string{"refresh_token": first.RefreshToken}, "").ExpectStatus(http.StatusOK).Decode(&second)
+	if second.Token == "" || second.RefreshToken == first.RefreshToken {
+		t.Fatalf("刷新未轮换令牌: %+v", second)
+	}
+	s.Get("/users/me", second.Token).ExpectStatus(http.StatusOK)
+
+	// 旧的刷新令牌再次使用被视为泄露，该用户的全部会话失效
+	s.Post("/auth/refresh", map[string]string{"refresh_token": first.RefreshToken}, "").ExpectStatus(http.StatusUnauthorized)
+	s.Post("/auth/refresh", map[string]string{"refresh_token": second.RefreshToken}, "").ExpectStatus(http.StatusUnauthorized)
+	s.Get("/users/me", second.Token).ExpectStatus(http.StatusUnauthorized)
+
+	s.Post("/auth/refresh", map[string]string{"refresh_token": "unknown"}, "").ExpectStatus(http.StatusUnauthorized)
+	s.Post("/auth/refresh", map[string]string{}, "").ExpectStatus(http.StatusBadRequest)
+}
+
+func TestLogout(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	s.Register("alice", "secret1")
+	tokens := s.LoginTokens("alice", "secret1")
+	other := s.LoginTokens("alice", "secret1")
+
+	s.Post("/auth/logout", map[string]string{"refresh_token": tokens.RefreshToken}, tokens.Token).ExpectStatus(http.StatusOK)
+
+	// 当前令牌和刷新令牌失效，其他登录不受影响
+	s.Get("/users/me", tokens.Token).ExpectStatus(http.StatusUnauthorized)
+	s.Post("/auth/refresh", map[string]string{"refresh_token": tokens.RefreshToken}, "").ExpectStatus(http.StatusUnauthorized)
+	s.Get("/users/me", other.Token).ExpectStatus(http.StatusOK)
+
+	s.Post("/auth/logout", nil, "").ExpectStatus(http.StatusUnauthorized)
+}
+
+func TestChangePasswordRevokesSessions(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	s.Register("alice", "secret1")
+	tokens := s.LoginTokens("alice", "secret1")
+	other := s.LoginTokens("alice", "secret1")
+
+	var fresh model.TokenPair
+	s.Put("/users/me/password", map[string]string{"old_password": "secret1", "new_password": "secret2"}, tokens.Token).
+		ExpectStatus(http.StatusOK).Decode(&fresh)
+
+	s.Get("/users/me", tokens.Token).ExpectStatus(http.StatusUnauthorized)
+	s.Get("/users/me", other.Token).ExpectStatus(http.StatusUnauthorized)
+	s.Post("/auth/refresh", map[string]string{"refresh_token": other.RefreshToken}, "").ExpectStatus(http.StatusUnauthorized)
+
+	// 修改密码的客户端获得新令牌
+	s.Get("/users/me", fresh.Token).ExpectStatus(http.StatusOK)
+	s.Login("alice", "secret2")
+}
+
+func TestRoleChangeRevokesSessions(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	user, _ := s.Register("alice", "secret1")
+	tokens := s.LoginTokens("alice", "secret1")
+
+	s.Put(fmt.Sprintf("/admin/users/%d/role", user.ID), map[string]string{"role": "admin"}, s.AdminToken()).ExpectStatus(http.StatusOK)
+	s.Get("/users/me", tokens.Token).ExpectStatus(http.StatusUnauthorized)
+	s.Post("/auth/refresh", map[string]string{"refresh_token": tokens.RefreshToken}, "").ExpectStatus(http.StatusUnauthorized)
+
+	// 重新登录后的令牌带有新角色
+	token := s.Login("alice", "secret1")
+	s.Post("/admin/categories", map[string]string{"name": "新分类"}, token).ExpectStatus(http.StatusOK)
+}
@@ -0,0 +1,298 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/utils"
+)
+
+// totpCode 计算指定偏移时间的验证码
+// 同一时间步的验证码只能使用一次，需要多个验证码时使用下一个时间步
+func totpCode(t *testing.T, secret string, offset time.Duration) string {
+	t.Helper()
+	code, err := utils.TOTPCode(secret, time.Now().Add(offset))
+	if err != nil {
+		t.Fatalf("计算验证码失败: %v", err)
+	}
+	return code
+}
+
+// enableTwoFactor 为用户启用两步验证，返回密钥、备用码和新令牌
+func enableTwoFactor(s *Server, token string) (string, model.TwoFactorActivation) {
+	s.t.Helper()
+
+	var setup model.TwoFactorSetup
+	s.Post("/users/me/2fa/setup", nil, token).ExpectStatus(http.StatusOK).Decode(&setup)
+
+	var activation model.TwoFactorActivation
+	s.Post("/users/me/2fa/enable", map[string]string{
+		"code": totpCode(s.t, setup.Secret, 0),
+	}, token).ExpectStatus(http.StatusOK).Decode(&activation)
+	return setup.Secret, activation
+}
+
+// loginChallenge 使用密码登录，返回两步验证挑战
+func loginChallenge(s *Server, username, password string) model.LoginChallenge {
+	s.t.Helper()
+
+	var challenge model.LoginChallenge
+	s.Post("/auth/login", map[string]string{
+		"username": username,
+		"password": password,
+	}, "").ExpectStatus(http.StatusOK).Decode(&challenge)
+	if !challenge.TwoFactorRequired || challenge.ChallengeToken == "" {
+		s.t.Fatalf("期望返回两步验证挑战: %+v", challenge)
+	}
+	return challenge
+}
+
+func TestTwoFactorSetup(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, token := s.Register("alice", "secret1")
+
+	var setup model.TwoFactorSetup
+	s.Post("/users/me/2fa/setup", nil, token).ExpectStatus(http.StatusOK).Decode(&setup)
+	if !strings.HasPrefix(setup.OTPAuthURI, "otpauth://totp/chitchat:alice?") || !strings.Contains(setup.OTPAuthURI, "secret="+setup.Secret) {
+		t.Fatalf("otpauth URI 错误: %s", setup.OTPAuthURI)
+	}
+
+	// 错误的验证码不能启用
+	s.Post("/users/me/2fa/enable", map[string]string{"code": "000000"}, token).ExpectStatus(http.StatusBadRequest)
+
+	var activation model.TwoFactorActivation
+	s.Post("/users/me/2fa/enable", map[string]string{
+		"code": totpCode(t, setup.Secret, 0),
+	}, token).ExpectStatus(http.StatusOK).Decode(&activation)
+	if len(activation.BackupCodes) != 10 || activation.Token == "" {
+		t.Fatalf("启用结果错误: %+v", activation)
+	}
+
+	// 启用前的会话失效，新令牌可用
+	s.Get("/users/me", token).ExpectStatus(http.StatusUnauthorized)
+	var user model.User
+	s.Get("/users/me", activation.Token).ExpectStatus(http.StatusOK).Decode(&user)
+	if !user.TwoFactor {
+		t.Fatal("用户应已启用两步验证")
+	}
+
+	// 已启用时不能重复生成密钥
+	s.Post("/users/me/2fa/setup", nil, activation.Token).ExpectStatus(http.StatusBadRequest)
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, token := s.Register("alice", "secret1")
+
+	var setup model.TwoFactorSetup
+	s.Post("/users/me/2fa/setup", nil, token).ExpectStatus(http.StatusOK).Decode(&setup)
+	secret, used := setup.Secret, totpCode(t, setup.Secret, 0)
+	s.Post("/users/me/2fa/enable", map[string]string{"code": used}, token).ExpectStatus(http.StatusOK)
+
+	// 密码正确时只返回挑战，不签发令牌
+	challenge := loginChallenge(s, "alice", "secret1")
+
+	// 启用时已使用的验证码不能重放
+	s.Post("/auth/login/2fa", map[string]string{
+		"challenge_token": challenge.ChallengeToken,
+		"code":            used,
+	}, "").ExpectStatus(http.StatusBadRequest)
+
+	// 伪造的挑战无效
+	s.Post("/auth/login/2fa", map[string]string{
+		"challenge_token": "invalid",
+		"code":            totpCode(t, secret, 30*time.Second),
+	}, "").ExpectStatus(http.StatusUnauthorized)
+
+	var result model.UserWithToken
+	s.Post("/auth/login/2fa", map[string]string{
+		"challenge_token": challenge.ChallengeToken,
+		"code":            totpCode(t, secret, 30*time.Second),
+	}, "").ExpectStatus(http.StatusOK).Decode(&result)
+	s.Get("/users/me", result.Token).ExpectStatus(http.StatusOK)
+
+	// 访问令牌不能当作挑战使用
+	s.Post("/auth/login/2fa", map[string]string{
+		"challenge_token": result.Token,
+		"code":            "000000",
+	}, "").ExpectStatus(http.StatusUnauthorized)
+}
+
+func TestTwoFactorBackupCodes(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, token := s.Register("alice", "secret1")
+	_, activation := enableTwoFactor(s, token)
+	backup := activation.BackupCodes[0]
+
+	// 备用码忽略大小写和分隔符
+	challenge := loginChallenge(s, "alice", "secret1")
+	s.Post("/auth/login/2fa", map[string]string{
+		"challenge_token": challenge.ChallengeToken,
+		"code":            strings.ToLower(strings.ReplaceAll(backup, "-", "")),
+	}, "").ExpectStatus(http.StatusOK)
+
+	// 登录挑战只能成功使用一次
+	s.Post("/auth/login/2fa", map[string]string{
+		"challenge_token": challenge.ChallengeToken,
+		"code":            activation.BackupCodes[3],
+	}, "").ExpectStatus(http.StatusUnauthorized)
+
+	// 备用码只能使用一次
+	challenge = loginChallenge(s, "alice", "secret1")
+	s.Post("/auth/login/2fa", map[string]string{
+		"challenge_token": challenge.ChallengeToken,
+		"code":            backup,
+	}, "").ExpectStatus(http.StatusBadRequest)
+
+	// 重新生成后旧的备用码全部作废
+	var regenerated struct {
+		BackupCodes []string `json:"backup_codes"`
+	}
+	s.Post("/users/me/2fa/backup-codes", map[string]string{
+		"code": activation.BackupCodes[1],
+	}, activation.Token).ExpectStatus(http.StatusOK).Decode(&regenerated)
+	if len(regenerated.BackupCodes) != 10 {
+		t.Fatalf("期望 10 个备用码，实际为 %d", len(regenerated.BackupCodes))
+	}
+	s.Post("/auth/login/2fa", map[string]string{
+		"challenge_token": challenge.ChallengeToken,
+		"code":            activation.BackupCodes[2],
+	}, "").ExpectStatus(http.StatusBadRequest)
+	s.Post("/auth/login/2fa", map[string]string{
+		"challenge_token": challenge.ChallengeToken,
+		"code":            regenerated.BackupCodes[0],
+	}, "").ExpectStatus(http.StatusOK)
+}
+
+func TestTwoFactorBruteForce(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t, func(cfg *utils.Config) {
+		cfg.Auth.Lockout.TwoFactorLockAfter = 3
+	})
+	alice, token := s.Register("alice", "secret1")
+	secret, _ := enableTwoFactor(s, token)
+
+	guess := func(challenge model.LoginChallenge, code string) *Response {
+		return s.Post("/auth/login/2fa", map[string]string{
+			"challenge_token": challenge.ChallengeToken,
+			"code":            code,
+		}, "")
+	}
+
+	// 重新登录获取新的挑战不会重新计数
+	guess(loginChallenge(s, "alice", "secret1"), "AAAAA-AAAAA").ExpectStatus(http.StatusBadRequest)
+	challenge := loginChallenge(s, "alice", "secret1")
+	guess(challenge, "BBBBB-BBBBB").ExpectStatus(http.StatusBadRequest)
+
+	// 达到阈值的这次失败即锁定
+	resp := guess(challenge, "CCCCC-CCCCC").ExpectStatus(http.StatusForbidden)
+	if resp.Error != "ACCOUNT_LOCKED" || resp.Header.Get("Retry-After") == "" {
+		t.Fatalf("期望锁定并返回 Retry-After: %s %q", resp.Error, resp.Header.Get("Retry-After"))
+	}
+
+	// 锁定期间正确的验证码也被拒绝
+	code := totpCode(t, secret, 30*time.Second)
+	guess(challenge, code).ExpectStatus(http.StatusForbidden)
+	guess(loginChallenge(s, "alice", "secret1"), code).ExpectStatus(http.StatusForbidden)
+
+	// 管理员解锁后可以完成登录
+	s.Post(fmt.Sprintf("/admin/users/%d/unlock", alice.ID), nil, s.AdminToken()).ExpectStatus(http.StatusOK)
+	guess(loginChallenge(s, "alice", "secret1"), code).ExpectStatus(http.StatusOK)
+}
+
+func TestDisableTwoFactor(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, token := s.Register("alice", "secret1")
+	secret, activation := enableTwoFactor(s, token)
+	challenge := loginChallenge(s, "alice", "secret1")
+
+	s.Post("/users/me/2fa/disable", map[string]string{
+		"password": "wrong",
+		"code":     activation.BackupCodes[0],
+	}, activation.Token).ExpectStatus(http.StatusBadRequest)
+
+	var tokens model.TokenPair
+	s.Post("/users/me/2fa/disable", map[string]string{
+		"password": "secret1",
+		"code":     totpCode(t, secret, 30*time.Second),
+	}, activation.Token).ExpectStatus(http.StatusOK).Decode(&tokens)
+	s.Get("/users/me", activation.Token).ExpectStatus(http.StatusUnauthorized)
+	s.Get("/users/me", tokens.Token).ExpectStatus(http.StatusOK)
+
+	// 关闭前签发的挑战失效，密码登录直接签发令牌
+	s.Post("/auth/login/2fa", map[string]string{
+		"challenge_token": challenge.ChallengeToken,
+		"code":            activation.BackupCodes[1],
+	}, "").ExpectStatus(http.StatusUnauthorized)
+	s.Get("/users/me", s.Login("alice", "secret1")).ExpectStatus(http.StatusOK)
+}
+
+func TestStaffRequiresTwoFactor(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t, func(cfg *utils.Config) {
+		cfg.Auth.RequireStaffTwoFactor = true
+	})
+	token := s.AdminToken()
+
+	// 未通过两步验证的管理员不能使用管理功能
+	s.Post("/admin/categories", map[string]string{"name": "新分类"}, token).ExpectStatus(http.StatusForbidden)
+
+	// 启用后签发的令牌已通过两步验证
+	secret, activation := enableTwoFactor(s, token)
+	s.Post("/admin/categories", map[string]string{"name": "新分类"}, activation.Token).ExpectStatus(http.StatusOK)
+
+	// 两步验证状态在刷新令牌后保留
+	var refreshed model.TokenPair
+	s.Post("/auth/refresh", map[string]string{
+		"refresh_token": activation.RefreshToken,
+	}, "").ExpectStatus(http.StatusOK).Decode(&refreshed)
+	s.Post("/admin/categories", map[string]string{"name": "另一个分类"}, refreshed.Token).ExpectStatus(http.StatusOK)
+
+	// 重新登录时完成两步验证
+	challenge := loginChallenge(s, AdminUsername, AdminPassword)
+	var result model.UserWithToken
+	s.Post("/auth/login/2fa", map[string]string{
+		"challenge_token": challenge.ChallengeToken,
+		"code":            totpCode(t, secret, 30*time.Second),
+	}, "").ExpectStatus(http.StatusOK).Decode(&result)
+	s.Post("/admin/categories", map[string]string{"name": "第三个分类"}, result.Token).ExpectStatus(http.StatusOK)
+}
+
+func TestStaffModerationRequiresTwoFactor(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t, func(cfg *utils.Config) {
+		cfg.Auth.RequireStaffTwoFactor = true
+	})
+	_, admin := enableTwoFactor(s, s.AdminToken())
+
+	_, aliceToken := s.Register("alice", "secret1")
+	bob, _ := s.Register("bob", "secret1")
+	carol, _ := s.Register("carol", "secret1")
+	s.Put(fmt.Sprintf("/admin/users/%d/role", bob.ID), map[string]string{"role": "moderator"}, admin.Token).ExpectStatus(http.StatusOK)
+	s.Put(fmt.Sprintf("/admin/users/%d/role", carol.ID), map[string]string{"role": "admin"}, admin.Token).ExpectStatus(http.StatusOK)
+
+	post := s.CreatePost(aliceToken, "alice 的帖子", "内容")
+	comment := s.CreateComment(aliceToken, post.ID, "alice 的评论", 0)
+
+	// 未通过两步验证的版主和管理员不能修改或删除他人的内容
+	moderator := s.Login("bob", "secret1")
+	s.Put(fmt.Sprintf("/comments/%d", comment.ID), map[string]string{"content": "改掉"}, moderator).ExpectStatus(http.StatusForbidden)
+	s.Delete(fmt.Sprintf("/comments/%d", comment.ID), moderator).ExpectStatus(http.StatusForbidden)
+	s.Delete(fmt.Sprintf("/posts/%d", post.ID), s.Login("carol", "secret1")).ExpectStatus(http.StatusForbidden)
+
+	// 自己的内容不受影响
+	s.Put(fmt.Sprintf("/comments/%d", comment.ID), map[string]string{"content": "alice 改的"}, aliceToken).ExpectStatus(http.StatusOK)
+
+	// 通过两步验证后可以行使管理权限
+	_, activation := enableTwoFactor(s, moderator)
+	s.Delete(fmt.Sprintf("/comments/%d", comment.ID), activation.Token).ExpectStatus(http.StatusOK)
+	s.Delete(fmt.Sprintf("/posts/%d", post.ID), admin.Token).ExpectStatus(http.StatusOK)
+}
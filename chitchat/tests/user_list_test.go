@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lllllan02/chitchat/internal/app"
+	"github.com/lllllan02/chitchat/internal/model"
+)
+
+// listUsernames 使用查询参数获取用户列表，返回用户名和总数
+func listUsernames(s *Server, token string, params url.Values) ([]string, int64) {
+	s.t.Helper()
+
+	var result struct {
+		Users []model.User `json:"items"`
+		Meta  struct {
+			Total int64 `json:"total"`
+		} `json:"meta"`
+	}
+	s.Get("/users?"+params.Encode(), token).ExpectStatus(http.StatusOK).Decode(&result)
+
+	names := make([]string, len(result.Users))
+	for i, user := range result.Users {
+		names[i] = user.Username
+	}
+	return names, result.Meta.Total
+}
+
+func TestListUsersFilterAndSort(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	alice, aliceToken := s.Register("alice", "secret1")
+	bob, bobToken := s.Register("bob", "secret1")
+	_, carolToken := s.Register("carol", "secret1")
+
+	// alice 发两篇帖子，bob 发一篇；bob 有两个粉丝，alice 有一个
+	s.CreatePost(aliceToken, "第一篇", "内容")
+	s.CreatePost(aliceToken, "第二篇", "内容")
+	s.CreatePost(bobToken, "第三篇", "内容")
+	s.Post(fmt.Sprintf("/follows/%d", bob.ID), nil, aliceToken).ExpectStatus(http.StatusOK)
+	s.Post(fmt.Sprintf("/follows/%d", bob.ID), nil, carolToken).ExpectStatus(http.StatusOK)
+	s.Post(fmt.Sprintf("/follows/%d", alice.ID), nil, carolToken).ExpectStatus(http.StatusOK)
+
+	s.Put("/users/me", map[string]string{"bio": "喜欢写 Go 语言"}, carolToken).ExpectStatus(http.StatusOK)
+
+	cases := []struct {
+		name   string
+		params url.Values
+		want   []string
+	}{
+		{"posts", url.Values{"role": {"user"}, "order_by": {"posts"}}, []string{"alice", "bob", "carol"}},
+		{"followers", url.Values{"role": {"user"}, "order_by": {"followers"}}, []string{"bob", "alice", "carol"}},
+		{"joined asc", url.Values{"role": {"user"}, "order_by": {"joined"}, "order": {"asc"}}, []string{"alice", "bob", "carol"}},
+		{"role", url.Values{"role": {"admin"}}, []string{AdminUsername}},
+		{"keyword username", url.Values{"keyword": {"bob"}}, []string{"bob"}},
+		{"keyword bio", url.Values{"keyword": {"go"}}, []string{"carol"}},
+		{"keyword no match", url.Values{"keyword": {"nobody"}}, []string{}},
+	}
+	for _, tc := range cases {
+		got, total := listUsernames(s, aliceToken, tc.params)
+		if !reflect.DeepEqual(got, tc.want) || total != int64(len(tc.want)) {
+			t.Errorf("%s: got %v (total %d), want %v", tc.name, got, total, tc.want)
+		}
+	}
+
+	// 分页时总数不受页大小影响
+	got, total := listUsernames(s, aliceToken, url.Values{"role": {"user"}, "order_by": {"posts"}, "page": {"2"}, "page_size": {"2"}})
+	if !reflect.DeepEqual(got, []string{"carol"}) || total != 3 {
+		t.Errorf("page 2: got %v (total %d)", got, total)
+	}
+
+	// 注册日期范围包含结束日期当天
+	today := time.Now().Format("2006-01-02")
+	tomorrow := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	if _, total := listUsernames(s, aliceToken, url.Values{"role": {"user"}, "from": {yesterday}, "to": {today}}); total != 3 {
+		t.Errorf("date range including today: total %d, want 3", total)
+	}
+	if _, total := listUsernames(s, aliceToken, url.Values{"from": {tomorrow}}); total != 0 {
+		t.Errorf("from tomorrow: total %d, want 0", total)
+	}
+
+	s.Get("/users?from="+today+"&to="+yesterday, aliceToken).ExpectStatus(http.StatusBadRequest)
+	s.Get("/users?order_by=karma", aliceToken).ExpectStatus(http.StatusBadRequest)
+	s.Get("/users?role=owner", aliceToken).ExpectStatus(http.StatusBadRequest)
+}
+
+func TestListUsersKeywordTruncated(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, token := s.Register("alice", "secret1")
+
+	// 直接写入数据库的用户只能通过重建进入索引，数量超过关键词筛选的上限
+	users := make([]model.User, 1001)
+	for i := range users {
+		users[i] = model.User{Username: fmt.Sprintf("bulk%d", i), Email: fmt.Sprintf("bulk%d@example.com", i), PasswordHash: "-", Bio: "海量"}
+	}
+	if err := s.App.DB.CreateInBatches(users, 200).Error; err != nil {
+		t.Fatalf("写入用户失败: %v", err)
+	}
+	restarted, err := app.New(s.App.Config, s.App.DB)
+	if err != nil {
+		t.Fatalf("初始化应用失败: %v", err)
+	}
+	t.Cleanup(func() { restarted.Close() })
+	restarted.WaitSearchIndex()
+	fresh := &Server{t: t, App: restarted}
+
+	listMeta := func(keyword string) pageMeta {
+		var result struct {
+			Meta pageMeta `json:"meta"`
+		}
+		fresh.Get("/users?"+url.Values{"keyword": {keyword}}.Encode(), token).ExpectStatus(http.StatusOK).Decode(&result)
+		return result.Meta
+	}
+
+	// 匹配超过上限时列表只包含相关度最高的部分，并标记为已截断
+	if meta := listMeta("海量"); !meta.Truncated || meta.Total == nil || *meta.Total != 1000 {
+		t.Fatalf("meta = %+v", meta)
+	}
+	if meta := listMeta("alice"); meta.Truncated {
+		t.Fatalf("未超出上限时不应截断: %+v", meta)
+	}
+}
+
+func TestSuggestUsers(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t)
+	_, token := s.Register("alice", "secret1")
+	s.Register("alicia", "secret1")
+	s.Register("al_ex", "secret1")
+	s.Register("bob", "secret1")
+
+	suggest := func(query string) []model.UserSuggestion {
+		t.Helper()
+		var suggestions []model.UserSuggestion
+		s.Get("/users/suggest?"+query, token).ExpectStatus(http.StatusOK).Decode(&suggestions)
+		return suggestions
+	}
+	names := func(suggestions []model.UserSuggestion) []string {
+		out := make([]string, len(suggestions))
+		for i, suggestion := range suggestions {
+			out[i] = suggestion.Username
+		}
+		return out
+	}
+
+	// 前缀匹配不区分大小写，较短的用户名排在前面，支持带 @ 的输入
+	if got := names(suggest("q=ALI")); !reflect.DeepEqual(got, []string{"alice", "alicia"}) {
+		t.Errorf("q=ALI: got %v", got)
+	}
+	if got := names(suggest("q=%40bo")); !reflect.DeepEqual(got, []string{"bob"}) {
+		t.Errorf("q=@bo: got %v", got)
+	}
+	// 通配符按字面匹配
+	if got := names(suggest("q=al_")); !reflect.DeepEqual(got, []string{"al_ex"}) {
+		t.Errorf("q=al_: got %v", got)
+	}
+	if got := suggest("q=al&limit=1"); len(got) != 1 || got[0].ID == 0 {
+		t.Errorf("limit=1: got %+v", got)
+	}
+
+	s.Get("/users/suggest", token).ExpectStatus(http.StatusBadRequest)
+	s.Get("/users/suggest?q=al&limit=100", token).ExpectStatus(http.StatusBadRequest)
+	s.Get("/users/suggest?q=al", "").ExpectStatus(http.StatusUnauthorized)
+}
@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/lllllan02/chitchat/internal/model"
+	"github.com/lllllan02/chitchat/internal/utils"
+)
+
+// viewPost 以指定的 User-Agent 和 IP 浏览帖子
+func viewPost(s *Server, postID uint, token, userAgent, ip string) {
+	s.t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/v1/posts/%d", postID), nil)
+	req.RemoteAddr = ip + ":12345"
+	if userAgent != "" {
+		req.Header.Set("User-Agent", userAgent)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	s.Send(req).ExpectStatus(http.StatusOK)
+}
+
+// storedViewCount 读取数据库中的浏览次数
+func storedViewCount(s *Server, postID uint) int {
+	s.t.Helper()
+
+	var post model.Post
+	if err := s.App.DB.First(&post, postID).Error; err != nil {
+		s.t.Fatalf("查询帖子失败: %v", err)
+	}
+	return post.ViewCount
+}
+
+const browserUA = "Mozilla/5.0 (X11; Linux x86_64) Firefox/120.0"
+
+// exerciseViewCount 重复浏览只计一次，爬虫不计数，写入前不修改数据库
+func exerciseViewCount(t *testing.T, s *Server) {
+	_, alice := s.Register("alice", "secret1")
+	_, bob := s.Register("bob", "secret1")
+	post := s.CreatePost(alice, "浏览次数", "这篇帖子用于统计浏览次数")
+
+	for i := 0; i < 3; i++ {
+		viewPost(s, post.ID, alice, browserUA, "10.0.0.1")
+		viewPost(s, post.ID, "", browserUA, "10.0.0.2")
+	}
+	viewPost(s, post.ID, bob, browserUA, "10.0.0.1")
+	viewPost(s, post.ID, "", "Googlebot/2.1 (+http://www.google.com/bot.html)", "10.0.0.3")
+	viewPost(s, post.ID, "", "", "10.0.0.4")
+
+	if got := storedViewCount(s, post.ID); got != 0 {
+		t.Fatalf("写入前数据库中的浏览次数应为 0，实际为 %d", got)
+	}
+
+	if err := s.App.Views.Flush(); err != nil {
+		t.Fatalf("写入浏览次数失败: %v", err)
+	}
+	if got := storedViewCount(s, post.ID); got != 3 {
+		t.Fatalf("浏览次数应为 3，实际为 %d", got)
+	}
+
+	// 已写入的计数不会重复写入
+	if err := s.App.Views.Flush(); err != nil {
+		t.Fatalf("写入浏览次数失败: %v", err)
+	}
+	if got := storedViewCount(s, post.ID); got != 3 {
+		t.Fatalf("重复写入后浏览次数应为 3，实际为 %d", got)
+	}
+}
+
+func TestViewCountMemoryStore(t *testing.T) {
+	t.Parallel()
+	exerciseViewCount(t, NewServer(t))
+}
+
+func TestViewCountRedisStore(t *testing.T) {
+	t.Parallel()
+	mr := miniredis.RunT(t)
+	s := NewServer(t, func(cfg *utils.Config) {
+		cfg.Redis = utils.RedisConfig{Enabled: true, Host: mr.Host(), Port: mustPort(t, mr.Port())}
+	})
+
+	exerciseViewCount(t, s)
+}
+
+func TestViewCountDedupWindow(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t, func(cfg *utils.Config) {
+		cfg.ViewCount.DedupWindow = "1ns"
+	})
+	_, alice := s.Register("alice", "secret1")
+	post := s.CreatePost(alice, "浏览次数", "这篇帖子用于统计浏览次数")
+
+	// 去重窗口结束后再次浏览重新计数
+	viewPost(s, post.ID, "", browserUA, "10.0.0.1")
+	viewPost(s, post.ID, "", browserUA, "10.0.0.1")
+	if err := s.App.Views.Flush(); err != nil {
+		t.Fatalf("写入浏览次数失败: %v", err)
+	}
+	if got := storedViewCount(s, post.ID); got != 2 {
+		t.Fatalf("浏览次数应为 2，实际为 %d", got)
+	}
+}
+
+func TestViewCountFlushedOnClose(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t, func(cfg *utils.Config) {
+		cfg.ViewCount.FlushInterval = "1h"
+	})
+	_, alice := s.Register("alice", "secret1")
+	post := s.CreatePost(alice, "浏览次数", "这篇帖子用于统计浏览次数")
+
+	viewPost(s, post.ID, alice, browserUA, "10.0.0.1")
+	viewPost(s, post.ID, "", browserUA, "10.0.0.2")
+
+	// 关闭应用时写入剩余的计数
+	if err := s.App.Close(); err != nil {
+		t.Fatalf("关闭应用失败: %v", err)
+	}
+	if got := storedViewCount(s, post.ID); got != 2 {
+		t.Fatalf("关闭后浏览次数应为 2，实际为 %d", got)
+	}
+}
+
+func TestViewCountPeriodicFlush(t *testing.T) {
+	t.Parallel()
+	s := NewServer(t, func(cfg *utils.Config) {
+		cfg.ViewCount.FlushInterval = "10ms"
+	})
+	_, alice := s.Register("alice", "secret1")
+	post := s.CreatePost(alice, "浏览次数", "这篇帖子用于统计浏览次数")
+
+	viewPost(s, post.ID, alice, browserUA, "10.0.0.1")
+	waitFor(t, func() bool { return storedViewCount(s, post.ID) == 1 })
+}
+
+// waitFor 等待条件成立，超时后测试失败
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("等待条件成立超时")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}